@@ -13,6 +13,7 @@ import (
 	"github.com/cerberus/backend/internal/api"
 	"github.com/cerberus/backend/internal/platform/db"
 	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/cerberus/backend/internal/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -31,6 +32,7 @@ func main() {
 	dbUser := getEnv("DB_USER", "cerberus")
 	dbPassword := getEnv("DB_PASSWORD", "cerberus_dev")
 	natsURL := getEnv("NATS_URL", "nats://localhost:4222")
+	eventBusType := getEnv("EVENT_BUS", "nats")
 
 	// Connect to database
 	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
@@ -50,14 +52,55 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	// Connect to NATS event bus
-	eventBus, err := events.NewNATSBus(natsURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to NATS: %v", err)
+	// Background context for the outbox relay, and the in-process event bus and worker (single-process mode)
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	workerDone := make(chan struct{})
+	close(workerDone)
+
+	// Create event bus: NATS by default, or in-process with an embedded worker (EVENT_BUS=memory)
+	var eventBus events.Bus
+	switch eventBusType {
+	case "memory":
+		memoryBus := events.NewMemoryBus()
+		defer memoryBus.Close()
+		eventBus = memoryBus
+
+		w, err := worker.New(database, memoryBus, worker.ConfigFromEnv())
+		if err != nil {
+			log.Fatalf("Failed to create in-process worker: %v", err)
+		}
+		defer w.Close()
+
+		go func() {
+			if err := memoryBus.Start(backgroundCtx); err != nil {
+				log.Printf("Event bus error: %v", err)
+			}
+		}()
+
+		workerDone = make(chan struct{})
+		go func() {
+			w.Run(backgroundCtx)
+			close(workerDone)
+		}()
+
+		log.Println("In-memory event bus started with in-process worker")
+	case "nats":
+		natsBus, err := events.NewNATSBus(natsURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		defer natsBus.Close()
+		eventBus = natsBus
+
+		log.Println("NATS connection established")
+	default:
+		log.Fatalf("Unknown EVENT_BUS %q (expected nats or memory)", eventBusType)
 	}
-	defer eventBus.Close()
 
-	log.Println("NATS connection established")
+	// Relay events written to the transactional outbox onto the event bus
+	outboxRelay := events.NewOutboxRelay(database, eventBus, events.DefaultOutboxRelayConfig())
+	go outboxRelay.Run(backgroundCtx)
 
 	// Create router
 	r := chi.NewRouter()
@@ -67,13 +110,12 @@ func main() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8080"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Last-Event-ID"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -89,7 +131,8 @@ func main() {
 	// API routes
 	r.Mount("/api/v1", api.NewRouter(database, eventBus))
 
-	// Start server
+	// Start server. Upload routes extend the read and write deadlines to fit the program's
+	// upload limit, and event streams manage their own.
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      r,
@@ -138,6 +181,10 @@ func main() {
 
 	// Wait for server context to be stopped
 	<-serverCtx.Done()
+
+	// Stop the outbox relay and the in-process worker, if any; interrupted pipelines release their artifacts
+	stopBackground()
+	<-workerDone
 	log.Println("Server stopped gracefully")
 }
 
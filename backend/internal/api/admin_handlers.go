@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/auth"
+	"github.com/cerberus/backend/internal/platform/jobs"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// registerAdminJobRoutes registers the scheduled job admin endpoints (global admins only)
+func registerAdminJobRoutes(r chi.Router, jobsRepo jobs.RepositoryInterface) {
+	r.Route("/admin/jobs", func(r chi.Router) {
+		r.Use(auth.RequireGlobalAdmin())
+		r.Get("/", handleListJobs(jobsRepo))
+		r.Get("/{jobName}", handleGetJob(jobsRepo))
+		r.Get("/{jobName}/runs", handleListJobRuns(jobsRepo))
+		r.Post("/{jobName}/trigger", handleTriggerJob(jobsRepo))
+	})
+}
+
+// handleListJobs lists scheduled jobs with their last run and outcome
+func handleListJobs(jobsRepo jobs.RepositoryInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statuses, err := jobsRepo.ListJobs(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"jobs":  statuses,
+			"count": len(statuses),
+		})
+	}
+}
+
+// handleGetJob returns a scheduled job with its last run and recent history
+func handleGetJob(jobsRepo jobs.RepositoryInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobName := chi.URLParam(r, "jobName")
+
+		status, err := jobsRepo.GetJob(r.Context(), jobName)
+		if err != nil {
+			respondError(w, jobErrorStatus(err), err.Error())
+			return
+		}
+
+		runs, err := jobsRepo.ListRuns(r.Context(), jobName, 20, 0)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"job":         status,
+			"recent_runs": runs,
+		})
+	}
+}
+
+// handleListJobRuns returns a job's run history, newest first
+func handleListJobRuns(jobsRepo jobs.RepositoryInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobName := chi.URLParam(r, "jobName")
+
+		limit := queryInt(r, "limit", 50)
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		offset := queryInt(r, "offset", 0)
+		if offset < 0 {
+			offset = 0
+		}
+
+		if _, err := jobsRepo.GetJob(r.Context(), jobName); err != nil {
+			respondError(w, jobErrorStatus(err), err.Error())
+			return
+		}
+
+		runs, err := jobsRepo.ListRuns(r.Context(), jobName, limit, offset)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"runs":   runs,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
+
+// handleTriggerJob queues a manual run; a worker starts it within a few seconds, once no other
+// run of the job is in progress
+func handleTriggerJob(jobsRepo jobs.RepositoryInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobName := chi.URLParam(r, "jobName")
+
+		if _, err := jobsRepo.GetJob(r.Context(), jobName); err != nil {
+			respondError(w, jobErrorStatus(err), err.Error())
+			return
+		}
+
+		userID, _ := auth.GetUserID(r.Context())
+		run, err := jobsRepo.QueueRun(r.Context(), jobName, userID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondJSON(w, http.StatusAccepted, SuccessResponse{
+			Success: true,
+			Data:    run,
+			Meta: &Meta{
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+			},
+		})
+	}
+}
+
+// registerAdminAIRoutes registers the AI rate limit admin endpoints (global admins only)
+func registerAdminAIRoutes(r chi.Router, redisClient *redis.Client) {
+	r.Route("/admin/ai", func(r chi.Router) {
+		r.Use(auth.RequireGlobalAdmin())
+		r.Get("/rate-limits/{name}", handleGetRateLimit(redisClient))
+	})
+}
+
+// handleGetRateLimit reports the remaining budgets of a shared AI rate limit (named after the
+// provider, e.g. anthropic) and the requests queued on each worker
+func handleGetRateLimit(redisClient *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := ai.ReadRateLimitStatus(r.Context(), redisClient, chi.URLParam(r, "name"))
+		if err != nil {
+			respondError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+
+		respondSuccess(w, status)
+	}
+}
+
+// jobErrorStatus maps job lookup errors to HTTP statuses
+func jobErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// queryInt reads an integer query parameter, falling back on missing or invalid values
+func queryInt(r *http.Request, key string, fallback int) int {
+	if str := r.URL.Query().Get(key); str != "" {
+		if val, err := strconv.Atoi(str); err == nil {
+			return val
+		}
+	}
+	return fallback
+}
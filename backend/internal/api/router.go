@@ -1,21 +1,38 @@
 package api
 
 import (
+	"context"
+	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/cerberus/backend/internal/modules/aiusage"
 	"github.com/cerberus/backend/internal/modules/artifacts"
+	"github.com/cerberus/backend/internal/modules/eventstream"
 	"github.com/cerberus/backend/internal/modules/financial"
 	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/cerberus/backend/internal/modules/prompts"
 	"github.com/cerberus/backend/internal/modules/risk"
+	"github.com/cerberus/backend/internal/modules/webhooks"
+	"github.com/cerberus/backend/internal/platform/ai"
 	"github.com/cerberus/backend/internal/platform/auth"
 	"github.com/cerberus/backend/internal/platform/db"
 	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/cerberus/backend/internal/platform/jobs"
 	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
+// requestTimeout bounds every request except uploads, which extend their own deadlines to fit
+// the program's upload limit, and event streams, which stay open
+const requestTimeout = 60 * time.Second
+
 // NewRouter creates a new API router
-func NewRouter(database *db.DB, eventBus *events.NATSBus) chi.Router {
+func NewRouter(database *db.DB, eventBus events.Bus) chi.Router {
 	r := chi.NewRouter()
 
 	// Initialize storage client
@@ -25,6 +42,13 @@ func NewRouter(database *db.DB, eventBus *events.NATSBus) chi.Router {
 	}
 	storageClient := storage.NewRustFSClient(storageEndpoint)
 
+	// Initialize Redis client; the API only reads state that workers share there
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		redisURL = "localhost:6379"
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: redisURL})
+
 	// Initialize AI client
 	// TODO: Move AI client initialization to shared location
 	// For now, create a placeholder (will be properly initialized with Redis/metrics later)
@@ -44,10 +68,46 @@ func NewRouter(database *db.DB, eventBus *events.NATSBus) chi.Router {
 	riskService := risk.NewService(riskRepo)
 	conversationService := risk.NewConversationService(riskRepo)
 
+	// Initialize AI usage reporting
+	aiUsageService := aiusage.NewService(aiusage.NewRepository(database))
+
+	// Initialize prompt registry
+	promptsService := prompts.NewService(prompts.NewRepository(database))
+
+	// Initialize webhooks module
+	webhooksService := webhooks.NewService(webhooks.NewRepository(database))
+
+	// Initialize the program event stream; every API process listens to the whole bus and
+	// resumes reconnecting clients from the outbox
+	eventHub := eventstream.NewHub(eventstream.DefaultHubConfig())
+	eventHub.SetHistory(events.NewOutboxReader(database))
+	if err := eventBus.Listen(context.Background(), eventHub.Publish); err != nil {
+		log.Printf("Warning: Program event streams disabled: %v", err)
+	}
+
 	// Initialize programs module
 	programsRepo := programs.NewRepository(database)
 	programsService := programs.NewService(programsRepo)
 	configService := programs.NewConfigService(database)
+
+	// Programs may set their own upload size limit; UPLOAD_MAX_BYTES sets the default
+	artifactsService.SetProgramConfigs(configService)
+	if value := os.Getenv("UPLOAD_MAX_BYTES"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 {
+			log.Printf("Warning: Ignoring invalid UPLOAD_MAX_BYTES %q", value)
+		} else {
+			artifactsService.SetMaxUploadBytes(limit)
+		}
+	}
+
+	// Cached AI responses of a program are stale once its configuration changes
+	responseCache := ai.NewResponseCache(redisClient, ai.DefaultResponseCacheConfig())
+	configService.OnChange(func(ctx context.Context, programID uuid.UUID) {
+		if err := responseCache.InvalidateProgram(ctx, programID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	})
 	stakeholderRepo := programs.NewStakeholderRepository(database)
 
 	// Initialize auth
@@ -57,6 +117,7 @@ func NewRouter(database *db.DB, eventBus *events.NATSBus) chi.Router {
 
 	// Auth routes (PUBLIC - no middleware)
 	r.Route("/auth", func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
 		r.Post("/register", handleRegister(database))
 		r.Post("/login", handleLogin(database, authService))
 		r.Post("/refresh", handleRefreshToken(database, authService))
@@ -69,17 +130,34 @@ func NewRouter(database *db.DB, eventBus *events.NATSBus) chi.Router {
 		})
 	})
 
+	// Uploads stream the file for as long as it takes to send, and event streams stay open,
+	// outside the request timeout
+	r.Group(func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(tokenService, authRepo))
+		artifacts.RegisterUploadRoutes(r, artifactsService, authRepo)
+		eventstream.RegisterRoutes(r, eventHub, authRepo)
+	})
+
 	// PROTECTED ROUTES - Require authentication
 	r.Group(func(r chi.Router) {
 		r.Use(auth.AuthMiddleware(tokenService, authRepo))
+		r.Use(middleware.Timeout(requestTimeout))
 
 		// Register module routes (pass authRepo for program access checks)
-		artifacts.RegisterRoutes(r, artifactsService, authRepo, eventBus)
+		artifacts.RegisterRoutes(r, artifactsService, authRepo)
 		financial.RegisterRoutes(r, financialService, authRepo)
 		risk.RegisterRoutes(r, riskService, conversationService, authRepo)
 		programs.RegisterRoutes(r, programsService, authRepo)
 		programs.RegisterConfigRoutes(r, configService, authRepo)
 		programs.RegisterStakeholderRoutes(r, stakeholderRepo, authRepo)
+		webhooks.RegisterRoutes(r, webhooksService, authRepo)
+		aiusage.RegisterRoutes(r, aiUsageService, authRepo)
+		prompts.RegisterRoutes(r, promptsService, authRepo)
+
+		// Admin routes
+		registerAdminJobRoutes(r, jobs.NewRepository(database))
+		registerAdminAIRoutes(r, redisClient)
+		prompts.RegisterAdminRoutes(r, promptsService)
 	})
 
 	return r
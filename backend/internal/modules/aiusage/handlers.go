@@ -0,0 +1,140 @@
+package aiusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// dateLayout is the format of the from and to query parameters
+const dateLayout = "2006-01-02"
+
+// RegisterRoutes registers the AI usage report
+func RegisterRoutes(r chi.Router, service *Service, authRepo *auth.Repository) {
+	r.With(auth.RequireProgramAccess(auth.RoleViewer, authRepo)).
+		Get("/programs/{programId}/ai-usage", handleGetUsage(service))
+}
+
+// handleGetUsage returns a program's AI usage report, or the usage as CSV with format=csv (or
+// Accept: text/csv). from and to are inclusive UTC dates and default to the last 30 days;
+// top sets how many of the most expensive artifacts are listed.
+func handleGetUsage(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		filter, err := parseFilter(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if wantsCSV(r) {
+			filename := fmt.Sprintf("ai-usage-%s-%s-%s.csv", programID,
+				filter.From.Format(dateLayout), filter.To.AddDate(0, 0, -1).Format(dateLayout))
+
+			// Build the export before writing headers so a failure can still be reported as JSON
+			var body strings.Builder
+			if err := service.WriteCSV(r.Context(), &body, programID, filter); err != nil {
+				respondError(w, statusForError(err), err.Error())
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body.String()))
+			return
+		}
+
+		report, err := service.GetReport(r.Context(), programID, filter)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, report)
+	}
+}
+
+// parseFilter reads the report period and top-N size from the query string
+func parseFilter(r *http.Request) (ReportFilter, error) {
+	query := r.URL.Query()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	filter := ReportFilter{
+		To:   today.AddDate(0, 0, 1),
+		TopN: DefaultTopN,
+	}
+
+	if to := query.Get("to"); to != "" {
+		day, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date: use YYYY-MM-DD")
+		}
+		filter.To = day.AddDate(0, 0, 1)
+	}
+
+	filter.From = filter.To.Add(-DefaultPeriod)
+	if from := query.Get("from"); from != "" {
+		day, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date: use YYYY-MM-DD")
+		}
+		filter.From = day
+	}
+
+	if top := query.Get("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil {
+			return filter, fmt.Errorf("invalid top: must be a number")
+		}
+		filter.TopN = n
+	}
+
+	return filter, nil
+}
+
+// wantsCSV reports whether the client asked for the CSV export
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// statusForError maps service errors to HTTP statuses
+func statusForError(err error) int {
+	if strings.HasPrefix(err.Error(), "invalid") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondSuccess(w http.ResponseWriter, data interface{}) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
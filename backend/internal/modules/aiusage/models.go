@@ -0,0 +1,79 @@
+package aiusage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Breakdown dimensions
+const (
+	DimensionDay     = "day"
+	DimensionModule  = "module"
+	DimensionJobType = "job_type"
+	DimensionModel   = "model"
+)
+
+// UsageStats sums AI usage over a set of requests
+type UsageStats struct {
+	Requests     int64   `json:"requests"` // Including those answered from the response cache
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CachedTokens int64   `json:"cached_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+
+	// CacheHitRatio is the share of input tokens read from the prompt cache
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+
+	// ResponseCacheHits counts requests answered from the response cache without an API call
+	ResponseCacheHits int64 `json:"response_cache_hits"`
+}
+
+// Totals is the usage over the whole report period
+type Totals struct {
+	UsageStats
+	ArtifactsProcessed int64   `json:"artifacts_processed"`
+	CostPerArtifact    float64 `json:"cost_per_artifact_usd"` // Artifact-attributed cost / artifacts processed
+}
+
+// Breakdown is the usage for one value of a dimension (a day, module, job type or model)
+type Breakdown struct {
+	Key string `json:"key"`
+	UsageStats
+}
+
+// ArtifactCost is the AI usage spent on one artifact
+type ArtifactCost struct {
+	ArtifactID uuid.UUID `json:"artifact_id"`
+	Filename   string    `json:"filename"`
+	UsageStats
+}
+
+// UsageRow is the usage for one day, module, job type and model; the rows of the CSV export
+type UsageRow struct {
+	Day     string
+	Module  string
+	JobType string
+	Model   string
+	UsageStats
+}
+
+// ReportFilter selects the period and size of a report
+type ReportFilter struct {
+	From time.Time // Inclusive
+	To   time.Time // Exclusive
+	TopN int       // Number of most expensive artifacts to list
+}
+
+// Report is a program's AI usage and cost over a period
+type Report struct {
+	ProgramID    uuid.UUID      `json:"program_id"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Totals       Totals         `json:"totals"`
+	ByDay        []Breakdown    `json:"by_day"`
+	ByModule     []Breakdown    `json:"by_module"`
+	ByJobType    []Breakdown    `json:"by_job_type"`
+	ByModel      []Breakdown    `json:"by_model"`
+	TopArtifacts []ArtifactCost `json:"top_artifacts"`
+}
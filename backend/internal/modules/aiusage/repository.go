@@ -0,0 +1,183 @@
+package aiusage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+)
+
+// RepositoryInterface defines methods for reading AI usage
+type RepositoryInterface interface {
+	GetTotals(ctx context.Context, programID uuid.UUID, from, to time.Time) (*Totals, error)
+	GetBreakdown(ctx context.Context, programID uuid.UUID, dimension string, from, to time.Time) ([]Breakdown, error)
+	GetTopArtifacts(ctx context.Context, programID uuid.UUID, from, to time.Time, limit int) ([]ArtifactCost, error)
+	GetUsageRows(ctx context.Context, programID uuid.UUID, from, to time.Time) ([]UsageRow, error)
+}
+
+// Repository reads AI usage from the ai_usage table
+type Repository struct {
+	db *db.DB
+}
+
+// NewRepository creates a new AI usage repository
+func NewRepository(database *db.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// statsColumns aggregates ai_usage rows into the fields scanned by scanStats
+const statsColumns = `
+	COUNT(*),
+	COALESCE(SUM(tokens_input), 0),
+	COALESCE(SUM(tokens_output), 0),
+	COALESCE(SUM(tokens_cached), 0),
+	COALESCE(SUM(cost_usd), 0),
+	COUNT(*) FILTER (WHERE cache_hit)
+`
+
+// dimensionColumns maps each breakdown dimension to the expression it groups by. Days are UTC.
+var dimensionColumns = map[string]string{
+	DimensionDay:     `to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')`,
+	DimensionModule:  `module`,
+	DimensionJobType: `COALESCE(job_type, '')`,
+	DimensionModel:   `model`,
+}
+
+// GetTotals sums a program's usage in [from, to), including the artifacts it was spent on
+func (r *Repository) GetTotals(ctx context.Context, programID uuid.UUID, from, to time.Time) (*Totals, error) {
+	query := `
+		SELECT ` + statsColumns + `,
+		       COUNT(DISTINCT artifact_id),
+		       COALESCE(SUM(cost_usd) FILTER (WHERE artifact_id IS NOT NULL), 0)
+		FROM ai_usage
+		WHERE program_id = $1 AND created_at >= $2 AND created_at < $3
+	`
+
+	var totals Totals
+	var artifactCost float64
+	dest := append(statsDest(&totals.UsageStats), &totals.ArtifactsProcessed, &artifactCost)
+	if err := r.db.QueryRowContext(ctx, query, programID, from, to).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to get AI usage totals: %w", err)
+	}
+
+	totals.UsageStats.computeRatios()
+	if totals.ArtifactsProcessed > 0 {
+		totals.CostPerArtifact = artifactCost / float64(totals.ArtifactsProcessed)
+	}
+
+	return &totals, nil
+}
+
+// GetBreakdown sums a program's usage in [from, to) per value of a dimension. Days are listed
+// in order; other dimensions most expensive first.
+func (r *Repository) GetBreakdown(ctx context.Context, programID uuid.UUID, dimension string, from, to time.Time) ([]Breakdown, error) {
+	column, ok := dimensionColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("invalid breakdown dimension: %s", dimension)
+	}
+
+	orderBy := "SUM(cost_usd) DESC, 1"
+	if dimension == DimensionDay {
+		orderBy = "1"
+	}
+
+	query := `
+		SELECT ` + column + `, ` + statsColumns + `
+		FROM ai_usage
+		WHERE program_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY 1
+		ORDER BY ` + orderBy
+
+	rows, err := r.db.QueryContext(ctx, query, programID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI usage by %s: %w", dimension, err)
+	}
+	defer rows.Close()
+
+	breakdown := make([]Breakdown, 0)
+	for rows.Next() {
+		var b Breakdown
+		if err := rows.Scan(append([]interface{}{&b.Key}, statsDest(&b.UsageStats)...)...); err != nil {
+			return nil, fmt.Errorf("failed to scan AI usage: %w", err)
+		}
+		b.UsageStats.computeRatios()
+		breakdown = append(breakdown, b)
+	}
+
+	return breakdown, rows.Err()
+}
+
+// GetTopArtifacts returns the artifacts a program spent the most on in [from, to)
+func (r *Repository) GetTopArtifacts(ctx context.Context, programID uuid.UUID, from, to time.Time, limit int) ([]ArtifactCost, error) {
+	query := `
+		SELECT u.artifact_id, COALESCE(a.filename, ''), ` + statsColumns + `
+		FROM ai_usage u
+		LEFT JOIN artifacts a ON a.artifact_id = u.artifact_id
+		WHERE u.program_id = $1 AND u.created_at >= $2 AND u.created_at < $3
+		  AND u.artifact_id IS NOT NULL
+		GROUP BY u.artifact_id, a.filename
+		ORDER BY SUM(u.cost_usd) DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, programID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get most expensive artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	artifacts := make([]ArtifactCost, 0)
+	for rows.Next() {
+		var a ArtifactCost
+		if err := rows.Scan(append([]interface{}{&a.ArtifactID, &a.Filename}, statsDest(&a.UsageStats)...)...); err != nil {
+			return nil, fmt.Errorf("failed to scan artifact cost: %w", err)
+		}
+		a.UsageStats.computeRatios()
+		artifacts = append(artifacts, a)
+	}
+
+	return artifacts, rows.Err()
+}
+
+// GetUsageRows returns a program's usage in [from, to) per day, module, job type and model
+func (r *Repository) GetUsageRows(ctx context.Context, programID uuid.UUID, from, to time.Time) ([]UsageRow, error) {
+	query := `
+		SELECT ` + dimensionColumns[DimensionDay] + `, module, COALESCE(job_type, ''), model, ` + statsColumns + `
+		FROM ai_usage
+		WHERE program_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, programID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI usage rows: %w", err)
+	}
+	defer rows.Close()
+
+	usageRows := make([]UsageRow, 0)
+	for rows.Next() {
+		var u UsageRow
+		if err := rows.Scan(append([]interface{}{&u.Day, &u.Module, &u.JobType, &u.Model}, statsDest(&u.UsageStats)...)...); err != nil {
+			return nil, fmt.Errorf("failed to scan AI usage row: %w", err)
+		}
+		u.UsageStats.computeRatios()
+		usageRows = append(usageRows, u)
+	}
+
+	return usageRows, rows.Err()
+}
+
+// statsDest returns scan destinations for statsColumns
+func statsDest(s *UsageStats) []interface{} {
+	return []interface{}{&s.Requests, &s.InputTokens, &s.OutputTokens, &s.CachedTokens, &s.CostUSD, &s.ResponseCacheHits}
+}
+
+// computeRatios fills in the fields derived from the sums
+func (s *UsageStats) computeRatios() {
+	if s.InputTokens > 0 {
+		s.CacheHitRatio = float64(s.CachedTokens) / float64(s.InputTokens)
+	}
+}
@@ -0,0 +1,151 @@
+// Package aiusage reports what AI analysis costs each program, from the usage the AI client
+// records in ai_usage: totals, breakdowns by day, module, job type and model, and the most
+// expensive artifacts.
+package aiusage
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPeriod is the report period when no range is given
+	DefaultPeriod = 30 * 24 * time.Hour
+
+	// MaxPeriod bounds the range of a single report
+	MaxPeriod = 366 * 24 * time.Hour
+
+	DefaultTopN = 10
+	MaxTopN     = 100
+)
+
+// Service builds AI usage reports
+type Service struct {
+	repo RepositoryInterface
+}
+
+// NewService creates a new AI usage service
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// NewServiceWithMocks creates a service with mock dependencies (useful for testing)
+func NewServiceWithMocks(repo RepositoryInterface) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// GetReport builds a program's usage report for the filter's period
+func (s *Service) GetReport(ctx context.Context, programID uuid.UUID, filter ReportFilter) (*Report, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.GetTotals(ctx, programID, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		ProgramID: programID,
+		From:      filter.From,
+		To:        filter.To,
+		Totals:    *totals,
+	}
+
+	breakdowns := []struct {
+		dimension string
+		dest      *[]Breakdown
+	}{
+		{DimensionDay, &report.ByDay},
+		{DimensionModule, &report.ByModule},
+		{DimensionJobType, &report.ByJobType},
+		{DimensionModel, &report.ByModel},
+	}
+	for _, b := range breakdowns {
+		if *b.dest, err = s.repo.GetBreakdown(ctx, programID, b.dimension, filter.From, filter.To); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.TopArtifacts, err = s.repo.GetTopArtifacts(ctx, programID, filter.From, filter.To, filter.TopN); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// WriteCSV writes a program's usage for the filter's period as CSV, one row per day, module,
+// job type and model
+func (s *Service) WriteCSV(ctx context.Context, w io.Writer, programID uuid.UUID, filter ReportFilter) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+
+	rows, err := s.repo.GetUsageRows(ctx, programID, filter.From, filter.To)
+	if err != nil {
+		return err
+	}
+
+	return writeUsageCSV(w, rows)
+}
+
+// csvHeader names the columns of the CSV export
+var csvHeader = []string{
+	"date", "module", "job_type", "model", "requests",
+	"input_tokens", "output_tokens", "cached_tokens", "cache_hit_ratio", "cost_usd", "response_cache_hits",
+}
+
+func writeUsageCSV(w io.Writer, rows []UsageRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Day,
+			row.Module,
+			row.JobType,
+			row.Model,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.InputTokens, 10),
+			strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.CachedTokens, 10),
+			strconv.FormatFloat(row.CacheHitRatio, 'f', 4, 64),
+			strconv.FormatFloat(row.CostUSD, 'f', 4, 64),
+			strconv.FormatInt(row.ResponseCacheHits, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func validateFilter(filter ReportFilter) error {
+	if !filter.From.Before(filter.To) {
+		return fmt.Errorf("invalid date range: from must be before to")
+	}
+	if filter.To.Sub(filter.From) > MaxPeriod {
+		return fmt.Errorf("invalid date range: at most %d days can be reported at once", int(MaxPeriod.Hours()/24))
+	}
+	if filter.TopN < 1 || filter.TopN > MaxTopN {
+		return fmt.Errorf("invalid top: must be between 1 and %d", MaxTopN)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package aiusage
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type mockRepository struct {
+	RepositoryInterface
+	rows []UsageRow
+}
+
+func (m *mockRepository) GetUsageRows(ctx context.Context, programID uuid.UUID, from, to time.Time) ([]UsageRow, error) {
+	return m.rows, nil
+}
+
+func TestWriteCSV(t *testing.T) {
+	repo := &mockRepository{rows: []UsageRow{
+		{Day: "2026-03-01", Module: "artifacts", JobType: "artifact_analysis", Model: "claude-sonnet-4-5-20250929",
+			UsageStats: UsageStats{Requests: 3, ResponseCacheHits: 1, InputTokens: 1000, OutputTokens: 200, CachedTokens: 250, CostUSD: 0.0123, CacheHitRatio: 0.25}},
+		{Day: "2026-03-02", Module: "financial", JobType: "invoice_extraction, retry", Model: "claude-sonnet-4-5-20250929",
+			UsageStats: UsageStats{Requests: 1, InputTokens: 10, OutputTokens: 5, CostUSD: 0.5}},
+	}}
+	service := NewServiceWithMocks(repo)
+
+	filter := ReportFilter{
+		From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		TopN: DefaultTopN,
+	}
+
+	var out strings.Builder
+	if err := service.WriteCSV(context.Background(), &out, uuid.New(), filter); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	want := "date,module,job_type,model,requests,input_tokens,output_tokens,cached_tokens,cache_hit_ratio,cost_usd,response_cache_hits\n" +
+		"2026-03-01,artifacts,artifact_analysis,claude-sonnet-4-5-20250929,3,1000,200,250,0.2500,0.0123,1\n" +
+		"2026-03-02,financial,\"invoice_extraction, retry\",claude-sonnet-4-5-20250929,1,10,5,0,0.0000,0.5000,0\n"
+	if out.String() != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantTopN int
+		wantErr  bool
+	}{
+		{
+			name:     "explicit range is inclusive of the to date",
+			query:    "from=2026-01-01&to=2026-01-31&top=5",
+			wantFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantTopN: 5,
+		},
+		{
+			name:     "from defaults to 30 days before to",
+			query:    "to=2026-01-31",
+			wantFrom: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantTopN: DefaultTopN,
+		},
+		{name: "malformed date", query: "from=01/01/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/programs/x/ai-usage?"+tt.query, nil)
+			filter, err := parseFilter(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !filter.From.Equal(tt.wantFrom) || !filter.To.Equal(tt.wantTo) || filter.TopN != tt.wantTopN {
+				t.Errorf("parseFilter() = %v..%v top %d, want %v..%v top %d",
+					filter.From, filter.To, filter.TopN, tt.wantFrom, tt.wantTo, tt.wantTopN)
+			}
+		})
+	}
+}
+
+func TestGetReport_RejectsInvalidFilter(t *testing.T) {
+	service := NewServiceWithMocks(&mockRepository{})
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filters := map[string]ReportFilter{
+		"empty range":  {From: from, To: from, TopN: DefaultTopN},
+		"over a year":  {From: from, To: from.AddDate(2, 0, 0), TopN: DefaultTopN},
+		"top too high": {From: from, To: from.AddDate(0, 1, 0), TopN: MaxTopN + 1},
+	}
+	for name, filter := range filters {
+		if _, err := service.GetReport(context.Background(), uuid.New(), filter); err == nil || statusForError(err) != 400 {
+			t.Errorf("%s: GetReport() error = %v, want a bad request error", name, err)
+		}
+	}
+}
@@ -4,17 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/cerberus/backend/internal/modules/artifacts/extractors"
 	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/google/uuid"
 )
 
 // AIAnalyzer handles AI-powered artifact analysis
 type AIAnalyzer struct {
-	client             *ai.Client
+	client             ai.Provider
 	prompts            *ai.PromptLibrary
 	repo               RepositoryInterface
 	contextGraphBuilder *ContextGraphBuilder
@@ -22,7 +25,7 @@ type AIAnalyzer struct {
 }
 
 // NewAIAnalyzer creates a new AI analyzer
-func NewAIAnalyzer(client *ai.Client, repo RepositoryInterface) *AIAnalyzer {
+func NewAIAnalyzer(client ai.Provider, repo RepositoryInterface) *AIAnalyzer {
 	return &AIAnalyzer{
 		client:             client,
 		prompts:            ai.NewPromptLibrary(),
@@ -32,7 +35,7 @@ func NewAIAnalyzer(client *ai.Client, repo RepositoryInterface) *AIAnalyzer {
 }
 
 // NewAIAnalyzerWithContext creates a new AI analyzer with enriched context support
-func NewAIAnalyzerWithContext(client *ai.Client, repo RepositoryInterface, contextBuilder *ContextGraphBuilder) *AIAnalyzer {
+func NewAIAnalyzerWithContext(client ai.Provider, repo RepositoryInterface, contextBuilder *ContextGraphBuilder) *AIAnalyzer {
 	return &AIAnalyzer{
 		client:              client,
 		prompts:             ai.NewPromptLibrary(),
@@ -53,6 +56,27 @@ func (a *AIAnalyzer) EnableEnrichedContext(enabled bool) {
 	a.useEnrichedContext = enabled
 }
 
+// SetPromptStore serves analysis prompts from stored versions, including program overrides
+// and candidate versions under evaluation
+func (a *AIAnalyzer) SetPromptStore(store ai.PromptStore) {
+	a.prompts.SetStore(store)
+}
+
+// AnalysisParseError reports model output that did not match the analysis schema, even after
+// a repair attempt
+type AnalysisParseError struct {
+	Prompt ai.PromptRef
+	Err    error
+}
+
+func (e *AnalysisParseError) Error() string {
+	return fmt.Sprintf("failed to parse AI response: %v", e.Err)
+}
+
+func (e *AnalysisParseError) Unwrap() error {
+	return e.Err
+}
+
 // AnalysisResult contains all extracted metadata from AI analysis
 type AnalysisResult struct {
 	DocumentType           string
@@ -65,44 +89,53 @@ type AnalysisResult struct {
 	ProcessingTime         time.Duration
 	TokensUsed             int
 	Cost                   float64
+	Prompt                 ai.PromptRef // Prompt version the analysis was produced with
+	OutputRepaired         bool         // The model had to be asked to fix invalid output
 }
 
-// AIExtractionResponse matches the JSON schema from the prompt
+// AIExtractionResponse is the structured output of artifact analysis
 type AIExtractionResponse struct {
 	DocumentType           string  `json:"document_type"`
-	DocumentTypeConfidence float64 `json:"document_type_confidence"`
+	DocumentTypeConfidence float64 `json:"document_type_confidence" jsonschema:"minimum=0,maximum=1"`
 	Summary                string  `json:"summary"`
 	KeyTopics              []struct {
 		Topic      string  `json:"topic"`
-		Confidence float64 `json:"confidence"`
+		Confidence float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
 	} `json:"key_topics"`
 	PersonsMentioned []struct {
 		Name         string  `json:"name"`
-		Role         string  `json:"role"`
-		Organization string  `json:"organization"`
-		Context      string  `json:"context"`
-		Confidence   float64 `json:"confidence"`
+		Role         string  `json:"role,omitempty"`
+		Organization string  `json:"organization,omitempty"`
+		Context      string  `json:"context,omitempty"`
+		Confidence   float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
 	} `json:"persons_mentioned"`
 	Facts []struct {
 		Type         string  `json:"type"`
 		Key          string  `json:"key"`
 		Value        string  `json:"value"`
 		NumericValue float64 `json:"numeric_value,omitempty"`
-		DateValue    string  `json:"date_value,omitempty"`
+		DateValue    string  `json:"date_value,omitempty" jsonschema:"description=YYYY-MM-DD"`
 		Unit         string  `json:"unit,omitempty"`
-		Confidence   float64 `json:"confidence"`
+		Confidence   float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
 	} `json:"facts"`
 	Insights []struct {
 		Type            string   `json:"type"`
 		Title           string   `json:"title"`
 		Description     string   `json:"description"`
-		Severity        string   `json:"severity"`
-		SuggestedAction string   `json:"suggested_action"`
-		ImpactedModules []string `json:"impacted_modules"`
-		Confidence      float64  `json:"confidence"`
+		Severity        string   `json:"severity" jsonschema:"enum=low|medium|high|critical"`
+		SuggestedAction string   `json:"suggested_action,omitempty"`
+		ImpactedModules []string `json:"impacted_modules,omitempty"`
+		Confidence      float64  `json:"confidence" jsonschema:"minimum=0,maximum=1"`
 	} `json:"insights"`
-	Sentiment string `json:"sentiment"`
-	Priority  int    `json:"priority"`
+	Sentiment string `json:"sentiment" jsonschema:"enum=positive|neutral|concern|negative"`
+	Priority  int    `json:"priority" jsonschema:"minimum=1,maximum=5"`
+}
+
+// analysisOutput is the structured output artifact analysis asks the model for
+var analysisOutput = ai.StructuredOutput{
+	Name:        "record_artifact_analysis",
+	Description: "Record the metadata extracted from the artifact",
+	Schema:      ai.SchemaFor(AIExtractionResponse{}),
 }
 
 // AnalyzeArtifact performs AI analysis on an artifact
@@ -116,11 +149,11 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 
 	if a.useEnrichedContext && a.contextGraphBuilder != nil {
 		// Use context-aware prompt
-		promptTmpl, err = a.prompts.Get("artifact_analysis_with_context_v2")
+		promptTmpl, err = a.prompts.Resolve(ctx, "artifact_analysis_with_context_v2", artifact.ProgramID, artifact.ArtifactID)
 		if err != nil {
 			// Fall back to v1 if v2 not available
 			fmt.Printf("Warning: Failed to get v2 prompt, falling back to v1: %v\n", err)
-			promptTmpl, err = a.prompts.Get("artifact_analysis_v1")
+			promptTmpl, err = a.prompts.Resolve(ctx, "artifact_analysis_v1", artifact.ProgramID, artifact.ArtifactID)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get prompt template: %w", err)
 			}
@@ -138,7 +171,7 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 		}
 	} else {
 		// Use standard prompt
-		promptTmpl, err = a.prompts.Get("artifact_analysis_v1")
+		promptTmpl, err = a.prompts.Resolve(ctx, "artifact_analysis_v1", artifact.ProgramID, artifact.ArtifactID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get prompt template: %w", err)
 		}
@@ -172,34 +205,34 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 	// Prepare static context for caching
 	staticContext := programContext.ToPromptString()
 
-	// Call Claude API with caching
-	resp, err := a.client.RequestWithContext(
-		ctx,
+	// Call Claude API with caching. The response is streamed since long documents can take
+	// longer to analyze than a single HTTP request is allowed.
+	ctx = ai.WithAttribution(ctx, ai.Attribution{Prompt: promptTmpl.Ref()})
+	req := ai.NewContextRequest(
 		promptTmpl.Model,
 		promptTmpl.SystemPrompt,
 		staticContext,
 		userPrompt,
 		promptTmpl.MaxTokens,
 	)
+	req.Stream = true
 
-	if err != nil {
-		return nil, fmt.Errorf("Claude API request failed: %w", err)
+	// The enriched context changes as the program grows, so cache the analysis on the file
+	// and prompt version; reanalysis invalidates it
+	if artifact.ContentHash != "" {
+		req.CacheKey = fmt.Sprintf("artifact:%s:%s@%s", artifact.ContentHash, promptTmpl.ID, promptTmpl.Version)
 	}
 
-	// Parse JSON response
-	responseText := resp.GetExtractedText()
-
-	// Strip markdown code blocks if present
-	responseText = stripMarkdownCodeBlocks(responseText)
-
-	// Log the response for debugging
-	fmt.Printf("AI Response (first 500 chars): %s\n", truncateString(responseText, 500))
-
 	var extraction AIExtractionResponse
-	if err := json.Unmarshal([]byte(responseText), &extraction); err != nil {
-		fmt.Printf("Failed to parse AI response. Full response length: %d\n", len(responseText))
-		fmt.Printf("Full response: %s\n", responseText)
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	resp, err := ai.RequestStructured(ctx, a.client, req, analysisOutput, &extraction)
+
+	var validationErr *ai.OutputValidationError
+	if errors.As(err, &validationErr) {
+		fmt.Printf("Invalid AI output for artifact %s: %s\n", artifact.ArtifactID, truncateString(validationErr.Raw, 500))
+		return nil, &AnalysisParseError{Prompt: promptTmpl.Ref(), Err: err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Claude API request failed: %w", err)
 	}
 
 	// Convert to domain models
@@ -209,7 +242,11 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 		ProcessingTime:         time.Since(startTime),
 		TokensUsed:             resp.Usage.InputTokens + resp.Usage.OutputTokens,
 		Cost:                   ai.NewCostCalculator().CalculateCost(resp.Model, &resp.Usage),
+		Prompt:                 promptTmpl.Ref(),
+		OutputRepaired:         resp.Repaired,
 	}
+	promptID := sql.NullString{String: promptTmpl.ID, Valid: true}
+	promptVersion := sql.NullString{String: promptTmpl.Version, Valid: true}
 
 	// Convert summary
 	result.Summary = ArtifactSummary{
@@ -221,6 +258,8 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 		Priority:         sql.NullInt32{Int32: int32(extraction.Priority), Valid: extraction.Priority > 0},
 		ConfidenceScore:  sql.NullFloat64{Float64: 0.9, Valid: true}, // Overall confidence
 		AIModel:          sql.NullString{String: resp.Model, Valid: true},
+		PromptID:         promptID,
+		PromptVersion:    promptVersion,
 		CreatedAt:        time.Now(),
 	}
 
@@ -252,7 +291,8 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 		})
 	}
 
-	// Convert facts
+	// Convert facts, citing where in the document each value appears
+	structure := artifactStructure(artifact)
 	for _, fact := range extraction.Facts {
 		f := Fact{
 			FactID:          uuid.New(),
@@ -262,6 +302,7 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 			FactValue:       fact.Value,
 			Unit:            sql.NullString{String: fact.Unit, Valid: fact.Unit != ""},
 			ConfidenceScore: sql.NullFloat64{Float64: fact.Confidence, Valid: true},
+			SourceLocation:  sourceLocation(artifact, structure, fact.Value),
 			ExtractedAt:     time.Now(),
 		}
 
@@ -291,6 +332,8 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 			SuggestedAction: sql.NullString{String: insight.SuggestedAction, Valid: insight.SuggestedAction != ""},
 			ImpactedModules: insight.ImpactedModules,
 			ConfidenceScore: sql.NullFloat64{Float64: insight.Confidence, Valid: true},
+			PromptID:        promptID,
+			PromptVersion:   promptVersion,
 			IsDismissed:     false,
 			ExtractedAt:     time.Now(),
 		})
@@ -299,56 +342,21 @@ func (a *AIAnalyzer) AnalyzeArtifact(ctx context.Context, artifact *Artifact, pr
 	return result, nil
 }
 
-// StoreAnalysisResults saves all analysis results to the database
-func (a *AIAnalyzer) StoreAnalysisResults(ctx context.Context, artifactID uuid.UUID, result *AnalysisResult) error {
-	// Save summary
-	if err := a.repo.SaveSummary(ctx, &result.Summary); err != nil {
-		return fmt.Errorf("failed to save summary: %w", err)
-	}
-
-	// Save topics
-	if err := a.repo.SaveTopics(ctx, result.Topics); err != nil {
-		return fmt.Errorf("failed to save topics: %w", err)
-	}
-
-	// Save persons
-	if err := a.repo.SavePersons(ctx, result.Persons); err != nil {
-		return fmt.Errorf("failed to save persons: %w", err)
-	}
-
-	// Save facts
-	if err := a.repo.SaveFacts(ctx, result.Facts); err != nil {
-		return fmt.Errorf("failed to save facts: %w", err)
-	}
-
-	// Save insights
-	if err := a.repo.SaveInsights(ctx, result.Insights); err != nil {
-		return fmt.Errorf("failed to save insights: %w", err)
-	}
-
-	// Update artifact category with document type
-	if result.DocumentType != "" {
-		query := `
-			UPDATE artifacts
-			SET artifact_category = $1
-			WHERE artifact_id = $2
-		`
-		_, err := a.repo.ExecContext(ctx, query, result.DocumentType, artifactID)
-		if err != nil {
-			return fmt.Errorf("failed to update artifact category: %w", err)
-		}
-	}
-
-	// Update artifact status to completed
-	if err := a.repo.UpdateStatus(ctx, artifactID, "completed"); err != nil {
-		return fmt.Errorf("failed to update artifact status: %w", err)
-	}
-
-	return nil
+// StoreAnalysisResults saves all analysis results to the database and queues outboxEvents in
+// the same transaction
+func (a *AIAnalyzer) StoreAnalysisResults(ctx context.Context, artifactID uuid.UUID, result *AnalysisResult, outboxEvents ...*events.Event) error {
+	return a.repo.StoreAnalysisResults(ctx, artifactID, result, outboxEvents...)
 }
 
 // ProcessArtifact performs complete analysis: analyze + store results
 func (a *AIAnalyzer) ProcessArtifact(ctx context.Context, artifact *Artifact, programContext *ai.ProgramContext) error {
+	ctx = ai.WithAttribution(ctx, ai.Attribution{
+		ProgramID:  artifact.ProgramID,
+		ArtifactID: artifact.ArtifactID,
+		Module:     "artifacts",
+		JobType:    "artifact_analysis",
+	})
+
 	// Update status to processing
 	if err := a.repo.UpdateStatus(ctx, artifact.ArtifactID, "processing"); err != nil {
 		return fmt.Errorf("failed to update status to processing: %w", err)
@@ -356,14 +364,19 @@ func (a *AIAnalyzer) ProcessArtifact(ctx context.Context, artifact *Artifact, pr
 
 	// Analyze artifact
 	result, err := a.AnalyzeArtifact(ctx, artifact, programContext)
+	a.recordPromptRun(ctx, artifact, result, err)
 	if err != nil {
 		// Mark as failed
 		a.repo.UpdateStatus(ctx, artifact.ArtifactID, "failed")
 		return fmt.Errorf("analysis failed: %w", err)
 	}
 
-	// Store results
-	if err := a.StoreAnalysisResults(ctx, artifact.ArtifactID, result); err != nil {
+	// Store results along with decision and change events for the insights
+	outboxEvents, err := insightEvents(artifact, result.Insights)
+	if err == nil {
+		err = a.StoreAnalysisResults(ctx, artifact.ArtifactID, result, outboxEvents...)
+	}
+	if err != nil {
 		// Mark as failed
 		a.repo.UpdateStatus(ctx, artifact.ArtifactID, "failed")
 		return fmt.Errorf("failed to store results: %w", err)
@@ -372,25 +385,68 @@ func (a *AIAnalyzer) ProcessArtifact(ctx context.Context, artifact *Artifact, pr
 	return nil
 }
 
-// stripMarkdownCodeBlocks removes markdown code block wrappers from text
-func stripMarkdownCodeBlocks(text string) string {
-	// Remove ```json\n and ``` markers
-	text = strings.TrimSpace(text)
-
-	// Check if wrapped in code blocks
-	if strings.HasPrefix(text, "```") {
-		// Find the first newline after ```
-		firstNewline := strings.Index(text, "\n")
-		if firstNewline > 0 {
-			text = text[firstNewline+1:]
+// recordPromptRun records the outcome of an analysis against the prompt version that produced
+// it. Failures before a response was parsed (API errors, missing content) say nothing about
+// the prompt and are not recorded.
+func (a *AIAnalyzer) recordPromptRun(ctx context.Context, artifact *Artifact, result *AnalysisResult, err error) {
+	run := &PromptRun{
+		ProgramID:  artifact.ProgramID,
+		ArtifactID: artifact.ArtifactID,
+	}
+
+	var parseErr *AnalysisParseError
+	switch {
+	case err == nil:
+		run.Prompt = result.Prompt
+		run.Outcome = PromptRunSucceeded
+		if result.OutputRepaired {
+			run.Outcome = PromptRunRepaired
 		}
+	case errors.As(err, &parseErr):
+		run.Prompt = parseErr.Prompt
+		run.Outcome = PromptRunParseFailed
+		run.ErrorMessage = sql.NullString{String: parseErr.Err.Error(), Valid: true}
+	default:
+		return
+	}
 
-		// Remove trailing ```
-		text = strings.TrimSuffix(text, "```")
-		text = strings.TrimSpace(text)
+	if err := a.repo.RecordPromptRun(ctx, run); err != nil {
+		fmt.Printf("Warning: Failed to record prompt run for artifact %s: %v\n", artifact.ArtifactID, err)
+	}
+}
+
+// artifactStructure decodes the document structure recorded at upload, or returns nil if there
+// is none
+func artifactStructure(artifact *Artifact) *extractors.ExtractionResult {
+	if len(artifact.Extraction) == 0 {
+		return nil
+	}
+	var structure extractors.ExtractionResult
+	if err := json.Unmarshal(artifact.Extraction, &structure); err != nil {
+		fmt.Printf("Warning: Failed to decode extraction structure for artifact %s: %v\n", artifact.ArtifactID, err)
+		return nil
+	}
+	return &structure
+}
+
+// sourceLocation locates the first occurrence of text in the artifact's content, e.g. "page 7"
+func sourceLocation(artifact *Artifact, structure *extractors.ExtractionResult, text string) sql.NullString {
+	text = strings.TrimSpace(text)
+	if structure == nil || text == "" {
+		return sql.NullString{}
+	}
+
+	content := artifact.RawContent.String
+	offset := strings.Index(content, text)
+	if offset < 0 {
+		offset = strings.Index(strings.ToLower(content), strings.ToLower(text))
+	}
+	if offset < 0 {
+		return sql.NullString{}
 	}
 
-	return text
+	location := structure.Location(offset)
+	return sql.NullString{String: location, Valid: location != ""}
 }
 
 // truncateString truncates a string to a maximum length
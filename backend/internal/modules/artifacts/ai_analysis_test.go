@@ -0,0 +1,62 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/google/uuid"
+)
+
+const scriptedAnalysis = `{
+	"document_type": "status_report",
+	"document_type_confidence": 0.92,
+	"summary": "Migration is two weeks behind schedule.",
+	"key_topics": [{"topic": "Data migration", "confidence": 0.9}],
+	"persons_mentioned": [{"name": "Dana Ortiz", "role": "PM", "organization": "Acme", "context": "owns the cutover", "confidence": 0.8}],
+	"facts": [{"type": "date", "key": "cutover", "value": "June 30", "date_value": "2026-06-30", "confidence": 0.85}],
+	"insights": [{"type": "risk", "title": "Schedule slip", "description": "Cutover at risk", "severity": "high", "confidence": 0.7}],
+	"sentiment": "negative",
+	"priority": 2
+}`
+
+func TestAIAnalyzer_AnalyzeArtifactWithFakeProvider(t *testing.T) {
+	fake := ai.NewFakeProvider(ai.FakeRule{
+		Match:    "weekly-status.txt",
+		Response: "```json\n" + scriptedAnalysis + "\n```",
+		Usage:    ai.Usage{InputTokens: 1200, OutputTokens: 300},
+	})
+	client := ai.NewClient(&ai.ClientConfig{Provider: fake})
+	analyzer := NewAIAnalyzer(client, nil)
+
+	artifact := &Artifact{
+		ArtifactID: uuid.New(),
+		Filename:   "weekly-status.txt",
+		RawContent: sql.NullString{String: "Cutover moved to June 30.", Valid: true},
+	}
+	result, err := analyzer.AnalyzeArtifact(context.Background(), artifact, &ai.ProgramContext{ProgramName: "Phoenix"})
+	if err != nil {
+		t.Fatalf("AnalyzeArtifact() error = %v", err)
+	}
+
+	if result.DocumentType != "status_report" || result.TokensUsed != 1500 {
+		t.Errorf("result = %s with %d tokens, want status_report with 1500", result.DocumentType, result.TokensUsed)
+	}
+	if len(result.Topics) != 1 || len(result.Persons) != 1 || len(result.Facts) != 1 || len(result.Insights) != 1 {
+		t.Fatalf("extracted %d topics, %d persons, %d facts, %d insights, want one of each",
+			len(result.Topics), len(result.Persons), len(result.Facts), len(result.Insights))
+	}
+	if !result.Facts[0].NormalizedValueDate.Valid {
+		t.Errorf("fact date was not normalized")
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("provider received %d requests, want 1", len(requests))
+	}
+	if prompt := requests[0].Messages[0].Content[1].Text; !strings.Contains(prompt, "Cutover moved to June 30.") {
+		t.Errorf("prompt does not include the artifact content")
+	}
+}
@@ -12,6 +12,7 @@ type Chunk struct {
 	StartOffset int
 	EndOffset   int
 	TokenCount  int
+	Location    string // Where the chunk is in the source document, if known
 }
 
 // ChunkingStrategy defines parameters for document chunking
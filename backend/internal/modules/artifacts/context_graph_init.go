@@ -1,10 +1,13 @@
 package artifacts
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -60,7 +63,7 @@ func InitializeContextGraphServices(
 // InitializeAIAnalyzerWithContext creates an AI analyzer with enriched context support
 // This is the recommended way to create an AI analyzer for production use
 func InitializeAIAnalyzerWithContext(
-	aiClient *ai.Client,
+	aiClient ai.Provider,
 	repo RepositoryInterface,
 	redisClient *redis.Client,
 ) (*AIAnalyzer, error) {
@@ -242,36 +245,114 @@ func NewBackgroundJobs(cache *ContextCache, entityGraph *EntityGraphQuery, tempo
 	}
 }
 
-// RunCacheCleanup should run periodically (e.g., every hour)
-func (bj *BackgroundJobs) RunCacheCleanup() {
+// RunCacheCleanup removes expired context cache entries. Scheduled hourly by the worker.
+func (bj *BackgroundJobs) RunCacheCleanup(ctx context.Context) error {
 	if bj.contextCache == nil {
-		return
+		return nil
 	}
 
-	// Clean up expired cache entries
-	// This would typically be called from a cron job or background worker
 	log.Println("Running cache cleanup...")
-	// Implementation would call contextCache.CleanupExpiredCache()
+	_, err := bj.contextCache.CleanupExpiredCache(ctx)
+	return err
 }
 
-// RunEntityGraphRebuild should run daily to recompute entity relationships
-func (bj *BackgroundJobs) RunEntityGraphRebuild() {
+// RunEntityGraphRebuild recomputes each program's entity co-occurrences and relationship
+// strengths from its artifacts. Scheduled daily by the worker.
+func (bj *BackgroundJobs) RunEntityGraphRebuild(ctx context.Context) error {
 	if bj.entityGraph == nil {
-		return
+		return nil
 	}
 
 	log.Println("Rebuilding entity graph...")
-	// This would recompute co-occurrences and relationship strengths
-	// Implementation would iterate through programs and rebuild their graphs
+	programIDs, err := bj.entityGraph.repo.ListActiveProgramIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, programID := range programIDs {
+		if err := bj.rebuildEntityGraph(ctx, programID); err != nil {
+			log.Printf("Failed to rebuild entity graph for program %s: %v", programID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("entity graph rebuild failed for %d of %d programs", failed, len(programIDs))
+	}
+	return nil
+}
+
+// rebuildEntityGraph clears a program's entity graph and adds the people of each of its
+// artifacts again, so edges of deleted artifacts drop out
+func (bj *BackgroundJobs) rebuildEntityGraph(ctx context.Context, programID uuid.UUID) error {
+	artifactIDs, err := bj.entityGraph.repo.GetArtifactIDsByProgram(ctx, programID)
+	if err != nil {
+		return err
+	}
+
+	if err := bj.entityGraph.repo.DeleteEntityGraph(ctx, programID); err != nil {
+		return err
+	}
+
+	for _, artifactID := range artifactIDs {
+		if err := bj.entityGraph.BuildEntityGraphForArtifact(ctx, artifactID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// RunSequenceDetection should run daily to detect temporal sequences
-func (bj *BackgroundJobs) RunSequenceDetection() {
+// RunSequenceDetection detects recurring artifact sequences, such as weekly reports, in each
+// program and replaces the sequences detected before. Scheduled daily by the worker.
+func (bj *BackgroundJobs) RunSequenceDetection(ctx context.Context) error {
 	if bj.temporal == nil {
-		return
+		return nil
 	}
 
 	log.Println("Detecting temporal sequences...")
-	// This would detect patterns like weekly meetings, monthly reports, etc.
-	// Implementation would call temporal.DetectSequences() for each program
+	programIDs, err := bj.temporal.repo.ListActiveProgramIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	detected := 0
+	for _, programID := range programIDs {
+		count, err := bj.detectSequences(ctx, programID)
+		if err != nil {
+			log.Printf("Failed to detect sequences for program %s: %v", programID, err)
+			failed++
+			continue
+		}
+		detected += count
+	}
+
+	log.Printf("Detected %d temporal sequences across %d programs", detected, len(programIDs))
+	if failed > 0 {
+		return fmt.Errorf("sequence detection failed for %d of %d programs", failed, len(programIDs))
+	}
+	return nil
+}
+
+// detectSequences replaces a program's automatically detected sequences and returns how many
+// were found
+func (bj *BackgroundJobs) detectSequences(ctx context.Context, programID uuid.UUID) (int, error) {
+	sequences, err := bj.temporal.DetectSequences(ctx, programID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := bj.temporal.repo.DeleteDetectedSequences(ctx, programID); err != nil {
+		return 0, err
+	}
+
+	for i := range sequences {
+		if err := bj.temporal.SaveSequence(ctx, &sequences[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(sequences), nil
 }
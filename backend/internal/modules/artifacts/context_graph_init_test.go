@@ -0,0 +1,103 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// graphRepository serves the artifacts and people the entity graph rebuild reads
+type graphRepository struct {
+	RepositoryInterface
+
+	programs  []uuid.UUID
+	artifacts map[uuid.UUID][]uuid.UUID
+	persons   map[uuid.UUID][]Person
+	failFor   uuid.UUID
+
+	cleared []uuid.UUID
+	edges   int
+}
+
+func (r *graphRepository) ListActiveProgramIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return r.programs, nil
+}
+
+func (r *graphRepository) GetArtifactIDsByProgram(ctx context.Context, programID uuid.UUID) ([]uuid.UUID, error) {
+	if programID == r.failFor {
+		return nil, errors.New("database unavailable")
+	}
+	return r.artifacts[programID], nil
+}
+
+func (r *graphRepository) DeleteEntityGraph(ctx context.Context, programID uuid.UUID) error {
+	r.cleared = append(r.cleared, programID)
+	return nil
+}
+
+func (r *graphRepository) GetPersonsByArtifact(ctx context.Context, artifactID uuid.UUID) ([]Person, error) {
+	return r.persons[artifactID], nil
+}
+
+func (r *graphRepository) GetArtifactByID(ctx context.Context, artifactID uuid.UUID) (*Artifact, error) {
+	for programID, ids := range r.artifacts {
+		for _, id := range ids {
+			if id == artifactID {
+				return &Artifact{ArtifactID: artifactID, ProgramID: programID}, nil
+			}
+		}
+	}
+	return nil, errors.New("artifact not found")
+}
+
+func (r *graphRepository) UpsertEntityGraphEdge(ctx context.Context, programID, person1ID, person2ID, artifactID uuid.UUID) error {
+	r.edges++
+	return nil
+}
+
+// TestRunEntityGraphRebuild clears each program's graph and adds an edge per pair of people
+func TestRunEntityGraphRebuild(t *testing.T) {
+	programID := uuid.New()
+	artifactID := uuid.New()
+	repo := &graphRepository{
+		programs:  []uuid.UUID{programID},
+		artifacts: map[uuid.UUID][]uuid.UUID{programID: {artifactID, uuid.New()}},
+		persons: map[uuid.UUID][]Person{
+			artifactID: {{PersonID: uuid.New()}, {PersonID: uuid.New()}, {PersonID: uuid.New()}},
+		},
+	}
+
+	jobs := NewBackgroundJobs(nil, NewEntityGraphQuery(repo), nil)
+	if err := jobs.RunEntityGraphRebuild(context.Background()); err != nil {
+		t.Fatalf("RunEntityGraphRebuild() error = %v", err)
+	}
+
+	if len(repo.cleared) != 1 || repo.cleared[0] != programID {
+		t.Errorf("cleared graphs = %v, want [%s]", repo.cleared, programID)
+	}
+	if repo.edges != 3 {
+		t.Errorf("upserted %d edges, want 3", repo.edges)
+	}
+}
+
+// TestRunEntityGraphRebuildReportsFailures keeps rebuilding other programs and fails the run
+func TestRunEntityGraphRebuildReportsFailures(t *testing.T) {
+	failing := uuid.New()
+	healthy := uuid.New()
+	repo := &graphRepository{
+		programs:  []uuid.UUID{failing, healthy},
+		artifacts: map[uuid.UUID][]uuid.UUID{},
+		failFor:   failing,
+	}
+
+	jobs := NewBackgroundJobs(nil, NewEntityGraphQuery(repo), nil)
+	if err := jobs.RunEntityGraphRebuild(context.Background()); err == nil {
+		t.Fatal("RunEntityGraphRebuild() succeeded although a program failed")
+	}
+
+	if len(repo.cleared) != 1 || repo.cleared[0] != healthy {
+		t.Errorf("cleared graphs = %v, want [%s]", repo.cleared, healthy)
+	}
+}
@@ -0,0 +1,47 @@
+package artifacts
+
+import (
+	"strings"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// insightEventType maps an insight type to the domain event it raises, if any
+func insightEventType(insightType string) (events.EventType, bool) {
+	switch strings.ToLower(insightType) {
+	case "decision":
+		return events.DecisionExtracted, true
+	case "change", "change_request", "scope_change":
+		return events.ChangeProposed, true
+	default:
+		return "", false
+	}
+}
+
+// insightEvents builds DecisionExtracted and ChangeProposed events for an artifact's insights
+func insightEvents(artifact *Artifact, insights []Insight) ([]*events.Event, error) {
+	var insightEvents []*events.Event
+	for _, insight := range insights {
+		eventType, ok := insightEventType(insight.InsightType)
+		if !ok {
+			continue
+		}
+
+		event, err := events.NewEventFromPayload(eventType, artifact.ProgramID, "artifacts", events.InsightEventPayload{
+			InsightID:       insight.InsightID,
+			ArtifactID:      artifact.ArtifactID,
+			InsightType:     insight.InsightType,
+			Title:           insight.Title,
+			Description:     insight.Description,
+			Severity:        insight.Severity.String,
+			SuggestedAction: insight.SuggestedAction.String,
+			ConfidenceScore: insight.ConfidenceScore.Float64,
+		})
+		if err != nil {
+			return nil, err
+		}
+		insightEvents = append(insightEvents, event.WithAIMetadata(insight.ConfidenceScore.Float64, []uuid.UUID{artifact.ArtifactID}))
+	}
+	return insightEvents, nil
+}
@@ -0,0 +1,333 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DOCXExtractor extracts text from Word documents (.docx), keeping headings, lists, tables and
+// reviewer comments
+type DOCXExtractor struct{}
+
+// NewDOCXExtractor creates a new Word document extractor
+func NewDOCXExtractor() *DOCXExtractor {
+	return &DOCXExtractor{}
+}
+
+// CanExtract returns true for Word document MIME types
+func (e *DOCXExtractor) CanExtract(mimeType string) bool {
+	wordTypes := []string{
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document", // .docx
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.template", // .dotx
+		"application/vnd.ms-word.document.macroenabled.12",                        // .docm
+	}
+
+	for _, t := range wordTypes {
+		if strings.HasPrefix(strings.ToLower(mimeType), t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Extract extracts text content from a Word document
+func (e *DOCXExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts text content with its headed sections, tables and document properties
+func (e *DOCXExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	documentPart := pkg.mainPart("word/document.xml")
+	document, err := pkg.parse(documentPart)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, fmt.Errorf("no document found in Word file")
+	}
+	body := document.descendants("body")
+	if len(body) == 0 {
+		return nil, fmt.Errorf("no document body found in Word file")
+	}
+
+	rels, err := pkg.relationships(documentPart)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &docxWriter{out: newResultBuilder(), headings: map[string]int{}, anchors: map[string]*strings.Builder{}, anchored: map[string]string{}}
+	for _, stylesPart := range related(rels, "/styles") {
+		styles, err := pkg.parse(stylesPart)
+		if err != nil {
+			return nil, err
+		}
+		if styles != nil {
+			w.loadHeadingStyles(styles)
+		}
+	}
+
+	w.block(body[0])
+
+	if strings.TrimSpace(w.out.String()) == "" {
+		return nil, fmt.Errorf("no content extracted from Word document")
+	}
+
+	w.out.trimRight()
+	w.out.endSection(SectionHeading)
+	w.out.WriteString("\n")
+	for _, commentsPart := range related(rels, "/comments") {
+		comments, err := pkg.parse(commentsPart)
+		if err != nil {
+			return nil, err
+		}
+		if comments != nil {
+			writeComments(w.out, w.comments(comments))
+		}
+	}
+
+	if err := pkg.readCoreProperties(w.out); err != nil {
+		return nil, err
+	}
+
+	return w.out.finish(), nil
+}
+
+// Heading styles are recognized by name, or by ID when the styles part is missing
+var (
+	headingStyleName = regexp.MustCompile(`(?i)^heading ?([1-9])$`)
+	headingStyleID   = regexp.MustCompile(`^Heading([1-9])$`)
+)
+
+// docxWriter renders the body of a Word document
+type docxWriter struct {
+	out      *resultBuilder
+	headings map[string]int // Paragraph style ID -> heading level
+
+	// Text covered by each comment: accumulating while its range is open, then complete
+	anchors  map[string]*strings.Builder
+	anchored map[string]string
+}
+
+// loadHeadingStyles finds the paragraph styles that are headings: built-in heading and title
+// styles, and styles with an outline level
+func (w *docxWriter) loadHeadingStyles(styles *xmlNode) {
+	for _, style := range styles.descendants("style") {
+		if style.attr("type") != "paragraph" {
+			continue
+		}
+		id := style.attr("styleId")
+
+		if name := style.child("name"); name != nil {
+			if m := headingStyleName.FindStringSubmatch(name.attr("val")); m != nil {
+				w.headings[id], _ = strconv.Atoi(m[1])
+				continue
+			}
+			if strings.EqualFold(name.attr("val"), "title") {
+				w.headings[id] = 1
+				continue
+			}
+		}
+		if level := outlineLevel(style.child("pPr")); level > 0 {
+			w.headings[id] = level
+		}
+	}
+}
+
+// block renders the paragraphs and tables of a body, table cell or content control
+func (w *docxWriter) block(node *xmlNode) {
+	for _, child := range node.children {
+		switch child.name.Local {
+		case "p":
+			w.paragraph(child)
+		case "tbl":
+			w.table(child)
+		case "sdt":
+			if content := child.child("sdtContent"); content != nil {
+				w.block(content)
+			}
+		case "customXml":
+			w.block(child)
+		}
+	}
+}
+
+func (w *docxWriter) paragraph(p *xmlNode) {
+	text := strings.TrimSpace(w.text(p))
+	for _, anchor := range w.anchors {
+		anchor.WriteString("\n")
+	}
+	if text == "" {
+		return
+	}
+
+	props := p.child("pPr")
+	if level := w.headingLevel(props); level > 0 {
+		if w.out.Len() > 0 {
+			w.out.WriteString("\n")
+		}
+		w.out.startSection(SectionHeading, oneLine(text))
+		w.out.WriteString(strings.Repeat("#", level) + " " + oneLine(text) + "\n\n")
+		return
+	}
+
+	if props != nil && props.child("numPr") != nil {
+		indent := 0
+		if ilvl := props.child("numPr").child("ilvl"); ilvl != nil {
+			indent, _ = strconv.Atoi(ilvl.attr("val"))
+		}
+		w.out.WriteString(strings.Repeat("  ", indent) + "- ")
+	}
+	w.out.WriteString(text + "\n")
+}
+
+func (w *docxWriter) headingLevel(props *xmlNode) int {
+	if props == nil {
+		return 0
+	}
+	if style := props.child("pStyle"); style != nil {
+		if level, ok := w.headings[style.attr("val")]; ok {
+			return level
+		}
+		if m := headingStyleID.FindStringSubmatch(style.attr("val")); m != nil {
+			level, _ := strconv.Atoi(m[1])
+			return level
+		}
+	}
+	return outlineLevel(props)
+}
+
+// outlineLevel returns the heading level set by a paragraph's outline level, or 0 for body text
+func outlineLevel(props *xmlNode) int {
+	if props == nil || props.child("outlineLvl") == nil {
+		return 0
+	}
+	level, err := strconv.Atoi(props.child("outlineLvl").attr("val"))
+	if err != nil || level > 8 {
+		return 0
+	}
+	return level + 1
+}
+
+// text returns the text of a paragraph's runs, hyperlinks and insertions. Deleted text, field
+// instructions and the fallback copies of drawings are left out.
+func (w *docxWriter) text(node *xmlNode) string {
+	var b strings.Builder
+	for _, child := range node.children {
+		switch child.name.Local {
+		case "t":
+			w.write(&b, textContent(child))
+		case "tab", "ptab":
+			w.write(&b, "\t")
+		case "br", "cr":
+			w.write(&b, "\n")
+		case "noBreakHyphen":
+			w.write(&b, "-")
+		case "commentRangeStart":
+			w.anchors[child.attr("id")] = &strings.Builder{}
+		case "commentRangeEnd":
+			if anchor, ok := w.anchors[child.attr("id")]; ok {
+				w.anchored[child.attr("id")] = anchor.String()
+				delete(w.anchors, child.attr("id"))
+			}
+		case "pPr", "rPr", "delText", "instrText", "Fallback":
+		default:
+			b.WriteString(w.text(child))
+		}
+	}
+	return b.String()
+}
+
+// write adds text to a paragraph and to the comments whose range is open
+func (w *docxWriter) write(b *strings.Builder, s string) {
+	b.WriteString(s)
+	for _, anchor := range w.anchors {
+		anchor.WriteString(s)
+	}
+}
+
+func (w *docxWriter) table(tbl *xmlNode) {
+	var rows [][]string
+	for _, tr := range tbl.children {
+		if !tr.is("tr") {
+			continue
+		}
+
+		var row []string
+		for _, tc := range tr.children {
+			if !tc.is("tc") {
+				continue
+			}
+
+			var cell []string
+			for _, p := range tc.descendants("p") {
+				if text := strings.TrimSpace(w.text(p)); text != "" {
+					cell = append(cell, text)
+				}
+			}
+			row = append(row, strings.Join(cell, " "))
+
+			// Keep columns aligned under cells spanning several of them
+			if props := tc.child("tcPr"); props != nil && props.child("gridSpan") != nil {
+				span, _ := strconv.Atoi(props.child("gridSpan").attr("val"))
+				for i := 1; i < span; i++ {
+					row = append(row, "")
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if w.out.Len() > 0 {
+		w.out.WriteString("\n")
+	}
+	writeTable(w.out, rows)
+	w.out.WriteString("\n")
+}
+
+// comments reads the comments part, attaching the text each comment covers
+func (w *docxWriter) comments(root *xmlNode) []documentComment {
+	var comments []documentComment
+	for _, c := range root.descendants("comment") {
+		var paragraphs []string
+		for _, p := range c.descendants("p") {
+			if text := strings.TrimSpace(w.text(p)); text != "" {
+				paragraphs = append(paragraphs, text)
+			}
+		}
+		if len(paragraphs) == 0 {
+			continue
+		}
+
+		comments = append(comments, documentComment{
+			author: c.attr("author"),
+			date:   c.attr("date"),
+			text:   strings.Join(paragraphs, " "),
+			anchor: w.anchored[c.attr("id")],
+		})
+	}
+	return comments
+}
+
+// textContent returns the character data directly inside an element
+func textContent(n *xmlNode) string {
+	var b strings.Builder
+	for _, c := range n.children {
+		if c.name.Local == "" {
+			b.WriteString(c.text)
+		}
+	}
+	return b.String()
+}
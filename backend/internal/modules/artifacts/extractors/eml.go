@@ -3,12 +3,25 @@ package extractors
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
+	"mime/quotedprintable"
 	"net/mail"
+	"net/textproto"
+	"regexp"
 	"strings"
+	"time"
+)
+
+const (
+	// maxMIMEDepth bounds how deeply nested multipart bodies are read
+	maxMIMEDepth = 10
+
+	// maxAttachmentSize bounds how much of one attachment is decoded
+	maxAttachmentSize = 100 << 20
 )
 
 // EMLExtractor extracts text from email files (.eml)
@@ -38,198 +51,264 @@ func (e *EMLExtractor) CanExtract(mimeType string) bool {
 
 // Extract extracts text content from an email message
 func (e *EMLExtractor) Extract(ctx context.Context, data []byte) (string, error) {
-	// Parse the email message
-	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts the message text, recording its headers as metadata. Attachments are
+// listed by name; ParseEmail returns their content.
+func (e *EMLExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
+	msg, err := ParseEmail(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	text := msg.Text()
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no text content found in email")
+	}
+
+	result := plainResult(text)
+	for key, value := range map[string]string{
+		"from":        msg.From,
+		"to":          msg.To,
+		"cc":          msg.Cc,
+		"subject":     msg.Subject,
+		"date":        msg.Header.Get("Date"),
+		"message_id":  msg.MessageID,
+		"in_reply_to": msg.InReplyTo,
+	} {
+		if value != "" {
+			result.Metadata[key] = value
+		}
+	}
+	return result, nil
+}
+
+// EmailMessage is a parsed email message
+type EmailMessage struct {
+	Header      mail.Header
+	MessageID   string
+	InReplyTo   string   // Message-ID of the message this one replies to
+	References  []string // Message-IDs of the earlier messages in the conversation, oldest first
+	From        string
+	To          string
+	Cc          string
+	Subject     string
+	Date        time.Time // Zero if the message has no valid Date header
+	Body        string    // Plain text body, or the HTML body stripped of tags
+	Attachments []Attachment
+}
+
+// Attachment represents an email attachment
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	Data        []byte
+}
+
+var messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+// ParseEmail parses an RFC 5322 message, decoding its headers, body and attachments
+func ParseEmail(ctx context.Context, data []byte) (*EmailMessage, error) {
+	raw, err := mail.ReadMessage(bytes.NewReader(data))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse email: %w", err)
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	msg := &EmailMessage{
+		Header:     raw.Header,
+		From:       decodeHeader(raw.Header.Get("From")),
+		To:         decodeHeader(raw.Header.Get("To")),
+		Cc:         decodeHeader(raw.Header.Get("Cc")),
+		Subject:    decodeHeader(raw.Header.Get("Subject")),
+		References: messageIDPattern.FindAllString(raw.Header.Get("References"), -1),
+	}
+	if id := messageIDPattern.FindString(raw.Header.Get("Message-Id")); id != "" {
+		msg.MessageID = id
+	}
+	if ids := messageIDPattern.FindAllString(raw.Header.Get("In-Reply-To"), -1); len(ids) > 0 {
+		msg.InReplyTo = ids[0]
 	}
+	if date, err := raw.Header.Date(); err == nil {
+		msg.Date = date
+	}
+
+	var body emailBody
+	if err := body.read(ctx, textproto.MIMEHeader(raw.Header), raw.Body, 0); err != nil {
+		return nil, err
+	}
+	msg.Attachments = body.attachments
+	if body.plain != "" {
+		msg.Body = body.plain
+	} else if body.html != "" {
+		// Strip basic HTML tags for better readability
+		msg.Body = stripBasicHTMLTags(body.html)
+	}
+	msg.Body += body.notes
 
+	return msg, nil
+}
+
+// Text renders the message as the text content of an email artifact
+func (m *EmailMessage) Text() string {
 	var result strings.Builder
 
-	// Extract headers
 	result.WriteString("=== Email Message ===\n\n")
 	result.WriteString("--- Headers ---\n")
 
-	if from := msg.Header.Get("From"); from != "" {
-		result.WriteString(fmt.Sprintf("From: %s\n", from))
+	if m.From != "" {
+		result.WriteString(fmt.Sprintf("From: %s\n", m.From))
 	}
-	if to := msg.Header.Get("To"); to != "" {
-		result.WriteString(fmt.Sprintf("To: %s\n", to))
+	if m.To != "" {
+		result.WriteString(fmt.Sprintf("To: %s\n", m.To))
 	}
-	if cc := msg.Header.Get("Cc"); cc != "" {
-		result.WriteString(fmt.Sprintf("Cc: %s\n", cc))
+	if m.Cc != "" {
+		result.WriteString(fmt.Sprintf("Cc: %s\n", m.Cc))
 	}
-	if subject := msg.Header.Get("Subject"); subject != "" {
-		result.WriteString(fmt.Sprintf("Subject: %s\n", subject))
+	if m.Subject != "" {
+		result.WriteString(fmt.Sprintf("Subject: %s\n", m.Subject))
 	}
-	if date := msg.Header.Get("Date"); date != "" {
+	if date := m.Header.Get("Date"); date != "" {
 		result.WriteString(fmt.Sprintf("Date: %s\n", date))
 	}
 
 	result.WriteString("\n--- Body ---\n\n")
+	result.WriteString(m.Body)
 
-	// Extract body content
-	contentType := msg.Header.Get("Content-Type")
-	if contentType == "" {
-		// Default to plain text if no content type specified
-		body, err := io.ReadAll(msg.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read email body: %w", err)
-		}
-		result.WriteString(string(body))
-	} else {
-		// Parse the content type
-		mediaType, params, err := mime.ParseMediaType(contentType)
-		if err != nil {
-			// If parsing fails, try to read as plain text
-			body, err := io.ReadAll(msg.Body)
-			if err != nil {
-				return "", fmt.Errorf("failed to read email body: %w", err)
-			}
-			result.WriteString(string(body))
-		} else {
-			// Handle different content types
-			if strings.HasPrefix(mediaType, "multipart/") {
-				// Handle multipart messages
-				boundary := params["boundary"]
-				if boundary == "" {
-					return "", fmt.Errorf("multipart message missing boundary")
-				}
-
-				mr := multipart.NewReader(msg.Body, boundary)
-				bodyText, attachments, err := e.extractMultipart(ctx, mr)
-				if err != nil {
-					return "", fmt.Errorf("failed to extract multipart content: %w", err)
-				}
-
-				result.WriteString(bodyText)
-
-				if len(attachments) > 0 {
-					result.WriteString("\n\n--- Attachments ---\n")
-					for _, att := range attachments {
-						result.WriteString(fmt.Sprintf("- %s (%s)\n", att.Filename, att.ContentType))
-					}
-				}
-			} else if strings.HasPrefix(mediaType, "text/") {
-				// Handle simple text content
-				body, err := io.ReadAll(msg.Body)
-				if err != nil {
-					return "", fmt.Errorf("failed to read email body: %w", err)
-				}
-				result.WriteString(string(body))
-			} else {
-				// Unsupported content type, try to read anyway
-				body, err := io.ReadAll(msg.Body)
-				if err != nil {
-					return "", fmt.Errorf("failed to read email body: %w", err)
-				}
-				result.WriteString(fmt.Sprintf("[Content Type: %s]\n%s", mediaType, string(body)))
-			}
+	if len(m.Attachments) > 0 {
+		result.WriteString("\n\n--- Attachments ---\n")
+		for _, att := range m.Attachments {
+			result.WriteString(fmt.Sprintf("- %s (%s)\n", att.Filename, att.ContentType))
 		}
 	}
 
-	extracted := result.String()
-	if strings.TrimSpace(extracted) == "" {
-		return "", fmt.Errorf("no text content found in email")
-	}
+	return result.String()
+}
 
-	return extracted, nil
+// Addresses returns the email addresses in an address list header, lowercased
+func Addresses(header string) []string {
+	list, err := mail.ParseAddressList(header)
+	if err != nil {
+		return nil
+	}
+	addresses := make([]string, len(list))
+	for i, a := range list {
+		addresses[i] = strings.ToLower(a.Address)
+	}
+	return addresses
 }
 
-// Attachment represents an email attachment
-type Attachment struct {
-	Filename    string
-	ContentType string
-	Size        int64
+// emailBody collects the text bodies and attachments of a message's MIME parts
+type emailBody struct {
+	plain       string
+	html        string
+	notes       string // Parts that could not be read
+	attachments []Attachment
 }
 
-// extractMultipart extracts content from a multipart email message
-func (e *EMLExtractor) extractMultipart(ctx context.Context, mr *multipart.Reader) (string, []Attachment, error) {
-	var bodyText strings.Builder
-	var attachments []Attachment
-	var plainTextPart string
-	var htmlPart string
-
-	for {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return "", nil, ctx.Err()
-		default:
-		}
+func (b *emailBody) read(ctx context.Context, header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
 
-		part, err := mr.NextPart()
-		if err == io.EOF {
-			break
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return fmt.Errorf("multipart message missing boundary")
 		}
-		if err != nil {
-			return "", nil, fmt.Errorf("failed to read multipart part: %w", err)
+		if depth >= maxMIMEDepth {
+			b.notes += "\n[Nested multipart content]\n"
+			return nil
 		}
 
-		contentType := part.Header.Get("Content-Type")
-		mediaType, _, _ := mime.ParseMediaType(contentType)
-
-		contentDisposition := part.Header.Get("Content-Disposition")
-		disposition, params, _ := mime.ParseMediaType(contentDisposition)
-
-		// Check if this is an attachment
-		if disposition == "attachment" || (disposition == "inline" && params["filename"] != "") {
-			filename := params["filename"]
-			if filename == "" {
-				filename = "unnamed"
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
 			}
-
-			// Read the attachment to get size (but don't store content)
-			data, err := io.ReadAll(part)
 			if err != nil {
-				continue
+				return fmt.Errorf("failed to read multipart part: %w", err)
+			}
+			if err := b.read(ctx, part.Header, part, depth+1); err != nil {
+				return err
 			}
-
-			attachments = append(attachments, Attachment{
-				Filename:    filename,
-				ContentType: mediaType,
-				Size:        int64(len(data)),
-			})
-			continue
 		}
+	}
 
-		// Extract body content based on content type
-		switch {
-		case strings.HasPrefix(mediaType, "text/plain"):
-			data, err := io.ReadAll(part)
-			if err != nil {
-				continue
-			}
-			plainTextPart = string(data)
+	data, err := io.ReadAll(io.LimitReader(decodeTransferEncoding(header, body), maxAttachmentSize))
+	if err != nil {
+		// Skip parts that cannot be decoded rather than losing the whole message
+		b.notes += fmt.Sprintf("\n[Unreadable %s part]\n", mediaType)
+		return nil
+	}
 
-		case strings.HasPrefix(mediaType, "text/html"):
-			data, err := io.ReadAll(part)
-			if err != nil {
-				continue
+	// Forwarded messages are attachments too, so they become artifacts of their own
+	if disposition == "attachment" || filename != "" || mediaType == "message/rfc822" {
+		filename = decodeHeader(filename)
+		if filename == "" {
+			filename = "unnamed"
+			if mediaType == "message/rfc822" {
+				filename = "forwarded.eml"
 			}
-			htmlPart = string(data)
-
-		case strings.HasPrefix(mediaType, "multipart/"):
-			// Nested multipart (e.g., multipart/alternative within multipart/mixed)
-			// For simplicity, we'll just note it
-			bodyText.WriteString("[Nested multipart content]\n")
+		}
+		b.attachments = append(b.attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        int64(len(data)),
+			Data:        data,
+		})
+		return nil
+	}
 
-		default:
-			// Skip other content types
-			continue
+	switch {
+	case strings.HasPrefix(mediaType, "text/plain"):
+		if b.plain == "" {
+			b.plain = string(data)
 		}
+	case strings.HasPrefix(mediaType, "text/html"):
+		if b.html == "" {
+			b.html = string(data)
+		}
+	case depth == 0:
+		// Unsupported content type, try to read anyway
+		b.plain = fmt.Sprintf("[Content Type: %s]\n%s", mediaType, string(data))
 	}
+	return nil
+}
 
-	// Prefer plain text over HTML
-	if plainTextPart != "" {
-		bodyText.WriteString(plainTextPart)
-	} else if htmlPart != "" {
-		// Strip basic HTML tags for better readability
-		cleanHtml := stripBasicHTMLTags(htmlPart)
-		bodyText.WriteString(cleanHtml)
+// decodeTransferEncoding decodes a base64 or quoted-printable part
+func decodeTransferEncoding(header textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
 	}
+	return body
+}
 
-	return bodyText.String(), attachments, nil
+// decodeHeader decodes RFC 2047 encoded words, returning the value unchanged if it cannot
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
 }
 
 // stripBasicHTMLTags removes common HTML tags to make content more readable
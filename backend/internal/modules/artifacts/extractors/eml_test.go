@@ -0,0 +1,148 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const replyWithAttachment = "From: \"Chen, Maria\" <Maria.Chen@acme.example>\r\n" +
+	"To: ops@acme.example, Dev Patel <dev@acme.example>\r\n" +
+	"Subject: =?UTF-8?Q?Re:_Cutover_plan_=E2=80=93_v2?=\r\n" +
+	"Date: Tue, 04 Mar 2025 09:15:00 +0000\r\n" +
+	"Message-ID: <reply-1@acme.example>\r\n" +
+	"In-Reply-To: <root@acme.example>\r\n" +
+	"References: <root@acme.example>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Updated plan attached.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Updated plan attached.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/octet-stream; name=\"cutover.csv\"\r\n" +
+	"Content-Disposition: attachment; filename=\"cutover.csv\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"c3RlcCxvd25lcgpmcmVlemUsbWFyaWEK\r\n" +
+	"--outer--\r\n"
+
+func TestParseEmail_ThreadingAndAttachments(t *testing.T) {
+	msg, err := ParseEmail(context.Background(), []byte(replyWithAttachment))
+	if err != nil {
+		t.Fatalf("ParseEmail failed: %v", err)
+	}
+
+	if msg.MessageID != "<reply-1@acme.example>" || msg.InReplyTo != "<root@acme.example>" {
+		t.Errorf("unexpected ids: message %q, in reply to %q", msg.MessageID, msg.InReplyTo)
+	}
+	if len(msg.References) != 1 || msg.References[0] != "<root@acme.example>" {
+		t.Errorf("unexpected references: %v", msg.References)
+	}
+	if msg.Subject != "Re: Cutover plan – v2" {
+		t.Errorf("subject not decoded: %q", msg.Subject)
+	}
+	if got := Addresses(msg.To); strings.Join(got, ",") != "ops@acme.example,dev@acme.example" {
+		t.Errorf("unexpected to addresses: %v", got)
+	}
+	if strings.TrimSpace(msg.Body) != "Updated plan attached." {
+		t.Errorf("expected the plain text body, got %q", msg.Body)
+	}
+
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("expected 1 attachment, got %d", len(msg.Attachments))
+	}
+	if att := msg.Attachments[0]; att.Filename != "cutover.csv" || string(att.Data) != "step,owner\nfreeze,maria\n" {
+		t.Errorf("unexpected attachment %q: %q", att.Filename, att.Data)
+	}
+
+	// The attachment is listed in the message text but its content is not
+	text := msg.Text()
+	assertContains(t, text, "Subject: Re: Cutover plan – v2", "- cutover.csv (application/octet-stream)")
+	if strings.Contains(text, "freeze,maria") {
+		t.Error("attachment content should not be part of the message text")
+	}
+}
+
+func TestSplitMbox(t *testing.T) {
+	mbox := "From maria@acme.example Tue Mar  4 09:15:00 2025\n" +
+		"Subject: First\n" +
+		"\n" +
+		">From the archive, quoted.\n" +
+		"From within a paragraph stays.\n" +
+		"\n" +
+		"From dev@acme.example Tue Mar  4 10:00:00 2025\n" +
+		"Subject: Second\n" +
+		"\n" +
+		"Body two.\n"
+
+	messages := SplitMbox([]byte(mbox))
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	first := string(messages[0])
+	if !strings.HasPrefix(first, "Subject: First\n") || !strings.Contains(first, "\nFrom the archive, quoted.\n") {
+		t.Errorf("unexpected first message: %q", first)
+	}
+	if !strings.Contains(first, "From within a paragraph stays.") {
+		t.Errorf("body line starting with From was split: %q", first)
+	}
+
+	result, err := NewMboxExtractor().ExtractResult(context.Background(), []byte(mbox))
+	if err != nil {
+		t.Fatalf("ExtractResult failed: %v", err)
+	}
+	if result.PageCount != 2 {
+		t.Errorf("expected 2 messages, got %d", result.PageCount)
+	}
+	if loc := result.Location(strings.Index(result.Content, "Body two.")); loc != "message 2" {
+		t.Errorf("expected message 2, got %q", loc)
+	}
+}
+
+func TestMboxReader_ReadsOneMessageAtATime(t *testing.T) {
+	mbox := "From maria@acme.example Tue Mar  4 09:15:00 2025\n" +
+		"Subject: First\n" +
+		"\n" +
+		"From dev@acme.example Tue Mar  4 10:00:00 2025\n" +
+		"Subject: Second\n"
+
+	// The reader works from any stream, even one that returns a byte per read
+	reader := NewMboxReader(iotest.OneByteReader(strings.NewReader(mbox)))
+	var subjects []string
+	for {
+		message, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		subjects = append(subjects, strings.TrimSpace(string(message)))
+	}
+	if strings.Join(subjects, ",") != "Subject: First,Subject: Second" {
+		t.Errorf("unexpected messages: %q", subjects)
+	}
+
+	// A failed read is reported rather than taken for the end of the mailbox
+	failing := io.MultiReader(strings.NewReader(mbox), iotest.ErrReader(errors.New("connection reset")))
+	reader = NewMboxReader(failing)
+	if _, err := reader.Next(); err != nil {
+		t.Fatalf("expected the first message before the failure, got: %v", err)
+	}
+	if _, err := reader.Next(); err == nil || err == io.EOF {
+		t.Errorf("expected the read error, got: %v", err)
+	}
+}
@@ -36,19 +36,28 @@ func (e *ExcelExtractor) CanExtract(mimeType string) bool {
 
 // Extract extracts text content from Excel data
 func (e *ExcelExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts text content sheet by sheet, recording where each row starts
+func (e *ExcelExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
 	// Open Excel file from bytes
 	reader := bytes.NewReader(data)
 	workbook, err := excelize.OpenReader(reader)
 	if err != nil {
-		return "", fmt.Errorf("failed to open Excel file: %w", err)
+		return nil, fmt.Errorf("failed to open Excel file: %w", err)
 	}
 	defer workbook.Close()
 
-	var content strings.Builder
+	content := newResultBuilder()
 	sheetList := workbook.GetSheetList()
 
 	if len(sheetList) == 0 {
-		return "", fmt.Errorf("no sheets found in Excel file")
+		return nil, fmt.Errorf("no sheets found in Excel file")
 	}
 
 	hasContent := false
@@ -59,6 +68,7 @@ func (e *ExcelExtractor) Extract(ctx context.Context, data []byte) (string, erro
 			content.WriteString("\n\n")
 		}
 
+		content.startSection(SectionSheet, sheetName)
 		content.WriteString(fmt.Sprintf("Sheet: %s\n", sheetName))
 		content.WriteString(strings.Repeat("-", len(sheetName)+7))
 		content.WriteString("\n\n")
@@ -95,6 +105,7 @@ func (e *ExcelExtractor) Extract(ctx context.Context, data []byte) (string, erro
 		}
 
 		// Format as markdown table
+		table := Table{StartOffset: content.Len()}
 		for i, row := range rows {
 			// Skip completely empty rows
 			if len(strings.TrimSpace(strings.Join(row, ""))) == 0 {
@@ -112,6 +123,10 @@ func (e *ExcelExtractor) Extract(ctx context.Context, data []byte) (string, erro
 			}
 
 			// Join cells with pipe separators (markdown table)
+			table.Rows = append(table.Rows, TableRow{Number: i + 1, Offset: content.Len()})
+			if i == 0 && len(rows) > 1 {
+				table.Columns = paddedRow
+			}
 			content.WriteString("| ")
 			content.WriteString(strings.Join(paddedRow, " | "))
 			content.WriteString(" |\n")
@@ -127,11 +142,24 @@ func (e *ExcelExtractor) Extract(ctx context.Context, data []byte) (string, erro
 
 			hasContent = true
 		}
+		table.EndOffset = content.Len()
+		content.addTable(table)
 	}
 
 	if !hasContent {
-		return "", fmt.Errorf("no content extracted from Excel file")
+		return nil, fmt.Errorf("no content extracted from Excel file")
+	}
+
+	if props, err := workbook.GetDocProps(); err == nil {
+		content.setMetadata("title", props.Title)
+		content.setMetadata("subject", props.Subject)
+		content.setMetadata("author", props.Creator)
+		content.setMetadata("last_modified_by", props.LastModifiedBy)
+		content.setMetadata("created", props.Created)
+		content.setMetadata("modified", props.Modified)
 	}
 
-	return content.String(), nil
+	result := content.finish()
+	result.PageCount = len(sheetList)
+	return result, nil
 }
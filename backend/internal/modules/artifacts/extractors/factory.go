@@ -18,7 +18,11 @@ func NewExtractorFactory() *ExtractorFactory {
 			NewTextExtractor(),
 			NewExcelExtractor(),
 			NewEMLExtractor(),
-			// Future: Add DOCX, Image extractors
+			NewDOCXExtractor(),
+			NewPPTXExtractor(),
+			NewOpenDocumentExtractor(),
+			NewMboxExtractor(),
+			// Future: Add Image extractors
 		},
 	}
 
@@ -51,6 +55,24 @@ func (f *ExtractorFactory) Extract(ctx context.Context, mimeType string, data []
 	return extractor.Extract(ctx, data)
 }
 
+// ExtractResult extracts content and, where the extractor supports it, document structure
+func (f *ExtractorFactory) ExtractResult(ctx context.Context, mimeType string, data []byte) (*ExtractionResult, error) {
+	extractor, err := f.GetExtractor(mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	if structured, ok := extractor.(StructuredExtractor); ok {
+		return structured.ExtractResult(ctx, data)
+	}
+
+	content, err := extractor.Extract(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return plainResult(content), nil
+}
+
 // CanExtract checks if any extractor can handle the MIME type
 func (f *ExtractorFactory) CanExtract(mimeType string) bool {
 	for _, extractor := range f.extractors {
@@ -4,26 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
 )
 
-// ImageOCRExtractor uses Claude Vision API for OCR on scanned PDFs and images
+// ocrPrompt asks the model for the document's text only
+const ocrPrompt = "Please extract all text from this document. Preserve the structure and formatting as much as possible. Return ONLY the extracted text content, without any commentary or explanation."
+
+// ImageOCRExtractor uses a vision-capable model for OCR on scanned PDFs and images
 type ImageOCRExtractor struct {
-	apiKey     string
-	httpClient *http.Client
+	provider ai.Provider
 }
 
 // NewImageOCRExtractor creates a new image OCR extractor
-func NewImageOCRExtractor(apiKey string) *ImageOCRExtractor {
+func NewImageOCRExtractor(provider ai.Provider) *ImageOCRExtractor {
 	return &ImageOCRExtractor{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+		provider: provider,
 	}
 }
 
@@ -38,10 +35,10 @@ func (e *ImageOCRExtractor) CanExtract(mimeType string) bool {
 		mimeType == "image/webp"
 }
 
-// Extract performs OCR using Claude Vision API
+// Extract performs OCR using the provider's vision support
 func (e *ImageOCRExtractor) Extract(ctx context.Context, data []byte) (string, error) {
-	if e.apiKey == "" {
-		return "", fmt.Errorf("Claude API key not configured for image OCR")
+	if e.provider == nil {
+		return "", fmt.Errorf("AI provider not configured for image OCR")
 	}
 
 	// Determine media type
@@ -53,83 +50,40 @@ func (e *ImageOCRExtractor) Extract(ctx context.Context, data []byte) (string, e
 		return "", fmt.Errorf("scanned PDF OCR requires PDF-to-image conversion (not yet implemented). Please use text-based PDFs or extract pages as images (PNG/JPEG)")
 	}
 
-	// Encode to base64
-	base64Data := base64.StdEncoding.EncodeToString(data)
-
-	// Create Claude Vision API request
-	reqBody := map[string]interface{}{
-		"model":      "claude-sonnet-4-5-20250929",
-		"max_tokens": 4096,
-		"messages": []map[string]interface{}{
+	req := &ai.Request{
+		Model:     ai.ModelSonnet4,
+		MaxTokens: 4096,
+		Messages: []ai.Message{
 			{
-				"role": "user",
-				"content": []map[string]interface{}{
+				Role: "user",
+				Content: []ai.ContentBlock{
 					{
-						"type": "image",
-						"source": map[string]interface{}{
-							"type":       "base64",
-							"media_type": mediaType,
-							"data":       base64Data,
+						Type: "image",
+						Source: &ai.ImageSource{
+							Type:      "base64",
+							MediaType: mediaType,
+							Data:      base64.StdEncoding.EncodeToString(data),
 						},
 					},
 					{
-						"type": "text",
-						"text": "Please extract all text from this document. Preserve the structure and formatting as much as possible. Return ONLY the extracted text content, without any commentary or explanation.",
+						Type: "text",
+						Text: ocrPrompt,
 					},
 				},
 			},
 		},
 	}
 
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", e.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-
-	// Send request
-	resp, err := e.httpClient.Do(httpReq)
+	resp, err := e.provider.Request(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
-	}
-
-	// Parse response
-	var apiResp struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
-		} `json:"content"`
-	}
-
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return "", fmt.Errorf("OCR request failed: %w", err)
 	}
 
-	if len(apiResp.Content) == 0 {
+	if len(resp.Content) == 0 {
 		return "", fmt.Errorf("no content in OCR response")
 	}
 
-	extractedText := apiResp.Content[0].Text
+	extractedText := resp.Content[0].Text
 	if extractedText == "" {
 		return "", fmt.Errorf("no text extracted from document via OCR")
 	}
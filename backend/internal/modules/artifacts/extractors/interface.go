@@ -11,10 +11,12 @@ type ContentExtractor interface {
 	Extract(ctx context.Context, data []byte) (string, error)
 }
 
-// ExtractionResult contains the extracted content and metadata
+// ExtractionResult contains the extracted content, its structure and the document's properties
 type ExtractionResult struct {
-	Content    string
-	PageCount  int
-	WordCount  int
-	Metadata   map[string]string
+	Content   string            `json:"-"`
+	PageCount int               `json:"page_count,omitempty"` // Pages, slides, sheets or messages
+	WordCount int               `json:"word_count"`
+	Metadata  map[string]string `json:"metadata,omitempty"` // Title, author, dates, email headers
+	Sections  []Section         `json:"sections,omitempty"`
+	Tables    []Table           `json:"tables,omitempty"`
 }
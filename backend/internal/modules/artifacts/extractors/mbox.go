@@ -0,0 +1,150 @@
+package extractors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MboxExtractor extracts text from mailbox exports (.mbox). Uploads of an mbox are split into
+// one artifact per message; this extractor covers mailboxes nested in other archives.
+type MboxExtractor struct{}
+
+// NewMboxExtractor creates a new mbox extractor
+func NewMboxExtractor() *MboxExtractor {
+	return &MboxExtractor{}
+}
+
+// CanExtract returns true for mbox MIME types
+func (e *MboxExtractor) CanExtract(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "application/mbox")
+}
+
+// Extract extracts the text of every message in the mailbox
+func (e *MboxExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts the text of every message in the mailbox, one section per message
+func (e *MboxExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
+	messages := SplitMbox(data)
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages found in mbox file")
+	}
+
+	content := newResultBuilder()
+	for i, message := range messages {
+		msg, err := ParseEmail(ctx, message)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if content.Len() > 0 {
+			content.WriteString("\n\n")
+		}
+		content.startSection(SectionMessage, strconv.Itoa(i+1))
+		content.WriteString(msg.Text())
+	}
+
+	if strings.TrimSpace(content.String()) == "" {
+		return nil, fmt.Errorf("no text content found in mbox file")
+	}
+
+	result := content.finish()
+	result.PageCount = len(messages)
+	return result, nil
+}
+
+// SplitMbox splits a mailbox into its messages. A message starts at a "From " line at the
+// start of the file or after a blank line; body lines escaped as ">From " are restored.
+func SplitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	mbox := NewMboxReader(bytes.NewReader(data))
+	for {
+		message, err := mbox.Next()
+		if err != nil {
+			return messages
+		}
+		messages = append(messages, message)
+	}
+}
+
+// MboxReader reads the messages of a mailbox one at a time, as SplitMbox splits them, so only
+// the message being read is held in memory
+type MboxReader struct {
+	r             *bufio.Reader
+	current       *bytes.Buffer // Message being read; nil before the first "From " line
+	previousBlank bool
+	err           error
+}
+
+// NewMboxReader creates a reader of the mailbox in r
+func NewMboxReader(r io.Reader) *MboxReader {
+	return &MboxReader{r: bufio.NewReader(r), previousBlank: true}
+}
+
+// Next returns the next message, or io.EOF after the last one
+func (m *MboxReader) Next() ([]byte, error) {
+	for m.err == nil {
+		line, err := m.r.ReadBytes('\n')
+		if err != nil {
+			m.err = err
+		}
+
+		if len(line) > 0 {
+			if message := m.readLine(line); message != nil {
+				return message, nil
+			}
+		}
+	}
+
+	if m.err != io.EOF {
+		return nil, m.err
+	}
+
+	// The last message ends with the file
+	message := m.current
+	m.current = nil
+	if message != nil && len(bytes.TrimSpace(message.Bytes())) > 0 {
+		return message.Bytes(), nil
+	}
+	return nil, io.EOF
+}
+
+// readLine adds a line to the current message, returning the previous message when the line
+// starts a new one
+func (m *MboxReader) readLine(line []byte) []byte {
+	if m.previousBlank && bytes.HasPrefix(line, []byte("From ")) {
+		previous := m.current
+		m.current = &bytes.Buffer{}
+		m.previousBlank = false
+		if previous != nil && len(bytes.TrimSpace(previous.Bytes())) > 0 {
+			return previous.Bytes()
+		}
+		return nil
+	}
+
+	trimmed := bytes.TrimRight(line, "\r\n")
+	m.previousBlank = len(trimmed) == 0
+	if m.current == nil {
+		return nil
+	}
+
+	// Undo mboxrd quoting: ">From ", ">>From " and so on lose one ">"
+	if unquoted := bytes.TrimLeft(line, ">"); len(unquoted) < len(line) && bytes.HasPrefix(unquoted, []byte("From ")) {
+		line = line[1:]
+	}
+	m.current.Write(line)
+	return nil
+}
@@ -0,0 +1,325 @@
+package extractors
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxPartSize bounds how much of one document part is decompressed
+const maxPartSize = 64 << 20
+
+// officePackage is an OOXML or OpenDocument file: a ZIP archive of XML parts
+type officePackage struct {
+	files map[string]*zip.File
+}
+
+func openOfficePackage(data []byte) (*officePackage, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document archive: %w", err)
+	}
+
+	pkg := &officePackage{files: make(map[string]*zip.File)}
+	for _, file := range reader.File {
+		pkg.files[file.Name] = file
+	}
+	return pkg, nil
+}
+
+// parse reads an XML part, returning nil if the package does not contain it
+func (p *officePackage) parse(name string) (*xmlNode, error) {
+	file, ok := p.files[name]
+	if !ok {
+		return nil, nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxPartSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxPartSize)
+	}
+
+	node, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return node, nil
+}
+
+// relationship is a link from an OOXML part to another part
+type relationship struct {
+	Type   string
+	Target string // Part name within the package
+}
+
+// relationships reads the relationships of an OOXML part ("" for the package itself) by ID
+func (p *officePackage) relationships(part string) (map[string]relationship, error) {
+	dir, file := path.Split(part)
+	root, err := p.parse(dir + "_rels/" + file + ".rels")
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	rels := make(map[string]relationship)
+	for _, rel := range root.descendants("Relationship") {
+		if rel.attr("TargetMode") == "External" {
+			continue
+		}
+		target := rel.attr("Target")
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(dir, target)
+		}
+		rels[rel.attr("Id")] = relationship{Type: rel.attr("Type"), Target: target}
+	}
+	return rels, nil
+}
+
+// mainPart returns the package's main document part, or fallback if the package does not name one
+func (p *officePackage) mainPart(fallback string) string {
+	rels, _ := p.relationships("")
+	for _, rel := range rels {
+		if strings.HasSuffix(rel.Type, "/officeDocument") {
+			return rel.Target
+		}
+	}
+	return fallback
+}
+
+// related returns the targets of a part's relationships whose type ends in typeSuffix
+func related(rels map[string]relationship, typeSuffix string) []string {
+	var targets []string
+	for _, rel := range rels {
+		if strings.HasSuffix(rel.Type, typeSuffix) {
+			targets = append(targets, rel.Target)
+		}
+	}
+	return targets
+}
+
+// xmlNode is an element of a parsed XML part, or a run of character data (a node without a name)
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     string
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: t.Copy().Attr}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &xmlNode{text: string(t)})
+		}
+	}
+
+	return root, nil
+}
+
+func (n *xmlNode) is(local string) bool {
+	return n.name.Local == local
+}
+
+// attr returns the value of the attribute with the given local name, in any namespace
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// relID returns the relationship ID (r:id) of an OOXML element
+func (n *xmlNode) relID() string {
+	for _, a := range n.attrs {
+		if a.Name.Local == "id" && a.Name.Space != "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// child returns the first child element with the given local name
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.children {
+		if c.is(local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// descendants returns the elements below n with the given local name, in document order
+func (n *xmlNode) descendants(local string) []*xmlNode {
+	var found []*xmlNode
+	for _, c := range n.children {
+		if c.is(local) {
+			found = append(found, c)
+		}
+		found = append(found, c.descendants(local)...)
+	}
+	return found
+}
+
+// documentComment is a reviewer comment on a document, slide or presentation
+type documentComment struct {
+	author string
+	date   string
+	text   string
+	anchor string // Text the comment is attached to, if known
+}
+
+// writeComments lists comments after the content they annotate
+func writeComments(b *resultBuilder, comments []documentComment) {
+	if len(comments) == 0 {
+		return
+	}
+
+	b.WriteString("\nComments:\n")
+	for _, c := range comments {
+		b.WriteString("- ")
+		if c.author != "" {
+			b.WriteString(c.author)
+			if len(c.date) >= 10 {
+				b.WriteString(" (" + c.date[:10] + ")")
+			}
+			b.WriteString(": ")
+		}
+		b.WriteString(oneLine(c.text))
+		if anchor := oneLine(c.anchor); anchor != "" {
+			b.WriteString(fmt.Sprintf(" [on %q]", anchor))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeTable writes rows as a markdown table, as the Excel extractor does, and records it
+func writeTable(b *resultBuilder, rows [][]string) {
+	maxCols := 0
+	for _, row := range rows {
+		if len(row) > maxCols {
+			maxCols = len(row)
+		}
+	}
+	if maxCols == 0 {
+		return
+	}
+
+	table := Table{StartOffset: b.Len()}
+	for i, row := range rows {
+		cells := make([]string, maxCols)
+		for j := range row {
+			cells[j] = strings.ReplaceAll(oneLine(row[j]), "|", "\\|")
+		}
+
+		table.Rows = append(table.Rows, TableRow{Number: i + 1, Offset: b.Len()})
+		if i == 0 && len(rows) > 1 {
+			table.Columns = cells
+		}
+		b.WriteString("| ")
+		b.WriteString(strings.Join(cells, " | "))
+		b.WriteString(" |\n")
+
+		if i == 0 && len(rows) > 1 {
+			b.WriteString("|")
+			b.WriteString(strings.Repeat(" --- |", maxCols))
+			b.WriteString("\n")
+		}
+	}
+	table.EndOffset = b.Len()
+	b.addTable(table)
+}
+
+// Document properties recorded as metadata, by element name in OOXML core properties
+// (docProps/core.xml) and OpenDocument meta.xml
+var documentProperties = map[string]string{
+	"title":           "title",
+	"subject":         "subject",
+	"creator":         "author",
+	"initial-creator": "author",
+	"lastModifiedBy":  "last_modified_by",
+	"created":         "created",
+	"creation-date":   "created",
+	"modified":        "modified",
+	"keywords":        "keywords",
+	"keyword":         "keywords",
+}
+
+// readProperties records the document properties in a core properties or meta.xml part. In
+// OpenDocument, dc:creator and dc:date are the last modifier and the modification date.
+func readProperties(b *resultBuilder, root *xmlNode, openDocument bool) {
+	if root == nil {
+		return
+	}
+	for _, parent := range append(root.descendants("coreProperties"), root.descendants("meta")...) {
+		for _, child := range parent.children {
+			key, ok := documentProperties[child.name.Local]
+			switch {
+			case openDocument && child.is("creator"):
+				key, ok = "last_modified_by", true
+			case openDocument && child.is("date"):
+				key, ok = "modified", true
+			}
+			if ok {
+				b.setMetadata(key, textContent(child))
+			}
+		}
+	}
+}
+
+// readCoreProperties records the core properties of an OOXML package
+func (p *officePackage) readCoreProperties(b *resultBuilder) error {
+	part := "docProps/core.xml"
+	rels, _ := p.relationships("")
+	if targets := related(rels, "/core-properties"); len(targets) > 0 {
+		part = targets[0]
+	}
+
+	root, err := p.parse(part)
+	if err != nil {
+		return err
+	}
+	readProperties(b, root, false)
+	return nil
+}
+
+// oneLine collapses whitespace, including line breaks, to single spaces
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,175 @@
+package extractors
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// buildPackage zips the given parts into an in-memory document
+func buildPackage(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func assertContains(t *testing.T, text string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(text, w) {
+			t.Errorf("extracted text missing %q:\n%s", w, text)
+		}
+	}
+}
+
+const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func TestDOCXExtractor_Extract(t *testing.T) {
+	data := buildPackage(t, map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+		</Relationships>`,
+		"word/_rels/document.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+			<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="comments.xml"/>
+		</Relationships>`,
+		"word/styles.xml": `<w:styles ` + wordNS + `>
+			<w:style w:type="paragraph" w:styleId="Berschrift1"><w:name w:val="heading 1"/></w:style>
+		</w:styles>`,
+		"word/document.xml": `<w:document ` + wordNS + `><w:body>
+			<w:p><w:pPr><w:pStyle w:val="Berschrift1"/></w:pPr><w:r><w:t>Scope</w:t></w:r></w:p>
+			<w:p><w:commentRangeStart w:id="0"/><w:r><w:t>Go-live is in March.</w:t></w:r><w:commentRangeEnd w:id="0"/></w:p>
+			<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Data migration</w:t></w:r></w:p>
+			<w:p><w:r><w:delText>Removed text</w:delText></w:r></w:p>
+			<w:tbl>
+				<w:tr><w:tc><w:p><w:r><w:t>Vendor</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Cost</w:t></w:r></w:p></w:tc></w:tr>
+				<w:tr><w:tc><w:p><w:r><w:t>Acme</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>$10</w:t></w:r></w:p></w:tc></w:tr>
+			</w:tbl>
+		</w:body></w:document>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">
+			<dc:title>Cutover Plan</dc:title><dc:creator>Dana</dc:creator><dcterms:created>2025-01-10T08:00:00Z</dcterms:created>
+		</cp:coreProperties>`,
+		"word/comments.xml": `<w:comments ` + wordNS + `>
+			<w:comment w:id="0" w:author="Dana" w:date="2025-01-15T10:00:00Z"><w:p><w:r><w:t>Is this confirmed?</w:t></w:r></w:p></w:comment>
+		</w:comments>`,
+	})
+
+	result, err := NewDOCXExtractor().ExtractResult(context.Background(), data)
+	if err != nil {
+		t.Fatalf("ExtractResult() error = %v", err)
+	}
+	text := result.Content
+
+	assertContains(t, text,
+		"# Scope\n",
+		"  - Data migration",
+		"| Vendor | Cost |\n| --- | --- |\n| Acme | $10 |",
+		`- Dana (2025-01-15): Is this confirmed? [on "Go-live is in March."]`,
+	)
+	if strings.Contains(text, "Removed text") {
+		t.Errorf("extracted text includes deleted text:\n%s", text)
+	}
+
+	if got := result.Location(strings.Index(text, "Acme")); got != `section "Scope", table 1, row 2` {
+		t.Errorf("Location() = %q, want the table row under the Scope heading", got)
+	}
+	if result.Metadata["title"] != "Cutover Plan" || result.Metadata["author"] != "Dana" || result.Metadata["created"] != "2025-01-10T08:00:00Z" {
+		t.Errorf("Metadata = %v, want the core properties", result.Metadata)
+	}
+}
+
+const drawingNS = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func TestPPTXExtractor_Extract(t *testing.T) {
+	data := buildPackage(t, map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/>
+		</Relationships>`,
+		"ppt/presentation.xml": `<p:presentation ` + drawingNS + `><p:sldIdLst>
+			<p:sldId id="256" r:id="rId2"/>
+		</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide1.xml"/>
+		</Relationships>`,
+		"ppt/slides/slide1.xml": `<p:sld ` + drawingNS + `><p:cSld><p:spTree>
+			<p:sp><p:nvSpPr><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>Status Update</a:t></a:r></a:p></p:txBody></p:sp>
+			<p:sp><p:nvSpPr><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr><p:txBody>
+				<a:p><a:r><a:t>On track</a:t></a:r></a:p>
+				<a:p><a:pPr lvl="1"/><a:r><a:t>Testing complete</a:t></a:r></a:p>
+			</p:txBody></p:sp>
+			<p:sp><p:nvSpPr><p:nvPr><p:ph type="sldNum"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>7</a:t></a:r></a:p></p:txBody></p:sp>
+		</p:spTree></p:cSld></p:sld>`,
+		"ppt/slides/_rels/slide1.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide" Target="../notesSlides/notesSlide1.xml"/>
+			<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="../comments/comment1.xml"/>
+		</Relationships>`,
+		"ppt/notesSlides/notesSlide1.xml": `<p:notes ` + drawingNS + `><p:cSld><p:spTree>
+			<p:sp><p:nvSpPr><p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>Mention the budget risk</a:t></a:r></a:p></p:txBody></p:sp>
+		</p:spTree></p:cSld></p:notes>`,
+		"ppt/commentAuthors.xml": `<p:cmAuthorLst ` + drawingNS + `><p:cmAuthor id="0" name="Sam"/></p:cmAuthorLst>`,
+		"ppt/comments/comment1.xml": `<p:cmLst ` + drawingNS + `>
+			<p:cm authorId="0" dt="2025-02-01T09:00:00.000"><p:text>Add the timeline</p:text></p:cm>
+		</p:cmLst>`,
+	})
+
+	text, err := NewPPTXExtractor().Extract(context.Background(), data)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	assertContains(t, text,
+		"## Slide 1: Status Update\n",
+		"On track\n  - Testing complete",
+		"Speaker notes:\nMention the budget risk",
+		"- Sam (2025-02-01): Add the timeline",
+	)
+	if strings.Contains(text, "7") {
+		t.Errorf("extracted text includes the slide number:\n%s", text)
+	}
+}
+
+const odfNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+func TestOpenDocumentExtractor_Extract(t *testing.T) {
+	data := buildPackage(t, map[string]string{
+		"mimetype": "application/vnd.oasis.opendocument.text",
+		"content.xml": `<office:document-content ` + odfNS + `><office:body><office:text>
+			<text:h text:outline-level="2">Risks</text:h>
+			<text:p>Vendor   delay<text:s text:c="2"/>likely.<office:annotation office:name="c1"><dc:creator>Lee</dc:creator><dc:date>2025-03-04T12:00:00</dc:date><text:p>Which vendor?</text:p></office:annotation>Acme<office:annotation-end office:name="c1"/></text:p>
+			<text:list><text:list-item><text:p>Escalate</text:p></text:list-item></text:list>
+			<table:table>
+				<table:table-header-rows><table:table-row><table:table-cell><text:p>Risk</text:p></table:table-cell><table:table-cell><text:p>Owner</text:p></table:table-cell><table:table-cell table:number-columns-repeated="50"/></table:table-row></table:table-header-rows>
+				<table:table-row><table:table-cell><text:p>Delay</text:p></table:table-cell><table:table-cell><text:p>Lee</text:p></table:table-cell></table:table-row>
+			</table:table>
+		</office:text></office:body></office:document-content>`,
+	})
+
+	text, err := NewOpenDocumentExtractor().Extract(context.Background(), data)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	assertContains(t, text,
+		"## Risks\n",
+		"Vendor delay  likely.Acme",
+		"- Escalate",
+		"| Risk | Owner |\n| --- | --- |\n| Delay | Lee |",
+		`- Lee (2025-03-04): Which vendor? [on "Acme"]`,
+	)
+}
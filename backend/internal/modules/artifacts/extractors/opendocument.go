@@ -0,0 +1,356 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxRepeatedCells bounds how many times a repeated table cell is written; tables use
+// repetition to pad rows out to the page width
+const maxRepeatedCells = 100
+
+// OpenDocumentExtractor extracts text from OpenDocument text documents (.odt) and presentations
+// (.odp), keeping headings, lists, tables, speaker notes and comments
+type OpenDocumentExtractor struct{}
+
+// NewOpenDocumentExtractor creates a new OpenDocument extractor
+func NewOpenDocumentExtractor() *OpenDocumentExtractor {
+	return &OpenDocumentExtractor{}
+}
+
+// CanExtract returns true for OpenDocument text and presentation MIME types
+func (e *OpenDocumentExtractor) CanExtract(mimeType string) bool {
+	odfTypes := []string{
+		"application/vnd.oasis.opendocument.text",         // .odt, .ott
+		"application/vnd.oasis.opendocument.presentation", // .odp, .otp
+	}
+
+	for _, t := range odfTypes {
+		if strings.HasPrefix(mimeType, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Extract extracts text content from an OpenDocument file
+func (e *OpenDocumentExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts text content with its headed sections or slides, tables and document
+// properties
+func (e *OpenDocumentExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := pkg.parse("content.xml")
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("no content found in OpenDocument file")
+	}
+
+	w := newODFWriter()
+	for _, body := range content.descendants("body") {
+		if text := body.child("text"); text != nil {
+			w.block(text, 0)
+		}
+		if presentation := body.child("presentation"); presentation != nil {
+			w.presentation(presentation)
+		}
+	}
+
+	if strings.TrimSpace(w.out.String()) == "" {
+		return nil, fmt.Errorf("no content extracted from OpenDocument file")
+	}
+
+	w.out.trimRight()
+	w.out.endSection(SectionHeading)
+	w.out.WriteString("\n")
+	writeComments(w.out, w.comments)
+
+	meta, err := pkg.parse("meta.xml")
+	if err != nil {
+		return nil, err
+	}
+	readProperties(w.out, meta, true)
+
+	result := w.out.finish()
+	result.PageCount = w.slides
+	return result, nil
+}
+
+// odfWriter renders the body of an OpenDocument file
+type odfWriter struct {
+	out      *resultBuilder
+	comments []documentComment
+	slides   int
+
+	// Text covered by each named comment while its range is open
+	anchors map[string]*strings.Builder
+}
+
+func newODFWriter() *odfWriter {
+	return &odfWriter{out: newResultBuilder(), anchors: map[string]*strings.Builder{}}
+}
+
+// block renders headings, paragraphs, lists and tables. listLevel is the depth of the list
+// the block is in, if any.
+func (w *odfWriter) block(node *xmlNode, listLevel int) {
+	for _, child := range node.children {
+		switch child.name.Local {
+		case "h":
+			text := oneLine(w.text(child))
+			if text == "" {
+				continue
+			}
+			level, err := strconv.Atoi(child.attr("outline-level"))
+			if err != nil || level < 1 {
+				level = 1
+			}
+			if w.out.Len() > 0 {
+				w.out.WriteString("\n")
+			}
+			w.out.startSection(SectionHeading, text)
+			w.out.WriteString(strings.Repeat("#", level) + " " + text + "\n\n")
+		case "p":
+			text := strings.TrimSpace(w.text(child))
+			if text == "" {
+				continue
+			}
+			if listLevel > 0 {
+				text = strings.Repeat("  ", listLevel-1) + "- " + text
+			}
+			w.out.WriteString(text + "\n")
+		case "list":
+			w.block(child, listLevel+1)
+		case "list-item", "list-header", "section", "index-body", "text-box":
+			w.block(child, listLevel)
+		case "table":
+			if w.out.Len() > 0 {
+				w.out.WriteString("\n")
+			}
+			writeTable(w.out, w.table(child))
+			w.out.WriteString("\n")
+		case "frame", "custom-shape", "g":
+			w.block(child, listLevel)
+		}
+	}
+}
+
+// text returns the inline text of a paragraph or heading. Comments are collected rather than
+// inlined, and footnotes are left out.
+func (w *odfWriter) text(node *xmlNode) string {
+	var b strings.Builder
+	for _, child := range node.children {
+		switch child.name.Local {
+		case "":
+			w.write(&b, collapseSpace(child.text))
+		case "s":
+			count, err := strconv.Atoi(child.attr("c"))
+			if err != nil || count < 1 {
+				count = 1
+			}
+			w.write(&b, strings.Repeat(" ", count))
+		case "tab":
+			w.write(&b, "\t")
+		case "line-break":
+			w.write(&b, "\n")
+		case "annotation":
+			w.annotation(child)
+		case "annotation-end":
+			if anchor, ok := w.anchors[child.attr("name")]; ok {
+				w.setAnchor(child.attr("name"), anchor.String())
+				delete(w.anchors, child.attr("name"))
+			}
+		case "note", "bookmark-ref", "sequence-decls", "tracked-changes":
+		default:
+			b.WriteString(w.text(child))
+		}
+	}
+	return b.String()
+}
+
+// write adds text to a paragraph and to the comments whose range is open
+func (w *odfWriter) write(b *strings.Builder, s string) {
+	b.WriteString(s)
+	for _, anchor := range w.anchors {
+		anchor.WriteString(s)
+	}
+}
+
+// annotation records a comment. A named comment covers the text up to its annotation-end.
+func (w *odfWriter) annotation(node *xmlNode) {
+	comment := documentComment{anchor: node.attr("name")}
+	var paragraphs []string
+	for _, child := range node.children {
+		switch child.name.Local {
+		case "creator":
+			comment.author = textContent(child)
+		case "date":
+			comment.date = textContent(child)
+		case "p", "list":
+			saved := w.anchors
+			w.anchors = map[string]*strings.Builder{}
+			if text := strings.TrimSpace(w.text(child)); text != "" {
+				paragraphs = append(paragraphs, text)
+			}
+			w.anchors = saved
+		}
+	}
+	comment.text = strings.Join(paragraphs, " ")
+	if comment.text == "" {
+		return
+	}
+
+	// The anchor holds the comment's name until its range ends
+	if comment.anchor != "" {
+		w.anchors[comment.anchor] = &strings.Builder{}
+	}
+	w.comments = append(w.comments, comment)
+}
+
+// setAnchor replaces a named comment's name with the text it covers
+func (w *odfWriter) setAnchor(name, text string) {
+	for i := range w.comments {
+		if w.comments[i].anchor == name {
+			w.comments[i].anchor = text
+		}
+	}
+}
+
+func (w *odfWriter) table(table *xmlNode) [][]string {
+	var rows [][]string
+	for _, row := range table.descendants("table-row") {
+		var cells []string
+		for _, cell := range row.children {
+			if !cell.is("table-cell") && !cell.is("covered-table-cell") {
+				continue
+			}
+
+			var paragraphs []string
+			for _, p := range cell.children {
+				if p.is("p") || p.is("h") {
+					if text := strings.TrimSpace(w.text(p)); text != "" {
+						paragraphs = append(paragraphs, text)
+					}
+				}
+			}
+			text := strings.Join(paragraphs, " ")
+
+			repeat, err := strconv.Atoi(cell.attr("number-columns-repeated"))
+			if err != nil || repeat < 1 {
+				repeat = 1
+			}
+			for i := 0; i < repeat && i < maxRepeatedCells; i++ {
+				cells = append(cells, text)
+			}
+		}
+
+		// Drop the empty padding cells at the end of the row
+		for len(cells) > 0 && cells[len(cells)-1] == "" {
+			cells = cells[:len(cells)-1]
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	}
+	return rows
+}
+
+// presentation renders each page (slide) with its title, content, speaker notes and comments
+func (w *odfWriter) presentation(node *xmlNode) {
+	for _, page := range node.children {
+		if !page.is("page") {
+			continue
+		}
+		w.slides++
+
+		var titleFrame *xmlNode
+		for _, child := range page.children {
+			if child.attr("class") == "title" {
+				titleFrame = child
+				break
+			}
+		}
+		title := page.attr("name")
+		if titleFrame != nil {
+			titleWriter := newODFWriter()
+			titleWriter.block(titleFrame, 0)
+			if text := oneLine(titleWriter.out.String()); text != "" {
+				title = text
+			}
+		}
+
+		if w.slides > 1 {
+			w.out.WriteString("\n")
+		}
+		w.out.startSection(SectionSlide, strconv.Itoa(w.slides))
+		w.out.WriteString(fmt.Sprintf("## Slide %d", w.slides))
+		if title != "" {
+			w.out.WriteString(": " + title)
+		}
+		w.out.WriteString("\n\n")
+
+		// Comments are listed with the slide they are on
+		documentComments := w.comments
+		w.comments = nil
+
+		var notes string
+		for _, child := range page.children {
+			switch {
+			case child == titleFrame:
+			case child.is("notes"):
+				notesWriter := newODFWriter()
+				for _, frame := range child.children {
+					if frame.attr("class") == "notes" {
+						notesWriter.block(frame, 0)
+					}
+				}
+				notes = strings.TrimSpace(notesWriter.out.String())
+			case child.is("annotation"):
+				w.annotation(child)
+			case child.attr("class") == "page-number" || child.attr("class") == "date-time" || child.attr("class") == "footer":
+			default:
+				w.block(&xmlNode{children: []*xmlNode{child}}, 0)
+			}
+		}
+
+		if notes != "" {
+			w.out.WriteString("\nSpeaker notes:\n" + notes + "\n")
+		}
+		writeComments(w.out, w.comments)
+		w.comments = documentComments
+	}
+}
+
+// collapseSpace collapses runs of whitespace to a single space, as OpenDocument readers do
+func collapseSpace(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+
+	collapsed := strings.Join(fields, " ")
+	if strings.TrimLeft(s, " \t\n\r") != s {
+		collapsed = " " + collapsed
+	}
+	if strings.TrimRight(s, " \t\n\r") != s {
+		collapsed += " "
+	}
+	return collapsed
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	pdf "github.com/ledongthuc/pdf"
 )
@@ -25,16 +27,25 @@ func (e *PDFExtractor) CanExtract(mimeType string) bool {
 
 // Extract extracts text content from PDF data
 func (e *PDFExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts text content page by page, with the document's properties
+func (e *PDFExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
 	reader := bytes.NewReader(data)
 
 	// Open PDF
 	pdfReader, err := pdf.NewReader(reader, int64(len(data)))
 	if err != nil {
-		return "", fmt.Errorf("failed to open PDF: %w", err)
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
 	}
 
 	// Extract text from all pages
-	var content strings.Builder
+	content := newResultBuilder()
 	numPages := pdfReader.NumPage()
 
 	for pageNum := 1; pageNum <= numPages; pageNum++ {
@@ -49,14 +60,36 @@ func (e *PDFExtractor) Extract(ctx context.Context, data []byte) (string, error)
 			continue
 		}
 
+		content.startSection(SectionPage, strconv.Itoa(pageNum))
 		content.WriteString(text)
 		content.WriteString("\n\n")
 	}
 
-	extractedText := content.String()
-	if strings.TrimSpace(extractedText) == "" {
-		return "", fmt.Errorf("no text content extracted from PDF")
+	if strings.TrimSpace(content.String()) == "" {
+		return nil, fmt.Errorf("no text content extracted from PDF")
 	}
 
-	return extractedText, nil
+	info := pdfReader.Trailer().Key("Info")
+	content.setMetadata("title", info.Key("Title").Text())
+	content.setMetadata("author", info.Key("Author").Text())
+	content.setMetadata("subject", info.Key("Subject").Text())
+	content.setMetadata("created", pdfDate(info.Key("CreationDate").Text()))
+	content.setMetadata("modified", pdfDate(info.Key("ModDate").Text()))
+
+	result := content.finish()
+	result.PageCount = numPages
+	return result, nil
+}
+
+// pdfDate converts a PDF date ("D:20240131093000+01'00'") to RFC 3339, returning it unchanged
+// if it cannot be parsed
+func pdfDate(value string) string {
+	date := strings.TrimPrefix(value, "D:")
+	date = strings.ReplaceAll(date, "'", "")
+	for _, layout := range []string{"20060102150405Z0700", "20060102150405Z07", "20060102150405", "20060102"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return value
 }
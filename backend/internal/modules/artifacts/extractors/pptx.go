@@ -0,0 +1,340 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PPTXExtractor extracts text from PowerPoint presentations (.pptx) slide by slide, keeping
+// slide titles, tables, speaker notes and reviewer comments
+type PPTXExtractor struct{}
+
+// NewPPTXExtractor creates a new PowerPoint extractor
+func NewPPTXExtractor() *PPTXExtractor {
+	return &PPTXExtractor{}
+}
+
+// CanExtract returns true for PowerPoint MIME types
+func (e *PPTXExtractor) CanExtract(mimeType string) bool {
+	presentationTypes := []string{
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation", // .pptx
+		"application/vnd.openxmlformats-officedocument.presentationml.slideshow",    // .ppsx
+		"application/vnd.ms-powerpoint.presentation.macroenabled.12",                // .pptm
+	}
+
+	for _, t := range presentationTypes {
+		if strings.HasPrefix(strings.ToLower(mimeType), t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Placeholders repeated on every slide rather than content of their own
+var skippedPlaceholders = map[string]bool{"sldNum": true, "dt": true, "ftr": true, "hdr": true, "sldImg": true}
+
+// Extract extracts text content from a PowerPoint presentation
+func (e *PPTXExtractor) Extract(ctx context.Context, data []byte) (string, error) {
+	result, err := e.ExtractResult(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExtractResult extracts text content slide by slide, with tables and document properties
+func (e *PPTXExtractor) ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	presentationPart := pkg.mainPart("ppt/presentation.xml")
+	presentation, err := pkg.parse(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+	if presentation == nil {
+		return nil, fmt.Errorf("no presentation found in PowerPoint file")
+	}
+	rels, err := pkg.relationships(presentationPart)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := commentAuthors(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	content := newResultBuilder()
+	hasContent := false
+	slideIDs := presentation.descendants("sldId")
+
+	for i, slideID := range slideIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rel, ok := rels[slideID.relID()]
+		if !ok {
+			continue
+		}
+		slide, err := pkg.parse(rel.Target)
+		if err != nil {
+			return nil, err
+		}
+		if slide == nil {
+			continue
+		}
+		slideRels, err := pkg.relationships(rel.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.startSection(SectionSlide, strconv.Itoa(i+1))
+
+		s := &slideWriter{out: content}
+		trees := slide.descendants("spTree")
+		for _, tree := range trees {
+			if s.title == nil {
+				s.title = slideTitle(tree)
+			}
+		}
+
+		content.WriteString(fmt.Sprintf("## Slide %d", i+1))
+		if s.title != nil {
+			content.WriteString(": " + oneLine(drawingText(s.title.child("txBody"))))
+			hasContent = true
+		}
+		content.WriteString("\n\n")
+
+		bodyStart := content.Len()
+		for _, tree := range trees {
+			s.shapes(tree)
+		}
+		if content.Len() > bodyStart {
+			hasContent = true
+		}
+
+		for _, notesPart := range related(slideRels, "/notesSlide") {
+			notes, err := pkg.parse(notesPart)
+			if err != nil {
+				return nil, err
+			}
+			if text := notesText(notes); text != "" {
+				content.WriteString("\nSpeaker notes:\n" + text + "\n")
+				hasContent = true
+			}
+		}
+
+		for _, commentsPart := range related(slideRels, "/comments") {
+			comments, err := pkg.parse(commentsPart)
+			if err != nil {
+				return nil, err
+			}
+			writeComments(content, slideComments(comments, authors))
+		}
+	}
+
+	if !hasContent {
+		return nil, fmt.Errorf("no content extracted from PowerPoint file")
+	}
+
+	if err := pkg.readCoreProperties(content); err != nil {
+		return nil, err
+	}
+
+	result := content.finish()
+	result.PageCount = len(slideIDs)
+	return result, nil
+}
+
+// slideWriter renders the shapes of a slide other than its title
+type slideWriter struct {
+	out   *resultBuilder
+	title *xmlNode
+}
+
+func (s *slideWriter) shapes(tree *xmlNode) {
+	for _, shape := range tree.children {
+		switch shape.name.Local {
+		case "sp":
+			if shape == s.title || skippedPlaceholders[placeholderType(shape)] {
+				continue
+			}
+			if text := drawingText(shape.child("txBody")); text != "" {
+				s.out.WriteString(text + "\n")
+			}
+		case "graphicFrame":
+			for _, tbl := range shape.descendants("tbl") {
+				s.out.WriteString("\n")
+				writeTable(s.out, drawingTable(tbl))
+				s.out.WriteString("\n")
+			}
+		case "grpSp":
+			s.shapes(shape)
+		}
+	}
+}
+
+// slideTitle returns the first title placeholder with text in a shape tree
+func slideTitle(tree *xmlNode) *xmlNode {
+	for _, shape := range tree.children {
+		switch shape.name.Local {
+		case "sp":
+			placeholder := placeholderType(shape)
+			if (placeholder == "title" || placeholder == "ctrTitle") && drawingText(shape.child("txBody")) != "" {
+				return shape
+			}
+		case "grpSp":
+			if title := slideTitle(shape); title != nil {
+				return title
+			}
+		}
+	}
+	return nil
+}
+
+// placeholderType returns the type of the layout placeholder a shape fills, if any. Body
+// placeholders have no type.
+func placeholderType(shape *xmlNode) string {
+	for _, ph := range shape.descendants("ph") {
+		if t := ph.attr("type"); t != "" {
+			return t
+		}
+		return "body"
+	}
+	return ""
+}
+
+// drawingText returns the paragraphs of a DrawingML text body, indenting nested bullets
+func drawingText(txBody *xmlNode) string {
+	if txBody == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, p := range txBody.children {
+		if !p.is("p") {
+			continue
+		}
+
+		var b strings.Builder
+		for _, run := range p.children {
+			switch run.name.Local {
+			case "r", "fld":
+				if t := run.child("t"); t != nil {
+					b.WriteString(textContent(t))
+				}
+			case "br":
+				b.WriteString("\n")
+			}
+		}
+
+		text := strings.TrimSpace(b.String())
+		if text == "" {
+			continue
+		}
+		if props := p.child("pPr"); props != nil {
+			if level, err := strconv.Atoi(props.attr("lvl")); err == nil && level > 0 {
+				text = strings.Repeat("  ", level) + "- " + text
+			}
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// drawingTable returns the cell text of a DrawingML table
+func drawingTable(tbl *xmlNode) [][]string {
+	var rows [][]string
+	for _, tr := range tbl.children {
+		if !tr.is("tr") {
+			continue
+		}
+		var row []string
+		for _, tc := range tr.children {
+			if tc.is("tc") {
+				row = append(row, drawingText(tc.child("txBody")))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// notesText returns the speaker notes of a notes slide: the text of its body placeholder
+func notesText(notes *xmlNode) string {
+	if notes == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, shape := range notes.descendants("sp") {
+		if placeholderType(shape) != "body" {
+			continue
+		}
+		if text := drawingText(shape.child("txBody")); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// commentAuthors maps comment author IDs to names, from both the legacy and the modern
+// (PowerPoint 365) comment author parts
+func commentAuthors(pkg *officePackage) (map[string]string, error) {
+	authors := make(map[string]string)
+	for _, part := range []string{"ppt/commentAuthors.xml", "ppt/authors.xml"} {
+		root, err := pkg.parse(part)
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+		for _, author := range append(root.descendants("cmAuthor"), root.descendants("author")...) {
+			authors[author.attr("id")] = author.attr("name")
+		}
+	}
+	return authors, nil
+}
+
+// slideComments reads a slide's legacy or modern comments part
+func slideComments(root *xmlNode, authors map[string]string) []documentComment {
+	if root == nil {
+		return nil
+	}
+
+	var comments []documentComment
+	for _, cm := range root.descendants("cm") {
+		var text string
+		if legacy := cm.child("text"); legacy != nil {
+			text = textContent(legacy)
+		} else {
+			text = drawingText(cm.child("txBody"))
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		date := cm.attr("dt")
+		if date == "" {
+			date = cm.attr("created")
+		}
+
+		comments = append(comments, documentComment{
+			author: authors[cm.attr("authorId")],
+			date:   date,
+			text:   text,
+		})
+	}
+	return comments
+}
@@ -0,0 +1,212 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StructuredExtractor is implemented by extractors that can report a document's structure
+// (pages, sheets, slides, sections and tables) and properties along with its text
+type StructuredExtractor interface {
+	ContentExtractor
+
+	// ExtractResult extracts text content and structure from the file data
+	ExtractResult(ctx context.Context, data []byte) (*ExtractionResult, error)
+}
+
+// Section kinds
+const (
+	SectionPage    = "page"
+	SectionSheet   = "sheet"
+	SectionSlide   = "slide"
+	SectionHeading = "section"
+	SectionMessage = "message"
+)
+
+// Section is a page, sheet, slide, headed section or mailbox message of the extracted content. Offsets are byte
+// offsets into ExtractionResult.Content.
+type Section struct {
+	Kind        string `json:"kind"`
+	Label       string `json:"label"` // Page, slide or message number, sheet name or heading text
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+}
+
+// Table is a table in the extracted content
+type Table struct {
+	StartOffset int        `json:"start_offset"`
+	EndOffset   int        `json:"end_offset"`
+	Columns     []string   `json:"columns,omitempty"` // Header row, if the table has one
+	Rows        []TableRow `json:"rows"`
+}
+
+// TableRow marks where a row of a table starts in the extracted content
+type TableRow struct {
+	Number int `json:"number"` // Row number in the source: the spreadsheet row for sheets, else 1-based
+	Offset int `json:"offset"`
+}
+
+// Location describes where offset falls in the source document, e.g. "page 7" or
+// "sheet Budget, row 14". It returns "" for unstructured content.
+func (r *ExtractionResult) Location(offset int) string {
+	var parts []string
+	sheet := false
+	for _, s := range r.Sections {
+		if offset < s.StartOffset || offset >= s.EndOffset {
+			continue
+		}
+		switch s.Kind {
+		case SectionHeading:
+			parts = append(parts, fmt.Sprintf("section %q", s.Label))
+		default:
+			parts = append(parts, s.Kind+" "+s.Label)
+		}
+		if s.Kind == SectionSheet {
+			sheet = true
+		}
+	}
+
+	for i, t := range r.Tables {
+		if offset < t.StartOffset || offset >= t.EndOffset {
+			continue
+		}
+		row := 0
+		for _, tr := range t.Rows {
+			if tr.Offset > offset {
+				break
+			}
+			row = tr.Number
+		}
+		if row == 0 {
+			continue
+		}
+		if sheet {
+			parts = append(parts, fmt.Sprintf("row %d", row))
+		} else {
+			parts = append(parts, fmt.Sprintf("table %d, row %d", i+1, row))
+		}
+		break
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Span describes the content between start and end, e.g. "page 3" or "page 3 to page 4"
+func (r *ExtractionResult) Span(start, end int) string {
+	from := r.Location(start)
+	if end <= start {
+		return from
+	}
+	to := r.Location(end - 1)
+	if from == to || to == "" {
+		return from
+	}
+	if from == "" {
+		return to
+	}
+	return from + " to " + to
+}
+
+// resultBuilder accumulates extracted text while recording the offsets of its structure
+type resultBuilder struct {
+	out    strings.Builder
+	result ExtractionResult
+	open   map[string]int // Section kind -> index of the open section of that kind
+}
+
+func newResultBuilder() *resultBuilder {
+	return &resultBuilder{
+		result: ExtractionResult{Metadata: make(map[string]string)},
+		open:   make(map[string]int),
+	}
+}
+
+// WriteString adds sanitized text
+func (b *resultBuilder) WriteString(s string) {
+	b.out.WriteString(sanitizeText(s))
+}
+
+func (b *resultBuilder) Len() int {
+	return b.out.Len()
+}
+
+func (b *resultBuilder) String() string {
+	return b.out.String()
+}
+
+// startSection opens a section at the current offset, ending the open section of the same kind
+func (b *resultBuilder) startSection(kind, label string) {
+	b.endSection(kind)
+	b.open[kind] = len(b.result.Sections)
+	b.result.Sections = append(b.result.Sections, Section{Kind: kind, Label: label, StartOffset: b.Len()})
+}
+
+// endSection ends the open section of a kind, if any
+func (b *resultBuilder) endSection(kind string) {
+	if i, ok := b.open[kind]; ok {
+		b.result.Sections[i].EndOffset = b.Len()
+		delete(b.open, kind)
+	}
+}
+
+// setMetadata records a document property, ignoring empty values
+func (b *resultBuilder) setMetadata(key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		b.result.Metadata[key] = value
+	}
+}
+
+// addTable records a table that has been written
+func (b *resultBuilder) addTable(table Table) {
+	if len(table.Rows) > 0 {
+		b.result.Tables = append(b.result.Tables, table)
+	}
+}
+
+// trimRight drops trailing whitespace, pulling back offsets that pointed past it
+func (b *resultBuilder) trimRight() {
+	content := strings.TrimRight(b.out.String(), " \t\r\n")
+	b.out.Reset()
+	b.out.WriteString(content)
+
+	clamp := func(offset *int) {
+		if *offset > len(content) {
+			*offset = len(content)
+		}
+	}
+	for i := range b.result.Sections {
+		clamp(&b.result.Sections[i].EndOffset)
+	}
+	for i := range b.result.Tables {
+		clamp(&b.result.Tables[i].EndOffset)
+	}
+}
+
+// finish ends the open sections and returns the result
+func (b *resultBuilder) finish() *ExtractionResult {
+	for kind := range b.open {
+		b.endSection(kind)
+	}
+
+	sections := b.result.Sections[:0]
+	for _, s := range b.result.Sections {
+		if s.EndOffset > s.StartOffset {
+			sections = append(sections, s)
+		}
+	}
+	b.result.Sections = sections
+
+	b.result.Content = b.out.String()
+	b.result.WordCount = len(strings.Fields(b.result.Content))
+	return &b.result
+}
+
+// plainResult wraps the text of an extractor that reports no structure
+func plainResult(content string) *ExtractionResult {
+	return &ExtractionResult{
+		Content:   content,
+		WordCount: len(strings.Fields(content)),
+		Metadata:  make(map[string]string),
+	}
+}
@@ -0,0 +1,60 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelExtractor_ExtractResult(t *testing.T) {
+	workbook := excelize.NewFile()
+	workbook.SetSheetName("Sheet1", "Summary")
+	workbook.SetCellValue("Summary", "A1", "Total")
+	workbook.NewSheet("Budget")
+	workbook.SetCellValue("Budget", "A1", "Item")
+	workbook.SetCellValue("Budget", "B1", "Cost")
+	for row := 2; row <= 20; row++ {
+		workbook.SetCellValue("Budget", fmt.Sprintf("A%d", row), fmt.Sprintf("Line item %d", row))
+		workbook.SetCellValue("Budget", fmt.Sprintf("B%d", row), row*100)
+	}
+	workbook.SetDocProps(&excelize.DocProperties{Title: "FY25 Budget", Creator: "Finance"})
+	buf, err := workbook.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to write workbook: %v", err)
+	}
+
+	result, err := NewExcelExtractor().ExtractResult(context.Background(), buf.Bytes())
+	if err != nil {
+		t.Fatalf("ExtractResult() error = %v", err)
+	}
+
+	if result.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2 sheets", result.PageCount)
+	}
+	offset := strings.Index(result.Content, "Line item 14")
+	if got := result.Location(offset); got != "sheet Budget, row 14" {
+		t.Errorf("Location() = %q, want %q", got, "sheet Budget, row 14")
+	}
+	if got := result.Span(strings.Index(result.Content, "Total"), offset); got != "sheet Summary, row 1 to sheet Budget, row 14" {
+		t.Errorf("Span() = %q", got)
+	}
+	if result.Metadata["title"] != "FY25 Budget" || result.Metadata["author"] != "Finance" {
+		t.Errorf("Metadata = %v, want the document properties", result.Metadata)
+	}
+	if len(result.Tables) != 2 || strings.Join(result.Tables[1].Columns, ",") != "Item,Cost" {
+		t.Errorf("Tables = %+v, want a table per sheet with the Budget header", result.Tables)
+	}
+}
+
+func TestExtractorFactory_ExtractResult_PlainText(t *testing.T) {
+	result, err := NewExtractorFactory().ExtractResult(context.Background(), "text/plain", []byte("three plain words"))
+	if err != nil {
+		t.Fatalf("ExtractResult() error = %v", err)
+	}
+	if result.WordCount != 3 || result.Location(0) != "" {
+		t.Errorf("ExtractResult() = %+v, want a word count and no structure", result)
+	}
+}
@@ -145,8 +145,14 @@ func getMimeTypeFromFilename(filename string) string {
 		".xls":  "application/vnd.ms-excel",
 		".doc":  "application/msword",
 		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".docm": "application/vnd.ms-word.document.macroenabled.12",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".pptm": "application/vnd.ms-powerpoint.presentation.macroenabled.12",
+		".odt":  "application/vnd.oasis.opendocument.text",
+		".odp":  "application/vnd.oasis.opendocument.presentation",
 		".eml":  "message/rfc822",
 		".msg":  "application/vnd.ms-outlook",
+		".mbox": "application/mbox",
 	}
 
 	if mimeType, ok := mimeTypes[ext]; ok {
@@ -0,0 +1,168 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cerberus/backend/internal/modules/artifacts/extractors"
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/ai/aitest"
+	"github.com/google/uuid"
+)
+
+const goldenDir = "testdata/golden"
+
+// goldenProgram is the program context every corpus artifact is analyzed under
+var goldenProgram = &ai.ProgramContext{
+	ProgramName:  "Project Phoenix",
+	ProgramCode:  "PHX",
+	CompanyName:  "Acme Corp",
+	KnownVendors: "Globex",
+}
+
+// goldenAnalysis is the part of an AnalysisResult that depends on the model and on parsing,
+// without generated IDs, timings or cost
+type goldenAnalysis struct {
+	DocumentType           string          `json:"document_type"`
+	DocumentTypeConfidence float64         `json:"document_type_confidence"`
+	Summary                string          `json:"summary"`
+	Sentiment              string          `json:"sentiment,omitempty"`
+	Priority               int32           `json:"priority,omitempty"`
+	Topics                 []goldenTopic   `json:"topics"`
+	Persons                []goldenPerson  `json:"persons"`
+	Facts                  []goldenFact    `json:"facts"`
+	Insights               []goldenInsight `json:"insights"`
+}
+
+type goldenTopic struct {
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+}
+
+type goldenPerson struct {
+	Name         string  `json:"name"`
+	Role         string  `json:"role,omitempty"`
+	Organization string  `json:"organization,omitempty"`
+	Confidence   float64 `json:"confidence"`
+}
+
+type goldenFact struct {
+	Type       string   `json:"type"`
+	Key        string   `json:"key"`
+	Value      string   `json:"value"`
+	Numeric    *float64 `json:"numeric,omitempty"`
+	Date       string   `json:"date,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	Confidence float64  `json:"confidence"`
+}
+
+type goldenInsight struct {
+	Type            string   `json:"type"`
+	Title           string   `json:"title"`
+	Severity        string   `json:"severity,omitempty"`
+	SuggestedAction string   `json:"suggested_action,omitempty"`
+	ImpactedModules []string `json:"impacted_modules,omitempty"`
+	Confidence      float64  `json:"confidence"`
+}
+
+func toGoldenAnalysis(result *AnalysisResult) goldenAnalysis {
+	golden := goldenAnalysis{
+		DocumentType:           result.DocumentType,
+		DocumentTypeConfidence: result.DocumentTypeConfidence,
+		Summary:                result.Summary.ExecutiveSummary,
+		Sentiment:              result.Summary.Sentiment.String,
+		Priority:               result.Summary.Priority.Int32,
+		Topics:                 []goldenTopic{},
+		Persons:                []goldenPerson{},
+		Facts:                  []goldenFact{},
+		Insights:               []goldenInsight{},
+	}
+
+	for _, topic := range result.Topics {
+		golden.Topics = append(golden.Topics, goldenTopic{Name: topic.TopicName, Confidence: topic.ConfidenceScore})
+	}
+	for _, person := range result.Persons {
+		golden.Persons = append(golden.Persons, goldenPerson{
+			Name:         person.PersonName,
+			Role:         person.PersonRole.String,
+			Organization: person.PersonOrganization.String,
+			Confidence:   person.ConfidenceScore.Float64,
+		})
+	}
+	for _, fact := range result.Facts {
+		f := goldenFact{
+			Type:       fact.FactType,
+			Key:        fact.FactKey,
+			Value:      fact.FactValue,
+			Unit:       fact.Unit.String,
+			Confidence: fact.ConfidenceScore.Float64,
+		}
+		if fact.NormalizedValueNumeric.Valid {
+			f.Numeric = &fact.NormalizedValueNumeric.Float64
+		}
+		if fact.NormalizedValueDate.Valid {
+			f.Date = fact.NormalizedValueDate.Time.Format("2006-01-02")
+		}
+		golden.Facts = append(golden.Facts, f)
+	}
+	for _, insight := range result.Insights {
+		golden.Insights = append(golden.Insights, goldenInsight{
+			Type:            insight.InsightType,
+			Title:           insight.Title,
+			Severity:        insight.Severity.String,
+			SuggestedAction: insight.SuggestedAction.String,
+			ImpactedModules: insight.ImpactedModules,
+			Confidence:      insight.ConfidenceScore.Float64,
+		})
+	}
+
+	return golden
+}
+
+// TestAnalyzeArtifact_Golden extracts and analyzes each artifact in the corpus against
+// recorded model responses (see package aitest to record them again)
+func TestAnalyzeArtifact_Golden(t *testing.T) {
+	corpus := []struct {
+		file     string
+		mimeType string
+	}{
+		{"steering-committee-minutes.pdf", "application/pdf"},
+		{"vendor-escalation.eml", "message/rfc822"},
+		{"q2-resource-plan.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	}
+
+	client := ai.NewClient(&ai.ClientConfig{Provider: aitest.Provider(t, filepath.Join(goldenDir, "recordings"))})
+	analyzer := NewAIAnalyzer(client, nil)
+	factory := extractors.NewExtractorFactory()
+
+	for _, tc := range corpus {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(goldenDir, "corpus", tc.file))
+			if err != nil {
+				t.Fatalf("failed to read corpus file: %v", err)
+			}
+
+			content, err := factory.Extract(context.Background(), tc.mimeType, data)
+			if err != nil {
+				t.Fatalf("failed to extract %s: %v", tc.file, err)
+			}
+
+			artifact := &Artifact{
+				ArtifactID: uuid.New(),
+				Filename:   tc.file,
+				RawContent: sql.NullString{String: content, Valid: true},
+			}
+			result, err := analyzer.AnalyzeArtifact(context.Background(), artifact, goldenProgram)
+			if err != nil {
+				t.Fatalf("AnalyzeArtifact() error = %v", err)
+			}
+
+			name := strings.TrimSuffix(tc.file, filepath.Ext(tc.file)) + ".golden.json"
+			aitest.AssertGolden(t, filepath.Join(goldenDir, "expected", name), toGoldenAnalysis(result))
+		})
+	}
+}
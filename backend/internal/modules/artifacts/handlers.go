@@ -3,47 +3,102 @@ package artifacts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cerberus/backend/internal/platform/auth"
-	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
-// EventPublisher defines the interface for publishing events
-type EventPublisher interface {
-	Publish(ctx context.Context, event *events.Event) error
-}
-
 // RegisterRoutes registers all artifact endpoints
-func RegisterRoutes(r chi.Router, service *Service, authRepo *auth.Repository, eventBus EventPublisher) {
+func RegisterRoutes(r chi.Router, service *Service, authRepo *auth.Repository) {
 	r.Route("/programs/{programId}/artifacts", func(r chi.Router) {
 		// Viewer access (read operations)
 		r.Group(func(r chi.Router) {
 			r.Use(auth.RequireProgramAccess(auth.RoleViewer, authRepo))
 			r.Get("/", handleList(service))
+			r.Get("/dead-letters", handleListDeadLetters(service))
 			r.Get("/{artifactId}", handleGet(service))
 			r.Get("/{artifactId}/metadata", handleGetMetadata(service))
 			r.Get("/{artifactId}/download", handleDownload(service))
+			r.Get("/{artifactId}/thread", handleGetThread(service))
+			r.Get("/{artifactId}/attachments", handleListAttachments(service))
 		})
 
 		// Contributor access (write operations)
 		r.Group(func(r chi.Router) {
 			r.Use(auth.RequireProgramAccess(auth.RoleContributor, authRepo))
-			r.Post("/upload", handleUpload(service, eventBus))
+			r.Post("/uploads", handleCreateUploadSession(service))
+			r.Get("/uploads/{sessionId}", handleGetUploadSession(service))
+			r.Post("/uploads/{sessionId}/complete", handleCompleteUploadSession(service))
+			r.Delete("/uploads/{sessionId}", handleAbortUploadSession(service))
 			r.Post("/search", handleSearch(service))
-			r.Post("/{artifactId}/reanalyze", handleReanalyze(service, eventBus))
+			r.Post("/{artifactId}/reanalyze", handleReanalyze(service))
 			r.Delete("/{artifactId}", handleDelete(service))
+			r.Post("/dead-letters/{deadLetterId}/requeue", handleRequeueDeadLetter(service))
+			r.Put("/{artifactId}/insights/{insightId}/feedback", handleInsightFeedback(service))
 		})
 	})
 }
 
+// RegisterUploadRoutes registers the endpoints that stream a file from the client. They take as
+// long as the file takes to send, so they are mounted outside the API's request timeout and
+// extend their connection deadlines to fit the program's upload limit instead.
+func RegisterUploadRoutes(r chi.Router, service *Service, authRepo *auth.Repository) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireProgramAccess(auth.RoleContributor, authRepo))
+		registerUploadRoutes(r, service)
+	})
+}
+
+// registerUploadRoutes registers the streaming upload endpoints without access checks
+func registerUploadRoutes(r chi.Router, service *Service) {
+	r.Post("/programs/{programId}/artifacts/upload", handleUpload(service))
+	r.Put("/programs/{programId}/artifacts/uploads/{sessionId}/parts/{partNumber}", handleUploadPart(service))
+}
+
+const (
+	// minUploadBytesPerSecond is the slowest connection an upload is given time to finish on
+	minUploadBytesPerSecond = 256 << 10
+
+	// minUploadTimeout is the least time an upload is given, however low the upload limit
+	minUploadTimeout = time.Minute
+)
+
+// extendUploadDeadline extends the connection's read and write deadlines to give an upload of up
+// to limit bytes time to arrive, and returns the request's context bounded by the same deadline
+func extendUploadDeadline(w http.ResponseWriter, r *http.Request, limit int64) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(limit/minUploadBytesPerSecond) * time.Second
+	if timeout < minUploadTimeout {
+		timeout = minUploadTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	// Writers that cannot set deadlines keep the server's timeouts
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+
+	return context.WithDeadline(r.Context(), deadline)
+}
+
+// maxMultipartOverhead allows for the multipart framing and form fields around the file when a
+// request's Content-Length is checked against the upload limit
+const maxMultipartOverhead = 1 << 20
+
 // handleUpload handles artifact upload
-func handleUpload(service *Service, eventBus EventPublisher) http.HandlerFunc {
+// The file is streamed from the request to storage rather than read into memory; its content is
+// extracted by the worker. ZIP archives are expanded in memory, within the upload limit, and
+// mailboxes are split as they stream. The artifact.uploaded event is written to the outbox by
+// the service along with the artifact.
+func handleUpload(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Parse program ID from URL
 		programIDStr := chi.URLParam(r, "programId")
@@ -53,25 +108,42 @@ func handleUpload(service *Service, eventBus EventPublisher) http.HandlerFunc {
 			return
 		}
 
-		// Parse multipart form (50MB max)
-		if err := r.ParseMultipartForm(50 << 20); err != nil {
-			respondError(w, http.StatusBadRequest, "Failed to parse upload")
+		// Refuse uploads that cannot fit the program's limit before reading them
+		limit := service.UploadLimit(r.Context(), programID)
+		if r.ContentLength > limit+maxMultipartOverhead {
+			respondTooLarge(w, limit)
 			return
 		}
 
-		file, header, err := r.FormFile("file")
+		ctx, cancel := extendUploadDeadline(w, r, limit+maxMultipartOverhead)
+		defer cancel()
+		r = r.WithContext(ctx)
+		r.Body = http.MaxBytesReader(w, r.Body, limit+maxMultipartOverhead)
+
+		// Read the multipart form up to the file, skipping any fields before it
+		reader, err := r.MultipartReader()
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "No file provided")
+			respondError(w, http.StatusBadRequest, "Failed to parse upload")
 			return
 		}
-		defer file.Close()
 
-		// Read file data
-		data, err := io.ReadAll(file)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to read file")
-			return
+		var file *multipart.Part
+		for file == nil {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				respondError(w, http.StatusBadRequest, "No file provided")
+				return
+			}
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Failed to parse upload")
+				return
+			}
+			if part.FormName() == "file" && part.FileName() != "" {
+				file = part
+			}
 		}
+		defer file.Close()
+		filename := file.FileName()
 
 		// TODO: Get uploadedBy from JWT claims (for now use hardcoded value)
 		uploadedBy := uuid.MustParse("00000000-0000-0000-0000-000000000001")
@@ -79,110 +151,335 @@ func handleUpload(service *Service, eventBus EventPublisher) http.HandlerFunc {
 		// Parse force parameter from query string
 		forceUpload := r.URL.Query().Get("force") == "true"
 
-		// Detect if this is a ZIP file
-		mimeType := header.Header.Get("Content-Type")
-		isZipFile := strings.HasPrefix(mimeType, "application/zip") ||
-			strings.HasPrefix(mimeType, "application/x-zip") ||
-			strings.HasSuffix(strings.ToLower(header.Filename), ".zip")
+		// Detect if this is a ZIP file or a mailbox export
+		mimeType := file.Header.Get("Content-Type")
+
+		if isZipUpload(mimeType, filename) {
+			// Read file data, one byte past the limit to detect larger files
+			data, err := io.ReadAll(io.LimitReader(file, limit+1))
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "Failed to read file")
+				return
+			}
+			if int64(len(data)) > limit {
+				respondTooLarge(w, limit)
+				return
+			}
 
-		if isZipFile {
 			// Handle ZIP file - expand and upload each file separately
 			artifactIDs, err := service.UploadZipArchive(r.Context(), UploadRequest{
 				ProgramID:   programID,
-				Filename:    header.Filename,
+				Filename:    filename,
 				MimeType:    mimeType,
 				Data:        data,
 				UploadedBy:  uploadedBy,
 				ForceUpload: forceUpload,
 			})
-
 			if err != nil {
 				respondError(w, http.StatusInternalServerError, err.Error())
 				return
 			}
 
-			// Publish events for each uploaded artifact
-			for _, artifactID := range artifactIDs {
-				event := events.NewEvent(
-					events.ArtifactUploaded,
-					programID,
-					"artifacts",
-					map[string]interface{}{
-						"artifact_id": artifactID.String(),
-					},
-				)
-				if err := eventBus.Publish(r.Context(), event); err != nil {
-					// Log error but don't fail the upload
-					fmt.Printf("Warning: Failed to publish event for artifact %s: %v\n", artifactID, err)
-				}
-			}
+			respondExpanded(w, artifactIDs, "ZIP archive expanded. %d files uploaded successfully. AI analysis queued.")
+			return
+		}
 
-			// Return success with list of artifact IDs
-			artifactIDStrings := make([]string, len(artifactIDs))
-			for i, id := range artifactIDs {
-				artifactIDStrings[i] = id.String()
+		if isMboxUpload(mimeType, filename) {
+			// Handle mbox file - split it as it streams and upload each message as a separate
+			// email artifact
+			artifactIDs, err := service.UploadMboxStream(r.Context(), UploadRequest{
+				ProgramID:   programID,
+				Filename:    filename,
+				MimeType:    "application/mbox",
+				Body:        file,
+				Size:        -1,
+				UploadedBy:  uploadedBy,
+				ForceUpload: forceUpload,
+			})
+			if err != nil {
+				if tooLargeErr, ok := err.(*UploadTooLargeError); ok {
+					respondTooLarge(w, tooLargeErr.LimitBytes)
+					return
+				}
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
 			}
 
-			respondCreated(w, map[string]interface{}{
-				"artifact_ids": artifactIDStrings,
-				"count":        len(artifactIDs),
-				"message":      fmt.Sprintf("ZIP archive expanded. %d files uploaded successfully. AI analysis queued.", len(artifactIDs)),
-			})
+			respondExpanded(w, artifactIDs, "Mailbox split. %d messages uploaded successfully. AI analysis queued.")
 			return
 		}
 
-		// Upload single artifact
-		artifactID, err := service.UploadArtifact(r.Context(), UploadRequest{
+		// Stream single artifact to storage
+		artifactID, err := service.UploadArtifactStream(r.Context(), UploadRequest{
 			ProgramID:   programID,
-			Filename:    header.Filename,
+			Filename:    filename,
 			MimeType:    mimeType,
-			Data:        data,
+			Body:        file,
+			Size:        -1,
 			UploadedBy:  uploadedBy,
 			ForceUpload: forceUpload,
 		})
 
 		if err != nil {
-			// Check if encrypted PDF error
-			if encErr, ok := err.(*EncryptedPDFError); ok {
-				respondError(w, http.StatusBadRequest, encErr.Message)
+			// Check if the file is over the program's limit
+			if tooLargeErr, ok := err.(*UploadTooLargeError); ok {
+				respondTooLarge(w, tooLargeErr.LimitBytes)
 				return
 			}
 
 			// Check if duplicate error
 			if dupErr, ok := err.(*DuplicateError); ok {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusConflict)
-				json.NewEncoder(w).Encode(map[string]interface{}{
-					"success":              false,
-					"error":                "Duplicate artifact already exists",
-					"existing_artifact_id": dupErr.ExistingArtifactID.String(),
-					"existing_status":      dupErr.Status,
-					"message":              "This file was already uploaded. Use ?force=true to replace it.",
-				})
+				respondDuplicate(w, dupErr)
 				return
 			}
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		// Publish event for successful upload
-		event := events.NewEvent(
-			events.ArtifactUploaded,
-			programID,
-			"artifacts",
-			map[string]interface{}{
-				"artifact_id": artifactID.String(),
-			},
-		)
-		if err := eventBus.Publish(r.Context(), event); err != nil {
-			// Log error but don't fail the upload
-			fmt.Printf("Warning: Failed to publish artifact upload event: %v\n", err)
-		}
-
 		respondCreated(w, map[string]string{
 			"artifact_id": artifactID.String(),
-			"message":     "Artifact uploaded successfully. AI analysis queued.",
+			"message":     "Artifact uploaded successfully. Content extraction and AI analysis queued.",
+		})
+	}
+}
+
+// respondTooLarge reports an upload over the program's size limit
+func respondTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          false,
+		"error":            "File too large",
+		"max_upload_bytes": limit,
+		"message":          fmt.Sprintf("Files uploaded to this program are limited to %.1f MB.", float64(limit)/(1<<20)),
+	})
+}
+
+// respondExpanded reports the artifacts created from an archive or mailbox; message has a %d
+// for their count
+func respondExpanded(w http.ResponseWriter, artifactIDs []uuid.UUID, message string) {
+	artifactIDStrings := make([]string, len(artifactIDs))
+	for i, id := range artifactIDs {
+		artifactIDStrings[i] = id.String()
+	}
+
+	respondCreated(w, map[string]interface{}{
+		"artifact_ids": artifactIDStrings,
+		"count":        len(artifactIDs),
+		"message":      fmt.Sprintf(message, len(artifactIDs)),
+	})
+}
+
+// respondDuplicate reports an upload of a file the program already has
+func respondDuplicate(w http.ResponseWriter, dupErr *DuplicateError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":              false,
+		"error":                "Duplicate artifact already exists",
+		"existing_artifact_id": dupErr.ExistingArtifactID.String(),
+		"existing_status":      dupErr.Status,
+		"message":              "This file was already uploaded. Use ?force=true to replace it.",
+	})
+}
+
+// handleCreateUploadSession starts a resumable upload
+// The client then PUTs each part to /uploads/{sessionId}/parts/{partNumber}, or to the
+// presigned storage URLs returned with presign, and completes the session.
+func handleCreateUploadSession(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programIDStr := chi.URLParam(r, "programId")
+		programID, err := uuid.Parse(programIDStr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		var req CreateUploadSessionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Filename == "" || req.FileSizeBytes <= 0 {
+			respondError(w, http.StatusBadRequest, "filename and file_size_bytes are required")
+			return
+		}
+
+		uploadedBy, err := auth.GetUserID(r.Context())
+		if err != nil {
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		req.ProgramID = programID
+		req.UploadedBy = uploadedBy
+
+		progress, err := service.CreateUploadSession(r.Context(), req)
+		if err != nil {
+			respondUploadSessionError(w, err)
+			return
+		}
+
+		respondCreated(w, progress)
+	}
+}
+
+// handleGetUploadSession reports the stored and missing parts of an upload session, so an
+// interrupted upload can resume. ?presign=true returns fresh storage URLs for the missing parts.
+func handleGetUploadSession(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, sessionID, ok := parseUploadSessionParams(w, r)
+		if !ok {
+			return
+		}
+
+		presign := r.URL.Query().Get("presign") == "true"
+		progress, err := service.GetUploadSession(r.Context(), programID, sessionID, presign)
+		if err != nil {
+			respondUploadSessionError(w, err)
+			return
+		}
+
+		respondSuccess(w, progress)
+	}
+}
+
+// handleUploadPart stores one part of an upload session from the raw request body
+func handleUploadPart(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, sessionID, ok := parseUploadSessionParams(w, r)
+		if !ok {
+			return
+		}
+
+		partNumber, err := strconv.Atoi(chi.URLParam(r, "partNumber"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid part number")
+			return
+		}
+
+		// The part's length is checked before it is read
+		if r.ContentLength < 0 {
+			respondError(w, http.StatusLengthRequired, "Content-Length is required")
+			return
+		}
+
+		// A part is never larger than the file, so the program's limit bounds its deadline
+		ctx, cancel := extendUploadDeadline(w, r, service.UploadLimit(r.Context(), programID))
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		part, err := service.UploadSessionPart(r.Context(), programID, sessionID, partNumber, r.Body, r.ContentLength)
+		if err != nil {
+			respondUploadSessionError(w, err)
+			return
+		}
+
+		respondSuccess(w, part)
+	}
+}
+
+// handleCompleteUploadSession joins the parts of an upload session and creates its artifacts
+// The artifact.uploaded events are written to the outbox by the service along with the artifacts.
+func handleCompleteUploadSession(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, sessionID, ok := parseUploadSessionParams(w, r)
+		if !ok {
+			return
+		}
+
+		artifactIDs, err := service.CompleteUploadSession(r.Context(), programID, sessionID)
+		if err != nil {
+			respondUploadSessionError(w, err)
+			return
+		}
+
+		artifactIDStrings := make([]string, len(artifactIDs))
+		for i, id := range artifactIDs {
+			artifactIDStrings[i] = id.String()
+		}
+
+		response := map[string]interface{}{
+			"artifact_ids": artifactIDStrings,
+			"count":        len(artifactIDs),
+			"message":      "Upload completed. Content extraction and AI analysis queued.",
+		}
+		if len(artifactIDs) == 1 {
+			response["artifact_id"] = artifactIDStrings[0]
+		}
+		respondCreated(w, response)
+	}
+}
+
+// handleAbortUploadSession discards an upload session and its stored parts
+func handleAbortUploadSession(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, sessionID, ok := parseUploadSessionParams(w, r)
+		if !ok {
+			return
+		}
+
+		if err := service.AbortUploadSession(r.Context(), programID, sessionID); err != nil {
+			respondUploadSessionError(w, err)
+			return
+		}
+
+		respondNoContent(w)
+	}
+}
+
+// parseUploadSessionParams parses the program and session IDs of an upload session route,
+// responding with an error if either is invalid
+func parseUploadSessionParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid program ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid upload session ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return programID, sessionID, true
+}
+
+// respondUploadSessionError reports an upload session error with its matching status
+func respondUploadSessionError(w http.ResponseWriter, err error) {
+	var tooLargeErr *UploadTooLargeError
+	var dupErr *DuplicateError
+	var incompleteErr *IncompleteUploadError
+	var stateErr *UploadSessionStateError
+	var partErr *InvalidPartError
+	var unprocessableErr *UnprocessableUploadError
+
+	switch {
+	case errors.Is(err, ErrUploadSessionNotFound):
+		respondError(w, http.StatusNotFound, "Upload session not found")
+	case errors.As(err, &tooLargeErr):
+		respondTooLarge(w, tooLargeErr.LimitBytes)
+	case errors.As(err, &dupErr):
+		respondDuplicate(w, dupErr)
+	case errors.Is(err, ErrDuplicateContent):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &unprocessableErr):
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.As(err, &incompleteErr):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":       false,
+			"error":         "Upload incomplete",
+			"missing_parts": incompleteErr.MissingParts,
+			"message":       fmt.Sprintf("%d parts have not been uploaded yet.", len(incompleteErr.MissingParts)),
 		})
+	case errors.As(err, &stateErr):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &partErr):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
 	}
 }
 
@@ -271,6 +568,51 @@ func handleGetMetadata(service *Service) http.HandlerFunc {
 	}
 }
 
+// handleGetThread retrieves the conversation thread of an email artifact
+func handleGetThread(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		artifactIDStr := chi.URLParam(r, "artifactId")
+		artifactID, err := uuid.Parse(artifactIDStr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid artifact ID")
+			return
+		}
+
+		messages, err := service.GetEmailThread(r.Context(), artifactID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Email thread not found")
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"thread_id": messages[0].ThreadID,
+			"messages":  messages,
+		})
+	}
+}
+
+// handleListAttachments lists the artifacts created from an email artifact's attachments
+func handleListAttachments(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		artifactIDStr := chi.URLParam(r, "artifactId")
+		artifactID, err := uuid.Parse(artifactIDStr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid artifact ID")
+			return
+		}
+
+		attachments, err := service.ListAttachments(r.Context(), artifactID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"attachments": attachments,
+		})
+	}
+}
+
 // handleDownload downloads the original artifact file
 func handleDownload(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -326,7 +668,7 @@ func handleDelete(service *Service) http.HandlerFunc {
 }
 
 // handleReanalyze queues an artifact for reanalysis
-func handleReanalyze(service *Service, eventBus EventPublisher) http.HandlerFunc {
+func handleReanalyze(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		artifactIDStr := chi.URLParam(r, "artifactId")
 		artifactID, err := uuid.Parse(artifactIDStr)
@@ -335,9 +677,7 @@ func handleReanalyze(service *Service, eventBus EventPublisher) http.HandlerFunc
 			return
 		}
 
-		// Get artifact to retrieve program ID
-		artifact, err := service.GetArtifact(r.Context(), artifactID)
-		if err != nil {
+		if _, err := service.GetArtifact(r.Context(), artifactID); err != nil {
 			respondError(w, http.StatusNotFound, "Artifact not found")
 			return
 		}
@@ -347,23 +687,133 @@ func handleReanalyze(service *Service, eventBus EventPublisher) http.HandlerFunc
 			return
 		}
 
-		// Publish event to trigger reanalysis
-		event := events.NewEvent(
-			events.ArtifactUploaded,
-			artifact.ProgramID,
-			"artifacts",
-			map[string]interface{}{
-				"artifact_id": artifactID.String(),
-				"reanalysis":  true,
-			},
-		)
-		if err := eventBus.Publish(r.Context(), event); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Warning: Failed to publish reanalysis event: %v\n", err)
+		respondSuccess(w, map[string]string{
+			"message": "Artifact queued for reanalysis",
+		})
+	}
+}
+
+// handleListDeadLetters lists artifacts that exhausted their processing retries; error_code
+// filters by kind of failure (e.g. output_validation)
+func handleListDeadLetters(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		limit := 50
+		offset := 0
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var l int
+			if _, err := fmt.Sscanf(limitStr, "%d", &l); err == nil && l > 0 {
+				limit = l
+			}
+		}
+		if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+			var o int
+			if _, err := fmt.Sscanf(offsetStr, "%d", &o); err == nil && o >= 0 {
+				offset = o
+			}
+		}
+		includeRequeued := r.URL.Query().Get("include_requeued") == "true"
+		errorCode := r.URL.Query().Get("error_code")
+
+		deadLetters, err := service.ListDeadLetters(r.Context(), programID, includeRequeued, errorCode, limit, offset)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"dead_letters": deadLetters,
+			"limit":        limit,
+			"offset":       offset,
+		})
+	}
+}
+
+// handleRequeueDeadLetter sends a dead-lettered artifact back through the pipeline
+func handleRequeueDeadLetter(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		deadLetterID, err := uuid.Parse(chi.URLParam(r, "deadLetterId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid dead letter ID")
+			return
+		}
+
+		// Requeue is still allowed if the caller's ID is unavailable; requeued_by is left empty
+		userID, _ := auth.GetUserID(r.Context())
+
+		deadLetter, err := service.RequeueDeadLetter(r.Context(), programID, deadLetterID, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				respondError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"message":     "Artifact requeued for processing",
+			"artifact_id": deadLetter.ArtifactID,
+			"stage":       deadLetter.Stage,
+		})
+	}
+}
+
+// handleInsightFeedback rates or dismisses an insight. Feedback feeds the insight acceptance
+// rate used to compare prompt versions.
+func handleInsightFeedback(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		artifactID, err := uuid.Parse(chi.URLParam(r, "artifactId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid artifact ID")
+			return
+		}
+
+		insightID, err := uuid.Parse(chi.URLParam(r, "insightId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid insight ID")
+			return
+		}
+
+		var req InsightFeedbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		userID, _ := auth.GetUserID(r.Context())
+
+		if err := service.UpdateInsightFeedback(r.Context(), programID, artifactID, insightID, userID, &req); err != nil {
+			switch {
+			case strings.HasPrefix(err.Error(), "invalid"):
+				respondError(w, http.StatusBadRequest, err.Error())
+			case strings.Contains(err.Error(), "not found"):
+				respondError(w, http.StatusNotFound, err.Error())
+			default:
+				respondError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
 		}
 
 		respondSuccess(w, map[string]string{
-			"message": "Artifact queued for reanalysis",
+			"message": "Insight feedback recorded",
 		})
 	}
 }
@@ -0,0 +1,72 @@
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// TestUploadRoutes_OutlastRequestTimeouts streams an upload through a router and server whose
+// timeouts end long before the file has been sent
+func TestUploadRoutes_OutlastRequestTimeouts(t *testing.T) {
+	service := NewServiceWithMocks(&mockRepository{}, &mockDBExecutor{}, &mockStorage{})
+
+	// Routed as the API routes them: uploads outside the request timeout, the rest inside it
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		registerUploadRoutes(r, service)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(50 * time.Millisecond))
+		RegisterRoutes(r, service, nil)
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ReadTimeout = 50 * time.Millisecond
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="notes.txt"`)
+		header.Set("Content-Type", "text/plain")
+		file, err := form.CreatePart(header)
+		if err != nil {
+			writer.CloseWithError(err)
+			return
+		}
+
+		for i := 0; i < 5; i++ {
+			time.Sleep(50 * time.Millisecond)
+			if _, err := fmt.Fprintf(file, "Line %d of a slowly sent upload.\n", i); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(form.Close())
+	}()
+
+	url := fmt.Sprintf("%s/programs/%s/artifacts/upload", server.URL, uuid.New())
+	resp, err := http.Post(url, form.FormDataContentType(), body)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, resp.StatusCode, message)
+	}
+}
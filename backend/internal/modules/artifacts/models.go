@@ -2,9 +2,14 @@ package artifacts
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/google/uuid"
 )
 
@@ -30,6 +35,34 @@ type Artifact struct {
 	VersionNumber      int            `json:"version_number"`
 	SupersededBy       uuid.NullUUID  `json:"superseded_by,omitempty"`
 	DeletedAt          sql.NullTime   `json:"deleted_at,omitempty"`
+
+	// Document structure and properties reported by the extractor (extractors.ExtractionResult)
+	Extraction json.RawMessage `json:"extraction,omitempty"`
+
+	// Email artifact this artifact was attached to
+	ParentArtifactID uuid.NullUUID `json:"parent_artifact_id,omitempty"`
+
+	// Headers of an email artifact, recorded and threaded when the artifact is created
+	Email *ArtifactEmail `json:"email,omitempty"`
+
+	// Content has not been extracted yet; the worker's extract stage extracts it
+	ExtractionPending bool `json:"extraction_pending"`
+}
+
+// ArtifactEmail holds the headers of an email artifact and the conversation thread it belongs to
+type ArtifactEmail struct {
+	ArtifactID uuid.UUID    `json:"artifact_id"`
+	ProgramID  uuid.UUID    `json:"program_id"`
+	ThreadID   uuid.UUID    `json:"thread_id"`
+	MessageID  string       `json:"message_id,omitempty"`
+	InReplyTo  string       `json:"in_reply_to,omitempty"`
+	References []string     `json:"references,omitempty"`
+	From       string       `json:"from,omitempty"`
+	To         []string     `json:"to,omitempty"`
+	Cc         []string     `json:"cc,omitempty"`
+	Subject    string       `json:"subject,omitempty"`
+	SentAt     sql.NullTime `json:"sent_at,omitempty"`
+	Filename   string       `json:"filename,omitempty"` // Filename of the email artifact, when listing a thread
 }
 
 // Topic represents an AI-extracted topic from an artifact
@@ -70,6 +103,7 @@ type Fact struct {
 	Unit                    sql.NullString  `json:"unit,omitempty"`
 	ConfidenceScore         sql.NullFloat64 `json:"confidence_score,omitempty"`
 	ContextSnippet          sql.NullString  `json:"context_snippet,omitempty"`
+	SourceLocation          sql.NullString  `json:"source_location,omitempty"` // e.g. "page 7"
 	ExtractedAt             time.Time       `json:"extracted_at"`
 }
 
@@ -85,6 +119,8 @@ type Insight struct {
 	SuggestedAction  sql.NullString `json:"suggested_action,omitempty"`
 	ImpactedModules  []string       `json:"impacted_modules"`
 	ConfidenceScore  sql.NullFloat64 `json:"confidence_score,omitempty"`
+	PromptID         sql.NullString `json:"prompt_id,omitempty"`
+	PromptVersion    sql.NullString `json:"prompt_version,omitempty"`
 	UserRating       sql.NullInt32  `json:"user_rating,omitempty"`
 	UserFeedback     sql.NullString `json:"user_feedback,omitempty"`
 	IsDismissed      bool           `json:"is_dismissed"`
@@ -102,6 +138,7 @@ type ArtifactChunk struct {
 	ChunkStartOffset sql.NullInt32 `json:"chunk_start_offset,omitempty"`
 	ChunkEndOffset   sql.NullInt32 `json:"chunk_end_offset,omitempty"`
 	TokenCount     sql.NullInt32 `json:"token_count,omitempty"`
+	SourceLocation sql.NullString `json:"source_location,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
@@ -125,6 +162,8 @@ type ArtifactSummary struct {
 	Priority         sql.NullInt32  `json:"priority,omitempty"`
 	ConfidenceScore  sql.NullFloat64 `json:"confidence_score,omitempty"`
 	AIModel          sql.NullString `json:"ai_model,omitempty"`
+	PromptID         sql.NullString `json:"prompt_id,omitempty"`
+	PromptVersion    sql.NullString `json:"prompt_version,omitempty"`
 	CreatedAt        time.Time `json:"created_at"`
 }
 
@@ -146,6 +185,11 @@ type UploadRequest struct {
 	Data       []byte
 	UploadedBy uuid.UUID
 	ForceUpload bool // Allow re-upload even if duplicate exists
+	ParentArtifactID uuid.UUID // Email the file was attached to, if any
+
+	// Body streams the file instead of Data for UploadArtifactStream; Size is its length, or -1 if unknown
+	Body io.Reader
+	Size int64
 }
 
 // DuplicateError indicates a duplicate artifact exists
@@ -158,6 +202,27 @@ func (e *DuplicateError) Error() string {
 	return fmt.Sprintf("duplicate artifact exists (status: %s)", e.Status)
 }
 
+// UploadTooLargeError indicates a file exceeds the program's upload size limit
+type UploadTooLargeError struct {
+	LimitBytes int64
+}
+
+func (e *UploadTooLargeError) Error() string {
+	return fmt.Sprintf("file exceeds the upload size limit of %d bytes", e.LimitBytes)
+}
+
+// ErrDuplicateContent indicates the program already has an artifact with the same content
+var ErrDuplicateContent = errors.New("duplicate artifact: file with same content already exists in this program")
+
+// UnprocessableUploadError indicates an archive or mailbox holds nothing that can be uploaded
+type UnprocessableUploadError struct {
+	Message string
+}
+
+func (e *UnprocessableUploadError) Error() string {
+	return e.Message
+}
+
 // EncryptedPDFError indicates a PDF is password-protected
 type EncryptedPDFError struct {
 	Message string
@@ -188,3 +253,133 @@ type SearchResult struct {
 	Similarity float64  `json:"similarity"`
 	Snippet    string   `json:"snippet"`
 }
+
+// PipelineStageState tracks execution of a single pipeline stage for an artifact
+type PipelineStageState struct {
+	ArtifactID  uuid.UUID      `json:"artifact_id"`
+	Stage       string         `json:"stage"`
+	Status      string         `json:"status"` // pending, running, completed, skipped, failed
+	Attempts    int            `json:"attempts"`
+	LastError   sql.NullString `json:"last_error,omitempty"`
+	ErrorCode   sql.NullString `json:"error_code,omitempty"` // Kind of LastError, e.g. output_validation
+	StartedAt   sql.NullTime   `json:"started_at,omitempty"`
+	CompletedAt sql.NullTime   `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// DeadLetter records an artifact whose pipeline stage exhausted its retries
+type DeadLetter struct {
+	DeadLetterID uuid.UUID     `json:"dead_letter_id"`
+	ArtifactID   uuid.UUID     `json:"artifact_id"`
+	ProgramID    uuid.UUID     `json:"program_id"`
+	Filename     string        `json:"filename"`
+	Stage        string        `json:"stage"`
+	ErrorCode    string        `json:"error_code,omitempty"` // Kind of error, e.g. output_validation
+	ErrorMessage string        `json:"error_message"`
+	Attempts     int           `json:"attempts"`
+	CreatedAt    time.Time     `json:"created_at"`
+	RequeuedAt   sql.NullTime  `json:"requeued_at,omitempty"`
+	RequeuedBy   uuid.NullUUID `json:"requeued_by,omitempty"`
+}
+
+// Outcomes of a prompt run
+const (
+	PromptRunSucceeded   = "succeeded"
+	PromptRunRepaired    = "repaired" // Succeeded after the model was asked to fix invalid output
+	PromptRunParseFailed = "parse_failed"
+)
+
+// PromptRun records how an analysis turned out for the prompt version that produced it
+type PromptRun struct {
+	Prompt       ai.PromptRef
+	ProgramID    uuid.UUID
+	ArtifactID   uuid.UUID
+	Outcome      string
+	ErrorMessage sql.NullString
+}
+
+// InsightFeedbackRequest rates or dismisses an insight; omitted fields are left unchanged
+type InsightFeedbackRequest struct {
+	Rating    *int    `json:"rating,omitempty"` // 1-5
+	Feedback  *string `json:"feedback,omitempty"`
+	Dismissed *bool   `json:"dismissed,omitempty"`
+}
+
+// Statuses of an upload session
+const (
+	UploadSessionActive    = "active"    // Receiving parts
+	UploadSessionAssembled = "assembled" // Parts joined into the file; artifact not created yet
+	UploadSessionCompleted = "completed"
+	UploadSessionAborted   = "aborted"
+	UploadSessionExpired   = "expired"
+)
+
+// UploadSession is a resumable upload: the file is sent in parts, which can be sent again after
+// a dropped connection, and its artifact is created once every part is stored
+type UploadSession struct {
+	SessionID       uuid.UUID   `json:"session_id"`
+	ProgramID       uuid.UUID   `json:"program_id"`
+	UploadedBy      uuid.UUID   `json:"uploaded_by"`
+	Filename        string      `json:"filename"`
+	MimeType        string      `json:"mime_type"`
+	FileSizeBytes   int64       `json:"file_size_bytes"`
+	PartSizeBytes   int64       `json:"part_size_bytes"`
+	PartCount       int         `json:"part_count"`
+	ForceUpload     bool        `json:"force_upload"`
+	FileID          string      `json:"-"`
+	StorageUploadID string      `json:"-"`
+	Status          string      `json:"status"`
+	ArtifactIDs     []uuid.UUID `json:"artifact_ids"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+	CreatedAt       time.Time   `json:"created_at"`
+}
+
+// UploadSessionProgress reports which parts of an upload session are stored, so a client can
+// resume by sending the missing ones
+type UploadSessionProgress struct {
+	UploadSession
+	UploadedParts []storage.Part `json:"uploaded_parts"`
+	MissingParts  []int          `json:"missing_parts"`
+	PartURLs      map[int]string `json:"part_urls,omitempty"` // Presigned storage URLs of the missing parts
+}
+
+// CreateUploadSessionRequest starts a resumable upload
+type CreateUploadSessionRequest struct {
+	ProgramID     uuid.UUID `json:"-"`
+	UploadedBy    uuid.UUID `json:"-"`
+	Filename      string    `json:"filename"`
+	MimeType      string    `json:"mime_type"`
+	FileSizeBytes int64     `json:"file_size_bytes"`
+	ForceUpload   bool      `json:"force_upload"`
+	Presign       bool      `json:"presign"` // Return storage URLs to PUT the parts to directly
+}
+
+// ErrUploadSessionNotFound indicates an upload session does not exist in the program
+var ErrUploadSessionNotFound = errors.New("upload session not found")
+
+// UploadSessionStateError indicates an upload session can no longer take parts or be completed
+type UploadSessionStateError struct {
+	Status string
+}
+
+func (e *UploadSessionStateError) Error() string {
+	return fmt.Sprintf("upload session is %s", e.Status)
+}
+
+// InvalidPartError indicates a part does not belong to its upload session
+type InvalidPartError struct {
+	Message string
+}
+
+func (e *InvalidPartError) Error() string {
+	return e.Message
+}
+
+// IncompleteUploadError indicates an upload session was completed before all its parts were stored
+type IncompleteUploadError struct {
+	MissingParts []int
+}
+
+func (e *IncompleteUploadError) Error() string {
+	return fmt.Sprintf("upload is missing %d parts", len(e.MissingParts))
+}
@@ -2,11 +2,11 @@ package artifacts
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"os"
 
 	"github.com/cerberus/backend/internal/modules/artifacts/extractors"
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/google/uuid"
 )
@@ -20,12 +20,11 @@ type OCRService struct {
 }
 
 // NewOCRService creates a new OCR service
-func NewOCRService(repo RepositoryInterface, stor storage.Storage) *OCRService {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+func NewOCRService(repo RepositoryInterface, stor storage.Storage, provider ai.Provider) *OCRService {
 	return &OCRService{
 		repo:       repo,
 		storage:    stor,
-		ocrExtractor: extractors.NewImageOCRExtractor(apiKey),
+		ocrExtractor: extractors.NewImageOCRExtractor(provider),
 		chunker:    DefaultChunkingStrategy(),
 	}
 }
@@ -57,48 +56,35 @@ func (s *OCRService) ProcessOCRRequired(ctx context.Context, artifactID uuid.UUI
 	}
 
 	// Perform OCR using Claude Vision
-	extractedText, err := s.ocrExtractor.Extract(ctx, data)
+	ocrCtx := ai.WithAttribution(ctx, ai.Attribution{
+		ProgramID:  artifact.ProgramID,
+		ArtifactID: artifactID,
+		Module:     "artifacts",
+		JobType:    "ocr",
+	})
+	extractedText, err := s.ocrExtractor.Extract(ocrCtx, data)
 	if err != nil {
 		s.repo.UpdateStatus(ctx, artifactID, "failed")
 		return fmt.Errorf("OCR extraction failed: %w", err)
 	}
 
-	// Update artifact with extracted content
-	err = s.updateArtifactContent(ctx, artifactID, extractedText)
-	if err != nil {
-		s.repo.UpdateStatus(ctx, artifactID, "failed")
-		return fmt.Errorf("failed to update content: %w", err)
-	}
-
 	// Chunk the extracted text
 	chunks := s.chunker.ChunkDocument(extractedText)
 
-	// Save chunks
-	if err := s.repo.SaveChunks(ctx, artifactID, chunks); err != nil {
+	event, err := events.NewEventFromPayload(events.ArtifactOCRCompleted, artifact.ProgramID, "artifacts", events.ArtifactOCRCompletedPayload{
+		ArtifactID:     artifactID,
+		CharacterCount: len(extractedText),
+		ChunkCount:     len(chunks),
+	})
+	if err != nil {
 		s.repo.UpdateStatus(ctx, artifactID, "failed")
-		return fmt.Errorf("failed to save chunks: %w", err)
-	}
-
-	// Update status to pending for AI analysis
-	if err := s.repo.UpdateStatus(ctx, artifactID, "pending"); err != nil {
-		return fmt.Errorf("failed to update status to pending: %w", err)
+		return fmt.Errorf("failed to create OCR completed event: %w", err)
 	}
 
-	return nil
-}
-
-// updateArtifactContent updates the raw_content field
-func (s *OCRService) updateArtifactContent(ctx context.Context, artifactID uuid.UUID, content string) error {
-	query := `
-		UPDATE artifacts
-		SET raw_content = $1,
-		    updated_at = NOW()
-		WHERE artifact_id = $2
-	`
-
-	_, err := s.repo.ExecContext(ctx, query, sql.NullString{String: content, Valid: true}, artifactID)
-	if err != nil {
-		return fmt.Errorf("failed to update content: %w", err)
+	// Save the text and chunks and queue the artifact for AI analysis
+	if err := s.repo.SaveOCRText(ctx, artifactID, extractedText, chunks, event); err != nil {
+		s.repo.UpdateStatus(ctx, artifactID, "failed")
+		return fmt.Errorf("failed to save OCR text: %w", err)
 	}
 
 	return nil
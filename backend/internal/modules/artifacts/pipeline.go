@@ -0,0 +1,443 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// Pipeline stage names, in execution order
+const (
+	StageExtract    = "extract"
+	StageAnalyze    = "analyze"
+	StageRisk       = "risk"
+	StageEmbeddings = "embeddings"
+	StageInvoice    = "invoice"
+)
+
+// ErrStageSkipped is returned by a stage that has nothing to do for an artifact
+// (e.g. invoice processing for a non-invoice). The stage is recorded as skipped.
+var ErrStageSkipped = errors.New("stage skipped")
+
+// ErrDeadLettered is returned when a stage exhausted its retries and the artifact
+// was moved to the dead-letter table. The work is parked, so callers should not retry.
+var ErrDeadLettered = errors.New("artifact moved to dead letter")
+
+// ErrLeaseLost is returned when a run stops because this worker's lease on the artifact lapsed
+// and another worker may have reclaimed it. The other worker finishes the artifact.
+var ErrLeaseLost = errors.New("pipeline lease lost")
+
+// ErrDeferred is returned when a stage deferred the artifact (see DeferredError). The run
+// resumes at that stage once the deferral ends, so callers should not retry.
+var ErrDeferred = errors.New("artifact deferred")
+
+// DeferredError is returned by a stage that cannot run until a later time, for instance
+// because the program's AI budget is spent. The run stops without using up the stage's
+// retries and the artifact is parked until Until.
+type DeferredError struct {
+	Until  time.Time
+	Reason string
+}
+
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("deferred until %s: %s", e.Until.Format(time.RFC3339), e.Reason)
+}
+
+// RepositoryError is returned when the pipeline could not read or record its own state, such as
+// marking a stage started or finished. It says nothing about the artifact, so the run is released
+// for a retry instead of counting against the stage's attempts.
+type RepositoryError struct {
+	Err error
+}
+
+func (e *RepositoryError) Error() string {
+	return "pipeline state: " + e.Err.Error()
+}
+
+func (e *RepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// Error codes recorded with failed stages and dead letters, so failures can be queried by kind
+const (
+	// ErrorCodeOutputValidation marks model output that failed schema validation after a repair attempt
+	ErrorCodeOutputValidation = "output_validation"
+)
+
+// StageErrorCode classifies a stage error, or returns "" for errors without a code
+func StageErrorCode(err error) string {
+	var validationErr *ai.OutputValidationError
+	if errors.As(err, &validationErr) {
+		return ErrorCodeOutputValidation
+	}
+	return ""
+}
+
+// StageFunc executes one stage of the pipeline for an artifact
+type StageFunc func(ctx context.Context, artifact *Artifact) error
+
+// PipelineStage is a named step in the artifact processing pipeline
+type PipelineStage struct {
+	Name string
+	Run  StageFunc
+}
+
+// PipelineConfig controls retry and liveness behaviour of the pipeline
+type PipelineConfig struct {
+	// MaxAttempts is the number of times a stage is tried within one run before dead-lettering
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry; it doubles on each attempt up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// LeaseDuration is how long a claim lasts without renewal before another worker may reclaim
+	// the artifact. HeartbeatInterval is how often a running pipeline renews it.
+	LeaseDuration     time.Duration
+	HeartbeatInterval time.Duration
+
+	// WorkerID identifies this worker's leases; defaults to hostname:pid
+	WorkerID string
+}
+
+// DefaultPipelineConfig returns the default pipeline configuration
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		MaxAttempts:       3,
+		InitialBackoff:    2 * time.Second,
+		MaxBackoff:        time.Minute,
+		LeaseDuration:     5 * time.Minute,
+		HeartbeatInterval: 30 * time.Second,
+	}
+}
+
+// Pipeline runs artifacts through an ordered set of stages, persisting the state of each
+// stage so an interrupted run resumes where it stopped instead of starting over
+type Pipeline struct {
+	repo   RepositoryInterface
+	stages []PipelineStage
+	config PipelineConfig
+}
+
+// NewPipeline creates a pipeline over the given stages
+func NewPipeline(repo RepositoryInterface, config PipelineConfig, stages ...PipelineStage) *Pipeline {
+	if config.WorkerID == "" {
+		hostname, _ := os.Hostname()
+		config.WorkerID = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+
+	return &Pipeline{
+		repo:   repo,
+		stages: stages,
+		config: config,
+	}
+}
+
+// Config returns the pipeline configuration
+func (p *Pipeline) Config() PipelineConfig {
+	return p.config
+}
+
+// Process claims an artifact and runs all stages that have not yet completed.
+// correlationID is propagated to emitted events and may be uuid.Nil.
+// Returns nil if the artifact is already owned by another worker or finished.
+// Returns ErrDeadLettered if a stage exhausted its retries, ErrDeferred if a stage deferred the
+// artifact, ErrLeaseLost if the lease lapsed mid-run, a *RepositoryError if the pipeline's state
+// could not be recorded, and the context error if the run was interrupted. In the last two cases
+// the artifact is released for another worker to resume.
+func (p *Pipeline) Process(ctx context.Context, artifactID, correlationID uuid.UUID) error {
+	claimed, err := p.repo.ClaimPipeline(ctx, artifactID, p.config.WorkerID, p.config.LeaseDuration)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		log.Printf("Pipeline: artifact %s is not claimable (already running or finished), skipping", artifactID)
+		return nil
+	}
+
+	return p.ProcessClaimed(ctx, artifactID, correlationID)
+}
+
+// ClaimBatch leases up to limit artifacts with outstanding work to this worker. Each must then
+// be run with ProcessClaimed before its lease expires.
+func (p *Pipeline) ClaimBatch(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	return p.repo.ClaimPipelineBatch(ctx, p.config.WorkerID, limit, p.config.LeaseDuration)
+}
+
+// ProcessClaimed runs an artifact this worker already holds the lease on. It returns the same
+// errors as Process.
+func (p *Pipeline) ProcessClaimed(ctx context.Context, artifactID, correlationID uuid.UUID) error {
+	// Keep the lease alive while stages run; losing it cancels the run
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go p.heartbeat(ctx, cancel, artifactID)
+
+	states, err := p.repo.GetPipelineStages(ctx, artifactID)
+	if err != nil {
+		p.release(artifactID)
+		return err
+	}
+
+	finished := make(map[string]bool, len(states))
+	for _, state := range states {
+		if state.Status == "completed" || state.Status == "skipped" {
+			finished[state.Stage] = true
+		}
+	}
+
+	var artifact *Artifact
+	for _, stage := range p.stages {
+		if finished[stage.Name] {
+			continue
+		}
+
+		// Reload before every stage so it sees the previous stage's output
+		artifact, err = p.repo.GetByID(ctx, artifactID)
+		if err != nil {
+			p.release(artifactID)
+			return fmt.Errorf("failed to load artifact for stage %s: %w", stage.Name, err)
+		}
+
+		// Attribute the stage's AI usage to the artifact's program
+		stageCtx := ai.WithAttribution(ctx, ai.Attribution{
+			ProgramID:  artifact.ProgramID,
+			ArtifactID: artifactID,
+			Module:     "artifacts",
+			JobType:    stage.Name,
+		})
+
+		attempts, err := p.runStage(stageCtx, artifact, stage)
+		if err == nil {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			p.release(artifactID)
+			return context.Cause(ctx)
+		}
+
+		// The stage's outcome was not recorded; the next run retries it
+		var repoErr *RepositoryError
+		if errors.As(err, &repoErr) {
+			p.release(artifactID)
+			return err
+		}
+
+		var deferred *DeferredError
+		if errors.As(err, &deferred) {
+			return p.deferRun(ctx, artifact, stage.Name, deferred)
+		}
+
+		return p.deadLetter(ctx, artifact, stage.Name, attempts, err, correlationID)
+	}
+
+	// Every stage had already completed if no artifact was loaded; nothing new to announce
+	var outboxEvents []*events.Event
+	if artifact != nil {
+		event, err := newPipelineEvent(events.ArtifactAnalyzed, artifact.ProgramID, events.ArtifactAnalyzedPayload{
+			ArtifactID: artifactID,
+		}, correlationID)
+		if err != nil {
+			p.release(artifactID)
+			return err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+
+	completed, err := p.repo.CompletePipeline(ctx, artifactID, p.config.WorkerID, outboxEvents...)
+	if err != nil {
+		p.release(artifactID)
+		return &RepositoryError{Err: err}
+	}
+	if !completed {
+		return ErrLeaseLost
+	}
+
+	if artifact != nil {
+		log.Printf("Pipeline completed for artifact: %s (%s)", artifact.Filename, artifactID)
+	}
+
+	return nil
+}
+
+// runStage executes a stage with bounded exponential backoff. Returns the number of attempts made.
+// Failures to record the stage's state are returned as a *RepositoryError; only errors from the
+// stage itself use up attempts.
+func (p *Pipeline) runStage(ctx context.Context, artifact *Artifact, stage PipelineStage) (int, error) {
+	backoff := p.config.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := p.repo.StartPipelineStage(ctx, artifact.ArtifactID, stage.Name); err != nil {
+			return attempt, &RepositoryError{Err: err}
+		}
+
+		err := stage.Run(ctx, artifact)
+		var deferred *DeferredError
+		switch {
+		case err == nil:
+			return attempt, p.finishStage(ctx, artifact, stage.Name, "completed", "")
+		case errors.Is(err, ErrStageSkipped):
+			return attempt, p.finishStage(ctx, artifact, stage.Name, "skipped", "")
+		case errors.As(err, &deferred):
+			if recordErr := p.finishStage(ctx, artifact, stage.Name, "pending", err.Error()); recordErr != nil {
+				return attempt, recordErr
+			}
+			return attempt, err
+		}
+
+		log.Printf("Pipeline stage %s failed for artifact %s (attempt %d/%d): %v",
+			stage.Name, artifact.ArtifactID, attempt, p.config.MaxAttempts, err)
+
+		// An interrupted attempt stays running so the next run retries it. Invalid model output
+		// already had its repair attempt, and retrying would replay the cached responses.
+		code := StageErrorCode(err)
+		interrupted := ctx.Err() != nil
+		exhausted := attempt >= p.config.MaxAttempts || code == ErrorCodeOutputValidation
+		status := "running"
+		if exhausted && !interrupted {
+			status = "failed"
+		}
+		if recordErr := p.repo.FinishPipelineStage(context.WithoutCancel(ctx), artifact.ArtifactID, stage.Name, status, code, err.Error()); recordErr != nil {
+			log.Printf("Warning: Failed to record stage failure: %v", recordErr)
+		}
+
+		if exhausted || interrupted {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > p.config.MaxBackoff {
+			backoff = p.config.MaxBackoff
+		}
+	}
+}
+
+// finishStage records the outcome of a stage that did not fail
+func (p *Pipeline) finishStage(ctx context.Context, artifact *Artifact, stage, status, message string) error {
+	if err := p.repo.FinishPipelineStage(ctx, artifact.ArtifactID, stage, status, "", message); err != nil {
+		return &RepositoryError{Err: err}
+	}
+	return nil
+}
+
+// deadLetter parks the artifact after a stage exhausted its retries
+func (p *Pipeline) deadLetter(ctx context.Context, artifact *Artifact, stage string, attempts int, stageErr error, correlationID uuid.UUID) error {
+	deadLetter := &DeadLetter{
+		DeadLetterID: uuid.New(),
+		ArtifactID:   artifact.ArtifactID,
+		ProgramID:    artifact.ProgramID,
+		Filename:     artifact.Filename,
+		Stage:        stage,
+		ErrorCode:    StageErrorCode(stageErr),
+		ErrorMessage: stageErr.Error(),
+		Attempts:     attempts,
+		CreatedAt:    time.Now(),
+	}
+
+	event, err := newPipelineEvent(events.ArtifactDeadLettered, artifact.ProgramID, events.ArtifactDeadLetteredPayload{
+		ArtifactID:   artifact.ArtifactID,
+		DeadLetterID: deadLetter.DeadLetterID,
+		Stage:        stage,
+		Error:        stageErr.Error(),
+		Attempts:     attempts,
+	}, correlationID)
+	if err != nil {
+		p.release(artifact.ArtifactID)
+		return fmt.Errorf("failed to dead-letter artifact after stage %s error (%v): %w", stage, stageErr, err)
+	}
+
+	if err := p.repo.CreateDeadLetter(ctx, deadLetter, event); err != nil {
+		p.release(artifact.ArtifactID)
+		return fmt.Errorf("failed to dead-letter artifact after stage %s error (%v): %w", stage, stageErr, err)
+	}
+
+	log.Printf("Pipeline: artifact %s dead-lettered at stage %s after %d attempts: %v",
+		artifact.ArtifactID, stage, attempts, stageErr)
+
+	return fmt.Errorf("%w: stage %s: %v", ErrDeadLettered, stage, stageErr)
+}
+
+// deferRun parks the artifact until the deferral ends; the stage is retried then
+func (p *Pipeline) deferRun(ctx context.Context, artifact *Artifact, stage string, deferred *DeferredError) error {
+	if err := p.repo.DeferPipeline(ctx, artifact.ArtifactID, p.config.WorkerID, deferred.Until, deferred.Reason); err != nil {
+		p.release(artifact.ArtifactID)
+		return err
+	}
+
+	log.Printf("Pipeline: artifact %s deferred at stage %s until %s: %s",
+		artifact.ArtifactID, stage, deferred.Until.Format(time.RFC3339), deferred.Reason)
+
+	return fmt.Errorf("%w: stage %s: %v", ErrDeferred, stage, deferred)
+}
+
+// heartbeat renews the pipeline lease until ctx is cancelled. If another worker took the
+// artifact over, or renewals keep failing until the lease lapses, it cancels the run with
+// ErrLeaseLost so two workers never analyze the artifact at once.
+func (p *Pipeline) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, artifactID uuid.UUID) {
+	ticker := time.NewTicker(p.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	expires := time.Now().Add(p.config.LeaseDuration)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewedAt := time.Now()
+		renewed, err := p.repo.RenewPipelineLease(ctx, artifactID, p.config.WorkerID, p.config.LeaseDuration)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Printf("Warning: Failed to renew pipeline lease for %s: %v", artifactID, err)
+			if time.Now().Before(expires) {
+				continue
+			}
+		case renewed:
+			expires = renewedAt.Add(p.config.LeaseDuration)
+			continue
+		}
+
+		log.Printf("Pipeline: lost lease on artifact %s, stopping run", artifactID)
+		cancel(ErrLeaseLost)
+		return
+	}
+}
+
+// release returns the artifact to the queue. Uses a fresh context since the
+// caller's context is often the one that was cancelled.
+func (p *Pipeline) release(artifactID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.repo.ReleasePipeline(ctx, artifactID, p.config.WorkerID); err != nil {
+		log.Printf("Warning: Failed to release pipeline for %s: %v", artifactID, err)
+	}
+}
+
+// newPipelineEvent builds a pipeline event to write to the outbox with the pipeline's state
+func newPipelineEvent(eventType events.EventType, programID uuid.UUID, payload events.Payload, correlationID uuid.UUID) (*events.Event, error) {
+	event, err := events.NewEventFromPayload(eventType, programID, "artifacts", payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s event: %w", eventType, err)
+	}
+	if correlationID != uuid.Nil {
+		event.WithCorrelationID(correlationID)
+	}
+	return event, nil
+}
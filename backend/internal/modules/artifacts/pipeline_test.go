@@ -0,0 +1,255 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// pipelineRepository records the lease calls the pipeline makes
+type pipelineRepository struct {
+	RepositoryInterface
+
+	mu        sync.Mutex
+	claimable bool
+	renewable bool
+	renewals  int
+	completed bool
+	deferred  time.Time
+	released  bool
+
+	// Failures of the pipeline's own bookkeeping
+	startErr  error
+	finishErr error
+
+	deadLetter   *DeadLetter
+	outboxEvents []*events.Event
+}
+
+func (r *pipelineRepository) ClaimPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error) {
+	return r.claimable, nil
+}
+
+func (r *pipelineRepository) RenewPipelineLease(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renewals++
+	return r.renewable, nil
+}
+
+func (r *pipelineRepository) CompletePipeline(ctx context.Context, artifactID uuid.UUID, workerID string, outboxEvents ...*events.Event) (bool, error) {
+	r.completed = true
+	r.outboxEvents = append(r.outboxEvents, outboxEvents...)
+	return true, nil
+}
+
+func (r *pipelineRepository) ReleasePipeline(ctx context.Context, artifactID uuid.UUID, workerID string) error {
+	r.released = true
+	return nil
+}
+
+func (r *pipelineRepository) DeferPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, until time.Time, reason string) error {
+	r.deferred = until
+	return nil
+}
+
+func (r *pipelineRepository) GetPipelineStages(ctx context.Context, artifactID uuid.UUID) ([]PipelineStageState, error) {
+	return nil, nil
+}
+
+func (r *pipelineRepository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifact, error) {
+	return &Artifact{ArtifactID: artifactID}, nil
+}
+
+func (r *pipelineRepository) StartPipelineStage(ctx context.Context, artifactID uuid.UUID, stage string) error {
+	return r.startErr
+}
+
+func (r *pipelineRepository) FinishPipelineStage(ctx context.Context, artifactID uuid.UUID, stage, status, errorCode, errorMessage string) error {
+	if status == "completed" || status == "skipped" {
+		return r.finishErr
+	}
+	return nil
+}
+
+func (r *pipelineRepository) CreateDeadLetter(ctx context.Context, deadLetter *DeadLetter, outboxEvents ...*events.Event) error {
+	r.deadLetter = deadLetter
+	r.outboxEvents = append(r.outboxEvents, outboxEvents...)
+	return nil
+}
+
+func testPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		MaxAttempts:       3,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        time.Millisecond,
+		LeaseDuration:     time.Minute,
+		HeartbeatInterval: 10 * time.Millisecond,
+		WorkerID:          "test-worker",
+	}
+}
+
+func TestPipeline_RenewsLeaseDuringLongStage(t *testing.T) {
+	repo := &pipelineRepository{claimable: true, renewable: true}
+	stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+
+	pipeline := NewPipeline(repo, testPipelineConfig(), stage)
+	if err := pipeline.Process(context.Background(), uuid.New(), uuid.Nil); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.renewals == 0 {
+		t.Error("expected the lease to be renewed while the stage ran")
+	}
+	if !repo.completed {
+		t.Error("expected the pipeline to complete")
+	}
+	if len(repo.outboxEvents) != 1 || repo.outboxEvents[0].Type != events.ArtifactAnalyzed {
+		t.Errorf("outbox events = %v, want one %s event written on completion", repo.outboxEvents, events.ArtifactAnalyzed)
+	}
+}
+
+func TestPipeline_LostLeaseStopsRun(t *testing.T) {
+	repo := &pipelineRepository{claimable: true, renewable: false}
+	stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	pipeline := NewPipeline(repo, testPipelineConfig(), stage)
+
+	done := make(chan error, 1)
+	go func() { done <- pipeline.Process(context.Background(), uuid.New(), uuid.Nil) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Process() error = %v, want ErrLeaseLost", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("run was not stopped after the lease was lost")
+	}
+
+	if repo.completed {
+		t.Error("a run that lost its lease must not complete the pipeline")
+	}
+}
+
+func TestPipeline_SkipsUnclaimableArtifact(t *testing.T) {
+	repo := &pipelineRepository{claimable: false}
+	ran := false
+	stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+		ran = true
+		return nil
+	}}
+
+	pipeline := NewPipeline(repo, testPipelineConfig(), stage)
+	if err := pipeline.Process(context.Background(), uuid.New(), uuid.Nil); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if ran {
+		t.Error("stage ran for an artifact held by another worker")
+	}
+}
+
+func TestPipeline_DeferredStageParksArtifactWithoutRetrying(t *testing.T) {
+	repo := &pipelineRepository{claimable: true, renewable: true}
+	resumeAt := time.Now().Add(time.Hour)
+	calls := 0
+	stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+		calls++
+		return &DeferredError{Until: resumeAt, Reason: "budget exceeded"}
+	}}
+
+	pipeline := NewPipeline(repo, testPipelineConfig(), stage)
+	err := pipeline.Process(context.Background(), uuid.New(), uuid.Nil)
+	if !errors.Is(err, ErrDeferred) {
+		t.Fatalf("Process() error = %v, want ErrDeferred", err)
+	}
+	if calls != 1 {
+		t.Errorf("stage ran %d times, want 1", calls)
+	}
+	if !repo.deferred.Equal(resumeAt) {
+		t.Errorf("deferred until %v, want %v", repo.deferred, resumeAt)
+	}
+	if repo.completed {
+		t.Error("a deferred run must not complete the pipeline")
+	}
+}
+
+func TestPipeline_InvalidOutputDeadLettersWithoutRetrying(t *testing.T) {
+	repo := &pipelineRepository{claimable: true, renewable: true}
+	calls := 0
+	stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+		calls++
+		return fmt.Errorf("analysis failed: %w", &AnalysisParseError{
+			Err: &ai.OutputValidationError{Output: "record_artifact_analysis", Problems: []string{`$: missing required property "summary"`}},
+		})
+	}}
+
+	pipeline := NewPipeline(repo, testPipelineConfig(), stage)
+	err := pipeline.Process(context.Background(), uuid.New(), uuid.Nil)
+	if !errors.Is(err, ErrDeadLettered) {
+		t.Fatalf("Process() error = %v, want ErrDeadLettered", err)
+	}
+	if calls != 1 {
+		t.Errorf("stage ran %d times, want 1", calls)
+	}
+	if repo.deadLetter == nil || repo.deadLetter.ErrorCode != ErrorCodeOutputValidation {
+		t.Errorf("dead letter = %+v, want error code %s", repo.deadLetter, ErrorCodeOutputValidation)
+	}
+	if len(repo.outboxEvents) != 1 || repo.outboxEvents[0].Type != events.ArtifactDeadLettered {
+		t.Errorf("outbox events = %v, want one %s event written with the dead letter", repo.outboxEvents, events.ArtifactDeadLettered)
+	}
+}
+
+func TestPipeline_RepositoryFailuresReleaseWithoutDeadLettering(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      *pipelineRepository
+		wantCalls int
+	}{
+		{"start stage", &pipelineRepository{startErr: errors.New("connection reset")}, 0},
+		{"finish stage", &pipelineRepository{finishErr: errors.New("connection reset")}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.repo.claimable, tt.repo.renewable = true, true
+			calls := 0
+			stage := PipelineStage{Name: StageAnalyze, Run: func(ctx context.Context, artifact *Artifact) error {
+				calls++
+				return nil
+			}}
+
+			pipeline := NewPipeline(tt.repo, testPipelineConfig(), stage)
+			err := pipeline.Process(context.Background(), uuid.New(), uuid.Nil)
+
+			var repoErr *RepositoryError
+			if !errors.As(err, &repoErr) {
+				t.Fatalf("Process() error = %v, want a *RepositoryError", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("stage ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if !tt.repo.released {
+				t.Error("expected the run to be released for a retry")
+			}
+			if tt.repo.deadLetter != nil || tt.repo.completed {
+				t.Errorf("dead letter = %+v, completed = %v; want neither", tt.repo.deadLetter, tt.repo.completed)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package artifacts
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -32,15 +34,95 @@ func (r *Repository) QueryContext(ctx context.Context, query string, args ...int
 
 // Create inserts a new artifact
 func (r *Repository) Create(ctx context.Context, artifact *Artifact) error {
+	return insertArtifact(ctx, r.db, artifact)
+}
+
+// CreateWithChunks inserts a new artifact with its chunks and writes outboxEvents in the same
+// transaction, so the events are published only if the artifact is committed
+func (r *Repository) CreateWithChunks(ctx context.Context, artifact *Artifact, chunks []Chunk, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertArtifact(ctx, tx, artifact); err != nil {
+		return err
+	}
+
+	if err := saveChunks(ctx, tx, artifact.ArtifactID, chunks); err != nil {
+		return err
+	}
+
+	if artifact.Email != nil {
+		if err := saveEmail(ctx, tx, artifact.Email); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveExtraction stores the content extracted from an artifact whose extraction was deferred
+// at upload, along with its chunks and, for emails, its thread, and clears extraction_pending
+func (r *Repository) SaveExtraction(ctx context.Context, artifact *Artifact, chunks []Chunk) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET raw_content = $1,
+		    extraction = $2,
+		    processing_status = $3,
+		    extraction_pending = FALSE
+		WHERE artifact_id = $4 AND extraction_pending
+	`, artifact.RawContent, nullJSON(artifact.Extraction), artifact.ProcessingStatus, artifact.ArtifactID)
+	if err != nil {
+		return fmt.Errorf("failed to save extraction: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("artifact not found or already extracted")
+	}
+
+	if err := saveChunks(ctx, tx, artifact.ArtifactID, chunks); err != nil {
+		return err
+	}
+
+	if artifact.Email != nil {
+		if err := saveEmail(ctx, tx, artifact.Email); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertArtifact inserts an artifact row using exec (the database or a transaction)
+func insertArtifact(ctx context.Context, exec db.Execer, artifact *Artifact) error {
 	query := `
 		INSERT INTO artifacts (
 			artifact_id, program_id, filename, storage_path, file_type,
 			file_size_bytes, mime_type, content_hash, raw_content,
-			processing_status, uploaded_by, uploaded_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			processing_status, uploaded_by, uploaded_at, extraction,
+			parent_artifact_id, extraction_pending
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		artifact.ArtifactID,
 		artifact.ProgramID,
 		artifact.Filename,
@@ -53,6 +135,9 @@ func (r *Repository) Create(ctx context.Context, artifact *Artifact) error {
 		artifact.ProcessingStatus,
 		artifact.UploadedBy,
 		artifact.UploadedAt,
+		nullJSON(artifact.Extraction),
+		artifact.ParentArtifactID,
+		artifact.ExtractionPending,
 	)
 
 	if err != nil {
@@ -62,6 +147,14 @@ func (r *Repository) Create(ctx context.Context, artifact *Artifact) error {
 	return nil
 }
 
+// nullJSON stores an empty JSON document as NULL
+func nullJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
 // GetByID retrieves an artifact by ID
 func (r *Repository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifact, error) {
 	query := `
@@ -69,12 +162,14 @@ func (r *Repository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifa
 			   file_size_bytes, mime_type, content_hash, raw_content,
 			   artifact_category, artifact_subcategory,
 			   processing_status, processed_at, ai_model_version, ai_processing_time_ms,
-			   uploaded_by, uploaded_at, version_number, superseded_by, deleted_at
+			   uploaded_by, uploaded_at, version_number, superseded_by, deleted_at,
+			   extraction, parent_artifact_id, extraction_pending
 		FROM artifacts
 		WHERE artifact_id = $1 AND deleted_at IS NULL
 	`
 
 	var artifact Artifact
+	var extraction []byte
 	err := r.db.QueryRowContext(ctx, query, artifactID).Scan(
 		&artifact.ArtifactID,
 		&artifact.ProgramID,
@@ -96,6 +191,9 @@ func (r *Repository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifa
 		&artifact.VersionNumber,
 		&artifact.SupersededBy,
 		&artifact.DeletedAt,
+		&extraction,
+		&artifact.ParentArtifactID,
+		&artifact.ExtractionPending,
 	)
 
 	if err == sql.ErrNoRows {
@@ -105,6 +203,7 @@ func (r *Repository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifa
 		return nil, fmt.Errorf("failed to get artifact: %w", err)
 	}
 
+	artifact.Extraction = extraction
 	return &artifact, nil
 }
 
@@ -175,51 +274,6 @@ func (r *Repository) UpdateStatus(ctx context.Context, artifactID uuid.UUID, sta
 	return nil
 }
 
-// GetPendingArtifacts retrieves artifacts that need AI processing
-func (r *Repository) GetPendingArtifacts(ctx context.Context, limit int) ([]Artifact, error) {
-	query := `
-		SELECT artifact_id, program_id, filename, storage_path, file_type,
-			   file_size_bytes, mime_type, content_hash, raw_content,
-			   processing_status, uploaded_by, uploaded_at
-		FROM artifacts
-		WHERE processing_status = 'pending'
-		  AND deleted_at IS NULL
-		ORDER BY uploaded_at ASC
-		LIMIT $1
-	`
-
-	rows, err := r.db.QueryContext(ctx, query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get pending artifacts: %w", err)
-	}
-	defer rows.Close()
-
-	artifacts := make([]Artifact, 0)
-	for rows.Next() {
-		var a Artifact
-		err := rows.Scan(
-			&a.ArtifactID,
-			&a.ProgramID,
-			&a.Filename,
-			&a.StoragePath,
-			&a.FileType,
-			&a.FileSizeBytes,
-			&a.MimeType,
-			&a.ContentHash,
-			&a.RawContent,
-			&a.ProcessingStatus,
-			&a.UploadedBy,
-			&a.UploadedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan artifact: %w", err)
-		}
-		artifacts = append(artifacts, a)
-	}
-
-	return artifacts, nil
-}
-
 // Delete soft-deletes an artifact
 func (r *Repository) Delete(ctx context.Context, artifactID uuid.UUID) error {
 	query := `
@@ -247,21 +301,27 @@ func (r *Repository) Delete(ctx context.Context, artifactID uuid.UUID) error {
 
 // SaveChunks stores document chunks
 func (r *Repository) SaveChunks(ctx context.Context, artifactID uuid.UUID, chunks []Chunk) error {
+	return saveChunks(ctx, r.db, artifactID, chunks)
+}
+
+// saveChunks inserts chunk rows using exec (the database or a transaction)
+func saveChunks(ctx context.Context, exec db.Execer, artifactID uuid.UUID, chunks []Chunk) error {
 	query := `
 		INSERT INTO artifact_chunks (
 			artifact_id, chunk_index, chunk_text,
-			chunk_start_offset, chunk_end_offset, token_count
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			chunk_start_offset, chunk_end_offset, token_count, source_location
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	for _, chunk := range chunks {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			artifactID,
 			chunk.Index,
 			chunk.Text,
 			chunk.StartOffset,
 			chunk.EndOffset,
 			chunk.TokenCount,
+			sql.NullString{String: chunk.Location, Valid: chunk.Location != ""},
 		)
 		if err != nil {
 			return fmt.Errorf("failed to save chunk %d: %w", chunk.Index, err)
@@ -275,7 +335,7 @@ func (r *Repository) SaveChunks(ctx context.Context, artifactID uuid.UUID, chunk
 func (r *Repository) GetChunks(ctx context.Context, artifactID uuid.UUID) ([]ArtifactChunk, error) {
 	query := `
 		SELECT chunk_id, artifact_id, chunk_index, chunk_text,
-			   chunk_start_offset, chunk_end_offset, token_count, created_at
+			   chunk_start_offset, chunk_end_offset, token_count, source_location, created_at
 		FROM artifact_chunks
 		WHERE artifact_id = $1
 		ORDER BY chunk_index
@@ -298,6 +358,7 @@ func (r *Repository) GetChunks(ctx context.Context, artifactID uuid.UUID) ([]Art
 			&c.ChunkStartOffset,
 			&c.ChunkEndOffset,
 			&c.TokenCount,
+			&c.SourceLocation,
 			&c.CreatedAt,
 		)
 		if err != nil {
@@ -311,21 +372,29 @@ func (r *Repository) GetChunks(ctx context.Context, artifactID uuid.UUID) ([]Art
 
 // SaveSummary stores an AI-generated summary
 func (r *Repository) SaveSummary(ctx context.Context, summary *ArtifactSummary) error {
+	return saveSummary(ctx, r.db, summary)
+}
+
+// saveSummary stores an AI-generated summary using exec (the database or a transaction)
+func saveSummary(ctx context.Context, exec db.Execer, summary *ArtifactSummary) error {
 	query := `
 		INSERT INTO artifact_summaries (
 			summary_id, artifact_id, executive_summary, key_takeaways,
-			sentiment, priority, confidence_score, ai_model
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			sentiment, priority, confidence_score, ai_model,
+			prompt_id, prompt_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (artifact_id) DO UPDATE SET
 			executive_summary = EXCLUDED.executive_summary,
 			key_takeaways = EXCLUDED.key_takeaways,
 			sentiment = EXCLUDED.sentiment,
 			priority = EXCLUDED.priority,
 			confidence_score = EXCLUDED.confidence_score,
-			ai_model = EXCLUDED.ai_model
+			ai_model = EXCLUDED.ai_model,
+			prompt_id = EXCLUDED.prompt_id,
+			prompt_version = EXCLUDED.prompt_version
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		summary.SummaryID,
 		summary.ArtifactID,
 		summary.ExecutiveSummary,
@@ -334,6 +403,8 @@ func (r *Repository) SaveSummary(ctx context.Context, summary *ArtifactSummary)
 		summary.Priority,
 		summary.ConfidenceScore,
 		summary.AIModel,
+		summary.PromptID,
+		summary.PromptVersion,
 	)
 
 	if err != nil {
@@ -345,6 +416,11 @@ func (r *Repository) SaveSummary(ctx context.Context, summary *ArtifactSummary)
 
 // SaveTopics stores extracted topics
 func (r *Repository) SaveTopics(ctx context.Context, topics []Topic) error {
+	return saveTopics(ctx, r.db, topics)
+}
+
+// saveTopics stores extracted topics using exec (the database or a transaction)
+func saveTopics(ctx context.Context, exec db.Execer, topics []Topic) error {
 	if len(topics) == 0 {
 		return nil
 	}
@@ -356,7 +432,7 @@ func (r *Repository) SaveTopics(ctx context.Context, topics []Topic) error {
 	`
 
 	for _, topic := range topics {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			topic.TopicID,
 			topic.ArtifactID,
 			topic.TopicName,
@@ -373,6 +449,11 @@ func (r *Repository) SaveTopics(ctx context.Context, topics []Topic) error {
 
 // SavePersons stores extracted person mentions
 func (r *Repository) SavePersons(ctx context.Context, persons []Person) error {
+	return savePersons(ctx, r.db, persons)
+}
+
+// savePersons stores extracted person mentions using exec (the database or a transaction)
+func savePersons(ctx context.Context, exec db.Execer, persons []Person) error {
 	if len(persons) == 0 {
 		return nil
 	}
@@ -385,7 +466,7 @@ func (r *Repository) SavePersons(ctx context.Context, persons []Person) error {
 	`
 
 	for _, person := range persons {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			person.PersonID,
 			person.ArtifactID,
 			person.PersonName,
@@ -405,6 +486,11 @@ func (r *Repository) SavePersons(ctx context.Context, persons []Person) error {
 
 // SaveFacts stores extracted facts
 func (r *Repository) SaveFacts(ctx context.Context, facts []Fact) error {
+	return saveFacts(ctx, r.db, facts)
+}
+
+// saveFacts stores extracted facts using exec (the database or a transaction)
+func saveFacts(ctx context.Context, exec db.Execer, facts []Fact) error {
 	if len(facts) == 0 {
 		return nil
 	}
@@ -413,12 +499,13 @@ func (r *Repository) SaveFacts(ctx context.Context, facts []Fact) error {
 		INSERT INTO artifact_facts (
 			fact_id, artifact_id, fact_type, fact_key, fact_value,
 			normalized_value_numeric, normalized_value_date,
-			normalized_value_boolean, unit, confidence_score, context_snippet
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			normalized_value_boolean, unit, confidence_score, context_snippet,
+			source_location
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	for _, fact := range facts {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			fact.FactID,
 			fact.ArtifactID,
 			fact.FactType,
@@ -430,6 +517,7 @@ func (r *Repository) SaveFacts(ctx context.Context, facts []Fact) error {
 			fact.Unit,
 			fact.ConfidenceScore,
 			fact.ContextSnippet,
+			fact.SourceLocation,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to save fact: %w", err)
@@ -441,6 +529,11 @@ func (r *Repository) SaveFacts(ctx context.Context, facts []Fact) error {
 
 // SaveInsights stores AI-generated insights
 func (r *Repository) SaveInsights(ctx context.Context, insights []Insight) error {
+	return saveInsights(ctx, r.db, insights)
+}
+
+// saveInsights stores AI-generated insights using exec (the database or a transaction)
+func saveInsights(ctx context.Context, exec db.Execer, insights []Insight) error {
 	if len(insights) == 0 {
 		return nil
 	}
@@ -449,12 +542,12 @@ func (r *Repository) SaveInsights(ctx context.Context, insights []Insight) error
 		INSERT INTO artifact_insights (
 			insight_id, artifact_id, insight_type, insight_category,
 			title, description, severity, suggested_action,
-			impacted_modules, confidence_score
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			impacted_modules, confidence_score, prompt_id, prompt_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	for _, insight := range insights {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			insight.InsightID,
 			insight.ArtifactID,
 			insight.InsightType,
@@ -465,6 +558,8 @@ func (r *Repository) SaveInsights(ctx context.Context, insights []Insight) error
 			insight.SuggestedAction,
 			pq.Array(insight.ImpactedModules),
 			insight.ConfidenceScore,
+			insight.PromptID,
+			insight.PromptVersion,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to save insight: %w", err)
@@ -474,6 +569,187 @@ func (r *Repository) SaveInsights(ctx context.Context, insights []Insight) error
 	return nil
 }
 
+// StoreAnalysisResults saves an artifact's analysis, marks the artifact completed and writes
+// outboxEvents in the same transaction, so the events are published only if the analysis is
+// committed
+func (r *Repository) StoreAnalysisResults(ctx context.Context, artifactID uuid.UUID, result *AnalysisResult, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveSummary(ctx, tx, &result.Summary); err != nil {
+		return err
+	}
+	if err := saveTopics(ctx, tx, result.Topics); err != nil {
+		return err
+	}
+	if err := savePersons(ctx, tx, result.Persons); err != nil {
+		return err
+	}
+	if err := saveFacts(ctx, tx, result.Facts); err != nil {
+		return err
+	}
+	if err := saveInsights(ctx, tx, result.Insights); err != nil {
+		return err
+	}
+
+	// The document type becomes the artifact's category
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET artifact_category = COALESCE(NULLIF($1, ''), artifact_category),
+		    processing_status = 'completed',
+		    processed_at = NOW()
+		WHERE artifact_id = $2
+	`, result.DocumentType, artifactID)
+	if err != nil {
+		return fmt.Errorf("failed to update artifact status: %w", err)
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveOCRText stores the text OCR extracted from an artifact along with its chunks, queues the
+// artifact for analysis and writes outboxEvents in the same transaction
+func (r *Repository) SaveOCRText(ctx context.Context, artifactID uuid.UUID, text string, chunks []Chunk, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET raw_content = $1,
+		    processing_status = 'pending'
+		WHERE artifact_id = $2
+	`, sql.NullString{String: text, Valid: true}, artifactID)
+	if err != nil {
+		return fmt.Errorf("failed to update content: %w", err)
+	}
+
+	if err := saveChunks(ctx, tx, artifactID, chunks); err != nil {
+		return err
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ResetForReanalysis removes an artifact's AI-generated metadata and pipeline state, queues it
+// to be analyzed again and writes outboxEvents in the same transaction
+func (r *Repository) ResetForReanalysis(ctx context.Context, artifactID uuid.UUID, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Pipeline stages go too, so every stage runs again
+	tables := []string{
+		"artifact_insights",
+		"artifact_facts",
+		"artifact_persons",
+		"artifact_topics",
+		"artifact_summaries",
+		"artifact_embeddings",
+		"artifact_pipeline_stages",
+	}
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE artifact_id = $1`, artifactID); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET processing_status = 'pending',
+		    pipeline_status = NULL, pipeline_stage = NULL, pipeline_updated_at = NULL,
+		    claimed_by = NULL, lease_expires_at = NULL,
+		    deferred_until = NULL, deferred_reason = NULL
+		WHERE artifact_id = $1
+	`, artifactID)
+	if err != nil {
+		return fmt.Errorf("failed to reset pipeline: %w", err)
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RecordPromptRun stores the outcome of an analysis for prompt evaluation
+func (r *Repository) RecordPromptRun(ctx context.Context, run *PromptRun) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO prompt_runs (
+			prompt_id, prompt_version, program_id, artifact_id, outcome, error_message
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		run.Prompt.ID,
+		run.Prompt.Version,
+		run.ProgramID,
+		run.ArtifactID,
+		run.Outcome,
+		run.ErrorMessage,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record prompt run: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateInsightFeedback rates or dismisses an insight of an artifact in the program.
+// Returns false when no such insight exists.
+func (r *Repository) UpdateInsightFeedback(ctx context.Context, programID, artifactID, insightID, userID uuid.UUID, req *InsightFeedbackRequest) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE artifact_insights i
+		SET user_rating = COALESCE($5, i.user_rating),
+		    user_feedback = COALESCE($6, i.user_feedback),
+		    is_dismissed = COALESCE($7, i.is_dismissed),
+		    dismissed_at = CASE
+		        WHEN $7::boolean IS NULL THEN i.dismissed_at
+		        WHEN $7 THEN COALESCE(i.dismissed_at, NOW())
+		        ELSE NULL END,
+		    dismissed_by = CASE
+		        WHEN $7::boolean IS NULL THEN i.dismissed_by
+		        WHEN $7 THEN COALESCE(i.dismissed_by, $4)
+		        ELSE NULL END
+		FROM artifacts a
+		WHERE i.insight_id = $3
+		  AND i.artifact_id = $2
+		  AND a.artifact_id = i.artifact_id
+		  AND a.program_id = $1
+		  AND a.deleted_at IS NULL
+	`, programID, artifactID, insightID, uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+		req.Rating, req.Feedback, req.Dismissed)
+	if err != nil {
+		return false, fmt.Errorf("failed to update insight feedback: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	return rows > 0, nil
+}
+
 // GetMetadata retrieves all metadata for an artifact
 func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*ArtifactWithMetadata, error) {
 	// Get artifact
@@ -490,7 +766,8 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 	var summary ArtifactSummary
 	err = r.db.QueryRowContext(ctx, `
 		SELECT summary_id, artifact_id, executive_summary, key_takeaways,
-			   sentiment, priority, confidence_score, ai_model, created_at
+			   sentiment, priority, confidence_score, ai_model,
+			   prompt_id, prompt_version, created_at
 		FROM artifact_summaries
 		WHERE artifact_id = $1
 	`, artifactID).Scan(
@@ -502,6 +779,8 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 		&summary.Priority,
 		&summary.ConfidenceScore,
 		&summary.AIModel,
+		&summary.PromptID,
+		&summary.PromptVersion,
 		&summary.CreatedAt,
 	)
 	if err != sql.ErrNoRows {
@@ -557,7 +836,7 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 	factRows, err := r.db.QueryContext(ctx, `
 		SELECT fact_id, artifact_id, fact_type, fact_key, fact_value,
 			   normalized_value_numeric, normalized_value_date, normalized_value_boolean,
-			   unit, confidence_score, context_snippet, extracted_at
+			   unit, confidence_score, context_snippet, source_location, extracted_at
 		FROM artifact_facts
 		WHERE artifact_id = $1
 		ORDER BY fact_type, fact_key
@@ -571,7 +850,7 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 		var f Fact
 		if err := factRows.Scan(&f.FactID, &f.ArtifactID, &f.FactType, &f.FactKey, &f.FactValue,
 			&f.NormalizedValueNumeric, &f.NormalizedValueDate, &f.NormalizedValueBoolean,
-			&f.Unit, &f.ConfidenceScore, &f.ContextSnippet, &f.ExtractedAt); err != nil {
+			&f.Unit, &f.ConfidenceScore, &f.ContextSnippet, &f.SourceLocation, &f.ExtractedAt); err != nil {
 			return nil, err
 		}
 		result.Facts = append(result.Facts, f)
@@ -581,8 +860,8 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 	insightRows, err := r.db.QueryContext(ctx, `
 		SELECT insight_id, artifact_id, insight_type, insight_category,
 			   title, description, severity, suggested_action,
-			   impacted_modules, confidence_score, user_rating, user_feedback,
-			   is_dismissed, extracted_at, dismissed_at, dismissed_by
+			   impacted_modules, confidence_score, prompt_id, prompt_version,
+			   user_rating, user_feedback, is_dismissed, extracted_at, dismissed_at, dismissed_by
 		FROM artifact_insights
 		WHERE artifact_id = $1 AND is_dismissed = FALSE
 		ORDER BY severity DESC, confidence_score DESC
@@ -596,8 +875,8 @@ func (r *Repository) GetMetadata(ctx context.Context, artifactID uuid.UUID) (*Ar
 		var i Insight
 		if err := insightRows.Scan(&i.InsightID, &i.ArtifactID, &i.InsightType, &i.InsightCategory,
 			&i.Title, &i.Description, &i.Severity, &i.SuggestedAction,
-			pq.Array(&i.ImpactedModules), &i.ConfidenceScore, &i.UserRating, &i.UserFeedback,
-			&i.IsDismissed, &i.ExtractedAt, &i.DismissedAt, &i.DismissedBy); err != nil {
+			pq.Array(&i.ImpactedModules), &i.ConfidenceScore, &i.PromptID, &i.PromptVersion,
+			&i.UserRating, &i.UserFeedback, &i.IsDismissed, &i.ExtractedAt, &i.DismissedAt, &i.DismissedBy); err != nil {
 			return nil, err
 		}
 		result.Insights = append(result.Insights, i)
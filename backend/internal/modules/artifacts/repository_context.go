@@ -1536,3 +1536,50 @@ func (r *Repository) getFactCountForArtifact(ctx context.Context, artifactID uui
 	}
 	return count, nil
 }
+
+// ============================================================================
+// Maintenance jobs
+// ============================================================================
+
+// ListActiveProgramIDs returns the IDs of programs that have not been deleted
+func (r *Repository) ListActiveProgramIDs(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT program_id FROM programs WHERE deleted_at IS NULL ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list programs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan program ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DeleteEntityGraph removes a program's entity graph edges before they are rebuilt
+func (r *Repository) DeleteEntityGraph(ctx context.Context, programID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM artifact_entity_graph WHERE program_id = $1`, programID)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity graph: %w", err)
+	}
+	return nil
+}
+
+// DeleteDetectedSequences removes a program's automatically detected sequences before they
+// are detected again; manually curated sequences are kept
+func (r *Repository) DeleteDetectedSequences(ctx context.Context, programID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM artifact_temporal_sequences WHERE program_id = $1 AND detection_method = 'auto'
+	`, programID)
+	if err != nil {
+		return fmt.Errorf("failed to delete detected sequences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// This file contains repository methods for email artifacts:
+// - Recording message headers and threading messages across uploads
+// - Listing a conversation thread
+// - Listing the attachments of a message
+
+// ============================================================================
+// Threading
+// ============================================================================
+
+// saveEmail records an email artifact's headers in tx and assigns its thread. The message
+// joins the thread of every message of the program that it references, that references it or
+// that shares its Message-ID; if those belong to several threads, they are merged into the
+// oldest. The program's threads are locked for the rest of tx so concurrent uploads of the
+// same conversation cannot start separate threads.
+func saveEmail(ctx context.Context, tx *sql.Tx, email *ArtifactEmail) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "artifact_emails:"+email.ProgramID.String()); err != nil {
+		return fmt.Errorf("failed to lock email threads: %w", err)
+	}
+
+	linked := append([]string{}, email.References...)
+	if email.InReplyTo != "" {
+		linked = append(linked, email.InReplyTo)
+	}
+	if email.MessageID != "" {
+		linked = append(linked, email.MessageID)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT thread_id
+		FROM artifact_emails
+		WHERE program_id = $1
+		  AND (
+		      message_id = ANY($2)
+		      OR ($3 <> '' AND (in_reply_to = $3 OR $3 = ANY(reference_ids)))
+		  )
+		GROUP BY thread_id
+		ORDER BY MIN(created_at)
+	`, email.ProgramID, pq.Array(linked), email.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to find email thread: %w", err)
+	}
+
+	var threads []uuid.UUID
+	for rows.Next() {
+		var threadID uuid.UUID
+		if err := rows.Scan(&threadID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan email thread: %w", err)
+		}
+		threads = append(threads, threadID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find email thread: %w", err)
+	}
+
+	email.ThreadID = uuid.New()
+	if len(threads) > 0 {
+		email.ThreadID = threads[0]
+	}
+	if len(threads) > 1 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE artifact_emails SET thread_id = $1 WHERE thread_id = ANY($2)
+		`, email.ThreadID, pq.Array(threads[1:]))
+		if err != nil {
+			return fmt.Errorf("failed to merge email threads: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO artifact_emails (
+			artifact_id, program_id, thread_id, message_id, in_reply_to, reference_ids,
+			from_address, to_addresses, cc_addresses, subject, sent_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11)
+	`,
+		email.ArtifactID,
+		email.ProgramID,
+		email.ThreadID,
+		email.MessageID,
+		email.InReplyTo,
+		pq.Array(nonNil(email.References)),
+		email.From,
+		pq.Array(nonNil(email.To)),
+		pq.Array(nonNil(email.Cc)),
+		email.Subject,
+		email.SentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save email: %w", err)
+	}
+
+	return nil
+}
+
+// nonNil stores a missing list as an empty array rather than NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// ============================================================================
+// Queries
+// ============================================================================
+
+// GetEmailThread returns the messages of the thread that an email artifact belongs to, in the
+// order they were sent. Returns no messages if the artifact is not an email.
+func (r *Repository) GetEmailThread(ctx context.Context, artifactID uuid.UUID) ([]ArtifactEmail, error) {
+	query := `
+		SELECT e.artifact_id, e.program_id, e.thread_id,
+			   COALESCE(e.message_id, ''), COALESCE(e.in_reply_to, ''), e.reference_ids,
+			   COALESCE(e.from_address, ''), e.to_addresses, e.cc_addresses,
+			   COALESCE(e.subject, ''), e.sent_at, a.filename
+		FROM artifact_emails e
+		JOIN artifacts a ON a.artifact_id = e.artifact_id AND a.deleted_at IS NULL
+		WHERE e.thread_id = (SELECT thread_id FROM artifact_emails WHERE artifact_id = $1)
+		ORDER BY e.sent_at NULLS LAST, e.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email thread: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]ArtifactEmail, 0)
+	for rows.Next() {
+		var m ArtifactEmail
+		err := rows.Scan(
+			&m.ArtifactID,
+			&m.ProgramID,
+			&m.ThreadID,
+			&m.MessageID,
+			&m.InReplyTo,
+			pq.Array(&m.References),
+			&m.From,
+			pq.Array(&m.To),
+			pq.Array(&m.Cc),
+			&m.Subject,
+			&m.SentAt,
+			&m.Filename,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// ListAttachments returns the artifacts created from the attachments of an email artifact
+func (r *Repository) ListAttachments(ctx context.Context, parentArtifactID uuid.UUID) ([]Artifact, error) {
+	query := `
+		SELECT artifact_id, program_id, filename, storage_path, file_type,
+			   file_size_bytes, mime_type, content_hash,
+			   processing_status, uploaded_by, uploaded_at, parent_artifact_id
+		FROM artifacts
+		WHERE parent_artifact_id = $1 AND deleted_at IS NULL
+		ORDER BY uploaded_at, filename
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, parentArtifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	artifacts := make([]Artifact, 0)
+	for rows.Next() {
+		var a Artifact
+		err := rows.Scan(
+			&a.ArtifactID,
+			&a.ProgramID,
+			&a.Filename,
+			&a.StoragePath,
+			&a.FileType,
+			&a.FileSizeBytes,
+			&a.MimeType,
+			&a.ContentHash,
+			&a.ProcessingStatus,
+			&a.UploadedBy,
+			&a.UploadedAt,
+			&a.ParentArtifactID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		artifacts = append(artifacts, a)
+	}
+
+	return artifacts, rows.Err()
+}
@@ -0,0 +1,498 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// This file contains repository methods for the worker processing pipeline:
+// - Leasing artifacts to a worker for a pipeline run
+// - Per-stage state tracking
+// - Dead-letter storage and requeue
+
+// ============================================================================
+// Pipeline Claiming
+// ============================================================================
+
+// claimableCondition matches artifacts with outstanding pipeline work: new uploads waiting for
+// analysis or OCR, requeued or interrupted runs, deferred runs that are due, and running
+// pipelines whose lease expired
+const claimableCondition = `
+		deleted_at IS NULL
+		AND (
+		    (pipeline_status IS NULL AND processing_status IN ('pending', 'ocr_required'))
+		    OR pipeline_status = 'queued'
+		    OR (pipeline_status = 'deferred' AND deferred_until <= NOW())
+		    OR (pipeline_status = 'running' AND lease_expires_at < NOW())
+		)
+`
+
+// ClaimPipeline leases an artifact's pipeline to workerID if no other worker holds it.
+// Returns false if the artifact is not claimable.
+func (r *Repository) ClaimPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE artifacts
+		SET pipeline_status = 'running',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = $2,
+		    lease_expires_at = NOW() + $3 * INTERVAL '1 second',
+		    deferred_until = NULL,
+		    deferred_reason = NULL
+		WHERE artifact_id IN (
+		    SELECT artifact_id
+		    FROM artifacts
+		    WHERE artifact_id = $1 AND ` + claimableCondition + `
+		    FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, artifactID, workerID, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim artifact pipeline: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ClaimPipelineBatch leases up to limit claimable artifacts to workerID, oldest uploads first.
+// Rows another worker is claiming at the same moment are skipped rather than waited on.
+func (r *Repository) ClaimPipelineBatch(ctx context.Context, workerID string, limit int, lease time.Duration) ([]uuid.UUID, error) {
+	query := `
+		UPDATE artifacts
+		SET pipeline_status = 'running',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = $1,
+		    lease_expires_at = NOW() + $2 * INTERVAL '1 second',
+		    deferred_until = NULL,
+		    deferred_reason = NULL
+		WHERE artifact_id IN (
+		    SELECT artifact_id
+		    FROM artifacts
+		    WHERE ` + claimableCondition + `
+		    ORDER BY uploaded_at ASC
+		    LIMIT $3
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING artifact_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workerID, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pipeline batch: %w", err)
+	}
+	defer rows.Close()
+
+	var artifactIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan artifact ID: %w", err)
+		}
+		artifactIDs = append(artifactIDs, id)
+	}
+
+	return artifactIDs, rows.Err()
+}
+
+// RenewPipelineLease extends workerID's lease on a running pipeline. Returns false if the
+// worker no longer holds the lease (it expired and another worker reclaimed the artifact).
+func (r *Repository) RenewPipelineLease(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_updated_at = NOW(),
+		    lease_expires_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE artifact_id = $1 AND pipeline_status = 'running' AND claimed_by = $2
+	`, artifactID, workerID, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to renew pipeline lease: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CompletePipeline marks the pipeline and the artifact as completed, ends workerID's lease and
+// writes outboxEvents in the same transaction. Returns false, writing nothing, if the worker no
+// longer holds the lease.
+func (r *Repository) CompletePipeline(ctx context.Context, artifactID uuid.UUID, workerID string, outboxEvents ...*events.Event) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_status = 'completed',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = NULL,
+		    lease_expires_at = NULL,
+		    processing_status = 'completed',
+		    processed_at = NOW()
+		WHERE artifact_id = $1 AND pipeline_status = 'running' AND claimed_by = $2
+	`, artifactID, workerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete pipeline: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleasePipeline hands an interrupted run back to the queue so any worker can resume it.
+// Does nothing if workerID no longer holds the lease.
+func (r *Repository) ReleasePipeline(ctx context.Context, artifactID uuid.UUID, workerID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_status = 'queued',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = NULL,
+		    lease_expires_at = NULL
+		WHERE artifact_id = $1 AND pipeline_status = 'running' AND claimed_by = $2
+	`, artifactID, workerID)
+	if err != nil {
+		return fmt.Errorf("failed to release pipeline: %w", err)
+	}
+	return nil
+}
+
+// DeferPipeline parks workerID's run until the given time, when any worker may resume it at
+// the stage that deferred. Artifacts that already completed analysis keep their processing status.
+func (r *Repository) DeferPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, until time.Time, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_status = 'deferred',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = NULL,
+		    lease_expires_at = NULL,
+		    deferred_until = $3,
+		    deferred_reason = $4,
+		    processing_status = CASE WHEN processing_status = 'completed' THEN processing_status ELSE 'deferred' END
+		WHERE artifact_id = $1 AND pipeline_status = 'running' AND claimed_by = $2
+	`, artifactID, workerID, until, reason)
+	if err != nil {
+		return fmt.Errorf("failed to defer pipeline: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Stage State
+// ============================================================================
+
+// GetPipelineStages retrieves the recorded stage states for an artifact
+func (r *Repository) GetPipelineStages(ctx context.Context, artifactID uuid.UUID) ([]PipelineStageState, error) {
+	query := `
+		SELECT artifact_id, stage, status, attempts, last_error, error_code,
+		       started_at, completed_at, updated_at
+		FROM artifact_pipeline_stages
+		WHERE artifact_id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline stages: %w", err)
+	}
+	defer rows.Close()
+
+	stages := make([]PipelineStageState, 0)
+	for rows.Next() {
+		var s PipelineStageState
+		err := rows.Scan(
+			&s.ArtifactID,
+			&s.Stage,
+			&s.Status,
+			&s.Attempts,
+			&s.LastError,
+			&s.ErrorCode,
+			&s.StartedAt,
+			&s.CompletedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline stage: %w", err)
+		}
+		stages = append(stages, s)
+	}
+
+	return stages, rows.Err()
+}
+
+// StartPipelineStage records a new attempt of a stage and makes it the artifact's current stage
+func (r *Repository) StartPipelineStage(ctx context.Context, artifactID uuid.UUID, stage string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO artifact_pipeline_stages (artifact_id, stage, status, attempts, started_at, updated_at)
+		VALUES ($1, $2, 'running', 1, NOW(), NOW())
+		ON CONFLICT (artifact_id, stage) DO UPDATE
+		SET status = 'running',
+		    attempts = artifact_pipeline_stages.attempts + 1,
+		    started_at = COALESCE(artifact_pipeline_stages.started_at, NOW()),
+		    updated_at = NOW()
+	`, artifactID, stage)
+	if err != nil {
+		return fmt.Errorf("failed to start pipeline stage: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_stage = $1,
+		    pipeline_updated_at = NOW()
+		WHERE artifact_id = $2
+	`, stage, artifactID)
+	if err != nil {
+		return fmt.Errorf("failed to update pipeline stage: %w", err)
+	}
+
+	return nil
+}
+
+// FinishPipelineStage records the outcome of a stage attempt.
+// status is one of completed, skipped, running (failed attempt that will be retried) or failed.
+func (r *Repository) FinishPipelineStage(ctx context.Context, artifactID uuid.UUID, stage, status, errorCode, errorMessage string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE artifact_pipeline_stages
+		SET status = $1,
+		    last_error = NULLIF($2, ''),
+		    error_code = NULLIF($6, ''),
+		    completed_at = CASE WHEN $3 IN ('completed', 'skipped') THEN NOW() ELSE NULL END,
+		    updated_at = NOW()
+		WHERE artifact_id = $4 AND stage = $5
+	`, status, errorMessage, status, artifactID, stage, errorCode)
+	if err != nil {
+		return fmt.Errorf("failed to finish pipeline stage: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Dead Letters
+// ============================================================================
+
+// CreateDeadLetter records an exhausted stage, parks the artifact's pipeline and writes
+// outboxEvents in the same transaction. Artifacts that already completed analysis keep their
+// processing status; otherwise they are marked failed.
+func (r *Repository) CreateDeadLetter(ctx context.Context, deadLetter *DeadLetter, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO artifact_dead_letters (
+			dead_letter_id, artifact_id, program_id, stage, error_code, error_message, attempts, created_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+	`,
+		deadLetter.DeadLetterID,
+		deadLetter.ArtifactID,
+		deadLetter.ProgramID,
+		deadLetter.Stage,
+		deadLetter.ErrorCode,
+		deadLetter.ErrorMessage,
+		deadLetter.Attempts,
+		deadLetter.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_status = 'dead_lettered',
+		    pipeline_updated_at = NOW(),
+		    claimed_by = NULL,
+		    lease_expires_at = NULL,
+		    processing_status = CASE WHEN processing_status = 'completed' THEN processing_status ELSE 'failed' END,
+		    processed_at = NOW()
+		WHERE artifact_id = $1
+	`, deadLetter.ArtifactID)
+	if err != nil {
+		return fmt.Errorf("failed to update artifact pipeline status: %w", err)
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListDeadLetters retrieves dead letters for a program, newest first. A non-empty errorCode
+// lists only dead letters with that code.
+func (r *Repository) ListDeadLetters(ctx context.Context, programID uuid.UUID, includeRequeued bool, errorCode string, limit, offset int) ([]DeadLetter, error) {
+	query := `
+		SELECT d.dead_letter_id, d.artifact_id, d.program_id, a.filename, d.stage, COALESCE(d.error_code, ''),
+		       d.error_message, d.attempts, d.created_at, d.requeued_at, d.requeued_by
+		FROM artifact_dead_letters d
+		JOIN artifacts a ON d.artifact_id = a.artifact_id
+		WHERE d.program_id = $1
+		  AND ($2 OR d.requeued_at IS NULL)
+		  AND ($5 = '' OR d.error_code = $5)
+		  AND a.deleted_at IS NULL
+		ORDER BY d.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, programID, includeRequeued, limit, offset, errorCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := make([]DeadLetter, 0)
+	for rows.Next() {
+		var d DeadLetter
+		if err := scanDeadLetter(rows, &d); err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, d)
+	}
+
+	return deadLetters, rows.Err()
+}
+
+// GetDeadLetter retrieves a single dead letter
+func (r *Repository) GetDeadLetter(ctx context.Context, deadLetterID uuid.UUID) (*DeadLetter, error) {
+	query := `
+		SELECT d.dead_letter_id, d.artifact_id, d.program_id, a.filename, d.stage, COALESCE(d.error_code, ''),
+		       d.error_message, d.attempts, d.created_at, d.requeued_at, d.requeued_by
+		FROM artifact_dead_letters d
+		JOIN artifacts a ON d.artifact_id = a.artifact_id
+		WHERE d.dead_letter_id = $1
+	`
+
+	var d DeadLetter
+	err := scanDeadLetter(r.db.QueryRowContext(ctx, query, deadLetterID), &d)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("dead letter not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// RequeueDeadLetter marks a dead letter as requeued, queues the artifact's pipeline to resume
+// at the failed stage and writes outboxEvents in the same transaction. Completed stages are not
+// re-run.
+func (r *Repository) RequeueDeadLetter(ctx context.Context, deadLetterID, requeuedBy uuid.UUID, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var artifactID uuid.UUID
+	var stage string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE artifact_dead_letters
+		SET requeued_at = NOW(),
+		    requeued_by = $2
+		WHERE dead_letter_id = $1 AND requeued_at IS NULL
+		RETURNING artifact_id, stage
+	`, deadLetterID, uuid.NullUUID{UUID: requeuedBy, Valid: requeuedBy != uuid.Nil}).Scan(&artifactID, &stage)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("dead letter not found or already requeued")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter requeued: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifact_pipeline_stages
+		SET status = 'pending',
+		    updated_at = NOW()
+		WHERE artifact_id = $1 AND stage = $2
+	`, artifactID, stage)
+	if err != nil {
+		return fmt.Errorf("failed to reset pipeline stage: %w", err)
+	}
+
+	// Artifacts that failed before analysis go back to the queue they came from
+	_, err = tx.ExecContext(ctx, `
+		UPDATE artifacts
+		SET pipeline_status = 'queued',
+		    pipeline_updated_at = NOW(),
+		    processing_status = CASE
+		        WHEN processing_status = 'completed' THEN processing_status
+		        WHEN extraction_pending THEN 'pending'
+		        WHEN COALESCE(raw_content, '') = '' THEN 'ocr_required'
+		        ELSE 'pending'
+		    END
+		WHERE artifact_id = $1 AND deleted_at IS NULL
+	`, artifactID)
+	if err != nil {
+		return fmt.Errorf("failed to queue artifact pipeline: %w", err)
+	}
+
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDeadLetter scans a dead letter row
+func scanDeadLetter(row rowScanner, d *DeadLetter) error {
+	err := row.Scan(
+		&d.DeadLetterID,
+		&d.ArtifactID,
+		&d.ProgramID,
+		&d.Filename,
+		&d.Stage,
+		&d.ErrorCode,
+		&d.ErrorMessage,
+		&d.Attempts,
+		&d.CreatedAt,
+		&d.RequeuedAt,
+		&d.RequeuedBy,
+	)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scan dead letter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// This file contains repository methods for resumable upload sessions:
+// - Recording a session and moving it through its statuses
+// - Finding expired sessions to discard
+
+const uploadSessionColumns = `
+	session_id, program_id, uploaded_by, filename, mime_type, file_size_bytes, part_size_bytes,
+	force_upload, file_id, storage_upload_id, status, artifact_ids, expires_at, created_at
+`
+
+// scanUploadSession scans a row selected with uploadSessionColumns
+func scanUploadSession(row rowScanner, s *UploadSession) error {
+	err := row.Scan(
+		&s.SessionID,
+		&s.ProgramID,
+		&s.UploadedBy,
+		&s.Filename,
+		&s.MimeType,
+		&s.FileSizeBytes,
+		&s.PartSizeBytes,
+		&s.ForceUpload,
+		&s.FileID,
+		&s.StorageUploadID,
+		&s.Status,
+		pq.Array(&s.ArtifactIDs),
+		&s.ExpiresAt,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.PartCount = partCount(s.FileSizeBytes, s.PartSizeBytes)
+	return nil
+}
+
+// CreateUploadSession records a new upload session
+func (r *Repository) CreateUploadSession(ctx context.Context, session *UploadSession) error {
+	query := `
+		INSERT INTO artifact_upload_sessions (
+			session_id, program_id, uploaded_by, filename, mime_type, file_size_bytes,
+			part_size_bytes, force_upload, file_id, storage_upload_id, status, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		session.SessionID,
+		session.ProgramID,
+		session.UploadedBy,
+		session.Filename,
+		session.MimeType,
+		session.FileSizeBytes,
+		session.PartSizeBytes,
+		session.ForceUpload,
+		session.FileID,
+		session.StorageUploadID,
+		session.Status,
+		session.ExpiresAt,
+	).Scan(&session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	return nil
+}
+
+// GetUploadSession retrieves an upload session, or returns ErrUploadSessionNotFound
+func (r *Repository) GetUploadSession(ctx context.Context, sessionID uuid.UUID) (*UploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + ` FROM artifact_upload_sessions WHERE session_id = $1`
+
+	var s UploadSession
+	err := scanUploadSession(r.db.QueryRowContext(ctx, query, sessionID), &s)
+	if err == sql.ErrNoRows {
+		return nil, ErrUploadSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+
+	return &s, nil
+}
+
+// TransitionUploadSession moves an upload session from one status to another. Returns false if
+// the session was not in the from status, e.g. because a concurrent request moved it first.
+func (r *Repository) TransitionUploadSession(ctx context.Context, sessionID uuid.UUID, from, to string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE artifact_upload_sessions SET status = $3 WHERE session_id = $1 AND status = $2
+	`, sessionID, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to update upload session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update upload session: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// CompleteUploadSession records the artifacts created from an assembled upload session
+func (r *Repository) CompleteUploadSession(ctx context.Context, sessionID uuid.UUID, artifactIDs []uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE artifact_upload_sessions
+		SET status = 'completed', artifact_ids = $2, completed_at = NOW()
+		WHERE session_id = $1
+	`, sessionID, pq.Array(artifactIDs))
+	if err != nil {
+		return fmt.Errorf("failed to complete upload session: %w", err)
+	}
+
+	return nil
+}
+
+// ListExpiredUploadSessions returns up to limit sessions that expired before completion
+func (r *Repository) ListExpiredUploadSessions(ctx context.Context, limit int) ([]UploadSession, error) {
+	query := `
+		SELECT ` + uploadSessionColumns + `
+		FROM artifact_upload_sessions
+		WHERE status IN ('active', 'assembled') AND expires_at < NOW()
+		ORDER BY expires_at
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]UploadSession, 0)
+	for rows.Next() {
+		var s UploadSession
+		if err := scanUploadSession(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan upload session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
@@ -9,6 +9,8 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -16,6 +18,8 @@ import (
 	"time"
 
 	"github.com/cerberus/backend/internal/modules/artifacts/extractors"
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -25,6 +29,7 @@ import (
 type RepositoryInterface interface {
 	// Core CRUD operations
 	Create(ctx context.Context, artifact *Artifact) error
+	CreateWithChunks(ctx context.Context, artifact *Artifact, chunks []Chunk, outboxEvents ...*events.Event) error
 	GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifact, error)
 	ListByProgram(ctx context.Context, programID uuid.UUID, limit, offset int) ([]Artifact, error)
 	Delete(ctx context.Context, artifactID uuid.UUID) error
@@ -37,6 +42,9 @@ type RepositoryInterface interface {
 	SavePersons(ctx context.Context, persons []Person) error
 	SaveFacts(ctx context.Context, facts []Fact) error
 	SaveInsights(ctx context.Context, insights []Insight) error
+	StoreAnalysisResults(ctx context.Context, artifactID uuid.UUID, result *AnalysisResult, outboxEvents ...*events.Event) error
+	SaveOCRText(ctx context.Context, artifactID uuid.UUID, text string, chunks []Chunk, outboxEvents ...*events.Event) error
+	ResetForReanalysis(ctx context.Context, artifactID uuid.UUID, outboxEvents ...*events.Event) error
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 
@@ -49,6 +57,7 @@ type RepositoryInterface interface {
 	GetPersonsByArtifact(ctx context.Context, artifactID uuid.UUID) ([]Person, error)
 	GetPersonIDsByArtifact(ctx context.Context, artifactID uuid.UUID) ([]uuid.UUID, error)
 	UpsertEntityGraphEdge(ctx context.Context, programID, person1ID, person2ID, artifactID uuid.UUID) error
+	DeleteEntityGraph(ctx context.Context, programID uuid.UUID) error
 	FindArtifactsWithEntityOverlap(ctx context.Context, targetArtifactID, programID uuid.UUID, personIDs []uuid.UUID, limit int) ([]ArtifactWithEntityOverlap, error)
 	GetKeyPeopleByProgram(ctx context.Context, programID uuid.UUID, limit int) ([]PersonContext, error)
 	FindPeopleByNamePattern(ctx context.Context, programID uuid.UUID, namePattern string) ([]Person, error)
@@ -65,6 +74,7 @@ type RepositoryInterface interface {
 	GetSummaryByArtifact(ctx context.Context, artifactID uuid.UUID) (*ArtifactSummary, error)
 	SaveTemporalSequence(ctx context.Context, sequence *ArtifactSequence) error
 	GetTemporalSequencesByProgram(ctx context.Context, programID uuid.UUID) ([]ArtifactSequence, error)
+	DeleteDetectedSequences(ctx context.Context, programID uuid.UUID) error
 
 	// Context Graph: Fact aggregation
 	GetFactsByArtifacts(ctx context.Context, artifactIDs []uuid.UUID) ([]Fact, error)
@@ -75,6 +85,9 @@ type RepositoryInterface interface {
 	CountFactsByType(ctx context.Context, programID uuid.UUID) (map[string]int, error)
 	GetAllFactsInProgram(ctx context.Context, programID uuid.UUID) ([]Fact, error)
 
+	// Context Graph: Maintenance jobs
+	ListActiveProgramIDs(ctx context.Context) ([]uuid.UUID, error)
+
 	// Context Graph: Cache management
 	GetContextCacheEntry(ctx context.Context, artifactID uuid.UUID) (*ContextCacheEntry, error)
 	UpsertContextCacheEntry(ctx context.Context, entry *ContextCacheEntry) error
@@ -84,6 +97,41 @@ type RepositoryInterface interface {
 	GetRecentArtifactsWithoutCache(ctx context.Context, programID uuid.UUID, limit int) ([]Artifact, error)
 	RefreshContextSummaryView(ctx context.Context) error
 	GetContextCacheStats(ctx context.Context) (map[string]interface{}, error)
+
+	// AI analysis outcomes
+	RecordPromptRun(ctx context.Context, run *PromptRun) error
+	UpdateInsightFeedback(ctx context.Context, programID, artifactID, insightID, userID uuid.UUID, req *InsightFeedbackRequest) (bool, error)
+
+	// Processing pipeline
+	ClaimPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error)
+	ClaimPipelineBatch(ctx context.Context, workerID string, limit int, lease time.Duration) ([]uuid.UUID, error)
+	RenewPipelineLease(ctx context.Context, artifactID uuid.UUID, workerID string, lease time.Duration) (bool, error)
+	CompletePipeline(ctx context.Context, artifactID uuid.UUID, workerID string, outboxEvents ...*events.Event) (bool, error)
+	ReleasePipeline(ctx context.Context, artifactID uuid.UUID, workerID string) error
+	DeferPipeline(ctx context.Context, artifactID uuid.UUID, workerID string, until time.Time, reason string) error
+	GetPipelineStages(ctx context.Context, artifactID uuid.UUID) ([]PipelineStageState, error)
+	StartPipelineStage(ctx context.Context, artifactID uuid.UUID, stage string) error
+	FinishPipelineStage(ctx context.Context, artifactID uuid.UUID, stage, status, errorCode, errorMessage string) error
+
+	// Processing pipeline: Dead letters
+	CreateDeadLetter(ctx context.Context, deadLetter *DeadLetter, outboxEvents ...*events.Event) error
+	ListDeadLetters(ctx context.Context, programID uuid.UUID, includeRequeued bool, errorCode string, limit, offset int) ([]DeadLetter, error)
+	GetDeadLetter(ctx context.Context, deadLetterID uuid.UUID) (*DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, deadLetterID, requeuedBy uuid.UUID, outboxEvents ...*events.Event) error
+
+	// Email threads and attachments
+	GetEmailThread(ctx context.Context, artifactID uuid.UUID) ([]ArtifactEmail, error)
+	ListAttachments(ctx context.Context, parentArtifactID uuid.UUID) ([]Artifact, error)
+
+	// Deferred extraction
+	SaveExtraction(ctx context.Context, artifact *Artifact, chunks []Chunk) error
+
+	// Resumable uploads
+	CreateUploadSession(ctx context.Context, session *UploadSession) error
+	GetUploadSession(ctx context.Context, sessionID uuid.UUID) (*UploadSession, error)
+	TransitionUploadSession(ctx context.Context, sessionID uuid.UUID, from, to string) (bool, error)
+	CompleteUploadSession(ctx context.Context, sessionID uuid.UUID, artifactIDs []uuid.UUID) error
+	ListExpiredUploadSessions(ctx context.Context, limit int) ([]UploadSession, error)
 }
 
 // DBExecutor defines methods for direct database access (for metadata clearing)
@@ -92,37 +140,71 @@ type DBExecutor interface {
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
+// ProgramConfigSource loads program configuration; *programs.ConfigService implements it
+type ProgramConfigSource interface {
+	GetProgramConfig(ctx context.Context, programID uuid.UUID) (*programs.ProgramConfig, error)
+}
+
+// DefaultMaxUploadBytes is the upload size limit of programs that do not set their own
+const DefaultMaxUploadBytes int64 = 50 << 20
+
+// DefaultMaxExtractBytes is the largest file whose content is extracted by ExtractDeferred.
+// Extractors read the whole file into memory, so this bounds the worker's memory per artifact.
+const DefaultMaxExtractBytes int64 = 100 << 20
+
 // Service handles business logic for artifacts
 type Service struct {
-	repo       RepositoryInterface
-	db         DBExecutor
-	storage    storage.Storage
-	extractors *extractors.ExtractorFactory
-	chunker    *ChunkingStrategy
+	repo            RepositoryInterface
+	db              DBExecutor
+	storage         storage.Storage
+	extractors      *extractors.ExtractorFactory
+	chunker         *ChunkingStrategy
+	configs         ProgramConfigSource
+	maxUploadBytes  int64
+	maxExtractBytes int64
 }
 
 // NewService creates a new artifacts service
 func NewService(repo *Repository, stor storage.Storage) *Service {
 	return &Service{
-		repo:       repo,
-		db:         repo.db,
-		storage:    stor,
-		extractors: extractors.NewExtractorFactory(),
-		chunker:    DefaultChunkingStrategy(),
+		repo:            repo,
+		db:              repo.db,
+		storage:         stor,
+		extractors:      extractors.NewExtractorFactory(),
+		chunker:         DefaultChunkingStrategy(),
+		maxUploadBytes:  DefaultMaxUploadBytes,
+		maxExtractBytes: DefaultMaxExtractBytes,
 	}
 }
 
 // NewServiceWithMocks creates a service with mock dependencies (useful for testing)
 func NewServiceWithMocks(repo RepositoryInterface, db DBExecutor, stor storage.Storage) *Service {
 	return &Service{
-		repo:       repo,
-		db:         db,
-		storage:    stor,
-		extractors: extractors.NewExtractorFactory(),
-		chunker:    DefaultChunkingStrategy(),
+		repo:            repo,
+		db:              db,
+		storage:         stor,
+		extractors:      extractors.NewExtractorFactory(),
+		chunker:         DefaultChunkingStrategy(),
+		maxUploadBytes:  DefaultMaxUploadBytes,
+		maxExtractBytes: DefaultMaxExtractBytes,
 	}
 }
 
+// SetProgramConfigs lets programs set their own upload size limit
+func (s *Service) SetProgramConfigs(configs ProgramConfigSource) {
+	s.configs = configs
+}
+
+// SetMaxUploadBytes sets the upload size limit of programs that do not set their own
+func (s *Service) SetMaxUploadBytes(limit int64) {
+	s.maxUploadBytes = limit
+}
+
+// SetMaxExtractBytes sets the largest file whose content ExtractDeferred extracts
+func (s *Service) SetMaxExtractBytes(limit int64) {
+	s.maxExtractBytes = limit
+}
+
 // UploadZipArchive expands a ZIP file and uploads each file as a separate artifact
 func (s *Service) UploadZipArchive(ctx context.Context, req UploadRequest) ([]uuid.UUID, error) {
 	// Import archive/zip and bytes packages at the top of the file
@@ -132,7 +214,7 @@ func (s *Service) UploadZipArchive(ctx context.Context, req UploadRequest) ([]uu
 	// Parse the ZIP archive
 	zipReader, err := zip.NewReader(bytes.NewReader(req.Data), int64(len(req.Data)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read ZIP archive: %w", err)
+		return nil, &UnprocessableUploadError{Message: fmt.Sprintf("failed to read ZIP archive: %v", err)}
 	}
 
 	// Extract and upload each file
@@ -181,6 +263,17 @@ func (s *Service) UploadZipArchive(ctx context.Context, req UploadRequest) ([]uu
 			ForceUpload: req.ForceUpload,
 		}
 
+		// Mailboxes are split into one artifact per message
+		if mimeType == "application/mbox" {
+			messageIDs, err := s.UploadMbox(ctx, fileReq)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", file.Name, err))
+				continue
+			}
+			artifactIDs = append(artifactIDs, messageIDs...)
+			continue
+		}
+
 		// Upload the file as a separate artifact
 		artifactID, err := s.UploadArtifact(ctx, fileReq)
 		if err != nil {
@@ -197,13 +290,115 @@ func (s *Service) UploadZipArchive(ctx context.Context, req UploadRequest) ([]uu
 		if len(errors) > 0 {
 			return nil, fmt.Errorf("no files could be extracted from ZIP: %s", strings.Join(errors, "; "))
 		}
-		return nil, fmt.Errorf("no supported files found in ZIP archive")
+		return nil, &UnprocessableUploadError{Message: "no supported files found in ZIP archive"}
 	}
 
 	return artifactIDs, nil
 }
 
-// UploadArtifact processes and stores a new artifact
+// UploadMbox splits a mailbox export and uploads each message as a separate email artifact.
+// The mailbox is read from req.Body, or from req.Data if there is no Body, one message at a time.
+// Messages that were already uploaded are skipped, so a growing mailbox can be re-uploaded.
+func (s *Service) UploadMbox(ctx context.Context, req UploadRequest) ([]uuid.UUID, error) {
+	body := req.Body
+	if body == nil {
+		body = bytes.NewReader(req.Data)
+	}
+	mbox := extractors.NewMboxReader(body)
+
+	name := strings.TrimSuffix(filepath.Base(req.Filename), filepath.Ext(req.Filename))
+	var artifactIDs []uuid.UUID
+	var failures []string
+	duplicates := 0
+
+	messages := 0
+	for {
+		message, err := mbox.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mbox file: %w", err)
+		}
+		messages++
+
+		filename := fmt.Sprintf("%s-%d.eml", name, messages)
+		artifactID, err := s.UploadArtifact(ctx, UploadRequest{
+			ProgramID:   req.ProgramID,
+			Filename:    filename,
+			MimeType:    "message/rfc822",
+			Data:        message,
+			UploadedBy:  req.UploadedBy,
+			ForceUpload: req.ForceUpload,
+		})
+		if err != nil {
+			var dupErr *DuplicateError
+			if errors.As(err, &dupErr) {
+				duplicates++
+				continue
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", filename, err))
+			continue
+		}
+
+		artifactIDs = append(artifactIDs, artifactID)
+	}
+
+	if messages == 0 {
+		return nil, &UnprocessableUploadError{Message: "no messages found in mbox file"}
+	}
+	if len(artifactIDs) == 0 {
+		if len(failures) > 0 {
+			return nil, fmt.Errorf("no messages could be uploaded from mbox: %s", strings.Join(failures, "; "))
+		}
+		return nil, &UnprocessableUploadError{Message: fmt.Sprintf("all %d messages in mbox were already uploaded", duplicates)}
+	}
+
+	return artifactIDs, nil
+}
+
+// UploadMboxStream uploads the messages of a mailbox export read from req.Body, as UploadMbox
+// does, without holding the export in memory. The export is stored while it is read, so that a
+// mailbox larger than the program's upload limit is refused with an UploadTooLargeError before
+// any message is uploaded; it is deleted again once split.
+func (s *Service) UploadMboxStream(ctx context.Context, req UploadRequest) ([]uuid.UUID, error) {
+	if req.Body == nil {
+		return nil, fmt.Errorf("file data is required")
+	}
+
+	limit := s.UploadLimit(ctx, req.ProgramID)
+	body := &uploadLimitReader{r: req.Body, remaining: limit}
+	fileInfo, err := s.storage.UploadStream(ctx, req.Filename, body, req.Size)
+	if body.exceeded {
+		if err == nil {
+			_ = s.storage.Delete(ctx, fileInfo.ID)
+		}
+		return nil, &UploadTooLargeError{LimitBytes: limit}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file to storage: %w", err)
+	}
+	defer s.storage.Delete(ctx, fileInfo.ID)
+
+	return s.uploadStoredMbox(ctx, req, fileInfo.ID)
+}
+
+// uploadStoredMbox uploads the messages of a mailbox export in storage, streaming it from there
+func (s *Service) uploadStoredMbox(ctx context.Context, req UploadRequest, fileID string) ([]uuid.UUID, error) {
+	reader, err := s.storage.DownloadStream(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer reader.Close()
+
+	req.Body = reader
+	req.Data = nil
+	return s.UploadMbox(ctx, req)
+}
+
+// UploadArtifact processes and stores a new artifact, extracting its content in the request.
+// It serves files that are already in memory, such as the members of ZIP archives, mailbox
+// messages and email attachments; uploads from clients go through UploadArtifactStream.
 func (s *Service) UploadArtifact(ctx context.Context, req UploadRequest) (uuid.UUID, error) {
 	// Validate request
 	if req.ProgramID == uuid.Nil {
@@ -225,21 +420,61 @@ func (s *Service) UploadArtifact(ctx context.Context, req UploadRequest) (uuid.U
 	contentHash := hex.EncodeToString(hasher.Sum(nil))
 
 	// Check for duplicates using smart deduplication
-	dupCheck, err := s.CheckDuplicate(ctx, req.ProgramID, contentHash)
+	if err := s.rejectDuplicate(ctx, req, contentHash); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Check if extractor is available for this MIME type
+	if !s.extractors.CanExtract(req.MimeType) {
+		return uuid.Nil, fmt.Errorf("unsupported file type: %s", req.MimeType)
+	}
+
+	// Upload file to storage
+	fileInfo, err := s.storage.Upload(ctx, req.Filename, req.Data)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to check duplicate: %w", err)
+		fmt.Printf("Storage upload failed: %v\n", err)
+		return uuid.Nil, fmt.Errorf("failed to upload file to storage: %w", err)
 	}
+	fmt.Printf("Storage upload successful: fileID=%s, size=%d\n", fileInfo.ID, fileInfo.Size)
 
-	if dupCheck.Exists && !dupCheck.AllowUpload && !req.ForceUpload {
-		return uuid.Nil, &DuplicateError{
-			ExistingArtifactID: dupCheck.ArtifactID,
-			Status:             dupCheck.Status,
-		}
+	artifact := newArtifact(req, fileInfo, contentHash)
+	artifact.FileType = s.inferFileType(req.MimeType, req.Filename)
+
+	// Extract text content and document structure
+	chunks, message, err := s.extractContent(ctx, artifact, req.Data)
+	if err != nil {
+		_ = s.storage.Delete(ctx, fileInfo.ID)
+		return uuid.Nil, err
 	}
 
-	// If duplicate exists and is allowed (failed/ocr_required/force), soft-delete the old one
-	if dupCheck.Exists && (dupCheck.AllowUpload || req.ForceUpload) {
-		_ = s.repo.Delete(ctx, dupCheck.ArtifactID)
+	if err := s.createArtifact(ctx, artifact, chunks, fileInfo.ID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if message != nil {
+		s.uploadAttachments(ctx, artifact, message.Attachments)
+	}
+
+	return artifact.ArtifactID, nil
+}
+
+// UploadArtifactStream stores an artifact read from req.Body without holding the file in
+// memory. The file is hashed as it is written to storage, and its content is extracted later
+// by the worker's extract stage (see ExtractDeferred). Returns an UploadTooLargeError if the
+// file is larger than the program's upload limit.
+func (s *Service) UploadArtifactStream(ctx context.Context, req UploadRequest) (uuid.UUID, error) {
+	// Validate request
+	if req.ProgramID == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("program_id is required")
+	}
+	if req.UploadedBy == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("uploaded_by is required")
+	}
+	if req.Filename == "" {
+		return uuid.Nil, fmt.Errorf("filename is required")
+	}
+	if req.Body == nil {
+		return uuid.Nil, fmt.Errorf("file data is required")
 	}
 
 	// Check if extractor is available for this MIME type
@@ -247,109 +482,322 @@ func (s *Service) UploadArtifact(ctx context.Context, req UploadRequest) (uuid.U
 		return uuid.Nil, fmt.Errorf("unsupported file type: %s", req.MimeType)
 	}
 
-	// Upload file to storage
-	fileInfo, err := s.storage.Upload(ctx, req.Filename, req.Data)
+	limit := s.UploadLimit(ctx, req.ProgramID)
+	if req.Size > limit {
+		return uuid.Nil, &UploadTooLargeError{LimitBytes: limit}
+	}
+
+	// Hash the file on its way to storage
+	hasher := sha256.New()
+	body := &uploadLimitReader{r: req.Body, remaining: limit}
+	fileInfo, err := s.storage.UploadStream(ctx, req.Filename, io.TeeReader(body, hasher), req.Size)
+	if body.exceeded {
+		if err == nil {
+			_ = s.storage.Delete(ctx, fileInfo.ID)
+		}
+		return uuid.Nil, &UploadTooLargeError{LimitBytes: limit}
+	}
 	if err != nil {
 		fmt.Printf("Storage upload failed: %v\n", err)
 		return uuid.Nil, fmt.Errorf("failed to upload file to storage: %w", err)
 	}
 	fmt.Printf("Storage upload successful: fileID=%s, size=%d\n", fileInfo.ID, fileInfo.Size)
 
-	// Extract text content
-	rawContent, err := s.extractors.Extract(ctx, req.MimeType, req.Data)
+	if fileInfo.Size == 0 {
+		_ = s.storage.Delete(ctx, fileInfo.ID)
+		return uuid.Nil, fmt.Errorf("file data is required")
+	}
+
+	// The hash is only known once the file is stored, so a duplicate is removed again
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+	if err := s.rejectDuplicate(ctx, req, contentHash); err != nil {
+		_ = s.storage.Delete(ctx, fileInfo.ID)
+		return uuid.Nil, err
+	}
+
+	artifact := newArtifact(req, fileInfo, contentHash)
+	artifact.FileType = s.inferFileType(req.MimeType, req.Filename)
+	artifact.ExtractionPending = true
+
+	if err := s.createArtifact(ctx, artifact, nil, fileInfo.ID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return artifact.ArtifactID, nil
+}
+
+// ExtractDeferred extracts the content of an artifact stored by UploadArtifactStream, and
+// uploads the attachments of emails as artifacts of their own. Artifacts without a text layer
+// are left in ocr_required status. Does nothing if the content was already extracted. Files
+// larger than the extraction limit are refused, since extractors hold the whole file in memory.
+func (s *Service) ExtractDeferred(ctx context.Context, artifact *Artifact) error {
+	if !artifact.ExtractionPending {
+		return nil
+	}
+
+	limit := s.maxExtractBytes
+	if artifact.FileSizeBytes > limit {
+		return fmt.Errorf("file of %d bytes exceeds the extraction limit of %d bytes", artifact.FileSizeBytes, limit)
+	}
+
+	reader, err := s.storage.DownloadStream(ctx, extractFileIDFromPath(artifact.StoragePath))
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return fmt.Errorf("file exceeds the extraction limit of %d bytes", limit)
+	}
+
+	chunks, message, err := s.extractContent(ctx, artifact, data)
+	if err != nil {
+		return err
+	}
+
+	// Attachments are uploaded before the extraction is saved, so none are lost if it is not:
+	// the next attempt uploads them again and skips those already stored as duplicates
+	if message != nil {
+		s.uploadAttachments(ctx, artifact, message.Attachments)
+	}
+
+	if err := s.repo.SaveExtraction(ctx, artifact, chunks); err != nil {
+		return err
+	}
+	artifact.ExtractionPending = false
+
+	return nil
+}
+
+// uploadLimitReader reads at most remaining bytes, failing once the stream goes past them
+type uploadLimitReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, fmt.Errorf("file exceeds the upload size limit")
+	}
+	return n, err
+}
+
+// UploadLimit returns the largest file a program may upload: its max_upload_bytes setting, or
+// the service default
+func (s *Service) UploadLimit(ctx context.Context, programID uuid.UUID) int64 {
+	if s.configs == nil {
+		return s.maxUploadBytes
+	}
+
+	config, err := s.configs.GetProgramConfig(ctx, programID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load upload limit of program %s, using the default: %v\n", programID, err)
+		return s.maxUploadBytes
+	}
+	if config.MaxUploadBytes > 0 {
+		return config.MaxUploadBytes
+	}
+	return s.maxUploadBytes
+}
+
+// rejectDuplicate returns a DuplicateError if the program already has an artifact with the
+// same content that may not be replaced. A copy that may be replaced is soft-deleted.
+func (s *Service) rejectDuplicate(ctx context.Context, req UploadRequest, contentHash string) error {
+	dupCheck, err := s.CheckDuplicate(ctx, req.ProgramID, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to check duplicate: %w", err)
+	}
+
+	if dupCheck.Exists && !dupCheck.AllowUpload && !req.ForceUpload {
+		return &DuplicateError{
+			ExistingArtifactID: dupCheck.ArtifactID,
+			Status:             dupCheck.Status,
+		}
+	}
+
+	// If duplicate exists and is allowed (failed/ocr_required/force), soft-delete the old one
+	if dupCheck.Exists && (dupCheck.AllowUpload || req.ForceUpload) {
+		_ = s.repo.Delete(ctx, dupCheck.ArtifactID)
+	}
+
+	return nil
+}
+
+// newArtifact builds the record of an uploaded file
+func newArtifact(req UploadRequest, fileInfo *storage.FileInfo, contentHash string) *Artifact {
+	return &Artifact{
+		ArtifactID:       uuid.New(),
+		ProgramID:        req.ProgramID,
+		Filename:         req.Filename,
+		StoragePath:      fileInfo.Path,
+		FileSizeBytes:    fileInfo.Size,
+		MimeType:         req.MimeType,
+		ContentHash:      contentHash,
+		ProcessingStatus: "pending",
+		UploadedBy:       req.UploadedBy,
+		UploadedAt:       time.Now(),
+		VersionNumber:    1,
+		ParentArtifactID: uuid.NullUUID{UUID: req.ParentArtifactID, Valid: req.ParentArtifactID != uuid.Nil},
+	}
+}
+
+// extractContent extracts an artifact's text content and document structure into the
+// artifact and returns its chunks. Files without a text layer (likely scanned PDFs) are marked
+// ocr_required. For emails it also records the headers, so they are threaded with the rest of
+// their conversation, and returns the parsed message.
+func (s *Service) extractContent(ctx context.Context, artifact *Artifact, data []byte) ([]Chunk, *extractors.EmailMessage, error) {
 	var chunks []Chunk
 
+	extraction, err := s.extractors.ExtractResult(ctx, artifact.MimeType, data)
 	if err != nil {
 		// Check if PDF is encrypted/password-protected - reject these files
 		if containsString(err.Error(), "encrypted PDF") || containsString(err.Error(), "invalid password") {
-			// Clean up uploaded file
-			_ = s.storage.Delete(ctx, fileInfo.ID)
-			return uuid.Nil, &EncryptedPDFError{
+			return nil, nil, &EncryptedPDFError{
 				Message: "This PDF is password-protected and cannot be processed. Please remove the password and upload again.",
 			}
 		}
 
 		// Check if this is a "no text content" error (likely scanned PDF)
-		if containsString(err.Error(), "no text content") {
-			// Allow upload but mark for manual review
-			rawContent = ""
-			chunks = []Chunk{} // No chunks for OCR-needed files
-			// Continue with artifact creation with empty content
-		} else {
+		if !containsString(err.Error(), "no text content") {
 			// Other extraction errors should fail
-			_ = s.storage.Delete(ctx, fileInfo.ID)
-			return uuid.Nil, fmt.Errorf("failed to extract content: %w", err)
+			return nil, nil, fmt.Errorf("failed to extract content: %w", err)
 		}
+
+		// Allow upload but mark for OCR; no chunks for OCR-needed files
+		artifact.RawContent = sql.NullString{}
+		artifact.ProcessingStatus = "ocr_required"
 	} else {
-		// Successfully extracted text - chunk it
-		chunks = s.chunker.ChunkDocument(rawContent)
+		// Successfully extracted text - chunk it, locating each chunk in the document
+		chunks = s.chunker.ChunkDocument(extraction.Content)
+		for i := range chunks {
+			chunks[i].Location = extraction.Span(chunks[i].StartOffset, chunks[i].EndOffset)
+		}
+
+		structure, err := json.Marshal(extraction)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode extraction result: %w", err)
+		}
+
+		artifact.RawContent = sql.NullString{String: extraction.Content, Valid: extraction.Content != ""}
+		artifact.Extraction = structure
+		artifact.ProcessingStatus = "pending"
+		if !artifact.RawContent.Valid {
+			artifact.ProcessingStatus = "ocr_required"
+		}
 	}
 
-	// Determine file type from MIME type or extension
-	fileType := s.inferFileType(req.MimeType, req.Filename)
+	// Record the headers of emails so they are threaded with the rest of their conversation
+	var message *extractors.EmailMessage
+	if artifact.FileType == "eml" {
+		if parsed, err := extractors.ParseEmail(ctx, data); err == nil {
+			message = parsed
+			artifact.Email = newArtifactEmail(artifact, message)
+		}
+	}
 
-	// Create artifact record
-	artifactID := uuid.New()
-	processingStatus := "pending"
-	hasContent := rawContent != ""
+	return chunks, message, nil
+}
 
-	// If no content extracted (scanned PDF), mark as needing OCR
-	if !hasContent {
-		processingStatus = "ocr_required"
+// createArtifact saves an artifact with its chunks and queues its upload event in the same
+// transaction. The stored file is deleted if the artifact cannot be saved.
+func (s *Service) createArtifact(ctx context.Context, artifact *Artifact, chunks []Chunk, fileID string) error {
+	if err := s.saveNewArtifact(ctx, artifact, chunks); err != nil {
+		_ = s.storage.Delete(ctx, fileID)
+		return err
 	}
+	return nil
+}
 
-	artifact := &Artifact{
-		ArtifactID:       artifactID,
-		ProgramID:        req.ProgramID,
-		Filename:         req.Filename,
-		StoragePath:      fileInfo.Path,
-		FileType:         fileType,
-		FileSizeBytes:    fileInfo.Size,
-		MimeType:         req.MimeType,
-		ContentHash:      contentHash,
-		RawContent:       sql.NullString{String: rawContent, Valid: hasContent},
-		ProcessingStatus: processingStatus,
-		UploadedBy:       req.UploadedBy,
-		UploadedAt:       time.Now(),
-		VersionNumber:    1,
+// saveNewArtifact saves an artifact with its chunks and queues its upload event in the same
+// transaction, leaving its stored file to the caller. Returns ErrDuplicateContent if the
+// program already has an artifact with the same content.
+func (s *Service) saveNewArtifact(ctx context.Context, artifact *Artifact, chunks []Chunk) error {
+	event, err := events.NewEventFromPayload(events.ArtifactUploaded, artifact.ProgramID, "artifacts", events.ArtifactUploadedPayload{
+		ArtifactID: artifact.ArtifactID,
+		Trigger:    events.UploadTriggerUpload,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Save artifact to database
-	err = s.repo.Create(ctx, artifact)
+	err = s.repo.CreateWithChunks(ctx, artifact, chunks, event)
 	if err != nil {
 		// Check for duplicate constraint violation
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" && strings.Contains(pqErr.Constraint, "content_hash_program_unique") {
-				// Clean up uploaded file on duplicate
-				_ = s.storage.Delete(ctx, fileInfo.ID)
-				return uuid.Nil, fmt.Errorf("duplicate artifact: file with same content already exists in this program")
+				return ErrDuplicateContent
 			}
 		}
-		// Clean up uploaded file on database error
-		_ = s.storage.Delete(ctx, fileInfo.ID)
-		return uuid.Nil, fmt.Errorf("failed to create artifact record: %w", err)
-	}
-
-	// Save chunks to database
-	chunkRecords := make([]Chunk, len(chunks))
-	for i, chunk := range chunks {
-		chunkRecords[i] = Chunk{
-			Index:       chunk.Index,
-			Text:        chunk.Text,
-			StartOffset: chunk.StartOffset,
-			EndOffset:   chunk.EndOffset,
-			TokenCount:  chunk.TokenCount,
-		}
+		return fmt.Errorf("failed to create artifact record: %w", err)
 	}
 
-	err = s.repo.SaveChunks(ctx, artifactID, chunkRecords)
-	if err != nil {
-		// Artifact is created but chunks failed - mark as failed
-		_ = s.repo.UpdateStatus(ctx, artifactID, "failed")
-		return uuid.Nil, fmt.Errorf("failed to save chunks: %w", err)
+	return nil
+}
+
+// newArtifactEmail collects the headers of an email artifact for threading
+func newArtifactEmail(artifact *Artifact, message *extractors.EmailMessage) *ArtifactEmail {
+	email := &ArtifactEmail{
+		ArtifactID: artifact.ArtifactID,
+		ProgramID:  artifact.ProgramID,
+		MessageID:  message.MessageID,
+		InReplyTo:  message.InReplyTo,
+		References: message.References,
+		From:       message.From,
+		To:         extractors.Addresses(message.To),
+		Cc:         extractors.Addresses(message.Cc),
+		Subject:    message.Subject,
+	}
+	if !message.Date.IsZero() {
+		email.SentAt = sql.NullTime{Time: message.Date, Valid: true}
 	}
+	return email
+}
+
+// uploadAttachments uploads each attachment of an email that can be extracted as an artifact
+// of its own, linked to the email. A failed attachment is logged rather than failing the email.
+func (s *Service) uploadAttachments(ctx context.Context, parent *Artifact, attachments []extractors.Attachment) {
+	for _, attachment := range attachments {
+		if len(attachment.Data) == 0 {
+			continue
+		}
 
-	return artifactID, nil
+		// Mail clients often send application/octet-stream, so fall back to the extension
+		mimeType := attachment.ContentType
+		if !s.extractors.CanExtract(mimeType) {
+			mimeType = getMimeTypeFromExtension(attachment.Filename)
+		}
+		if !s.extractors.CanExtract(mimeType) {
+			continue
+		}
+
+		_, err := s.UploadArtifact(ctx, UploadRequest{
+			ProgramID:        parent.ProgramID,
+			Filename:         filepath.Base(attachment.Filename),
+			MimeType:         mimeType,
+			Data:             attachment.Data,
+			UploadedBy:       parent.UploadedBy,
+			ParentArtifactID: parent.ArtifactID,
+		})
+		if err != nil {
+			// The same file is often attached to several messages of a thread; keep the first
+			var dupErr *DuplicateError
+			if errors.As(err, &dupErr) {
+				continue
+			}
+			fmt.Printf("Warning: failed to upload attachment %s of artifact %s: %v\n", attachment.Filename, parent.ArtifactID, err)
+		}
+	}
 }
 
 // GetArtifact retrieves an artifact by ID
@@ -366,6 +814,37 @@ func (s *Service) GetArtifact(ctx context.Context, artifactID uuid.UUID) (*Artif
 	return artifact, nil
 }
 
+// GetEmailThread retrieves the messages of the conversation an email artifact belongs to
+func (s *Service) GetEmailThread(ctx context.Context, artifactID uuid.UUID) ([]ArtifactEmail, error) {
+	if artifactID == uuid.Nil {
+		return nil, fmt.Errorf("artifact_id is required")
+	}
+
+	messages, err := s.repo.GetEmailThread(ctx, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email thread: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("artifact is not an email")
+	}
+
+	return messages, nil
+}
+
+// ListAttachments retrieves the artifacts created from an email artifact's attachments
+func (s *Service) ListAttachments(ctx context.Context, artifactID uuid.UUID) ([]Artifact, error) {
+	if artifactID == uuid.Nil {
+		return nil, fmt.Errorf("artifact_id is required")
+	}
+
+	attachments, err := s.repo.ListAttachments(ctx, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
 // GetArtifactWithMetadata retrieves an artifact with all its metadata
 func (s *Service) GetArtifactWithMetadata(ctx context.Context, artifactID uuid.UUID) (*ArtifactWithMetadata, error) {
 	if artifactID == uuid.Nil {
@@ -512,84 +991,94 @@ func (s *Service) DeleteArtifact(ctx context.Context, artifactID uuid.UUID) erro
 	return nil
 }
 
-// QueueForReanalysis resets an artifact for reprocessing
+// QueueForReanalysis resets an artifact for reprocessing and queues an upload event that
+// sends it through the pipeline again
 func (s *Service) QueueForReanalysis(ctx context.Context, artifactID uuid.UUID) error {
 	if artifactID == uuid.Nil {
 		return fmt.Errorf("artifact_id is required")
 	}
 
-	// Verify artifact exists
-	_, err := s.repo.GetByID(ctx, artifactID)
+	artifact, err := s.repo.GetByID(ctx, artifactID)
 	if err != nil {
 		return fmt.Errorf("failed to get artifact: %w", err)
 	}
 
-	// Clear existing metadata in a transaction-like manner
-	// Delete in reverse order of dependencies
-	err = s.clearArtifactMetadata(ctx, artifactID)
+	event, err := events.NewEventFromPayload(events.ArtifactUploaded, artifact.ProgramID, "artifacts", events.ArtifactUploadedPayload{
+		ArtifactID: artifactID,
+		Trigger:    events.UploadTriggerReanalysis,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to clear metadata: %w", err)
+		return fmt.Errorf("failed to create reanalysis event: %w", err)
 	}
 
-	// Reset status to pending
-	err = s.repo.UpdateStatus(ctx, artifactID, "pending")
-	if err != nil {
-		return fmt.Errorf("failed to update status: %w", err)
+	if err := s.repo.ResetForReanalysis(ctx, artifactID, event); err != nil {
+		return fmt.Errorf("failed to reset artifact: %w", err)
 	}
 
 	return nil
 }
 
-// clearArtifactMetadata removes all AI-generated metadata for an artifact
-func (s *Service) clearArtifactMetadata(ctx context.Context, artifactID uuid.UUID) error {
-	// Delete insights
-	_, err := s.db.ExecContext(ctx, `
-		DELETE FROM artifact_insights WHERE artifact_id = $1
-	`, artifactID)
-	if err != nil {
-		return fmt.Errorf("failed to delete insights: %w", err)
+// ListDeadLetters returns artifacts whose processing pipeline exhausted its retries, optionally
+// only those that failed with errorCode
+func (s *Service) ListDeadLetters(ctx context.Context, programID uuid.UUID, includeRequeued bool, errorCode string, limit, offset int) ([]DeadLetter, error) {
+	if programID == uuid.Nil {
+		return nil, fmt.Errorf("program_id is required")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
 	}
 
-	// Delete facts
-	_, err = s.db.ExecContext(ctx, `
-		DELETE FROM artifact_facts WHERE artifact_id = $1
-	`, artifactID)
+	return s.repo.ListDeadLetters(ctx, programID, includeRequeued, errorCode, limit, offset)
+}
+
+// RequeueDeadLetter queues a dead-lettered artifact to resume at the stage that failed
+func (s *Service) RequeueDeadLetter(ctx context.Context, programID, deadLetterID, requeuedBy uuid.UUID) (*DeadLetter, error) {
+	deadLetter, err := s.repo.GetDeadLetter(ctx, deadLetterID)
 	if err != nil {
-		return fmt.Errorf("failed to delete facts: %w", err)
+		return nil, err
 	}
 
-	// Delete persons
-	_, err = s.db.ExecContext(ctx, `
-		DELETE FROM artifact_persons WHERE artifact_id = $1
-	`, artifactID)
-	if err != nil {
-		return fmt.Errorf("failed to delete persons: %w", err)
+	// Dead letters are only visible within their own program
+	if deadLetter.ProgramID != programID {
+		return nil, fmt.Errorf("dead letter not found")
 	}
 
-	// Delete topics
-	_, err = s.db.ExecContext(ctx, `
-		DELETE FROM artifact_topics WHERE artifact_id = $1
-	`, artifactID)
+	// Wakes a worker immediately; the database poll picks the artifact up otherwise
+	event, err := events.NewEventFromPayload(events.ArtifactUploaded, programID, "artifacts", events.ArtifactUploadedPayload{
+		ArtifactID:   deadLetter.ArtifactID,
+		Trigger:      events.UploadTriggerRequeue,
+		DeadLetterID: deadLetterID,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete topics: %w", err)
+		return nil, fmt.Errorf("failed to create requeue event: %w", err)
 	}
 
-	// Delete summary
-	_, err = s.db.ExecContext(ctx, `
-		DELETE FROM artifact_summaries WHERE artifact_id = $1
-	`, artifactID)
-	if err != nil {
-		return fmt.Errorf("failed to delete summary: %w", err)
+	if err := s.repo.RequeueDeadLetter(ctx, deadLetterID, requeuedBy, event); err != nil {
+		return nil, err
 	}
 
-	// Delete embeddings
-	_, err = s.db.ExecContext(ctx, `
-		DELETE FROM artifact_embeddings WHERE artifact_id = $1
-	`, artifactID)
-	if err != nil {
-		return fmt.Errorf("failed to delete embeddings: %w", err)
+	return deadLetter, nil
+}
+
+// UpdateInsightFeedback records a user's rating, comment or dismissal of an insight
+func (s *Service) UpdateInsightFeedback(ctx context.Context, programID, artifactID, insightID, userID uuid.UUID, req *InsightFeedbackRequest) error {
+	if req.Rating == nil && req.Feedback == nil && req.Dismissed == nil {
+		return fmt.Errorf("invalid feedback: provide a rating, feedback or dismissed")
+	}
+	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
+		return fmt.Errorf("invalid feedback: rating must be between 1 and 5")
 	}
 
+	found, err := s.repo.UpdateInsightFeedback(ctx, programID, artifactID, insightID, userID, req)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("insight not found")
+	}
 	return nil
 }
 
@@ -649,6 +1138,12 @@ func (s *Service) inferFileType(mimeType, filename string) string {
 		return "markdown"
 	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
 		return "docx"
+	case "application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		return "pptx"
+	case "application/vnd.oasis.opendocument.text":
+		return "odt"
+	case "application/vnd.oasis.opendocument.presentation":
+		return "odp"
 	case "application/msword":
 		return "doc"
 	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
@@ -667,6 +1162,8 @@ func (s *Service) inferFileType(mimeType, filename string) string {
 		return "zip"
 	case "message/rfc822", "application/vnd.ms-outlook", "message/x-emlx":
 		return "eml"
+	case "application/mbox":
+		return "mbox"
 	}
 
 	// Fall back to file extension
@@ -711,8 +1208,14 @@ func getMimeTypeFromExtension(filename string) string {
 		".xls":  "application/vnd.ms-excel",
 		".doc":  "application/msword",
 		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".docm": "application/vnd.ms-word.document.macroenabled.12",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".pptm": "application/vnd.ms-powerpoint.presentation.macroenabled.12",
+		".odt":  "application/vnd.oasis.opendocument.text",
+		".odp":  "application/vnd.oasis.opendocument.presentation",
 		".eml":  "message/rfc822",
 		".msg":  "application/vnd.ms-outlook",
+		".mbox": "application/mbox",
 		".zip":  "application/zip",
 	}
 
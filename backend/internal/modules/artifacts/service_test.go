@@ -2,11 +2,19 @@ package artifacts
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -34,6 +42,14 @@ func (m *mockStorage) Upload(ctx context.Context, filename string, data []byte)
 	}, nil
 }
 
+func (m *mockStorage) UploadStream(ctx context.Context, filename string, r io.Reader, size int64) (*storage.FileInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return m.Upload(ctx, filename, data)
+}
+
 func (m *mockStorage) Delete(ctx context.Context, fileID string) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(ctx, fileID)
@@ -48,6 +64,14 @@ func (m *mockStorage) Download(ctx context.Context, fileID string) ([]byte, erro
 	return []byte("mock data"), nil
 }
 
+func (m *mockStorage) DownloadStream(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	data, err := m.Download(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(string(data))), nil
+}
+
 func (m *mockStorage) GetInfo(ctx context.Context, fileID string) (*storage.FileInfo, error) {
 	if m.getInfoFunc != nil {
 		return m.getInfoFunc(ctx, fileID)
@@ -62,7 +86,9 @@ func (m *mockStorage) GetInfo(ctx context.Context, fileID string) (*storage.File
 }
 
 // Mock repository implementation
+// Methods not overridden below fall through to the embedded (nil) interface
 type mockRepository struct {
+	RepositoryInterface
 	createFunc        func(ctx context.Context, artifact *Artifact) error
 	getByIDFunc       func(ctx context.Context, artifactID uuid.UUID) (*Artifact, error)
 	listByProgramFunc func(ctx context.Context, programID uuid.UUID, limit, offset int) ([]Artifact, error)
@@ -70,6 +96,7 @@ type mockRepository struct {
 	updateStatusFunc  func(ctx context.Context, artifactID uuid.UUID, status string) error
 	saveChunksFunc    func(ctx context.Context, artifactID uuid.UUID, chunks []Chunk) error
 	getMetadataFunc   func(ctx context.Context, artifactID uuid.UUID) (*ArtifactWithMetadata, error)
+	outboxEvents      []*events.Event // Events written alongside the artifact by CreateWithChunks and ResetForReanalysis
 	db                interface{ ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) }
 }
 
@@ -80,6 +107,25 @@ func (m *mockRepository) Create(ctx context.Context, artifact *Artifact) error {
 	return nil
 }
 
+func (m *mockRepository) CreateWithChunks(ctx context.Context, artifact *Artifact, chunks []Chunk, outboxEvents ...*events.Event) error {
+	if err := m.Create(ctx, artifact); err != nil {
+		return err
+	}
+	if err := m.SaveChunks(ctx, artifact.ArtifactID, chunks); err != nil {
+		return err
+	}
+	m.outboxEvents = append(m.outboxEvents, outboxEvents...)
+	return nil
+}
+
+func (m *mockRepository) ResetForReanalysis(ctx context.Context, artifactID uuid.UUID, outboxEvents ...*events.Event) error {
+	if err := m.UpdateStatus(ctx, artifactID, "pending"); err != nil {
+		return err
+	}
+	m.outboxEvents = append(m.outboxEvents, outboxEvents...)
+	return nil
+}
+
 func (m *mockRepository) GetByID(ctx context.Context, artifactID uuid.UUID) (*Artifact, error) {
 	if m.getByIDFunc != nil {
 		return m.getByIDFunc(ctx, artifactID)
@@ -131,7 +177,7 @@ func (m *mockRepository) GetMetadata(ctx context.Context, artifactID uuid.UUID)
 	}, nil
 }
 
-// Mock DB executor for clearMetadata
+// Mock DB executor for queries the service runs directly
 type mockDBExecutor struct {
 	execFunc  func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	queryFunc func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
@@ -148,10 +194,40 @@ func (m *mockDBExecutor) QueryContext(ctx context.Context, query string, args ..
 	if m.queryFunc != nil {
 		return m.queryFunc(ctx, query, args...)
 	}
-	// Return nil rows (tests that use this should provide their own implementation)
-	return nil, sql.ErrNoRows
+	// Default to an empty result set (no duplicate found)
+	return emptyRowsDB.QueryContext(ctx, query)
 }
 
+// emptyRowsDB is a database handle whose queries always return zero rows
+var emptyRowsDB = func() *sql.DB {
+	sql.Register("artifacts_empty_rows", emptyRowsDriver{})
+	db, _ := sql.Open("artifacts_empty_rows", "")
+	return db
+}()
+
+type emptyRowsDriver struct{}
+
+func (emptyRowsDriver) Open(name string) (driver.Conn, error) { return emptyRowsConn{}, nil }
+
+type emptyRowsConn struct{}
+
+func (emptyRowsConn) Prepare(query string) (driver.Stmt, error) { return emptyRowsStmt{}, nil }
+func (emptyRowsConn) Close() error                              { return nil }
+func (emptyRowsConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type emptyRowsStmt struct{}
+
+func (emptyRowsStmt) Close() error                                    { return nil }
+func (emptyRowsStmt) NumInput() int                                   { return -1 }
+func (emptyRowsStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (emptyRowsStmt) Query(args []driver.Value) (driver.Rows, error)  { return emptyRows{}, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
 type mockResult struct {
 	rowsAffected int64
 	err          error
@@ -192,6 +268,44 @@ func TestUploadArtifact_Success(t *testing.T) {
 	}
 }
 
+// Test UploadArtifact - Upload event is written with the artifact
+func TestUploadArtifact_WritesUploadEventToOutbox(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &mockRepository{}
+	mockStore := &mockStorage{}
+
+	mockDB := &mockDBExecutor{}
+	service := NewServiceWithMocks(mockRepo, mockDB, mockStore)
+
+	programID := uuid.New()
+	req := UploadRequest{
+		ProgramID:  programID,
+		Filename:   "test.txt",
+		MimeType:   "text/plain",
+		Data:       []byte("This is test content for the artifact upload."),
+		UploadedBy: uuid.New(),
+	}
+
+	artifactID, err := service.UploadArtifact(ctx, req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(mockRepo.outboxEvents) != 1 {
+		t.Fatalf("expected 1 outbox event, got %d", len(mockRepo.outboxEvents))
+	}
+	event := mockRepo.outboxEvents[0]
+	if event.Type != events.ArtifactUploaded {
+		t.Fatalf("expected %s event, got %s", events.ArtifactUploaded, event.Type)
+	}
+	if event.ProgramID != programID {
+		t.Fatalf("expected program %s, got %s", programID, event.ProgramID)
+	}
+	if event.Payload["artifact_id"] != artifactID.String() {
+		t.Fatalf("expected artifact_id %s, got %v", artifactID, event.Payload["artifact_id"])
+	}
+}
+
 // Test UploadArtifact - Missing ProgramID
 func TestUploadArtifact_MissingProgramID(t *testing.T) {
 	ctx := context.Background()
@@ -353,6 +467,532 @@ func TestUploadArtifact_StorageUploadFailure(t *testing.T) {
 	}
 }
 
+// fakeConfigSource serves one program configuration to every program
+type fakeConfigSource struct {
+	config *programs.ProgramConfig
+}
+
+func (f *fakeConfigSource) GetProgramConfig(ctx context.Context, programID uuid.UUID) (*programs.ProgramConfig, error) {
+	return f.config, nil
+}
+
+// Test UploadArtifactStream - The file is stored and hashed, and extraction is left to the worker
+func TestUploadArtifactStream_DefersExtraction(t *testing.T) {
+	ctx := context.Background()
+	var created *Artifact
+	mockRepo := &mockRepository{
+		createFunc: func(ctx context.Context, artifact *Artifact) error {
+			created = artifact
+			return nil
+		},
+	}
+	service := NewServiceWithMocks(mockRepo, &mockDBExecutor{}, &mockStorage{})
+
+	content := "This is test content for a streamed upload."
+	artifactID, err := service.UploadArtifactStream(ctx, UploadRequest{
+		ProgramID:  uuid.New(),
+		Filename:   "test.txt",
+		MimeType:   "text/plain",
+		Body:       strings.NewReader(content),
+		Size:       -1,
+		UploadedBy: uuid.New(),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if created == nil || created.ArtifactID != artifactID {
+		t.Fatal("expected the artifact to be created")
+	}
+	if !created.ExtractionPending || created.RawContent.Valid {
+		t.Errorf("expected extraction to be deferred, got pending=%v content=%q", created.ExtractionPending, created.RawContent.String)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if created.ContentHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected content hash %s", created.ContentHash)
+	}
+	if len(mockRepo.outboxEvents) != 1 {
+		t.Errorf("expected 1 outbox event, got %d", len(mockRepo.outboxEvents))
+	}
+}
+
+// Test UploadArtifactStream - Files over the program's limit are refused
+func TestUploadArtifactStream_SizeLimit(t *testing.T) {
+	ctx := context.Background()
+	service := NewServiceWithMocks(&mockRepository{}, &mockDBExecutor{}, &mockStorage{})
+	service.SetMaxUploadBytes(10)
+
+	req := UploadRequest{
+		ProgramID:  uuid.New(),
+		Filename:   "test.txt",
+		MimeType:   "text/plain",
+		Size:       -1,
+		UploadedBy: uuid.New(),
+	}
+
+	req.Body = strings.NewReader("more than ten bytes")
+	_, err := service.UploadArtifactStream(ctx, req)
+	var tooLarge *UploadTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.LimitBytes != 10 {
+		t.Fatalf("expected an UploadTooLargeError with a 10 byte limit, got: %v", err)
+	}
+
+	// A program may raise its own limit
+	service.SetProgramConfigs(&fakeConfigSource{config: &programs.ProgramConfig{MaxUploadBytes: 100}})
+	req.Body = strings.NewReader("more than ten bytes")
+	if _, err := service.UploadArtifactStream(ctx, req); err != nil {
+		t.Fatalf("expected the program limit to apply, got: %v", err)
+	}
+}
+
+// Test UploadMboxStream - A mailbox is split into a message per artifact as it is read back
+// from storage, and mailboxes over the program's limit are refused before any message is uploaded
+func TestUploadMboxStream_SplitsMessages(t *testing.T) {
+	ctx := context.Background()
+	var created []*Artifact
+	mockRepo := &mockRepository{
+		createFunc: func(ctx context.Context, artifact *Artifact) error {
+			created = append(created, artifact)
+			return nil
+		},
+	}
+	stored := make(map[string][]byte)
+	store := &mockStorage{
+		uploadFunc: func(ctx context.Context, filename string, data []byte) (*storage.FileInfo, error) {
+			id := uuid.New().String()
+			stored[id] = data
+			return &storage.FileInfo{ID: id, Filename: filename, Size: int64(len(data)), Path: "artifacts/" + id}, nil
+		},
+		downloadFunc: func(ctx context.Context, fileID string) ([]byte, error) {
+			return stored[fileID], nil
+		},
+		deleteFunc: func(ctx context.Context, fileID string) error {
+			delete(stored, fileID)
+			return nil
+		},
+	}
+	service := NewServiceWithMocks(mockRepo, &mockDBExecutor{}, store)
+
+	mbox := "From maria@acme.example Tue Mar  4 09:15:00 2025\n" +
+		"Subject: First\n" +
+		"\n" +
+		"Freeze on Friday.\n" +
+		"\n" +
+		"From dev@acme.example Tue Mar  4 10:00:00 2025\n" +
+		"Subject: Second\n" +
+		"\n" +
+		"Cut over on Saturday.\n"
+	req := UploadRequest{
+		ProgramID:  uuid.New(),
+		Filename:   "ops.mbox",
+		MimeType:   "application/mbox",
+		Size:       -1,
+		UploadedBy: uuid.New(),
+	}
+
+	service.SetMaxUploadBytes(10)
+	req.Body = strings.NewReader(mbox)
+	_, err := service.UploadMboxStream(ctx, req)
+	var tooLarge *UploadTooLargeError
+	if !errors.As(err, &tooLarge) || len(created) != 0 {
+		t.Fatalf("expected an UploadTooLargeError before any message, got %v and %d artifacts", err, len(created))
+	}
+
+	service.SetMaxUploadBytes(DefaultMaxUploadBytes)
+	req.Body = strings.NewReader(mbox)
+	artifactIDs, err := service.UploadMboxStream(ctx, req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(artifactIDs) != 2 || len(created) != 2 {
+		t.Fatalf("expected 2 message artifacts, got %d", len(artifactIDs))
+	}
+	if created[0].Filename != "ops-1.eml" || created[1].Filename != "ops-2.eml" {
+		t.Errorf("unexpected message filenames %q, %q", created[0].Filename, created[1].Filename)
+	}
+
+	// Only the messages are kept, not the export
+	if len(stored) != 2 {
+		t.Errorf("expected only the 2 messages in storage, got %d files", len(stored))
+	}
+}
+
+// extractionRepository records deferred extractions, failing them with saveErr
+type extractionRepository struct {
+	*mockRepository
+	saveErr error
+	saved   int
+}
+
+func (m *extractionRepository) SaveExtraction(ctx context.Context, artifact *Artifact, chunks []Chunk) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.saved++
+	return nil
+}
+
+// Test ExtractDeferred - An email's attachments are uploaded before its extraction is saved, so
+// a failed save loses none of them
+func TestExtractDeferred_UploadsAttachmentsFirst(t *testing.T) {
+	ctx := context.Background()
+	email := "From: maria@acme.example\r\n" +
+		"Subject: Cutover plan\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Updated plan attached.\r\n" +
+		"--outer\r\n" +
+		"Content-Type: text/plain; name=\"plan.txt\"\r\n" +
+		"Content-Disposition: attachment; filename=\"plan.txt\"\r\n" +
+		"\r\n" +
+		"Freeze on Friday, cut over on Saturday.\r\n" +
+		"--outer--\r\n"
+
+	var children []*Artifact
+	repo := &extractionRepository{
+		mockRepository: &mockRepository{
+			createFunc: func(ctx context.Context, artifact *Artifact) error {
+				children = append(children, artifact)
+				return nil
+			},
+		},
+		saveErr: errors.New("connection reset"),
+	}
+	store := &mockStorage{
+		downloadFunc: func(ctx context.Context, fileID string) ([]byte, error) {
+			return []byte(email), nil
+		},
+	}
+	service := NewServiceWithMocks(repo, &mockDBExecutor{}, store)
+
+	artifact := &Artifact{
+		ArtifactID:        uuid.New(),
+		ProgramID:         uuid.New(),
+		Filename:          "plan.eml",
+		StoragePath:       "artifacts/" + uuid.New().String(),
+		FileType:          "eml",
+		FileSizeBytes:     int64(len(email)),
+		MimeType:          "message/rfc822",
+		UploadedBy:        uuid.New(),
+		ExtractionPending: true,
+	}
+
+	if err := service.ExtractDeferred(ctx, artifact); err == nil {
+		t.Fatal("expected the failed save to be reported")
+	}
+	if !artifact.ExtractionPending {
+		t.Error("expected the artifact to stay pending extraction")
+	}
+	if len(children) != 1 || children[0].ParentArtifactID.UUID != artifact.ArtifactID {
+		t.Fatalf("expected the attachment to be uploaded as a child, got %d artifacts", len(children))
+	}
+
+	repo.saveErr = nil
+	if err := service.ExtractDeferred(ctx, artifact); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if repo.saved != 1 || artifact.ExtractionPending {
+		t.Errorf("expected the extraction to be saved, saved %d times", repo.saved)
+	}
+}
+
+// Test ExtractDeferred - Files over the extraction limit are refused without being downloaded
+func TestExtractDeferred_ExtractionLimit(t *testing.T) {
+	ctx := context.Background()
+	downloaded := false
+	store := &mockStorage{
+		downloadFunc: func(ctx context.Context, fileID string) ([]byte, error) {
+			downloaded = true
+			return []byte("more than ten bytes"), nil
+		},
+	}
+	service := NewServiceWithMocks(&extractionRepository{mockRepository: &mockRepository{}}, &mockDBExecutor{}, store)
+	service.SetMaxExtractBytes(10)
+
+	artifact := &Artifact{
+		ArtifactID:        uuid.New(),
+		StoragePath:       "artifacts/" + uuid.New().String(),
+		FileType:          "txt",
+		FileSizeBytes:     19,
+		MimeType:          "text/plain",
+		ExtractionPending: true,
+	}
+	if err := service.ExtractDeferred(ctx, artifact); err == nil || downloaded {
+		t.Fatalf("expected the file to be refused before download, got: %v", err)
+	}
+
+	// A stored file larger than its recorded size is cut off while reading
+	artifact.FileSizeBytes = 5
+	if err := service.ExtractDeferred(ctx, artifact); err == nil || !artifact.ExtractionPending {
+		t.Fatalf("expected the file to be refused while reading, got: %v", err)
+	}
+}
+
+// mockMultipartStorage keeps the parts of one multipart upload in memory
+type mockMultipartStorage struct {
+	*mockStorage
+	parts map[int][]byte
+	file  []byte
+}
+
+func (m *mockMultipartStorage) CreateMultipartUpload(ctx context.Context, filename string) (string, string, error) {
+	m.parts = make(map[int][]byte)
+	return uuid.New().String(), "upload-1", nil
+}
+
+func (m *mockMultipartStorage) UploadPart(ctx context.Context, fileID, uploadID string, partNumber int, r io.Reader, size int64) (*storage.Part, error) {
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	m.parts[partNumber] = data
+	return &storage.Part{Number: partNumber, Size: int64(len(data)), ETag: fmt.Sprintf("etag-%d", partNumber)}, nil
+}
+
+func (m *mockMultipartStorage) PresignPart(ctx context.Context, fileID, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://storage.example/%s?partNumber=%d", fileID, partNumber), nil
+}
+
+func (m *mockMultipartStorage) ListParts(ctx context.Context, fileID, uploadID string) ([]storage.Part, error) {
+	parts := make([]storage.Part, 0, len(m.parts))
+	for n := 1; n <= len(m.parts)+1; n++ {
+		if data, ok := m.parts[n]; ok {
+			parts = append(parts, storage.Part{Number: n, Size: int64(len(data))})
+		}
+	}
+	return parts, nil
+}
+
+func (m *mockMultipartStorage) CompleteMultipartUpload(ctx context.Context, fileID, uploadID string, parts []storage.Part) (*storage.FileInfo, error) {
+	for _, part := range parts {
+		m.file = append(m.file, m.parts[part.Number]...)
+	}
+	return &storage.FileInfo{ID: fileID, Path: "artifacts/" + fileID, Size: int64(len(m.file))}, nil
+}
+
+func (m *mockMultipartStorage) AbortMultipartUpload(ctx context.Context, fileID, uploadID string) error {
+	m.parts = nil
+	return nil
+}
+
+func (m *mockMultipartStorage) Download(ctx context.Context, fileID string) ([]byte, error) {
+	return m.file, nil
+}
+
+func (m *mockMultipartStorage) DownloadStream(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(m.file))), nil
+}
+
+func (m *mockMultipartStorage) GetInfo(ctx context.Context, fileID string) (*storage.FileInfo, error) {
+	return &storage.FileInfo{ID: fileID, Path: "artifacts/" + fileID, Size: int64(len(m.file))}, nil
+}
+
+// uploadSessionRepository keeps upload sessions in memory
+type uploadSessionRepository struct {
+	*mockRepository
+	sessions map[uuid.UUID]*UploadSession
+}
+
+func (m *uploadSessionRepository) CreateUploadSession(ctx context.Context, session *UploadSession) error {
+	stored := *session
+	m.sessions[session.SessionID] = &stored
+	return nil
+}
+
+func (m *uploadSessionRepository) GetUploadSession(ctx context.Context, sessionID uuid.UUID) (*UploadSession, error) {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, ErrUploadSessionNotFound
+	}
+	loaded := *session
+	return &loaded, nil
+}
+
+func (m *uploadSessionRepository) TransitionUploadSession(ctx context.Context, sessionID uuid.UUID, from, to string) (bool, error) {
+	session := m.sessions[sessionID]
+	if session.Status != from {
+		return false, nil
+	}
+	session.Status = to
+	return true, nil
+}
+
+func (m *uploadSessionRepository) CompleteUploadSession(ctx context.Context, sessionID uuid.UUID, artifactIDs []uuid.UUID) error {
+	m.sessions[sessionID].Status = UploadSessionCompleted
+	m.sessions[sessionID].ArtifactIDs = artifactIDs
+	return nil
+}
+
+// Test upload sessions - An interrupted upload resumes with its missing parts, and completing it
+// creates the artifact and its upload event once
+func TestUploadSession_ResumeAndComplete(t *testing.T) {
+	ctx := context.Background()
+	var created *Artifact
+	repo := &uploadSessionRepository{
+		mockRepository: &mockRepository{
+			createFunc: func(ctx context.Context, artifact *Artifact) error {
+				created = artifact
+				return nil
+			},
+		},
+		sessions: make(map[uuid.UUID]*UploadSession),
+	}
+	store := &mockMultipartStorage{mockStorage: &mockStorage{}}
+	service := NewServiceWithMocks(repo, &mockDBExecutor{}, store)
+
+	content := strings.Repeat("a", int(uploadPartSize)) + "the last part"
+	programID := uuid.New()
+	progress, err := service.CreateUploadSession(ctx, CreateUploadSessionRequest{
+		ProgramID:     programID,
+		UploadedBy:    uuid.New(),
+		Filename:      "plan.txt",
+		FileSizeBytes: int64(len(content)),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if progress.PartCount != 2 || progress.MimeType != "text/plain" {
+		t.Fatalf("expected 2 parts of a text file, got %d parts of %s", progress.PartCount, progress.MimeType)
+	}
+	sessionID := progress.SessionID
+
+	// The connection drops after the last part; completing reports the missing first part
+	last := content[uploadPartSize:]
+	if _, err := service.UploadSessionPart(ctx, programID, sessionID, 2, strings.NewReader(last), int64(len(last))); err != nil {
+		t.Fatalf("failed to upload part 2: %v", err)
+	}
+	_, err = service.CompleteUploadSession(ctx, programID, sessionID)
+	var incomplete *IncompleteUploadError
+	if !errors.As(err, &incomplete) || len(incomplete.MissingParts) != 1 || incomplete.MissingParts[0] != 1 {
+		t.Fatalf("expected part 1 to be missing, got: %v", err)
+	}
+
+	progress, err = service.GetUploadSession(ctx, programID, sessionID, true)
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if len(progress.MissingParts) != 1 || progress.PartURLs[1] == "" {
+		t.Fatalf("expected a presigned URL for the missing part, got %v", progress.PartURLs)
+	}
+
+	first := content[:uploadPartSize]
+	if _, err := service.UploadSessionPart(ctx, programID, sessionID, 1, strings.NewReader(first[:10]), 10); err == nil {
+		t.Error("expected a part of the wrong length to be refused")
+	}
+	if _, err := service.UploadSessionPart(ctx, programID, sessionID, 1, strings.NewReader(first), int64(len(first))); err != nil {
+		t.Fatalf("failed to upload part 1: %v", err)
+	}
+
+	artifactIDs, err := service.CompleteUploadSession(ctx, programID, sessionID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if created == nil || len(artifactIDs) != 1 || artifactIDs[0] != created.ArtifactID {
+		t.Fatal("expected the artifact to be created")
+	}
+	sum := sha256.Sum256([]byte(content))
+	if created.ContentHash != hex.EncodeToString(sum[:]) || !created.ExtractionPending {
+		t.Errorf("unexpected artifact: hash %s, extraction pending %v", created.ContentHash, created.ExtractionPending)
+	}
+
+	// A retried completion returns the same artifact without creating another
+	again, err := service.CompleteUploadSession(ctx, programID, sessionID)
+	if err != nil || len(again) != 1 || again[0] != created.ArtifactID {
+		t.Errorf("expected the completed artifact again, got %v, %v", again, err)
+	}
+	if len(repo.outboxEvents) != 1 {
+		t.Errorf("expected 1 outbox event, got %d", len(repo.outboxEvents))
+	}
+
+	// Sessions belong to their program
+	if _, err := service.GetUploadSession(ctx, uuid.New(), sessionID, false); !errors.Is(err, ErrUploadSessionNotFound) {
+		t.Errorf("expected the session to be hidden from other programs, got: %v", err)
+	}
+}
+
+// Test upload sessions - A completion that fails keeps the joined file for a retry, unless the
+// file itself is rejected
+func TestUploadSession_CompletionFailures(t *testing.T) {
+	ctx := context.Background()
+	programID := uuid.New()
+
+	tests := []struct {
+		name       string
+		createErr  error
+		wantStatus string
+	}{
+		{"database unavailable", errors.New("connection reset"), UploadSessionAssembled},
+		{"duplicate content", &pq.Error{Code: "23505", Constraint: "artifacts_content_hash_program_unique"}, UploadSessionAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createErr := tt.createErr
+			repo := &uploadSessionRepository{
+				mockRepository: &mockRepository{
+					createFunc: func(ctx context.Context, artifact *Artifact) error {
+						return createErr
+					},
+				},
+				sessions: make(map[uuid.UUID]*UploadSession),
+			}
+			var deleted []string
+			store := &mockMultipartStorage{mockStorage: &mockStorage{
+				deleteFunc: func(ctx context.Context, fileID string) error {
+					deleted = append(deleted, fileID)
+					return nil
+				},
+			}}
+			service := NewServiceWithMocks(repo, &mockDBExecutor{}, store)
+
+			content := "meeting notes"
+			progress, err := service.CreateUploadSession(ctx, CreateUploadSessionRequest{
+				ProgramID:     programID,
+				UploadedBy:    uuid.New(),
+				Filename:      "notes.txt",
+				FileSizeBytes: int64(len(content)),
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			sessionID := progress.SessionID
+			if _, err := service.UploadSessionPart(ctx, programID, sessionID, 1, strings.NewReader(content), int64(len(content))); err != nil {
+				t.Fatalf("failed to upload part 1: %v", err)
+			}
+
+			if _, err := service.CompleteUploadSession(ctx, programID, sessionID); err == nil {
+				t.Fatal("expected the completion to fail")
+			}
+			if status := repo.sessions[sessionID].Status; status != tt.wantStatus {
+				t.Fatalf("expected the session to be %s, got %s", tt.wantStatus, status)
+			}
+			if kept := len(deleted) == 0; kept != (tt.wantStatus == UploadSessionAssembled) {
+				t.Fatalf("expected the file to be kept only for a retry, deleted %v", deleted)
+			}
+			if tt.wantStatus != UploadSessionAssembled {
+				return
+			}
+
+			// The retried completion carries on from the joined file
+			createErr = nil
+			artifactIDs, err := service.CompleteUploadSession(ctx, programID, sessionID)
+			if err != nil || len(artifactIDs) != 1 {
+				t.Fatalf("expected the retry to create the artifact, got %v, %v", artifactIDs, err)
+			}
+			if status := repo.sessions[sessionID].Status; status != UploadSessionCompleted {
+				t.Errorf("expected the session to be completed, got %s", status)
+			}
+			if len(deleted) != 0 {
+				t.Errorf("expected the file to be kept as the artifact's, deleted %v", deleted)
+			}
+		})
+	}
+}
+
 // Test GetArtifact - Success
 func TestGetArtifact_Success(t *testing.T) {
 	ctx := context.Background()
@@ -563,6 +1203,13 @@ func TestQueueForReanalysis_Success(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
+
+	if len(mockRepo.outboxEvents) != 1 {
+		t.Fatalf("expected 1 outbox event, got %d", len(mockRepo.outboxEvents))
+	}
+	if mockRepo.outboxEvents[0].Type != events.ArtifactUploaded {
+		t.Errorf("expected %s event, got %s", events.ArtifactUploaded, mockRepo.outboxEvents[0].Type)
+	}
 }
 
 // Test QueueForReanalysis - Invalid ID
@@ -0,0 +1,491 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/storage"
+	"github.com/google/uuid"
+)
+
+// This file contains resumable uploads. A client starts an upload session, sends the file in
+// numbered parts, through the API or to presigned storage URLs, and completes the session once
+// every part is stored. A part that fails is sent again; after a dropped connection the session
+// lists the parts still missing. Completing the session creates the artifact as a direct upload
+// does, publishing artifact.uploaded through the outbox.
+
+const (
+	// uploadPartSize is the part size of upload sessions, raised for files that would otherwise
+	// need more than maxUploadParts parts
+	uploadPartSize int64 = 8 << 20
+	maxUploadParts       = 10000
+
+	// uploadSessionTTL is how long a session takes parts before it expires and is discarded
+	uploadSessionTTL = 24 * time.Hour
+
+	// presignedPartTTL is how long a presigned part URL is valid; clients ask for new URLs by
+	// fetching the session
+	presignedPartTTL = time.Hour
+
+	// expiredSessionBatch is the most expired sessions discarded per cleanup run
+	expiredSessionBatch = 100
+)
+
+// CreateUploadSession starts a resumable upload of a file of req.FileSizeBytes bytes. Returns
+// an UploadTooLargeError if the file is larger than the program's upload limit.
+func (s *Service) CreateUploadSession(ctx context.Context, req CreateUploadSessionRequest) (*UploadSessionProgress, error) {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if req.ProgramID == uuid.Nil {
+		return nil, fmt.Errorf("program_id is required")
+	}
+	if req.UploadedBy == uuid.Nil {
+		return nil, fmt.Errorf("uploaded_by is required")
+	}
+	if req.Filename == "" {
+		return nil, fmt.Errorf("filename is required")
+	}
+	if req.FileSizeBytes <= 0 {
+		return nil, fmt.Errorf("file_size_bytes is required")
+	}
+
+	if req.MimeType == "" || req.MimeType == "application/octet-stream" {
+		req.MimeType = getMimeTypeFromExtension(req.Filename)
+	}
+	if isMboxUpload(req.MimeType, req.Filename) {
+		req.MimeType = "application/mbox"
+	}
+	if !s.extractors.CanExtract(req.MimeType) {
+		return nil, fmt.Errorf("unsupported file type: %s", req.MimeType)
+	}
+
+	limit := s.UploadLimit(ctx, req.ProgramID)
+	if req.FileSizeBytes > limit {
+		return nil, &UploadTooLargeError{LimitBytes: limit}
+	}
+
+	partSize := uploadPartSize
+	if minPartSize := (req.FileSizeBytes + maxUploadParts - 1) / maxUploadParts; minPartSize > partSize {
+		partSize = minPartSize
+	}
+
+	fileID, storageUploadID, err := multipart.CreateMultipartUpload(ctx, req.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload: %w", err)
+	}
+
+	session := &UploadSession{
+		SessionID:       uuid.New(),
+		ProgramID:       req.ProgramID,
+		UploadedBy:      req.UploadedBy,
+		Filename:        req.Filename,
+		MimeType:        req.MimeType,
+		FileSizeBytes:   req.FileSizeBytes,
+		PartSizeBytes:   partSize,
+		PartCount:       partCount(req.FileSizeBytes, partSize),
+		ForceUpload:     req.ForceUpload,
+		FileID:          fileID,
+		StorageUploadID: storageUploadID,
+		Status:          UploadSessionActive,
+		ArtifactIDs:     []uuid.UUID{},
+		ExpiresAt:       time.Now().Add(uploadSessionTTL),
+	}
+	if err := s.repo.CreateUploadSession(ctx, session); err != nil {
+		_ = multipart.AbortMultipartUpload(ctx, fileID, storageUploadID)
+		return nil, err
+	}
+
+	return s.uploadProgress(ctx, multipart, session, req.Presign)
+}
+
+// GetUploadSession returns an upload session with the parts stored so far and those still
+// missing. With presign, it also returns storage URLs for the missing parts.
+func (s *Service) GetUploadSession(ctx context.Context, programID, sessionID uuid.UUID, presign bool) (*UploadSessionProgress, error) {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.loadUploadSession(ctx, programID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.uploadProgress(ctx, multipart, session, presign)
+}
+
+// UploadSessionPart stores part partNumber of an upload session, read from body. size is the
+// length of body and must match the part's length. Sending a part again replaces it.
+func (s *Service) UploadSessionPart(ctx context.Context, programID, sessionID uuid.UUID, partNumber int, body io.Reader, size int64) (*storage.Part, error) {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.loadUploadSession(ctx, programID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSessionActive(session); err != nil {
+		return nil, err
+	}
+
+	if partNumber < 1 || partNumber > session.PartCount {
+		return nil, &InvalidPartError{Message: fmt.Sprintf("part number must be between 1 and %d", session.PartCount)}
+	}
+	if expected := partLength(session, partNumber); size != expected {
+		return nil, &InvalidPartError{Message: fmt.Sprintf("part %d must be %d bytes, got %d", partNumber, expected, size)}
+	}
+
+	part, err := multipart.UploadPart(ctx, session.FileID, session.StorageUploadID, partNumber, body, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store part: %w", err)
+	}
+
+	return part, nil
+}
+
+// CompleteUploadSession joins the parts of an upload session into the file and creates its
+// artifact, or an artifact per file of ZIP archives and per message of mailboxes. Returns an
+// IncompleteUploadError if parts are missing. Completing a completed session returns its
+// artifacts again, so a client can retry a completion whose response it did not receive. If
+// the file is rejected, because it is a duplicate or holds nothing that can be uploaded, the
+// upload is discarded; after any other failure the joined file is kept and the completion can
+// be retried.
+func (s *Service) CompleteUploadSession(ctx context.Context, programID, sessionID uuid.UUID) ([]uuid.UUID, error) {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.loadUploadSession(ctx, programID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch session.Status {
+	case UploadSessionCompleted:
+		return session.ArtifactIDs, nil
+
+	case UploadSessionActive:
+		if err := checkSessionActive(session); err != nil {
+			return nil, err
+		}
+
+		stored, err := multipart.ListParts(ctx, session.FileID, session.StorageUploadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stored parts: %w", err)
+		}
+		parts, missing := sessionParts(session, stored)
+		if len(missing) > 0 {
+			return nil, &IncompleteUploadError{MissingParts: missing}
+		}
+
+		if _, err := multipart.CompleteMultipartUpload(ctx, session.FileID, session.StorageUploadID, parts); err != nil {
+			return nil, fmt.Errorf("failed to assemble upload: %w", err)
+		}
+		assembled, err := s.repo.TransitionUploadSession(ctx, session.SessionID, UploadSessionActive, UploadSessionAssembled)
+		if err != nil {
+			return nil, err
+		}
+		if !assembled {
+			return nil, &UploadSessionStateError{Status: "already being completed"}
+		}
+		session.Status = UploadSessionAssembled
+
+	case UploadSessionAssembled:
+		// A previous completion joined the parts but did not create the artifacts; carry on
+
+	default:
+		return nil, &UploadSessionStateError{Status: session.Status}
+	}
+
+	artifactIDs, err := s.createSessionArtifacts(ctx, session)
+	if err != nil {
+		if isRejectedUpload(err) {
+			if _, abortErr := s.repo.TransitionUploadSession(ctx, session.SessionID, UploadSessionAssembled, UploadSessionAborted); abortErr != nil {
+				fmt.Printf("Warning: failed to abort upload session %s: %v\n", session.SessionID, abortErr)
+			}
+			_ = s.storage.Delete(ctx, session.FileID)
+		}
+		return nil, err
+	}
+
+	if err := s.repo.CompleteUploadSession(ctx, session.SessionID, artifactIDs); err != nil {
+		return nil, err
+	}
+
+	// Archives and mailboxes were expanded into their own files; the single file of any other
+	// upload is now its artifact's
+	if isExpandedUpload(session) {
+		_ = s.storage.Delete(ctx, session.FileID)
+	}
+
+	return artifactIDs, nil
+}
+
+// AbortUploadSession discards an upload session and the parts stored for it
+func (s *Service) AbortUploadSession(ctx context.Context, programID, sessionID uuid.UUID) error {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return err
+	}
+
+	session, err := s.loadUploadSession(ctx, programID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return s.discardUploadSession(ctx, multipart, session, UploadSessionAborted)
+}
+
+// AbortExpiredUploadSessions discards sessions that were not completed in time, along with
+// their stored parts, and returns how many were discarded
+func (s *Service) AbortExpiredUploadSessions(ctx context.Context) (int, error) {
+	multipart, err := s.multipartStorage()
+	if err != nil {
+		return 0, err
+	}
+
+	sessions, err := s.repo.ListExpiredUploadSessions(ctx, expiredSessionBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	discarded := 0
+	for i := range sessions {
+		if err := s.discardUploadSession(ctx, multipart, &sessions[i], UploadSessionExpired); err != nil {
+			fmt.Printf("Warning: failed to discard expired upload session %s: %v\n", sessions[i].SessionID, err)
+			continue
+		}
+		discarded++
+	}
+
+	return discarded, nil
+}
+
+// multipartStorage returns the service's storage if it supports multipart uploads
+func (s *Service) multipartStorage() (storage.MultipartStorage, error) {
+	multipart, ok := s.storage.(storage.MultipartStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not support resumable uploads")
+	}
+	return multipart, nil
+}
+
+// loadUploadSession retrieves an upload session of a program
+func (s *Service) loadUploadSession(ctx context.Context, programID, sessionID uuid.UUID) (*UploadSession, error) {
+	session, err := s.repo.GetUploadSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ProgramID != programID {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+// uploadProgress lists the stored and missing parts of an active session, and presigns the
+// missing parts if asked to
+func (s *Service) uploadProgress(ctx context.Context, multipart storage.MultipartStorage, session *UploadSession, presign bool) (*UploadSessionProgress, error) {
+	progress := &UploadSessionProgress{
+		UploadSession: *session,
+		UploadedParts: []storage.Part{},
+		MissingParts:  []int{},
+	}
+	if session.Status != UploadSessionActive {
+		return progress, nil
+	}
+
+	stored, err := multipart.ListParts(ctx, session.FileID, session.StorageUploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored parts: %w", err)
+	}
+	progress.UploadedParts, progress.MissingParts = sessionParts(session, stored)
+
+	if presign && len(progress.MissingParts) > 0 {
+		expiry := presignedPartTTL
+		if untilExpiry := time.Until(session.ExpiresAt); untilExpiry < expiry {
+			expiry = untilExpiry
+		}
+		if expiry <= 0 {
+			return progress, nil
+		}
+
+		progress.PartURLs = make(map[int]string, len(progress.MissingParts))
+		for _, partNumber := range progress.MissingParts {
+			url, err := multipart.PresignPart(ctx, session.FileID, session.StorageUploadID, partNumber, expiry)
+			if err != nil {
+				return nil, err
+			}
+			progress.PartURLs[partNumber] = url
+		}
+	}
+
+	return progress, nil
+}
+
+// createSessionArtifacts creates the artifacts of an assembled upload session, as handleUpload
+// does for a direct upload
+func (s *Service) createSessionArtifacts(ctx context.Context, session *UploadSession) ([]uuid.UUID, error) {
+	req := UploadRequest{
+		ProgramID:   session.ProgramID,
+		Filename:    session.Filename,
+		MimeType:    session.MimeType,
+		UploadedBy:  session.UploadedBy,
+		ForceUpload: session.ForceUpload,
+	}
+
+	// Mailboxes are split as they are read back; archives are expanded in memory, within the
+	// upload limit. Neither is kept.
+	if isMboxUpload(req.MimeType, req.Filename) {
+		return s.uploadStoredMbox(ctx, req, session.FileID)
+	}
+	if isZipUpload(req.MimeType, req.Filename) {
+		data, err := s.storage.Download(ctx, session.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download file: %w", err)
+		}
+		req.Data = data
+		return s.UploadZipArchive(ctx, req)
+	}
+
+	fileInfo, err := s.storage.GetInfo(ctx, session.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find assembled file: %w", err)
+	}
+
+	// The parts were stored separately, so the file is hashed by reading it back
+	reader, err := s.storage.DownloadStream(ctx, session.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+	if err := s.rejectDuplicate(ctx, req, contentHash); err != nil {
+		return nil, err
+	}
+
+	artifact := newArtifact(req, fileInfo, contentHash)
+	artifact.FileType = s.inferFileType(req.MimeType, req.Filename)
+	artifact.ExtractionPending = true
+
+	// The file stays with the session until the completion succeeds or is rejected
+	if err := s.saveNewArtifact(ctx, artifact, nil); err != nil {
+		return nil, err
+	}
+
+	return []uuid.UUID{artifact.ArtifactID}, nil
+}
+
+// isExpandedUpload reports whether a session's file is split into several artifacts
+func isExpandedUpload(session *UploadSession) bool {
+	return isZipUpload(session.MimeType, session.Filename) || isMboxUpload(session.MimeType, session.Filename)
+}
+
+// isRejectedUpload reports whether an upload failed because of its content, so completing it
+// again cannot succeed
+func isRejectedUpload(err error) bool {
+	var dupErr *DuplicateError
+	var unprocessableErr *UnprocessableUploadError
+	return errors.As(err, &dupErr) || errors.Is(err, ErrDuplicateContent) || errors.As(err, &unprocessableErr)
+}
+
+// discardUploadSession ends a session that was not completed and deletes what it stored
+func (s *Service) discardUploadSession(ctx context.Context, multipart storage.MultipartStorage, session *UploadSession, status string) error {
+	if session.Status != UploadSessionActive && session.Status != UploadSessionAssembled {
+		return &UploadSessionStateError{Status: session.Status}
+	}
+
+	// End the session first so no more parts are accepted
+	discarded, err := s.repo.TransitionUploadSession(ctx, session.SessionID, session.Status, status)
+	if err != nil {
+		return err
+	}
+	if !discarded {
+		return &UploadSessionStateError{Status: "already being completed"}
+	}
+
+	if session.Status == UploadSessionAssembled {
+		return s.storage.Delete(ctx, session.FileID)
+	}
+	return multipart.AbortMultipartUpload(ctx, session.FileID, session.StorageUploadID)
+}
+
+// checkSessionActive returns an UploadSessionStateError unless a session takes parts
+func checkSessionActive(session *UploadSession) error {
+	if session.Status != UploadSessionActive {
+		return &UploadSessionStateError{Status: session.Status}
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return &UploadSessionStateError{Status: UploadSessionExpired}
+	}
+	return nil
+}
+
+// sessionParts sorts the stored parts of a session into those that complete it, in order, and
+// the numbers of the parts still missing. A part of the wrong length, which can only arrive
+// through a presigned URL, counts as missing.
+func sessionParts(session *UploadSession, stored []storage.Part) ([]storage.Part, []int) {
+	byNumber := make(map[int]storage.Part, len(stored))
+	for _, part := range stored {
+		byNumber[part.Number] = part
+	}
+
+	parts := make([]storage.Part, 0, session.PartCount)
+	missing := make([]int, 0)
+	for n := 1; n <= session.PartCount; n++ {
+		part, ok := byNumber[n]
+		if !ok || part.Size != partLength(session, n) {
+			missing = append(missing, n)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts, missing
+}
+
+// partCount returns the number of parts a file is sent in
+func partCount(fileSize, partSize int64) int {
+	if partSize <= 0 {
+		return 0
+	}
+	return int((fileSize + partSize - 1) / partSize)
+}
+
+// partLength returns the length of part partNumber of a session; only the last part is shorter
+func partLength(session *UploadSession, partNumber int) int64 {
+	if partNumber < session.PartCount {
+		return session.PartSizeBytes
+	}
+	return session.FileSizeBytes - int64(session.PartCount-1)*session.PartSizeBytes
+}
+
+// isZipUpload reports whether an upload is a ZIP archive, which is expanded into an artifact per file
+func isZipUpload(mimeType, filename string) bool {
+	return strings.HasPrefix(mimeType, "application/zip") ||
+		strings.HasPrefix(mimeType, "application/x-zip") ||
+		strings.HasSuffix(strings.ToLower(filename), ".zip")
+}
+
+// isMboxUpload reports whether an upload is a mailbox export, which is split into an artifact per message
+func isMboxUpload(mimeType, filename string) bool {
+	return strings.HasPrefix(mimeType, "application/mbox") ||
+		strings.HasSuffix(strings.ToLower(filename), ".mbox")
+}
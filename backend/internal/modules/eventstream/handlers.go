@@ -0,0 +1,149 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/auth"
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// keepaliveInterval keeps proxies from closing an idle stream
+	keepaliveInterval = 15 * time.Second
+
+	// writeTimeout bounds each write, so a client that stops reading is disconnected
+	writeTimeout = 30 * time.Second
+
+	// reconnectDelay is the retry hint sent to clients, in milliseconds
+	reconnectDelay = 2000
+)
+
+// RegisterRoutes registers the program event stream. Streams stay open indefinitely and manage
+// their own deadlines, so mount them outside the request timeout.
+func RegisterRoutes(r chi.Router, hub *Hub, authRepo *auth.Repository) {
+	r.With(auth.RequireProgramAccess(auth.RoleViewer, authRepo)).
+		Get("/programs/{programId}/events/stream", handleStream(hub))
+}
+
+// handleStream streams a program's events as Server-Sent Events. Each message carries the
+// event ID, so a reconnecting client resumes by sending it back as Last-Event-ID (or as the
+// last_event_id query parameter, for clients that cannot set headers). A "resync" message tells
+// the client that events were missed and it should reload instead.
+func handleStream(hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+
+		rc := http.NewResponseController(w)
+
+		// The server's read and write timeouts are sized for ordinary requests; the stream
+		// instead sets a write deadline before each write
+		if err := rc.SetReadDeadline(time.Time{}); err != nil {
+			respondError(w, http.StatusInternalServerError, "Streaming not supported")
+			return
+		}
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			respondError(w, http.StatusInternalServerError, "Streaming not supported")
+			return
+		}
+
+		sub, backlog, resync := hub.Subscribe(r.Context(), programID, lastEventID)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+		if resync {
+			// The client missed events we no longer have
+			fmt.Fprintf(w, "event: resync\ndata: {\"last_event_id\":%q}\n\n", lastEventID)
+		}
+
+		// Backlog events can also arrive live; send each once
+		sent := make(map[uuid.UUID]bool, len(backlog))
+		for _, event := range backlog {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			sent[event.ID] = true
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		keepalive := time.NewTicker(keepaliveInterval)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-sub.Events:
+				if !ok {
+					// Too slow to keep up; the client reconnects and catches up from the history
+					return
+				}
+				if sent[event.ID] {
+					delete(sent, event.ID)
+					continue
+				}
+				if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-keepalive.C:
+				if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes one SSE message named after the event type
+func writeEvent(w http.ResponseWriter, event *events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
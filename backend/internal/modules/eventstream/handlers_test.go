@@ -0,0 +1,68 @@
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestHandleStream_OutlivesServerTimeouts(t *testing.T) {
+	hub := NewHub(DefaultHubConfig())
+	programID := uuid.New()
+
+	r := chi.NewRouter()
+	r.Get("/programs/{programId}/events/stream", handleStream(hub))
+
+	server := httptest.NewUnstartedServer(r)
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/programs/" + programID.String() + "/events/stream")
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// Publish only once both server timeouts have passed
+	time.Sleep(300 * time.Millisecond)
+	event := newEvent(programID, events.ArtifactAnalyzed)
+	hub.Publish(context.Background(), event)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed before the event arrived")
+			}
+			if strings.TrimPrefix(line, "id: ") == event.ID.String() {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the event")
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package eventstream pushes a program's processing events to browsers over Server-Sent Events.
+// The Hub listens to the event bus in every API process, keeps a short per-program history so
+// clients that reconnect with Last-Event-ID don't miss anything, and fans events out to the
+// connected streams. A client that reconnects to another replica, or after a restart, resumes
+// from the durable History instead; when neither has its last event, it is told to resync.
+package eventstream
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// StreamedEventTypes are the events relayed to clients: an artifact's progress through the
+// pipeline and the results it produces
+var StreamedEventTypes = []events.EventType{
+	events.ArtifactUploaded,
+	events.ArtifactOCRCompleted,
+	events.ArtifactAnalyzed,
+	events.ArtifactDeadLettered,
+	events.RiskSuggested,
+	events.InvoiceProcessed,
+}
+
+// HubConfig controls how much the hub buffers
+type HubConfig struct {
+	HistorySize      int // Events kept per program for Last-Event-ID resume
+	SubscriberBuffer int // Events queued per client before it is disconnected as too slow
+	ResumeLimit      int // Most events replayed from the History; a client further behind resyncs
+}
+
+// DefaultHubConfig returns the buffering used by the API
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		HistorySize:      256,
+		SubscriberBuffer: 64,
+		ResumeLimit:      1000,
+	}
+}
+
+// History looks up events that are no longer, or never were, in a hub's in-memory history.
+// found is false when lastEventID is unknown to it. events.OutboxReader implements it.
+type History interface {
+	EventsAfter(ctx context.Context, programID, lastEventID uuid.UUID, types []events.EventType, limit int) (backlog []*events.Event, found bool, err error)
+}
+
+// Hub fans bus events out to the streams of each program
+type Hub struct {
+	config   HubConfig
+	types    map[events.EventType]bool
+	history  History
+	mu       sync.Mutex
+	programs map[uuid.UUID]*programStream
+}
+
+// programStream is one program's recent history and connected clients
+type programStream struct {
+	history     []*events.Event // Oldest first
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription is one connected client. Events is closed when the client falls too far behind;
+// it should then disconnect and resume with Last-Event-ID.
+type Subscription struct {
+	Events <-chan *events.Event
+
+	ch        chan *events.Event
+	hub       *Hub
+	programID uuid.UUID
+}
+
+// NewHub creates a hub relaying StreamedEventTypes
+func NewHub(config HubConfig) *Hub {
+	types := make(map[events.EventType]bool, len(StreamedEventTypes))
+	for _, t := range StreamedEventTypes {
+		types[t] = true
+	}
+
+	return &Hub{
+		config:   config,
+		types:    types,
+		programs: make(map[uuid.UUID]*programStream),
+	}
+}
+
+// SetHistory sets where resuming clients whose last event is not in memory catch up from
+func (h *Hub) SetHistory(history History) {
+	h.history = history
+}
+
+// Publish records an event and sends it to the program's clients. It is an events.EventHandler
+// for Bus.Listen.
+func (h *Hub) Publish(ctx context.Context, event *events.Event) error {
+	if event.ProgramID == uuid.Nil || !h.types[event.Type] {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stream := h.stream(event.ProgramID)
+
+	// The outbox relay delivers at least once; don't show a client the same event twice
+	for _, seen := range stream.history {
+		if seen.ID == event.ID {
+			return nil
+		}
+	}
+
+	stream.history = append(stream.history, event)
+	if len(stream.history) > h.config.HistorySize {
+		stream.history = append([]*events.Event(nil), stream.history[len(stream.history)-h.config.HistorySize:]...)
+	}
+
+	for sub := range stream.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			delete(stream.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe connects a client to a program's events. With a lastEventID it also returns the
+// events published after that one; resync is true when neither the in-memory history nor the
+// History has that event, in which case the client has missed events and should reload its
+// state. Events in the backlog may also arrive on the subscription.
+func (h *Hub) Subscribe(ctx context.Context, programID uuid.UUID, lastEventID string) (sub *Subscription, backlog []*events.Event, resync bool) {
+	h.mu.Lock()
+	stream := h.stream(programID)
+
+	found := lastEventID == ""
+	for i, event := range stream.history {
+		if event.ID.String() == lastEventID {
+			backlog = append(backlog, stream.history[i+1:]...)
+			found = true
+			break
+		}
+	}
+
+	// Subscribe before reading the History, so an event is either in the backlog or delivered
+	ch := make(chan *events.Event, h.config.SubscriberBuffer)
+	sub = &Subscription{Events: ch, ch: ch, hub: h, programID: programID}
+	stream.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	if !found {
+		backlog, found = h.readHistory(ctx, programID, lastEventID)
+	}
+
+	return sub, backlog, !found
+}
+
+// readHistory returns the events after lastEventID from the History. found is false if the
+// History does not have the event, cannot be read, or has more events than are replayed.
+func (h *Hub) readHistory(ctx context.Context, programID uuid.UUID, lastEventID string) (backlog []*events.Event, found bool) {
+	if h.history == nil {
+		return nil, false
+	}
+	afterID, err := uuid.Parse(lastEventID)
+	if err != nil {
+		return nil, false
+	}
+
+	backlog, found, err = h.history.EventsAfter(ctx, programID, afterID, StreamedEventTypes, h.config.ResumeLimit+1)
+	if err != nil {
+		log.Printf("Warning: Failed to read event history for program %s: %v", programID, err)
+		return nil, false
+	}
+	if len(backlog) > h.config.ResumeLimit {
+		return nil, false
+	}
+	return backlog, found
+}
+
+// Close disconnects the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	stream, ok := s.hub.programs[s.programID]
+	if !ok {
+		return
+	}
+	if _, ok := stream.subscribers[s]; ok {
+		delete(stream.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// stream returns a program's stream, creating it if needed. Caller must hold h.mu.
+func (h *Hub) stream(programID uuid.UUID) *programStream {
+	stream, ok := h.programs[programID]
+	if !ok {
+		stream = &programStream{subscribers: make(map[*Subscription]struct{})}
+		h.programs[programID] = stream
+	}
+	return stream
+}
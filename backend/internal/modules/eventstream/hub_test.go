@@ -0,0 +1,167 @@
+package eventstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+func newEvent(programID uuid.UUID, eventType events.EventType) *events.Event {
+	return events.NewEvent(eventType, programID, "test", map[string]interface{}{})
+}
+
+func TestHub_ResumesAfterLastEventID(t *testing.T) {
+	hub := NewHub(DefaultHubConfig())
+	programID := uuid.New()
+
+	first := newEvent(programID, events.ArtifactUploaded)
+	second := newEvent(programID, events.ArtifactAnalyzed)
+	hub.Publish(context.Background(), first)
+	hub.Publish(context.Background(), second)
+
+	sub, backlog, resync := hub.Subscribe(context.Background(), programID, first.ID.String())
+	defer sub.Close()
+
+	if resync {
+		t.Fatal("expected no resync for an event still in the history")
+	}
+	if len(backlog) != 1 || backlog[0].ID != second.ID {
+		t.Fatalf("expected the backlog to hold only the later event, got %v", backlog)
+	}
+
+	live := newEvent(programID, events.RiskSuggested)
+	hub.Publish(context.Background(), live)
+	if got := <-sub.Events; got.ID != live.ID {
+		t.Fatalf("expected live event %s, got %s", live.ID, got.ID)
+	}
+}
+
+func TestHub_RequestsResyncWhenHistoryIsGone(t *testing.T) {
+	hub := NewHub(HubConfig{HistorySize: 2, SubscriberBuffer: 4})
+	programID := uuid.New()
+
+	oldest := newEvent(programID, events.ArtifactUploaded)
+	hub.Publish(context.Background(), oldest)
+	hub.Publish(context.Background(), newEvent(programID, events.ArtifactOCRCompleted))
+	hub.Publish(context.Background(), newEvent(programID, events.ArtifactAnalyzed))
+
+	sub, backlog, resync := hub.Subscribe(context.Background(), programID, oldest.ID.String())
+	defer sub.Close()
+
+	if !resync || len(backlog) != 0 {
+		t.Fatalf("expected a resync with no backlog, got resync=%v backlog=%d", resync, len(backlog))
+	}
+}
+
+func TestHub_FiltersDeduplicatesAndIsolatesPrograms(t *testing.T) {
+	hub := NewHub(DefaultHubConfig())
+	programID := uuid.New()
+
+	sub, _, _ := hub.Subscribe(context.Background(), programID, "")
+	defer sub.Close()
+
+	uploaded := newEvent(programID, events.ArtifactUploaded)
+	hub.Publish(context.Background(), uploaded)
+	hub.Publish(context.Background(), uploaded)                                            // Redelivered by the relay
+	hub.Publish(context.Background(), newEvent(programID, events.BudgetThresholdExceeded)) // Not streamed
+	hub.Publish(context.Background(), newEvent(uuid.New(), events.ArtifactUploaded))       // Another program
+
+	if len(sub.Events) != 1 {
+		t.Fatalf("expected exactly one queued event, got %d", len(sub.Events))
+	}
+}
+
+func TestHub_DisconnectsSlowSubscribers(t *testing.T) {
+	hub := NewHub(HubConfig{HistorySize: 16, SubscriberBuffer: 1})
+	programID := uuid.New()
+
+	sub, _, _ := hub.Subscribe(context.Background(), programID, "")
+	defer sub.Close()
+
+	hub.Publish(context.Background(), newEvent(programID, events.ArtifactUploaded))
+	hub.Publish(context.Background(), newEvent(programID, events.ArtifactAnalyzed))
+
+	<-sub.Events
+	if _, ok := <-sub.Events; ok {
+		t.Fatal("expected the subscription to be closed once its buffer overflowed")
+	}
+}
+
+// fakeHistory is a durable History holding a program's events in order
+type fakeHistory struct {
+	events []*events.Event
+	err    error
+}
+
+func (f *fakeHistory) EventsAfter(ctx context.Context, programID, lastEventID uuid.UUID, types []events.EventType, limit int) ([]*events.Event, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	for i, event := range f.events {
+		if event.ID == lastEventID {
+			backlog := f.events[i+1:]
+			if len(backlog) > limit {
+				backlog = backlog[:limit]
+			}
+			return backlog, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func TestHub_ResumesFromHistoryAfterRestart(t *testing.T) {
+	programID := uuid.New()
+	first := newEvent(programID, events.ArtifactUploaded)
+	second := newEvent(programID, events.ArtifactAnalyzed)
+
+	// A fresh hub has nothing in memory, as after a restart or on another replica
+	hub := NewHub(DefaultHubConfig())
+	hub.SetHistory(&fakeHistory{events: []*events.Event{first, second}})
+
+	sub, backlog, resync := hub.Subscribe(context.Background(), programID, first.ID.String())
+	defer sub.Close()
+
+	if resync {
+		t.Fatal("expected no resync for an event in the history")
+	}
+	if len(backlog) != 1 || backlog[0].ID != second.ID {
+		t.Fatalf("expected the backlog to hold only the later event, got %v", backlog)
+	}
+}
+
+func TestHub_RequestsResyncWhenHistoryCannotResume(t *testing.T) {
+	programID := uuid.New()
+	first := newEvent(programID, events.ArtifactUploaded)
+	history := []*events.Event{first}
+	for i := 0; i < 3; i++ {
+		history = append(history, newEvent(programID, events.ArtifactAnalyzed))
+	}
+
+	tests := []struct {
+		name        string
+		history     *fakeHistory
+		lastEventID string
+	}{
+		{"unknown event", &fakeHistory{events: history}, uuid.New().String()},
+		{"malformed event ID", &fakeHistory{events: history}, "not-an-id"},
+		{"too far behind", &fakeHistory{events: history}, first.ID.String()},
+		{"history unavailable", &fakeHistory{err: errors.New("connection refused")}, first.ID.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(HubConfig{HistorySize: 16, SubscriberBuffer: 4, ResumeLimit: 2})
+			hub.SetHistory(tt.history)
+
+			sub, backlog, resync := hub.Subscribe(context.Background(), programID, tt.lastEventID)
+			defer sub.Close()
+
+			if !resync || len(backlog) != 0 {
+				t.Fatalf("expected a resync with no backlog, got resync=%v backlog=%d", resync, len(backlog))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package financial
+
+import (
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// eventSource identifies the financial module as the publisher of its events
+const eventSource = "financial"
+
+// budgetAlertThresholds are the utilization percentages that raise BudgetThresholdExceeded
+// when a budget category crosses them
+var budgetAlertThresholds = []float64{80, 90, 100}
+
+// budgetUtilization returns committed plus actual spend as a percentage of the budget
+func budgetUtilization(category *BudgetCategory) float64 {
+	if category.BudgetedAmount <= 0 {
+		return 0
+	}
+	return (category.ActualSpend + category.CommittedSpend) / category.BudgetedAmount * 100
+}
+
+// crossedBudgetThreshold returns the highest alert threshold crossed going from previous to
+// current utilization, or 0 if none was crossed
+func crossedBudgetThreshold(previous, current float64) float64 {
+	var crossed float64
+	for _, threshold := range budgetAlertThresholds {
+		if previous < threshold && current >= threshold {
+			crossed = threshold
+		}
+	}
+	return crossed
+}
+
+// budgetThresholdEvent builds the event raised when an update pushes a budget category over
+// an alert threshold, or returns nil if no threshold was crossed
+func budgetThresholdEvent(previous, category *BudgetCategory) (*events.Event, error) {
+	utilization := budgetUtilization(category)
+	threshold := crossedBudgetThreshold(budgetUtilization(previous), utilization)
+	if threshold == 0 {
+		return nil, nil
+	}
+
+	return events.NewEventFromPayload(events.BudgetThresholdExceeded, category.ProgramID, eventSource, events.BudgetThresholdExceededPayload{
+		CategoryID:         category.CategoryID,
+		CategoryName:       category.CategoryName,
+		FiscalYear:         category.FiscalYear,
+		BudgetedAmount:     category.BudgetedAmount,
+		ActualSpend:        category.ActualSpend,
+		CommittedSpend:     category.CommittedSpend,
+		UtilizationPercent: utilization,
+		ThresholdPercent:   threshold,
+	})
+}
+
+// varianceDetectedEvent builds the event for a saved financial variance
+func varianceDetectedEvent(variance FinancialVariance) (*events.Event, error) {
+	event, err := events.NewEventFromPayload(events.VarianceDetected, variance.ProgramID, eventSource, events.VarianceDetectedPayload{
+		VarianceID:         variance.VarianceID,
+		InvoiceID:          variance.InvoiceID.UUID,
+		VarianceType:       variance.VarianceType,
+		Severity:           variance.Severity,
+		Title:              variance.Title,
+		Description:        variance.Description,
+		VarianceAmount:     variance.VarianceAmount.Float64,
+		VariancePercentage: variance.VariancePercentage.Float64,
+		ConfidenceScore:    variance.AIConfidenceScore.Float64,
+		SourceArtifactIDs:  variance.SourceArtifactIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event.WithAIMetadata(variance.AIConfidenceScore.Float64, variance.SourceArtifactIDs), nil
+}
+
+// invoiceProcessedEvent builds the event for an invoice that finished extraction and validation
+func invoiceProcessedEvent(invoice *Invoice, lineItemCount, varianceCount int, replacedInvoiceIDs []uuid.UUID) (*events.Event, error) {
+	event, err := events.NewEventFromPayload(events.InvoiceProcessed, invoice.ProgramID, eventSource, events.InvoiceProcessedPayload{
+		InvoiceID:          invoice.InvoiceID,
+		ArtifactID:         invoice.ArtifactID.UUID,
+		InvoiceNumber:      invoice.InvoiceNumber.String,
+		VendorName:         invoice.VendorName,
+		TotalAmount:        invoice.TotalAmount,
+		Currency:           invoice.Currency,
+		LineItemCount:      lineItemCount,
+		VarianceCount:      varianceCount,
+		ReplacedInvoiceIDs: replacedInvoiceIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var artifactRefs []uuid.UUID
+	if invoice.ArtifactID.Valid {
+		artifactRefs = []uuid.UUID{invoice.ArtifactID.UUID}
+	}
+	return event.WithAIMetadata(invoice.AIConfidenceScore.Float64, artifactRefs), nil
+}
@@ -0,0 +1,77 @@
+package financial
+
+import (
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// TestCrossedBudgetThreshold tests which alert threshold a utilization change crosses
+func TestCrossedBudgetThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous float64
+		current  float64
+		want     float64
+	}{
+		{name: "Below all thresholds", previous: 10, current: 70, want: 0},
+		{name: "Crosses 80%", previous: 70, current: 85, want: 80},
+		{name: "Lands exactly on 90%", previous: 85, current: 90, want: 90},
+		{name: "Jumps over several thresholds", previous: 50, current: 120, want: 100},
+		{name: "Already over threshold", previous: 92, current: 95, want: 0},
+		{name: "Utilization decreases", previous: 105, current: 75, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := crossedBudgetThreshold(tt.previous, tt.current)
+			if got != tt.want {
+				t.Errorf("crossedBudgetThreshold(%v, %v) = %v, want %v", tt.previous, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBudgetThresholdEvent tests the event raised when a budget update crosses a threshold
+func TestBudgetThresholdEvent(t *testing.T) {
+	previous := &BudgetCategory{
+		CategoryID:     uuid.New(),
+		ProgramID:      uuid.New(),
+		CategoryName:   "Labor",
+		FiscalYear:     2025,
+		BudgetedAmount: 1000,
+		ActualSpend:    500,
+		CommittedSpend: 200,
+	}
+
+	updated := *previous
+	updated.ActualSpend = 750
+
+	event, err := budgetThresholdEvent(previous, &updated)
+	if err != nil {
+		t.Fatalf("budgetThresholdEvent() error = %v", err)
+	}
+	if event == nil {
+		t.Fatal("budgetThresholdEvent() = nil, want event for 95% utilization")
+	}
+	if event.Type != events.BudgetThresholdExceeded || event.ProgramID != previous.ProgramID {
+		t.Errorf("event type/program = %s/%s, want %s/%s", event.Type, event.ProgramID, events.BudgetThresholdExceeded, previous.ProgramID)
+	}
+
+	var payload events.BudgetThresholdExceededPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if payload.CategoryID != previous.CategoryID || payload.ThresholdPercent != 90 || payload.UtilizationPercent != 95 {
+		t.Errorf("payload = %+v, want category %s at 95%% crossing 90%%", payload, previous.CategoryID)
+	}
+
+	// A second update that stays in the same band raises nothing
+	again := updated
+	again.ActualSpend = 760
+	event, err = budgetThresholdEvent(&updated, &again)
+	if err != nil || event != nil {
+		t.Errorf("budgetThresholdEvent() = %v, %v; want nil, nil", event, err)
+	}
+}
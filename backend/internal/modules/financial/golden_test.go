@@ -0,0 +1,114 @@
+package financial
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/ai/aitest"
+	"github.com/google/uuid"
+)
+
+const goldenDir = "testdata/golden"
+
+// goldenInvoice is the extracted invoice without generated IDs, timings or statuses
+type goldenInvoice struct {
+	InvoiceNumber string           `json:"invoice_number"`
+	VendorName    string           `json:"vendor_name"`
+	VendorID      string           `json:"vendor_id,omitempty"`
+	InvoiceDate   string           `json:"invoice_date"`
+	DueDate       string           `json:"due_date,omitempty"`
+	PeriodStart   string           `json:"period_start,omitempty"`
+	PeriodEnd     string           `json:"period_end,omitempty"`
+	Subtotal      float64          `json:"subtotal"`
+	Tax           float64          `json:"tax"`
+	Total         float64          `json:"total"`
+	Currency      string           `json:"currency"`
+	Confidence    float64          `json:"confidence"`
+	LineItems     []goldenLineItem `json:"line_items"`
+}
+
+type goldenLineItem struct {
+	LineNumber    int     `json:"line_number"`
+	Description   string  `json:"description"`
+	PersonName    string  `json:"person_name,omitempty"`
+	Role          string  `json:"role,omitempty"`
+	Quantity      float64 `json:"quantity,omitempty"`
+	UnitRate      float64 `json:"unit_rate,omitempty"`
+	BilledHours   float64 `json:"billed_hours,omitempty"`
+	LineAmount    float64 `json:"line_amount"`
+	SpendCategory string  `json:"spend_category,omitempty"`
+	Confidence    float64 `json:"confidence"`
+}
+
+func toGoldenInvoice(invoice *Invoice, lineItems []InvoiceLineItem) goldenInvoice {
+	golden := goldenInvoice{
+		InvoiceNumber: invoice.InvoiceNumber.String,
+		VendorName:    invoice.VendorName,
+		VendorID:      invoice.VendorID.String,
+		InvoiceDate:   invoice.InvoiceDate.Format("2006-01-02"),
+		DueDate:       goldenDate(invoice.DueDate),
+		PeriodStart:   goldenDate(invoice.PeriodStartDate),
+		PeriodEnd:     goldenDate(invoice.PeriodEndDate),
+		Subtotal:      invoice.SubtotalAmount.Float64,
+		Tax:           invoice.TaxAmount.Float64,
+		Total:         invoice.TotalAmount,
+		Currency:      invoice.Currency,
+		Confidence:    invoice.AIConfidenceScore.Float64,
+		LineItems:     []goldenLineItem{},
+	}
+
+	for _, li := range lineItems {
+		golden.LineItems = append(golden.LineItems, goldenLineItem{
+			LineNumber:    li.LineNumber,
+			Description:   li.Description,
+			PersonName:    li.PersonName.String,
+			Role:          li.RoleDescription.String,
+			Quantity:      li.Quantity.Float64,
+			UnitRate:      li.UnitRate.Float64,
+			BilledHours:   li.BilledHours.Float64,
+			LineAmount:    li.LineAmount,
+			SpendCategory: li.SpendCategory.String,
+			Confidence:    li.AIConfidenceScore.Float64,
+		})
+	}
+
+	return golden
+}
+
+func goldenDate(nt sql.NullTime) string {
+	if !nt.Valid {
+		return ""
+	}
+	return nt.Time.Format("2006-01-02")
+}
+
+// TestAnalyzeInvoice_Golden extracts each invoice in the corpus against recorded model
+// responses (see package aitest to record them again)
+func TestAnalyzeInvoice_Golden(t *testing.T) {
+	corpus := []string{"globex-invoice-2026-03.txt"}
+
+	client := ai.NewClient(&ai.ClientConfig{Provider: aitest.Provider(t, filepath.Join(goldenDir, "recordings"))})
+	analyzer := NewInvoiceAnalyzer(client, nil)
+
+	for _, file := range corpus {
+		t.Run(file, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join(goldenDir, "corpus", file))
+			if err != nil {
+				t.Fatalf("failed to read corpus file: %v", err)
+			}
+
+			invoice, lineItems, err := analyzer.AnalyzeInvoice(context.Background(), string(content), uuid.New())
+			if err != nil {
+				t.Fatalf("AnalyzeInvoice() error = %v", err)
+			}
+
+			name := strings.TrimSuffix(file, filepath.Ext(file)) + ".golden.json"
+			aitest.AssertGolden(t, filepath.Join(goldenDir, "expected", name), toGoldenInvoice(invoice, lineItems))
+		})
+	}
+}
@@ -5,23 +5,24 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/google/uuid"
 )
 
 // InvoiceAnalyzer handles AI-powered invoice analysis and variance detection
 type InvoiceAnalyzer struct {
-	client  *ai.Client
+	client  ai.Provider
 	repo    RepositoryInterface
 	prompts *ai.PromptLibrary
 }
 
 // NewInvoiceAnalyzer creates a new invoice analyzer
-func NewInvoiceAnalyzer(client *ai.Client, repo RepositoryInterface) *InvoiceAnalyzer {
+func NewInvoiceAnalyzer(client ai.Provider, repo RepositoryInterface) *InvoiceAnalyzer {
 	return &InvoiceAnalyzer{
 		client:  client,
 		repo:    repo,
@@ -29,7 +30,7 @@ func NewInvoiceAnalyzer(client *ai.Client, repo RepositoryInterface) *InvoiceAna
 	}
 }
 
-// InvoiceExtractionResponse matches the JSON schema from the AI extraction prompt
+// InvoiceExtractionResponse is the structured output of invoice extraction
 type InvoiceExtractionResponse struct {
 	InvoiceNumber string `json:"invoice_number"`
 	VendorName    string `json:"vendor_name"`
@@ -51,10 +52,39 @@ type InvoiceExtractionResponse struct {
 		UnitRate        float64 `json:"unit_rate,omitempty"`
 		BilledHours     float64 `json:"billed_hours,omitempty"`
 		LineAmount      float64 `json:"line_amount"`
-		SpendCategory   string  `json:"spend_category,omitempty"`
-		Confidence      float64 `json:"confidence"`
+		SpendCategory   string  `json:"spend_category,omitempty" jsonschema:"enum=labor|materials|software|travel|other"`
+		Confidence      float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
 	} `json:"line_items"`
-	OverallConfidence float64 `json:"overall_confidence"`
+	OverallConfidence float64 `json:"overall_confidence" jsonschema:"minimum=0,maximum=1"`
+}
+
+// invoiceOutput is the structured output invoice extraction asks the model for
+var invoiceOutput = ai.StructuredOutput{
+	Name:        "record_invoice",
+	Description: "Record the data extracted from the invoice",
+	Schema:      ai.SchemaFor(InvoiceExtractionResponse{}),
+}
+
+// ConflictDetectionResponse is the structured output of cross-document conflict detection
+type ConflictDetectionResponse struct {
+	Conflicts []struct {
+		VarianceType    string   `json:"variance_type"`
+		Severity        string   `json:"severity" jsonschema:"enum=low|medium|high|critical"`
+		Title           string   `json:"title"`
+		Description     string   `json:"description"`
+		PersonName      string   `json:"person_name,omitempty"`
+		ExpectedValue   float64  `json:"expected_value,omitempty"`
+		ActualValue     float64  `json:"actual_value,omitempty"`
+		SourceDocuments []string `json:"source_documents"`
+		Confidence      float64  `json:"confidence" jsonschema:"minimum=0,maximum=1"`
+	} `json:"conflicts"`
+}
+
+// conflictOutput is the structured output conflict detection asks the model for
+var conflictOutput = ai.StructuredOutput{
+	Name:        "record_conflicts",
+	Description: "Record the conflicts found between the invoice and the program context; an empty list if there are none",
+	Schema:      ai.SchemaFor(ConflictDetectionResponse{}),
 }
 
 // VarianceDetectionResult contains detected variances from cross-document analysis
@@ -68,6 +98,7 @@ type VarianceDetectionResult struct {
 // AnalyzeInvoice extracts invoice data from artifact content using AI
 func (a *InvoiceAnalyzer) AnalyzeInvoice(ctx context.Context, artifactContent string, programID uuid.UUID) (*Invoice, []InvoiceLineItem, error) {
 	startTime := time.Now()
+	ctx = ai.WithAttribution(ctx, ai.Attribution{ProgramID: programID, Module: "financial", JobType: "invoice_extraction"})
 
 	// Build prompt for invoice extraction
 	systemPrompt := `You are an expert financial analyst specializing in invoice processing. Your task is to extract structured data from invoice documents with high accuracy.
@@ -122,25 +153,16 @@ IMPORTANT:
 Return only the JSON, no explanation.`, artifactContent)
 
 	// Call Claude API
-	resp, err := a.client.SimpleRequest(
-		ctx,
+	var extraction InvoiceExtractionResponse
+	resp, err := ai.RequestStructured(ctx, a.client, ai.NewSimpleRequest(
 		ai.ModelSonnet4,
 		systemPrompt,
 		userPrompt,
 		4096,
-	)
+	), invoiceOutput, &extraction)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("Claude API request failed: %w", err)
-	}
-
-	// Parse JSON response
-	responseText := resp.GetExtractedText()
-	responseText = stripMarkdownCodeBlocks(responseText)
-
-	var extraction InvoiceExtractionResponse
-	if err := json.Unmarshal([]byte(responseText), &extraction); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse AI response: %w", err)
+		return nil, nil, fmt.Errorf("invoice extraction failed: %w", err)
 	}
 
 	// Convert to domain models
@@ -356,6 +378,8 @@ func (a *InvoiceAnalyzer) ValidateAgainstRateCards(ctx context.Context, invoice
 
 // DetectCrossDocumentConflicts checks invoice against other artifacts for conflicts
 func (a *InvoiceAnalyzer) DetectCrossDocumentConflicts(ctx context.Context, invoice *Invoice, lineItems []InvoiceLineItem, programContext *ai.ProgramContext) ([]FinancialVariance, error) {
+	ctx = ai.WithAttribution(ctx, ai.Attribution{ProgramID: invoice.ProgramID, Module: "financial", JobType: "invoice_conflict_detection"})
+
 	// This method queries other artifacts in the program to find conflicting information
 	// For example: planning documents that specify different rates or hours
 
@@ -368,20 +392,22 @@ Look for:
 3. Person on invoice not mentioned in planning documents
 4. Billing period mismatches
 
-Return JSON array of conflicts:
-[
-  {
-    "variance_type": "cross_document_conflict",
-    "severity": "low|medium|high|critical",
-    "title": "Brief title",
-    "description": "Detailed description with evidence",
-    "person_name": "string",
-    "expected_value": number,
-    "actual_value": number,
-    "source_documents": ["doc names"],
-    "confidence": 0.0-1.0
-  }
-]`
+Return a JSON object listing the conflicts (an empty list if there are none):
+{
+  "conflicts": [
+    {
+      "variance_type": "cross_document_conflict",
+      "severity": "low|medium|high|critical",
+      "title": "Brief title",
+      "description": "Detailed description with evidence",
+      "person_name": "string",
+      "expected_value": number,
+      "actual_value": number,
+      "source_documents": ["doc names"],
+      "confidence": 0.0-1.0
+    }
+  ]
+}`
 
 	// Build context from line items
 	var lineItemsJSON bytes.Buffer
@@ -420,43 +446,28 @@ Analyze these invoice line items against the program context. Identify any confl
 	)
 
 	// Call Claude API
-	resp, err := a.client.RequestWithContext(
-		ctx,
+	var conflictResults ConflictDetectionResponse
+	_, err := ai.RequestStructured(ctx, a.client, ai.NewContextRequest(
 		ai.ModelSonnet4,
 		systemPrompt,
 		programContext.ToPromptString(), // Cache program context
 		userPrompt,
 		4096,
-	)
+	), conflictOutput, &conflictResults)
 
+	var validationErr *ai.OutputValidationError
+	if errors.As(err, &validationErr) {
+		// Conflict detection is advisory; invalid output means no conflicts were found
+		fmt.Printf("Warning: Ignoring invalid conflict detection output: %v\n", err)
+		return []FinancialVariance{}, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Claude API request failed: %w", err)
 	}
 
-	// Parse response
-	responseText := resp.GetExtractedText()
-	responseText = stripMarkdownCodeBlocks(responseText)
-
-	var conflictResults []struct {
-		VarianceType    string   `json:"variance_type"`
-		Severity        string   `json:"severity"`
-		Title           string   `json:"title"`
-		Description     string   `json:"description"`
-		PersonName      string   `json:"person_name,omitempty"`
-		ExpectedValue   float64  `json:"expected_value,omitempty"`
-		ActualValue     float64  `json:"actual_value,omitempty"`
-		SourceDocuments []string `json:"source_documents"`
-		Confidence      float64  `json:"confidence"`
-	}
-
-	if err := json.Unmarshal([]byte(responseText), &conflictResults); err != nil {
-		// If no conflicts found or parsing fails, return empty
-		return []FinancialVariance{}, nil
-	}
-
 	// Convert to domain models
 	var variances []FinancialVariance
-	for _, conflict := range conflictResults {
+	for _, conflict := range conflictResults.Conflicts {
 		// Find matching line item
 		var lineItemID uuid.NullUUID
 		for _, li := range lineItems {
@@ -656,17 +667,26 @@ func (a *InvoiceAnalyzer) ProcessInvoice(ctx context.Context, artifactID uuid.UU
 		return fmt.Errorf("failed to calculate variances: %w", err)
 	}
 
-	// Save variances
-	if len(varianceResult.Variances) > 0 {
-		if err := a.repo.SaveVariances(ctx, varianceResult.Variances); err != nil {
-			return fmt.Errorf("failed to save variances: %w", err)
+	// Queue variance.detected for each variance, then invoice.processed
+	var outboxEvents []*events.Event
+	for _, variance := range varianceResult.Variances {
+		event, err := varianceDetectedEvent(variance)
+		if err != nil {
+			return err
 		}
+		outboxEvents = append(outboxEvents, event)
 	}
 
-	// Update invoice status
+	// Save variances and update invoice status together with their events
 	invoice.ProcessingStatus = "validated"
-	if err := a.repo.UpdateInvoice(ctx, invoice); err != nil {
-		return fmt.Errorf("failed to update invoice status: %w", err)
+	processedEvent, err := invoiceProcessedEvent(invoice, len(lineItems), len(varianceResult.Variances), replacedInvoiceIDs)
+	if err != nil {
+		return err
+	}
+	outboxEvents = append(outboxEvents, processedEvent)
+
+	if err := a.repo.CompleteInvoiceProcessing(ctx, invoice, varianceResult.Variances, outboxEvents...); err != nil {
+		return fmt.Errorf("failed to complete invoice processing: %w", err)
 	}
 
 	return nil
@@ -674,19 +694,6 @@ func (a *InvoiceAnalyzer) ProcessInvoice(ctx context.Context, artifactID uuid.UU
 
 // Helper functions
 
-func stripMarkdownCodeBlocks(text string) string {
-	text = strings.TrimSpace(text)
-	if strings.HasPrefix(text, "```") {
-		firstNewline := strings.Index(text, "\n")
-		if firstNewline > 0 {
-			text = text[firstNewline+1:]
-		}
-		text = strings.TrimSuffix(text, "```")
-		text = strings.TrimSpace(text)
-	}
-	return text
-}
-
 func toNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: s != ""}
 }
@@ -155,50 +155,6 @@ func TestToNullFloat64(t *testing.T) {
 	}
 }
 
-// TestStripMarkdownCodeBlocks tests markdown code block removal
-func TestStripMarkdownCodeBlocks(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  string
-	}{
-		{
-			name:  "JSON with code blocks",
-			input: "```json\n{\"key\": \"value\"}\n```",
-			want:  "{\"key\": \"value\"}",
-		},
-		{
-			name:  "Text without code blocks",
-			input: "{\"key\": \"value\"}",
-			want:  "{\"key\": \"value\"}",
-		},
-		{
-			name:  "Code block without language",
-			input: "```\n{\"key\": \"value\"}\n```",
-			want:  "{\"key\": \"value\"}",
-		},
-		{
-			name:  "Multiline JSON with code blocks",
-			input: "```json\n{\n  \"key\": \"value\",\n  \"number\": 123\n}\n```",
-			want:  "{\n  \"key\": \"value\",\n  \"number\": 123\n}",
-		},
-		{
-			name:  "Empty string",
-			input: "",
-			want:  "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := stripMarkdownCodeBlocks(tt.input)
-			if got != tt.want {
-				t.Errorf("stripMarkdownCodeBlocks() = %q, want %q", got, tt.want)
-			}
-		})
-	}
-}
-
 // TestParseDate tests date parsing
 func TestParseDate(t *testing.T) {
 	tests := []struct {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -32,6 +33,8 @@ type RepositoryInterface interface {
 	GetInvoiceByID(ctx context.Context, invoiceID uuid.UUID) (*Invoice, error)
 	ListInvoices(ctx context.Context, filter InvoiceFilterRequest) ([]Invoice, error)
 	UpdateInvoice(ctx context.Context, invoice *Invoice) error
+	UpdateInvoiceWithEvents(ctx context.Context, invoice *Invoice, outboxEvents ...*events.Event) error
+	CompleteInvoiceProcessing(ctx context.Context, invoice *Invoice, variances []FinancialVariance, outboxEvents ...*events.Event) error
 	DeleteInvoice(ctx context.Context, invoiceID uuid.UUID) error
 	GetInvoiceByArtifactID(ctx context.Context, artifactID uuid.UUID) (*Invoice, error)
 	GetInvoicesByIdentifier(ctx context.Context, programID uuid.UUID, vendorName string, invoiceNumber string) ([]Invoice, error)
@@ -51,6 +54,7 @@ type RepositoryInterface interface {
 	GetBudgetCategoryByID(ctx context.Context, categoryID uuid.UUID) (*BudgetCategory, error)
 	ListBudgetCategories(ctx context.Context, programID uuid.UUID, fiscalYear int) ([]BudgetCategory, error)
 	UpdateBudgetCategory(ctx context.Context, category *BudgetCategory) error
+	UpdateBudgetCategoryWithEvents(ctx context.Context, category *BudgetCategory, outboxEvents ...*events.Event) error
 	DeleteBudgetCategory(ctx context.Context, categoryID uuid.UUID) error
 
 	// Financial Variances
@@ -818,6 +822,41 @@ func (r *Repository) ListInvoices(ctx context.Context, filter InvoiceFilterReque
 
 // UpdateInvoice updates an existing invoice
 func (r *Repository) UpdateInvoice(ctx context.Context, invoice *Invoice) error {
+	return updateInvoice(ctx, r.db, invoice)
+}
+
+// UpdateInvoiceWithEvents updates an invoice and writes outboxEvents in the same transaction,
+// so the events are published only if the update commits
+func (r *Repository) UpdateInvoiceWithEvents(ctx context.Context, invoice *Invoice, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateInvoice(ctx, tx, invoice); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// writeOutbox writes events to the outbox within tx
+func writeOutbox(ctx context.Context, tx db.Execer, outboxEvents []*events.Event) error {
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateInvoice updates an invoice row using exec (the database or a transaction)
+func updateInvoice(ctx context.Context, exec db.Execer, invoice *Invoice) error {
 	query := `
 		UPDATE invoices
 		SET processing_status = $1, payment_status = $2, approved_by = $3,
@@ -825,7 +864,7 @@ func (r *Repository) UpdateInvoice(ctx context.Context, invoice *Invoice) error
 		WHERE invoice_id = $6 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := exec.ExecContext(ctx, query,
 		invoice.ProcessingStatus,
 		invoice.PaymentStatus,
 		invoice.ApprovedBy,
@@ -1193,13 +1232,37 @@ func (r *Repository) ListBudgetCategories(ctx context.Context, programID uuid.UU
 
 // UpdateBudgetCategory updates an existing budget category
 func (r *Repository) UpdateBudgetCategory(ctx context.Context, category *BudgetCategory) error {
+	return updateBudgetCategory(ctx, r.db, category)
+}
+
+// UpdateBudgetCategoryWithEvents updates a budget category and writes outboxEvents in the same transaction
+func (r *Repository) UpdateBudgetCategoryWithEvents(ctx context.Context, category *BudgetCategory, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateBudgetCategory(ctx, tx, category); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateBudgetCategory updates a budget category row using exec (the database or a transaction)
+func updateBudgetCategory(ctx context.Context, exec db.Execer, category *BudgetCategory) error {
 	query := `
 		UPDATE budget_categories
 		SET budgeted_amount = $1, actual_spend = $2, committed_spend = $3
 		WHERE category_id = $4 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := exec.ExecContext(ctx, query,
 		category.BudgetedAmount,
 		category.ActualSpend,
 		category.CommittedSpend,
@@ -1249,6 +1312,35 @@ func (r *Repository) DeleteBudgetCategory(ctx context.Context, categoryID uuid.U
 
 // SaveVariances inserts multiple financial variances
 func (r *Repository) SaveVariances(ctx context.Context, variances []FinancialVariance) error {
+	return saveVariances(ctx, r.db, variances)
+}
+
+// CompleteInvoiceProcessing saves the variances found for an invoice, updates the invoice
+// and writes outboxEvents in one transaction
+func (r *Repository) CompleteInvoiceProcessing(ctx context.Context, invoice *Invoice, variances []FinancialVariance, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveVariances(ctx, tx, variances); err != nil {
+		return err
+	}
+
+	if err := updateInvoice(ctx, tx, invoice); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveVariances inserts variance rows using exec (the database or a transaction)
+func saveVariances(ctx context.Context, exec db.Execer, variances []FinancialVariance) error {
 	if len(variances) == 0 {
 		return nil
 	}
@@ -1263,7 +1355,7 @@ func (r *Repository) SaveVariances(ctx context.Context, variances []FinancialVar
 	`
 
 	for _, v := range variances {
-		_, err := r.db.ExecContext(ctx, query,
+		_, err := exec.ExecContext(ctx, query,
 			v.VarianceID,
 			v.ProgramID,
 			v.InvoiceID,
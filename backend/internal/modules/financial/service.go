@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/cerberus/backend/internal/platform/storage"
 	"github.com/google/uuid"
 )
@@ -20,7 +21,7 @@ type Service struct {
 }
 
 // NewService creates a new financial service
-func NewService(repo *Repository, stor storage.Storage, aiClient *ai.Client) *Service {
+func NewService(repo *Repository, stor storage.Storage, aiClient ai.Provider) *Service {
 	return &Service{
 		repo:     repo,
 		storage:  stor,
@@ -351,7 +352,19 @@ func (s *Service) ApproveInvoice(ctx context.Context, invoiceID uuid.UUID, appro
 	invoice.ApprovedBy = uuid.NullUUID{UUID: approvedBy, Valid: true}
 	invoice.ApprovedAt = toNullTime(time.Now().Format("2006-01-02"))
 
-	err = s.repo.UpdateInvoice(ctx, invoice)
+	// Queue the approval event in the same transaction as the status change
+	event, err := events.NewEventFromPayload(events.InvoiceApproved, invoice.ProgramID, "financial", events.InvoiceApprovedPayload{
+		InvoiceID:   invoice.InvoiceID,
+		VendorName:  invoice.VendorName,
+		TotalAmount: invoice.TotalAmount,
+		Currency:    invoice.Currency,
+		ApprovedBy:  approvedBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateInvoiceWithEvents(ctx, invoice, event)
 	if err != nil {
 		return fmt.Errorf("failed to approve invoice: %w", err)
 	}
@@ -521,7 +534,25 @@ func (s *Service) UpdateBudgetCategory(ctx context.Context, category *BudgetCate
 		return fmt.Errorf("category_id is required")
 	}
 
-	err := s.repo.UpdateBudgetCategory(ctx, category)
+	// Load the current state to detect budget alert thresholds crossed by this update
+	previous, err := s.repo.GetBudgetCategoryByID(ctx, category.CategoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get budget category: %w", err)
+	}
+	category.ProgramID = previous.ProgramID
+	category.CategoryName = previous.CategoryName
+	category.FiscalYear = previous.FiscalYear
+
+	var outboxEvents []*events.Event
+	thresholdEvent, err := budgetThresholdEvent(previous, category)
+	if err != nil {
+		return err
+	}
+	if thresholdEvent != nil {
+		outboxEvents = append(outboxEvents, thresholdEvent)
+	}
+
+	err = s.repo.UpdateBudgetCategoryWithEvents(ctx, category, outboxEvents...)
 	if err != nil {
 		return fmt.Errorf("failed to update budget category: %w", err)
 	}
@@ -62,7 +62,7 @@ func handleUpdateConfig(service *ConfigService) http.HandlerFunc {
 		}
 
 		// Validate the configuration if provided
-		if req.Company != nil || req.Taxonomy != nil || req.Vendors != nil {
+		if req.Company != nil || req.Taxonomy != nil || req.Vendors != nil || req.AIBudget != nil || req.AITier != nil || req.DisableAICache != nil || req.MaxUploadBytes != nil {
 			// Build a temporary config for validation
 			currentConfig, err := service.GetProgramConfig(r.Context(), programID)
 			if err != nil {
@@ -80,6 +80,18 @@ func handleUpdateConfig(service *ConfigService) http.HandlerFunc {
 			if req.Vendors != nil {
 				testConfig.Vendors = *req.Vendors
 			}
+			if req.AIBudget != nil {
+				testConfig.AIBudget = req.AIBudget
+			}
+			if req.AITier != nil {
+				testConfig.AITier = *req.AITier
+			}
+			if req.DisableAICache != nil {
+				testConfig.DisableAICache = *req.DisableAICache
+			}
+			if req.MaxUploadBytes != nil {
+				testConfig.MaxUploadBytes = *req.MaxUploadBytes
+			}
 
 			if err := service.ValidateConfig(&testConfig); err != nil {
 				respondError(w, http.StatusBadRequest, err.Error())
@@ -5,6 +5,14 @@ type ProgramConfig struct {
 	Company  CompanyConfig   `json:"company"`
 	Taxonomy TaxonomyConfig  `json:"taxonomy"`
 	Vendors  []VendorConfig  `json:"vendors"`
+	AIBudget *AIBudgetConfig `json:"ai_budget,omitempty"`
+	AITier   string          `json:"ai_tier,omitempty"` // Selects models through the AI routing policy
+
+	// DisableAICache keeps the program's AI responses out of the shared response cache
+	DisableAICache bool `json:"disable_ai_cache,omitempty"`
+
+	// MaxUploadBytes limits the size of uploaded files; zero uses the server default
+	MaxUploadBytes int64 `json:"max_upload_bytes,omitempty"`
 }
 
 // CompanyConfig represents company information
@@ -27,9 +35,20 @@ type VendorConfig struct {
 	Type string `json:"type,omitempty"`
 }
 
+// AIBudgetConfig caps a program's AI spend in USD. A zero limit is not enforced.
+type AIBudgetConfig struct {
+	DailyLimitUSD   float64 `json:"daily_limit_usd,omitempty"`
+	MonthlyLimitUSD float64 `json:"monthly_limit_usd,omitempty"`
+}
+
 // UpdateConfigRequest represents a request to update program configuration
 type UpdateConfigRequest struct {
 	Company  *CompanyConfig  `json:"company,omitempty"`
 	Taxonomy *TaxonomyConfig `json:"taxonomy,omitempty"`
 	Vendors  *[]VendorConfig `json:"vendors,omitempty"`
+	AIBudget *AIBudgetConfig `json:"ai_budget,omitempty"`
+	AITier   *string         `json:"ai_tier,omitempty"`
+
+	DisableAICache *bool  `json:"disable_ai_cache,omitempty"`
+	MaxUploadBytes *int64 `json:"max_upload_bytes,omitempty"`
 }
@@ -13,7 +13,8 @@ import (
 
 // ConfigService handles program configuration operations
 type ConfigService struct {
-	db *db.DB
+	db       *db.DB
+	onChange []func(ctx context.Context, programID uuid.UUID)
 }
 
 // NewConfigService creates a new config service
@@ -21,6 +22,11 @@ func NewConfigService(database *db.DB) *ConfigService {
 	return &ConfigService{db: database}
 }
 
+// OnChange registers fn to be called after a program's configuration is updated
+func (s *ConfigService) OnChange(fn func(ctx context.Context, programID uuid.UUID)) {
+	s.onChange = append(s.onChange, fn)
+}
+
 // GetProgram retrieves a program by ID with its configuration
 func (s *ConfigService) GetProgram(ctx context.Context, programID uuid.UUID) (*Program, error) {
 	query := `
@@ -112,6 +118,18 @@ func (s *ConfigService) UpdateProgramConfig(ctx context.Context, programID uuid.
 	if req.Vendors != nil {
 		currentConfig.Vendors = *req.Vendors
 	}
+	if req.AIBudget != nil {
+		currentConfig.AIBudget = req.AIBudget
+	}
+	if req.AITier != nil {
+		currentConfig.AITier = *req.AITier
+	}
+	if req.DisableAICache != nil {
+		currentConfig.DisableAICache = *req.DisableAICache
+	}
+	if req.MaxUploadBytes != nil {
+		currentConfig.MaxUploadBytes = *req.MaxUploadBytes
+	}
 
 	// Serialize to JSON
 	configJSON, err := json.Marshal(currentConfig)
@@ -140,6 +158,10 @@ func (s *ConfigService) UpdateProgramConfig(ctx context.Context, programID uuid.
 		return fmt.Errorf("program not found or already deleted")
 	}
 
+	for _, fn := range s.onChange {
+		fn(ctx, programID)
+	}
+
 	return nil
 }
 
@@ -288,6 +310,14 @@ func (s *ConfigService) ValidateConfig(config *ProgramConfig) error {
 		"other":           true,
 	}
 
+	if config.AIBudget != nil && (config.AIBudget.DailyLimitUSD < 0 || config.AIBudget.MonthlyLimitUSD < 0) {
+		return fmt.Errorf("AI budget limits cannot be negative")
+	}
+
+	if config.MaxUploadBytes < 0 {
+		return fmt.Errorf("upload size limit cannot be negative")
+	}
+
 	for _, vendor := range config.Vendors {
 		if vendor.Name == "" {
 			return fmt.Errorf("vendor name is required")
@@ -0,0 +1,246 @@
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// dateLayout is the format of the from and to query parameters
+const dateLayout = "2006-01-02"
+
+// RegisterAdminRoutes registers the global prompt version endpoints (global admins only).
+// Evaluations here cover all programs.
+func RegisterAdminRoutes(r chi.Router, service *Service) {
+	r.Route("/admin/prompts", func(r chi.Router) {
+		r.Use(auth.RequireGlobalAdmin())
+		r.Get("/", handleListVersions(service))
+		r.Get("/{promptId}/versions", handleListPromptVersions(service))
+		r.Post("/{promptId}/versions", handleCreateVersion(service))
+		r.Patch("/{promptId}/versions/{version}", handleUpdateVersion(service))
+		r.Get("/{promptId}/evaluation", handleGetEvaluation(service))
+	})
+}
+
+// RegisterRoutes registers a program's prompt override endpoints
+func RegisterRoutes(r chi.Router, service *Service, authRepo *auth.Repository) {
+	r.Route("/programs/{programId}/prompts", func(r chi.Router) {
+		// Viewer access
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireProgramAccess(auth.RoleViewer, authRepo))
+			r.Get("/", handleListVersions(service))
+			r.Get("/{promptId}/versions", handleListPromptVersions(service))
+			r.Get("/{promptId}/evaluation", handleGetEvaluation(service))
+		})
+
+		// Admin access
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireProgramAccess(auth.RoleAdmin, authRepo))
+			r.Post("/{promptId}/versions", handleCreateVersion(service))
+			r.Patch("/{promptId}/versions/{version}", handleUpdateVersion(service))
+		})
+	})
+}
+
+// handleListVersions lists the global prompt versions, and under a program its overrides
+func handleListVersions(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, ok := parseScope(w, r)
+		if !ok {
+			return
+		}
+
+		versions, err := service.ListVersions(r.Context(), programID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"versions": versions,
+			"count":    len(versions),
+		})
+	}
+}
+
+// handleListPromptVersions lists the versions of one prompt in the route's scope
+func handleListPromptVersions(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, ok := parseScope(w, r)
+		if !ok {
+			return
+		}
+
+		versions, err := service.ListPromptVersions(r.Context(), chi.URLParam(r, "promptId"), programID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"versions": versions,
+			"count":    len(versions),
+		})
+	}
+}
+
+// handleCreateVersion adds a prompt version in the route's scope
+func handleCreateVersion(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, ok := parseScope(w, r)
+		if !ok {
+			return
+		}
+
+		var req CreateVersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		userID, _ := auth.GetUserID(r.Context())
+		version, err := service.CreateVersion(r.Context(), chi.URLParam(r, "promptId"), programID, &req, userID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondCreated(w, version)
+	}
+}
+
+// handleUpdateVersion changes a prompt version's status or traffic share
+func handleUpdateVersion(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, ok := parseScope(w, r)
+		if !ok {
+			return
+		}
+
+		var req UpdateVersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		version, err := service.UpdateVersion(r.Context(), chi.URLParam(r, "promptId"), programID,
+			chi.URLParam(r, "version"), &req)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, version)
+	}
+}
+
+// handleGetEvaluation compares the versions of a prompt. from and to are inclusive UTC dates
+// and default to the last 30 days.
+func handleGetEvaluation(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, ok := parseScope(w, r)
+		if !ok {
+			return
+		}
+
+		from, to, err := parseRange(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		evaluation, err := service.GetEvaluation(r.Context(), chi.URLParam(r, "promptId"), programID, from, to)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, evaluation)
+	}
+}
+
+// Helper functions
+
+// parseScope returns the program of a program route, or no program for the admin routes,
+// responding on failure
+func parseScope(w http.ResponseWriter, r *http.Request) (uuid.NullUUID, bool) {
+	param := chi.URLParam(r, "programId")
+	if param == "" {
+		return uuid.NullUUID{}, true
+	}
+
+	programID, err := uuid.Parse(param)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid program ID")
+		return uuid.NullUUID{}, false
+	}
+	return uuid.NullUUID{UUID: programID, Valid: true}, true
+}
+
+// parseRange reads the evaluation period from the query string
+func parseRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	if param := query.Get("to"); param != "" {
+		day, err := time.Parse(dateLayout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date: use YYYY-MM-DD")
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+
+	from := to.Add(-DefaultPeriod)
+	if param := query.Get("from"); param != "" {
+		day, err := time.Parse(dateLayout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date: use YYYY-MM-DD")
+		}
+		from = day
+	}
+
+	return from, to, nil
+}
+
+// statusForError maps service errors to HTTP statuses
+func statusForError(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(msg, "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondSuccess(w http.ResponseWriter, data interface{}) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func respondCreated(w http.ResponseWriter, data interface{}) {
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,95 @@
+package prompts
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/google/uuid"
+)
+
+// PromptVersion is a stored version of a prompt, global or overriding it for one program
+type PromptVersion struct {
+	PromptTemplateID   uuid.UUID      `json:"prompt_template_id"`
+	PromptID           string         `json:"prompt_id"`
+	Version            string         `json:"version"`
+	ProgramID          uuid.NullUUID  `json:"program_id,omitempty"` // Not set for global versions
+	Status             string         `json:"status"`
+	TrafficPercent     int            `json:"traffic_percent"`
+	Module             string         `json:"module"`
+	Purpose            string         `json:"purpose"`
+	SystemPrompt       string         `json:"system_prompt"`
+	UserPromptTemplate string         `json:"user_prompt_template"`
+	Model              string         `json:"model"`
+	Temperature        float64        `json:"temperature"`
+	MaxTokens          int            `json:"max_tokens"`
+	Notes              sql.NullString `json:"notes,omitempty"`
+	CreatedBy          uuid.NullUUID  `json:"created_by,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+}
+
+// Template converts the version to the template the AI analyzers use
+func (v *PromptVersion) Template() *ai.PromptTemplate {
+	return &ai.PromptTemplate{
+		ID:             v.PromptID,
+		Version:        v.Version,
+		Module:         v.Module,
+		Purpose:        v.Purpose,
+		SystemPrompt:   v.SystemPrompt,
+		UserPromptTmpl: v.UserPromptTemplate,
+		Model:          v.Model,
+		Temperature:    v.Temperature,
+		MaxTokens:      v.MaxTokens,
+		Status:         v.Status,
+		TrafficPercent: v.TrafficPercent,
+	}
+}
+
+// CreateVersionRequest adds a version of a prompt. Empty prompt fields are copied from the
+// version currently serving the scope, so an override can change just the system prompt.
+type CreateVersionRequest struct {
+	Version            string   `json:"version"`
+	Status             string   `json:"status,omitempty"` // draft (default), candidate or active
+	TrafficPercent     int      `json:"traffic_percent,omitempty"`
+	SystemPrompt       string   `json:"system_prompt,omitempty"`
+	UserPromptTemplate string   `json:"user_prompt_template,omitempty"`
+	Model              string   `json:"model,omitempty"`
+	Temperature        *float64 `json:"temperature,omitempty"`
+	MaxTokens          int      `json:"max_tokens,omitempty"`
+	Notes              string   `json:"notes,omitempty"`
+}
+
+// UpdateVersionRequest changes a version's status or traffic share. Making a version active
+// or candidate retires the version that held that status in the same scope.
+type UpdateVersionRequest struct {
+	Status         *string `json:"status,omitempty"`
+	TrafficPercent *int    `json:"traffic_percent,omitempty"`
+}
+
+// VersionMetrics are the outcomes of one prompt version over an evaluation period
+type VersionMetrics struct {
+	Version          string  `json:"version"`
+	Runs             int64   `json:"runs"`
+	Repairs          int64   `json:"repairs"` // Runs whose output was only valid after a repair request
+	ParseFailures    int64   `json:"parse_failures"`
+	ParseFailureRate float64 `json:"parse_failure_rate"`
+	Insights         int64   `json:"insights"`
+	ReviewedInsights int64   `json:"reviewed_insights"` // Rated or dismissed
+	AcceptedInsights int64   `json:"accepted_insights"` // Rated 4-5 and not dismissed
+	RejectedInsights int64   `json:"rejected_insights"` // Dismissed or rated 1-2
+	AcceptanceRate   float64 `json:"acceptance_rate"`   // Accepted share of accepted and rejected insights
+	Requests         int64   `json:"requests"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CostPerRun       float64 `json:"cost_per_run"`
+}
+
+// Evaluation compares the versions of a prompt over a period
+type Evaluation struct {
+	PromptID  string           `json:"prompt_id"`
+	ProgramID uuid.NullUUID    `json:"program_id,omitempty"` // Not set when covering all programs
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Versions  []VersionMetrics `json:"versions"`
+}
@@ -0,0 +1,337 @@
+package prompts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+)
+
+// RepositoryInterface defines methods for prompt version data access
+type RepositoryInterface interface {
+	// Versions
+	ListVersions(ctx context.Context, programID uuid.NullUUID) ([]PromptVersion, error)
+	ListPromptVersions(ctx context.Context, promptID string, programID uuid.NullUUID) ([]PromptVersion, error)
+	GetVersion(ctx context.Context, promptID string, programID uuid.NullUUID, version string) (*PromptVersion, error)
+	GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]PromptVersion, error)
+	CreateVersion(ctx context.Context, v *PromptVersion) error
+	UpdateVersion(ctx context.Context, v *PromptVersion) error
+	EnsureDefaults(ctx context.Context, templates []*ai.PromptTemplate) error
+
+	// Evaluation
+	GetEvaluation(ctx context.Context, promptID string, programID uuid.NullUUID, from, to time.Time) ([]VersionMetrics, error)
+}
+
+// Repository handles prompt version data access
+type Repository struct {
+	db *db.DB
+}
+
+// NewRepository creates a new prompts repository
+func NewRepository(database *db.DB) *Repository {
+	return &Repository{db: database}
+}
+
+const versionColumns = `
+	prompt_template_id, prompt_id, version, program_id, status, traffic_percent, module, purpose,
+	system_prompt, user_prompt_template, model, temperature, max_tokens, notes, created_by,
+	created_at, updated_at`
+
+// scanVersion scans a row selected with versionColumns
+func scanVersion(row interface{ Scan(...interface{}) error }, v *PromptVersion) error {
+	return row.Scan(
+		&v.PromptTemplateID, &v.PromptID, &v.Version, &v.ProgramID, &v.Status, &v.TrafficPercent, &v.Module, &v.Purpose,
+		&v.SystemPrompt, &v.UserPromptTemplate, &v.Model, &v.Temperature, &v.MaxTokens, &v.Notes, &v.CreatedBy,
+		&v.CreatedAt, &v.UpdatedAt,
+	)
+}
+
+// ListVersions lists the versions of every prompt in a scope: the global versions, or with a
+// program the global versions and the program's overrides
+func (r *Repository) ListVersions(ctx context.Context, programID uuid.NullUUID) ([]PromptVersion, error) {
+	return r.listVersions(ctx, `
+		SELECT `+versionColumns+`
+		FROM prompt_templates
+		WHERE program_id IS NULL OR program_id = $1
+		ORDER BY prompt_id, program_id NULLS FIRST, created_at DESC
+	`, programID)
+}
+
+// ListPromptVersions lists the versions of one prompt in a single scope, newest first
+func (r *Repository) ListPromptVersions(ctx context.Context, promptID string, programID uuid.NullUUID) ([]PromptVersion, error) {
+	return r.listVersions(ctx, `
+		SELECT `+versionColumns+`
+		FROM prompt_templates
+		WHERE prompt_id = $1 AND program_id IS NOT DISTINCT FROM $2
+		ORDER BY created_at DESC
+	`, promptID, programID)
+}
+
+// GetServingVersions returns the active and candidate versions of a prompt, both global and
+// overriding it for the program
+func (r *Repository) GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]PromptVersion, error) {
+	return r.listVersions(ctx, `
+		SELECT `+versionColumns+`
+		FROM prompt_templates
+		WHERE prompt_id = $1 AND (program_id IS NULL OR program_id = $2)
+		  AND status IN ('active', 'candidate')
+	`, promptID, programID)
+}
+
+func (r *Repository) listVersions(ctx context.Context, query string, args ...interface{}) ([]PromptVersion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make([]PromptVersion, 0)
+	for rows.Next() {
+		var v PromptVersion
+		if err := scanVersion(rows, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// GetVersion retrieves a version of a prompt in a scope
+func (r *Repository) GetVersion(ctx context.Context, promptID string, programID uuid.NullUUID, version string) (*PromptVersion, error) {
+	var v PromptVersion
+	err := scanVersion(r.db.QueryRowContext(ctx, `
+		SELECT `+versionColumns+`
+		FROM prompt_templates
+		WHERE prompt_id = $1 AND program_id IS NOT DISTINCT FROM $2 AND version = $3
+	`, promptID, programID, version), &v)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("prompt version not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt version: %w", err)
+	}
+	return &v, nil
+}
+
+// CreateVersion inserts a prompt version, retiring the version it replaces as active or candidate
+func (r *Repository) CreateVersion(ctx context.Context, v *PromptVersion) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := retireReplaced(ctx, tx, v); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO prompt_templates (
+			prompt_template_id, prompt_id, version, program_id, status, traffic_percent, module, purpose,
+			system_prompt, user_prompt_template, model, temperature, max_tokens, notes, created_by,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`, v.PromptTemplateID, v.PromptID, v.Version, v.ProgramID, v.Status, v.TrafficPercent, v.Module, v.Purpose,
+		v.SystemPrompt, v.UserPromptTemplate, v.Model, v.Temperature, v.MaxTokens, v.Notes, v.CreatedBy,
+		v.CreatedAt, v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt version: %w", err)
+	}
+	return nil
+}
+
+// UpdateVersion saves a version's status and traffic share, retiring the version it replaces
+// as active or candidate
+func (r *Repository) UpdateVersion(ctx context.Context, v *PromptVersion) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := retireReplaced(ctx, tx, v); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE prompt_templates
+		SET status = $2, traffic_percent = $3, updated_at = $4
+		WHERE prompt_template_id = $1
+	`, v.PromptTemplateID, v.Status, v.TrafficPercent, v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update prompt version: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("prompt version not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt version: %w", err)
+	}
+	return nil
+}
+
+// retireReplaced retires the other version holding v's status in its scope, if v is active or candidate
+func retireReplaced(ctx context.Context, tx *sql.Tx, v *PromptVersion) error {
+	if v.Status != ai.PromptStatusActive && v.Status != ai.PromptStatusCandidate {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE prompt_templates
+		SET status = 'retired', traffic_percent = 0, updated_at = NOW()
+		WHERE prompt_id = $1 AND program_id IS NOT DISTINCT FROM $2 AND status = $3
+		  AND prompt_template_id <> $4
+	`, v.PromptID, v.ProgramID, v.Status, v.PromptTemplateID)
+	if err != nil {
+		return fmt.Errorf("failed to retire replaced prompt version: %w", err)
+	}
+	return nil
+}
+
+// EnsureDefaults stores the built-in templates as global versions. A version that is already
+// stored is left alone; a new one becomes active only if its prompt has no active global
+// version, so prompts promoted by an admin keep serving until the new version is promoted.
+func (r *Repository) EnsureDefaults(ctx context.Context, templates []*ai.PromptTemplate) error {
+	for _, t := range templates {
+		// DO NOTHING also covers another worker seeding the same prompt concurrently
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO prompt_templates (
+				prompt_id, version, status, module, purpose, system_prompt, user_prompt_template,
+				model, temperature, max_tokens, notes
+			)
+			SELECT $1, $2,
+			       CASE WHEN EXISTS (
+			           SELECT 1 FROM prompt_templates
+			           WHERE prompt_id = $1 AND program_id IS NULL AND status = 'active'
+			       ) THEN 'draft' ELSE 'active' END,
+			       $3, $4, $5, $6, $7, $8, $9, 'Built-in version'
+			WHERE NOT EXISTS (
+			    SELECT 1 FROM prompt_templates
+			    WHERE prompt_id = $1 AND program_id IS NULL AND version = $2
+			)
+			ON CONFLICT DO NOTHING
+		`, t.ID, t.Version, t.Module, t.Purpose, t.SystemPrompt, t.UserPromptTmpl,
+			t.Model, t.Temperature, t.MaxTokens)
+		if err != nil {
+			return fmt.Errorf("failed to store built-in prompt %s: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// GetEvaluation collects the outcomes of each version of a prompt in [from, to), for one
+// program or, without one, across all programs. Versions are listed in order.
+func (r *Repository) GetEvaluation(ctx context.Context, promptID string, programID uuid.NullUUID, from, to time.Time) ([]VersionMetrics, error) {
+	metrics := make(map[string]*VersionMetrics)
+	get := func(version string) *VersionMetrics {
+		if m, ok := metrics[version]; ok {
+			return m
+		}
+		m := &VersionMetrics{Version: version}
+		metrics[version] = m
+		return m
+	}
+
+	// Analysis runs, repairs and parse failures
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT prompt_version, COUNT(*),
+		       COUNT(*) FILTER (WHERE outcome = 'repaired'),
+		       COUNT(*) FILTER (WHERE outcome = 'parse_failed')
+		FROM prompt_runs
+		WHERE prompt_id = $1 AND created_at >= $2 AND created_at < $3
+		  AND ($4::uuid IS NULL OR program_id = $4)
+		GROUP BY prompt_version
+	`, promptID, from, to, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt runs: %w", err)
+	}
+	for rows.Next() {
+		var version string
+		var runs, repairs, failures int64
+		if err := rows.Scan(&version, &runs, &repairs, &failures); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan prompt runs: %w", err)
+		}
+		m := get(version)
+		m.Runs, m.Repairs, m.ParseFailures = runs, repairs, failures
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get prompt runs: %w", err)
+	}
+
+	// Insight feedback
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT i.prompt_version,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE i.user_rating IS NOT NULL OR COALESCE(i.is_dismissed, false)),
+		       COUNT(*) FILTER (WHERE NOT COALESCE(i.is_dismissed, false) AND i.user_rating >= 4),
+		       COUNT(*) FILTER (WHERE COALESCE(i.is_dismissed, false) OR i.user_rating <= 2)
+		FROM artifact_insights i
+		JOIN artifacts a ON a.artifact_id = i.artifact_id
+		WHERE i.prompt_id = $1 AND i.extracted_at >= $2 AND i.extracted_at < $3
+		  AND ($4::uuid IS NULL OR a.program_id = $4)
+		GROUP BY i.prompt_version
+	`, promptID, from, to, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insight feedback: %w", err)
+	}
+	for rows.Next() {
+		var version string
+		var insights, reviewed, accepted, rejected int64
+		if err := rows.Scan(&version, &insights, &reviewed, &accepted, &rejected); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan insight feedback: %w", err)
+		}
+		m := get(version)
+		m.Insights, m.ReviewedInsights, m.AcceptedInsights, m.RejectedInsights = insights, reviewed, accepted, rejected
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get insight feedback: %w", err)
+	}
+
+	// Cost
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT prompt_version, COUNT(*), COALESCE(SUM(tokens_total), 0), COALESCE(SUM(cost_usd), 0)
+		FROM ai_usage
+		WHERE prompt_id = $1 AND created_at >= $2 AND created_at < $3
+		  AND ($4::uuid IS NULL OR program_id = $4)
+		GROUP BY prompt_version
+	`, promptID, from, to, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt cost: %w", err)
+	}
+	for rows.Next() {
+		var version string
+		var requests, tokens int64
+		var cost float64
+		if err := rows.Scan(&version, &requests, &tokens, &cost); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan prompt cost: %w", err)
+		}
+		m := get(version)
+		m.Requests, m.TotalTokens, m.CostUSD = requests, tokens, cost
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get prompt cost: %w", err)
+	}
+
+	result := make([]VersionMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		result = append(result, *m)
+	}
+	return result, nil
+}
@@ -0,0 +1,291 @@
+// Package prompts manages the versions of the AI prompts: global versions, per-program
+// overrides, candidate versions serving a share of artifacts, and the evaluation that compares
+// versions by parse failures, insight acceptance and cost.
+package prompts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPeriod is the evaluation period when no range is given
+	DefaultPeriod = 30 * 24 * time.Hour
+
+	// MaxPeriod bounds the range of a single evaluation
+	MaxPeriod = 366 * 24 * time.Hour
+)
+
+// Service manages prompt versions
+type Service struct {
+	repo     RepositoryInterface
+	builtins *ai.PromptLibrary
+}
+
+// NewService creates a new prompts service
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo:     repo,
+		builtins: ai.NewPromptLibrary(),
+	}
+}
+
+// NewServiceWithMocks creates a service with mock dependencies (useful for testing)
+func NewServiceWithMocks(repo RepositoryInterface) *Service {
+	return &Service{
+		repo:     repo,
+		builtins: ai.NewPromptLibrary(),
+	}
+}
+
+// EnsureDefaults stores the built-in prompts as global versions
+func (s *Service) EnsureDefaults(ctx context.Context) error {
+	return s.repo.EnsureDefaults(ctx, s.builtins.Templates())
+}
+
+// GetServingVersions returns the versions of a prompt serving a program's requests: the
+// program's override when it has an active one, the global versions otherwise
+func (s *Service) GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]*ai.PromptTemplate, error) {
+	versions, err := s.repo.GetServingVersions(ctx, promptID, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := false
+	for _, v := range versions {
+		if v.ProgramID.Valid && v.Status == ai.PromptStatusActive {
+			overridden = true
+		}
+	}
+
+	templates := make([]*ai.PromptTemplate, 0, len(versions))
+	for i := range versions {
+		if versions[i].ProgramID.Valid == overridden {
+			templates = append(templates, versions[i].Template())
+		}
+	}
+	return templates, nil
+}
+
+// ListVersions lists the stored prompt versions: global ones, and with a program its overrides
+func (s *Service) ListVersions(ctx context.Context, programID uuid.NullUUID) ([]PromptVersion, error) {
+	return s.repo.ListVersions(ctx, programID)
+}
+
+// ListPromptVersions lists the versions of one prompt in a scope
+func (s *Service) ListPromptVersions(ctx context.Context, promptID string, programID uuid.NullUUID) ([]PromptVersion, error) {
+	if err := s.validatePromptID(promptID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPromptVersions(ctx, promptID, programID)
+}
+
+// CreateVersion adds a version of a prompt, globally or as an override for a program
+func (s *Service) CreateVersion(ctx context.Context, promptID string, programID uuid.NullUUID, req *CreateVersionRequest, userID uuid.UUID) (*PromptVersion, error) {
+	if err := s.validatePromptID(promptID); err != nil {
+		return nil, err
+	}
+	if req.Version == "" || len(req.Version) > 50 {
+		return nil, fmt.Errorf("invalid version: must be 1-50 characters")
+	}
+	if req.Status == "" {
+		req.Status = ai.PromptStatusDraft
+	}
+	if req.Status == ai.PromptStatusRetired {
+		return nil, fmt.Errorf("invalid status: new versions cannot be retired")
+	}
+	if err := validateTraffic(req.Status, req.TrafficPercent); err != nil {
+		return nil, err
+	}
+
+	base, err := s.baseTemplate(ctx, promptID, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	v := &PromptVersion{
+		PromptTemplateID:   uuid.New(),
+		PromptID:           promptID,
+		Version:            req.Version,
+		ProgramID:          programID,
+		Status:             req.Status,
+		TrafficPercent:     req.TrafficPercent,
+		Module:             base.Module,
+		Purpose:            base.Purpose,
+		SystemPrompt:       firstNonEmpty(req.SystemPrompt, base.SystemPrompt),
+		UserPromptTemplate: firstNonEmpty(req.UserPromptTemplate, base.UserPromptTmpl),
+		Model:              firstNonEmpty(req.Model, base.Model),
+		Temperature:        base.Temperature,
+		MaxTokens:          base.MaxTokens,
+		Notes:              sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+		CreatedBy:          uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if req.Temperature != nil {
+		v.Temperature = *req.Temperature
+	}
+	if req.MaxTokens != 0 {
+		v.MaxTokens = req.MaxTokens
+	}
+
+	if v.Temperature < 0 || v.Temperature > 1 {
+		return nil, fmt.Errorf("invalid temperature: must be between 0 and 1")
+	}
+	if v.MaxTokens < 1 {
+		return nil, fmt.Errorf("invalid max_tokens: must be positive")
+	}
+	if _, err := template.New(promptID).Parse(v.UserPromptTemplate); err != nil {
+		return nil, fmt.Errorf("invalid user_prompt_template: %v", err)
+	}
+
+	if _, err := s.repo.GetVersion(ctx, promptID, programID, req.Version); err == nil {
+		return nil, fmt.Errorf("invalid version: %s already exists", req.Version)
+	}
+
+	if err := s.repo.CreateVersion(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateVersion promotes, retires or re-weights a version. Activating a version retires the
+// previously active one; making a version the candidate retires the previous candidate.
+func (s *Service) UpdateVersion(ctx context.Context, promptID string, programID uuid.NullUUID, version string, req *UpdateVersionRequest) (*PromptVersion, error) {
+	if err := s.validatePromptID(promptID); err != nil {
+		return nil, err
+	}
+
+	v, err := s.repo.GetVersion(ctx, promptID, programID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != nil {
+		if *req.Status == ai.PromptStatusDraft && v.Status != ai.PromptStatusDraft {
+			return nil, fmt.Errorf("invalid status: a %s version cannot return to draft", v.Status)
+		}
+		if *req.Status != v.Status && *req.Status != ai.PromptStatusCandidate {
+			// Only candidates take traffic; keep the share unless it is being set
+			v.TrafficPercent = 0
+		}
+		v.Status = *req.Status
+	}
+	if req.TrafficPercent != nil {
+		v.TrafficPercent = *req.TrafficPercent
+	}
+	if err := validateTraffic(v.Status, v.TrafficPercent); err != nil {
+		return nil, err
+	}
+
+	v.UpdatedAt = time.Now()
+	if err := s.repo.UpdateVersion(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetEvaluation compares the versions of a prompt in [from, to), for one program or all of them
+func (s *Service) GetEvaluation(ctx context.Context, promptID string, programID uuid.NullUUID, from, to time.Time) (*Evaluation, error) {
+	if err := s.validatePromptID(promptID); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid date range: from must be before to")
+	}
+	if to.Sub(from) > MaxPeriod {
+		return nil, fmt.Errorf("invalid date range: at most %d days can be evaluated at once", int(MaxPeriod.Hours()/24))
+	}
+
+	versions, err := s.repo.GetEvaluation(ctx, promptID, programID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		versions[i].computeRates()
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	return &Evaluation{
+		PromptID:  promptID,
+		ProgramID: programID,
+		From:      from,
+		To:        to,
+		Versions:  versions,
+	}, nil
+}
+
+// computeRates fills in the fields derived from the counts
+func (m *VersionMetrics) computeRates() {
+	if m.Runs > 0 {
+		m.ParseFailureRate = float64(m.ParseFailures) / float64(m.Runs)
+		m.CostPerRun = m.CostUSD / float64(m.Runs)
+	}
+	if judged := m.AcceptedInsights + m.RejectedInsights; judged > 0 {
+		m.AcceptanceRate = float64(m.AcceptedInsights) / float64(judged)
+	}
+}
+
+// baseTemplate returns the version a new version copies unset fields from: the one serving
+// the scope, or the built-in template
+func (s *Service) baseTemplate(ctx context.Context, promptID string, programID uuid.NullUUID) (*ai.PromptTemplate, error) {
+	scopes := []uuid.NullUUID{programID}
+	if programID.Valid {
+		scopes = append(scopes, uuid.NullUUID{})
+	}
+
+	for _, scope := range scopes {
+		versions, err := s.repo.ListPromptVersions(ctx, promptID, scope)
+		if err != nil {
+			return nil, err
+		}
+		for i := range versions {
+			if versions[i].Status == ai.PromptStatusActive {
+				return versions[i].Template(), nil
+			}
+		}
+	}
+
+	return s.builtins.Get(promptID)
+}
+
+// validatePromptID checks that promptID names a prompt the analyzers use
+func (s *Service) validatePromptID(promptID string) error {
+	if _, err := s.builtins.Get(promptID); err != nil {
+		return fmt.Errorf("invalid prompt: %s is not a known prompt", promptID)
+	}
+	return nil
+}
+
+func validateTraffic(status string, trafficPercent int) error {
+	switch status {
+	case ai.PromptStatusDraft, ai.PromptStatusActive, ai.PromptStatusCandidate, ai.PromptStatusRetired:
+	default:
+		return fmt.Errorf("invalid status: must be draft, active, candidate or retired")
+	}
+	if trafficPercent < 0 || trafficPercent > 100 {
+		return fmt.Errorf("invalid traffic_percent: must be between 0 and 100")
+	}
+	if trafficPercent > 0 && status != ai.PromptStatusCandidate {
+		return fmt.Errorf("invalid traffic_percent: only candidate versions take a share of traffic")
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package prompts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/google/uuid"
+)
+
+type mockRepository struct {
+	RepositoryInterface
+	serving []PromptVersion
+}
+
+func (m *mockRepository) GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]PromptVersion, error) {
+	return m.serving, nil
+}
+
+func TestGetServingVersions(t *testing.T) {
+	programID := uuid.New()
+	global := []PromptVersion{
+		{PromptID: "artifact_analysis_v1", Version: "1.0", Status: ai.PromptStatusActive},
+		{PromptID: "artifact_analysis_v1", Version: "1.1", Status: ai.PromptStatusCandidate, TrafficPercent: 20},
+	}
+	programScope := uuid.NullUUID{UUID: programID, Valid: true}
+
+	tests := []struct {
+		name    string
+		serving []PromptVersion
+		want    []string
+	}{
+		{
+			name:    "global versions without an override",
+			serving: global,
+			want:    []string{"1.0", "1.1"},
+		},
+		{
+			name: "active override replaces the global versions",
+			serving: append([]PromptVersion{
+				{PromptID: "artifact_analysis_v1", Version: "acme-1", ProgramID: programScope, Status: ai.PromptStatusActive},
+			}, global...),
+			want: []string{"acme-1"},
+		},
+		{
+			name: "candidate override alone does not",
+			serving: append([]PromptVersion{
+				{PromptID: "artifact_analysis_v1", Version: "acme-1", ProgramID: programScope, Status: ai.PromptStatusCandidate},
+			}, global...),
+			want: []string{"1.0", "1.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewServiceWithMocks(&mockRepository{serving: tt.serving})
+
+			templates, err := service.GetServingVersions(context.Background(), "artifact_analysis_v1", programID)
+			if err != nil {
+				t.Fatalf("GetServingVersions() error = %v", err)
+			}
+
+			var got []string
+			for _, tmpl := range templates {
+				got = append(got, tmpl.Version)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetServingVersions() versions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("GetServingVersions() versions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTraffic(t *testing.T) {
+	tests := []struct {
+		status  string
+		traffic int
+		wantErr bool
+	}{
+		{ai.PromptStatusCandidate, 25, false},
+		{ai.PromptStatusCandidate, 0, false},
+		{ai.PromptStatusCandidate, 101, true},
+		{ai.PromptStatusActive, 0, false},
+		{ai.PromptStatusActive, 50, true},
+		{"shadow", 0, true},
+	}
+
+	for _, tt := range tests {
+		err := validateTraffic(tt.status, tt.traffic)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateTraffic(%q, %d) error = %v, wantErr %v", tt.status, tt.traffic, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// eventSource identifies the risk module as the publisher of its events
+const eventSource = "risk"
+
+// calculateSeverity mirrors the calculate_risk_severity() trigger so severity changes
+// can be detected before the database recomputes the column
+func calculateSeverity(probability, impact string) string {
+	scores := map[string]int{
+		"very_low":  1,
+		"low":       2,
+		"medium":    3,
+		"high":      4,
+		"very_high": 5,
+	}
+
+	total := scores[probability] * scores[impact]
+	switch {
+	case total <= 4:
+		return "low"
+	case total <= 9:
+		return "medium"
+	case total <= 16:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+// severityRank orders severities from low (1) to critical (4)
+func severityRank(severity string) int {
+	switch severity {
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	case "critical":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// riskIdentifiedEvent builds the event published when a risk enters the register
+func riskIdentifiedEvent(risk *Risk, suggestionID uuid.UUID) (*events.Event, error) {
+	return events.NewEventFromPayload(events.RiskIdentified, risk.ProgramID, eventSource, events.RiskIdentifiedPayload{
+		RiskID:       risk.RiskID,
+		SuggestionID: suggestionID,
+		Title:        risk.Title,
+		Category:     risk.Category,
+		Probability:  risk.Probability,
+		Impact:       risk.Impact,
+		Severity:     calculateSeverity(risk.Probability, risk.Impact),
+	})
+}
+
+// riskSuggestedEvent builds the event published when AI analysis proposes a risk
+func riskSuggestedEvent(suggestion *RiskSuggestion) (*events.Event, error) {
+	severity := suggestion.SuggestedSeverity
+	if severity == "" {
+		severity = calculateSeverity(suggestion.SuggestedProbability, suggestion.SuggestedImpact)
+	}
+
+	return events.NewEventFromPayload(events.RiskSuggested, suggestion.ProgramID, eventSource, events.RiskSuggestedPayload{
+		SuggestionID:      suggestion.SuggestionID,
+		Title:             suggestion.Title,
+		Category:          suggestion.SuggestedCategory,
+		Severity:          severity,
+		SourceType:        suggestion.SourceType,
+		SourceArtifactIDs: suggestion.SourceArtifactIDs,
+		SourceVarianceID:  suggestion.SourceVarianceID.UUID,
+		ConfidenceScore:   suggestion.AIConfidenceScore.Float64,
+	})
+}
+
+// riskUpdateEvents builds the events raised by an update: RiskEscalated when severity
+// goes up, IssueCreated when the risk is realized
+func riskUpdateEvents(previousSeverity, previousStatus string, risk *Risk) ([]*events.Event, error) {
+	var updateEvents []*events.Event
+	severity := calculateSeverity(risk.Probability, risk.Impact)
+
+	if severityRank(severity) > severityRank(previousSeverity) {
+		event, err := events.NewEventFromPayload(events.RiskEscalated, risk.ProgramID, eventSource, events.RiskEscalatedPayload{
+			RiskID:           risk.RiskID,
+			Title:            risk.Title,
+			PreviousSeverity: previousSeverity,
+			Severity:         severity,
+			Probability:      risk.Probability,
+			Impact:           risk.Impact,
+		})
+		if err != nil {
+			return nil, err
+		}
+		updateEvents = append(updateEvents, event)
+	}
+
+	if risk.Status == "realized" && previousStatus != "realized" {
+		event, err := events.NewEventFromPayload(events.IssueCreated, risk.ProgramID, eventSource, events.IssueCreatedPayload{
+			RiskID:         risk.RiskID,
+			Title:          risk.Title,
+			Category:       risk.Category,
+			Severity:       severity,
+			PreviousStatus: previousStatus,
+		})
+		if err != nil {
+			return nil, err
+		}
+		updateEvents = append(updateEvents, event)
+	}
+
+	return updateEvents, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/cerberus/backend/internal/platform/events"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -14,17 +15,21 @@ import (
 type RepositoryInterface interface {
 	// Risk CRUD
 	CreateRisk(ctx context.Context, risk *Risk) error
+	CreateRiskWithEvents(ctx context.Context, risk *Risk, outboxEvents ...*events.Event) error
 	GetRiskByID(ctx context.Context, riskID uuid.UUID) (*Risk, error)
 	ListRisks(ctx context.Context, filter RiskFilterRequest) ([]Risk, error)
 	ListRisksWithSuggestions(ctx context.Context, filter RiskFilterRequest, includeSuggestions bool) (*RiskListWithSuggestionsResponse, error)
 	UpdateRisk(ctx context.Context, risk *Risk) error
+	UpdateRiskWithEvents(ctx context.Context, risk *Risk, outboxEvents ...*events.Event) error
 	DeleteRisk(ctx context.Context, riskID uuid.UUID) error
 
 	// Risk Suggestions
 	CreateSuggestion(ctx context.Context, suggestion *RiskSuggestion) error
+	CreateSuggestionWithEvents(ctx context.Context, suggestion *RiskSuggestion, outboxEvents ...*events.Event) error
 	GetSuggestionByID(ctx context.Context, suggestionID uuid.UUID) (*RiskSuggestion, error)
 	ListSuggestions(ctx context.Context, programID uuid.UUID, includeProcessed bool) ([]RiskSuggestion, error)
 	UpdateSuggestion(ctx context.Context, suggestion *RiskSuggestion) error
+	ApproveSuggestion(ctx context.Context, risk *Risk, suggestion *RiskSuggestion, outboxEvents ...*events.Event) error
 
 	// Risk Mitigations
 	CreateMitigation(ctx context.Context, mitigation *RiskMitigation) error
@@ -87,6 +92,30 @@ func (r *Repository) QueryContext(ctx context.Context, query string, args ...int
 
 // CreateRisk inserts a new risk
 func (r *Repository) CreateRisk(ctx context.Context, risk *Risk) error {
+	return insertRisk(ctx, r.db, risk)
+}
+
+// CreateRiskWithEvents inserts a new risk and writes outboxEvents in the same transaction
+func (r *Repository) CreateRiskWithEvents(ctx context.Context, risk *Risk, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertRisk(ctx, tx, risk); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertRisk inserts a risk row using exec (the database or a transaction)
+func insertRisk(ctx context.Context, exec db.Execer, risk *Risk) error {
 	query := `
 		INSERT INTO risks (
 			risk_id, program_id, title, description, probability, impact,
@@ -96,7 +125,7 @@ func (r *Repository) CreateRisk(ctx context.Context, risk *Risk) error {
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		risk.RiskID,
 		risk.ProgramID,
 		risk.Title,
@@ -348,6 +377,30 @@ func (r *Repository) ListRisksWithSuggestions(ctx context.Context, filter RiskFi
 
 // UpdateRisk updates an existing risk
 func (r *Repository) UpdateRisk(ctx context.Context, risk *Risk) error {
+	return updateRisk(ctx, r.db, risk)
+}
+
+// UpdateRiskWithEvents updates a risk and writes outboxEvents in the same transaction
+func (r *Repository) UpdateRiskWithEvents(ctx context.Context, risk *Risk, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateRisk(ctx, tx, risk); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateRisk updates a risk row using exec (the database or a transaction)
+func updateRisk(ctx context.Context, exec db.Execer, risk *Risk) error {
 	query := `
 		UPDATE risks
 		SET title = $1, description = $2, probability = $3, impact = $4,
@@ -356,7 +409,7 @@ func (r *Repository) UpdateRisk(ctx context.Context, risk *Risk) error {
 		WHERE risk_id = $12 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := exec.ExecContext(ctx, query,
 		risk.Title,
 		risk.Description,
 		risk.Probability,
@@ -414,6 +467,30 @@ func (r *Repository) DeleteRisk(ctx context.Context, riskID uuid.UUID) error {
 
 // CreateSuggestion inserts a new risk suggestion
 func (r *Repository) CreateSuggestion(ctx context.Context, suggestion *RiskSuggestion) error {
+	return insertSuggestion(ctx, r.db, suggestion)
+}
+
+// CreateSuggestionWithEvents inserts a new risk suggestion and writes outboxEvents in the same transaction
+func (r *Repository) CreateSuggestionWithEvents(ctx context.Context, suggestion *RiskSuggestion, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertSuggestion(ctx, tx, suggestion); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertSuggestion inserts a suggestion row using exec (the database or a transaction)
+func insertSuggestion(ctx context.Context, exec db.Execer, suggestion *RiskSuggestion) error {
 	query := `
 		INSERT INTO risk_suggestions (
 			suggestion_id, program_id, title, description, rationale,
@@ -423,7 +500,7 @@ func (r *Repository) CreateSuggestion(ctx context.Context, suggestion *RiskSugge
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		suggestion.SuggestionID,
 		suggestion.ProgramID,
 		suggestion.Title,
@@ -551,6 +628,45 @@ func (r *Repository) ListSuggestions(ctx context.Context, programID uuid.UUID, i
 
 // UpdateSuggestion updates a risk suggestion
 func (r *Repository) UpdateSuggestion(ctx context.Context, suggestion *RiskSuggestion) error {
+	return updateSuggestion(ctx, r.db, suggestion)
+}
+
+// ApproveSuggestion creates the risk, marks the suggestion approved and writes outboxEvents
+// in one transaction, so the events are published only if the approval commits
+func (r *Repository) ApproveSuggestion(ctx context.Context, risk *Risk, suggestion *RiskSuggestion, outboxEvents ...*events.Event) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertRisk(ctx, tx, risk); err != nil {
+		return err
+	}
+
+	if err := updateSuggestion(ctx, tx, suggestion); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outboxEvents); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// writeOutbox writes events to the outbox within tx
+func writeOutbox(ctx context.Context, tx db.Execer, outboxEvents []*events.Event) error {
+	for _, event := range outboxEvents {
+		if err := events.WriteOutbox(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSuggestion updates a suggestion row using exec (the database or a transaction)
+func updateSuggestion(ctx context.Context, exec db.Execer, suggestion *RiskSuggestion) error {
 	query := `
 		UPDATE risk_suggestions
 		SET is_approved = $1, is_dismissed = $2, approved_by = $3, approved_at = $4,
@@ -558,7 +674,7 @@ func (r *Repository) UpdateSuggestion(ctx context.Context, suggestion *RiskSugge
 		WHERE suggestion_id = $9
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := exec.ExecContext(ctx, query,
 		suggestion.IsApproved,
 		suggestion.IsDismissed,
 		suggestion.ApprovedBy,
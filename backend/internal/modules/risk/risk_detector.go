@@ -70,9 +70,8 @@ func (d *RiskDetector) AnalyzeForRisks(ctx context.Context, insights []ArtifactI
 		}
 
 		// Save suggestion
-		err = d.repo.CreateSuggestion(ctx, suggestion)
-		if err != nil {
-			return fmt.Errorf("failed to create risk suggestion: %w", err)
+		if err := d.saveSuggestion(ctx, suggestion); err != nil {
+			return err
 		}
 	}
 
@@ -96,11 +95,19 @@ func (d *RiskDetector) ProcessFinancialVariance(ctx context.Context, variance Fi
 	}
 
 	// Save suggestion
-	err = d.repo.CreateSuggestion(ctx, suggestion)
+	return d.saveSuggestion(ctx, suggestion)
+}
+
+// saveSuggestion stores a suggestion together with its risk.suggested event
+func (d *RiskDetector) saveSuggestion(ctx context.Context, suggestion *RiskSuggestion) error {
+	event, err := riskSuggestedEvent(suggestion)
 	if err != nil {
-		return fmt.Errorf("failed to create risk suggestion: %w", err)
+		return err
 	}
 
+	if err := d.repo.CreateSuggestionWithEvents(ctx, suggestion, event); err != nil {
+		return fmt.Errorf("failed to create risk suggestion: %w", err)
+	}
 	return nil
 }
 
@@ -90,8 +90,13 @@ func (s *Service) CreateRisk(ctx context.Context, req CreateRiskRequest) (uuid.U
 		risk.TargetResolutionDate.Valid = true
 	}
 
-	// Save to database
-	err := s.repo.CreateRisk(ctx, risk)
+	// Save to database, queueing the risk.identified event
+	event, err := riskIdentifiedEvent(risk, uuid.Nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = s.repo.CreateRiskWithEvents(ctx, risk, event)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("failed to create risk: %w", err)
 	}
@@ -215,6 +220,7 @@ func (s *Service) UpdateRiskStatus(ctx context.Context, riskID uuid.UUID, newSta
 	}
 
 	// Update status
+	previousStatus := risk.Status
 	risk.Status = newStatus
 
 	// Set closed/realized dates based on status
@@ -228,8 +234,13 @@ func (s *Service) UpdateRiskStatus(ctx context.Context, riskID uuid.UUID, newSta
 		risk.RealizedDate.Valid = true
 	}
 
-	// Save updated risk
-	err = s.repo.UpdateRisk(ctx, risk)
+	// Save updated risk, queueing issue.created if the risk was realized
+	updateEvents, err := riskUpdateEvents(risk.Severity, previousStatus, risk)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateRiskWithEvents(ctx, risk, updateEvents...)
 	if err != nil {
 		return fmt.Errorf("failed to update risk status: %w", err)
 	}
@@ -249,6 +260,10 @@ func (s *Service) UpdateRisk(ctx context.Context, riskID uuid.UUID, req UpdateRi
 		return fmt.Errorf("failed to get risk: %w", err)
 	}
 
+	// Remember the state that lifecycle events are detected against
+	previousSeverity := risk.Severity
+	previousStatus := risk.Status
+
 	// Apply updates
 	if req.Title != nil {
 		risk.Title = *req.Title
@@ -303,8 +318,13 @@ func (s *Service) UpdateRisk(ctx context.Context, riskID uuid.UUID, req UpdateRi
 		risk.TargetResolutionDate.Valid = true
 	}
 
-	// Save updated risk
-	err = s.repo.UpdateRisk(ctx, risk)
+	// Save updated risk, queueing escalation and issue events
+	updateEvents, err := riskUpdateEvents(previousSeverity, previousStatus, risk)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateRiskWithEvents(ctx, risk, updateEvents...)
 	if err != nil {
 		return fmt.Errorf("failed to update risk: %w", err)
 	}
@@ -412,8 +432,20 @@ func (s *Service) ApproveSuggestion(ctx context.Context, req ApproveSuggestionRe
 		risk.TargetResolutionDate.Valid = true
 	}
 
-	// Create the risk
-	err = s.repo.CreateRisk(ctx, risk)
+	// Mark suggestion as approved
+	suggestion.IsApproved = true
+	suggestion.ApprovedBy = uuid.NullUUID{UUID: req.ApprovedBy, Valid: true}
+	suggestion.ApprovedAt.Time = now
+	suggestion.ApprovedAt.Valid = true
+	suggestion.CreatedRiskID = uuid.NullUUID{UUID: riskID, Valid: true}
+
+	// Create the risk and approve the suggestion atomically, queueing the risk.identified event
+	event, err := riskIdentifiedEvent(risk, suggestion.SuggestionID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = s.repo.ApproveSuggestion(ctx, risk, suggestion, event)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("failed to create risk from suggestion: %w", err)
 	}
@@ -434,18 +466,6 @@ func (s *Service) ApproveSuggestion(ctx context.Context, req ApproveSuggestionRe
 		_ = s.repo.LinkArtifact(ctx, link) // Best effort
 	}
 
-	// Update suggestion as approved
-	suggestion.IsApproved = true
-	suggestion.ApprovedBy = uuid.NullUUID{UUID: req.ApprovedBy, Valid: true}
-	suggestion.ApprovedAt.Time = now
-	suggestion.ApprovedAt.Valid = true
-	suggestion.CreatedRiskID = uuid.NullUUID{UUID: riskID, Valid: true}
-
-	err = s.repo.UpdateSuggestion(ctx, suggestion)
-	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to update suggestion: %w", err)
-	}
-
 	return riskID, nil
 }
 
@@ -0,0 +1,266 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// maxResponseBody is how much of an endpoint's response is kept in the delivery log
+const maxResponseBody = 1024
+
+// DispatcherConfig controls webhook delivery
+type DispatcherConfig struct {
+	PollInterval   time.Duration // How often to look for due deliveries when idle
+	BatchSize      int           // Deliveries claimed per poll
+	Timeout        time.Duration // Per-request timeout
+	MaxAttempts    int           // Attempts before a delivery is marked failed
+	InitialBackoff time.Duration // Delay after the first failure; doubles up to MaxBackoff
+	MaxBackoff     time.Duration
+}
+
+// DefaultDispatcherConfig returns the delivery settings used by the worker
+func DefaultDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		PollInterval:   2 * time.Second,
+		BatchSize:      20,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+	}
+}
+
+// Dispatcher fans program events out to webhook subscriptions and sends the resulting deliveries.
+// HandleEvent only records deliveries, so a slow endpoint never holds up the event bus; Run
+// sends them. Several dispatchers can run at once, as deliveries are claimed with SKIP LOCKED.
+type Dispatcher struct {
+	repo   RepositoryInterface
+	client *http.Client
+	config DispatcherConfig
+}
+
+// NewDispatcher creates a webhook dispatcher
+func NewDispatcher(repo RepositoryInterface, config DispatcherConfig) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: newDeliveryClient(config.Timeout, isInternalIP),
+		config: config,
+	}
+}
+
+// newDeliveryClient creates the HTTP client deliveries are sent with. Endpoint URLs are checked
+// when saved, but a hostname can resolve elsewhere by the time a delivery is sent, so the client
+// also refuses to connect to any address blocked reports and never follows redirects.
+func newDeliveryClient(timeout time.Duration, blocked func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+				return fmt.Errorf("refusing to connect to %s: not a public address", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy: the dialer's check must see the endpoint's own address
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		// A redirect is answered as the endpoint's response and fails the attempt
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// HandleEvent queues a delivery of the event for every active subscription of its program that
+// wants its type. Subscribe it to events.AllEvents.
+func (d *Dispatcher) HandleEvent(ctx context.Context, event *events.Event) error {
+	if event.ProgramID == uuid.Nil {
+		return nil
+	}
+
+	subscriptions, err := d.repo.ListActiveSubscriptions(ctx, event.ProgramID)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	var deliveries []Delivery
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(string(event.Type)) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to marshal webhook payload: %w", err)
+			}
+		}
+
+		deliveries = append(deliveries, Delivery{
+			DeliveryID:     uuid.New(),
+			SubscriptionID: subscription.SubscriptionID,
+			ProgramID:      event.ProgramID,
+			EventID:        event.ID,
+			EventType:      string(event.Type),
+			Payload:        body,
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+
+	return d.repo.EnqueueDeliveries(ctx, deliveries)
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back
+		for {
+			sent, err := d.DeliverBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Webhook dispatcher error: %v", err)
+				}
+				break
+			}
+			if sent < d.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverBatch claims up to BatchSize due deliveries, sends them and records the outcomes.
+// It returns the number of deliveries attempted.
+func (d *Dispatcher) DeliverBatch(ctx context.Context) (int, error) {
+	// A claim outlives the slowest possible request, so it only expires if this dispatcher died
+	lease := d.config.Timeout*2 + time.Minute
+	claimed, err := d.repo.ClaimDueDeliveries(ctx, d.config.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range claimed {
+		attempt := d.send(ctx, &delivery)
+		if ctx.Err() != nil {
+			// Shutting down: the claim expires and another dispatcher retries
+			return len(claimed), ctx.Err()
+		}
+
+		if attempt.Status != StatusSucceeded {
+			log.Printf("Webhook delivery %s to %s failed (attempt %d/%d): %s",
+				delivery.DeliveryID, delivery.URL, delivery.Attempts+1, d.config.MaxAttempts, attempt.Error)
+		}
+		if err := d.repo.RecordDeliveryAttempt(ctx, delivery.DeliveryID, attempt); err != nil {
+			return len(claimed), err
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// send POSTs a delivery and classifies the result
+func (d *Dispatcher) send(ctx context.Context, delivery *PendingDelivery) DeliveryAttempt {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		// The URL was validated when saved; a request that cannot be built will never succeed
+		return DeliveryAttempt{Status: StatusFailed, Error: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Cerberus-Webhooks/1.0")
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID.String())
+	req.Header.Set(HeaderDeliveryID, delivery.DeliveryID.String())
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return d.retry(delivery, DeliveryAttempt{Error: err.Error()}, 0)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt := DeliveryAttempt{
+		StatusCode:   resp.StatusCode,
+		ResponseBody: string(body),
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		attempt.Status = StatusSucceeded
+		return attempt
+	}
+
+	attempt.Error = fmt.Sprintf("endpoint returned %s", resp.Status)
+	return d.retry(delivery, attempt, parseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
+// retry schedules the next attempt with exponential backoff, or fails the delivery once its
+// attempts are exhausted. A Retry-After from the endpoint extends the delay (up to MaxBackoff).
+func (d *Dispatcher) retry(delivery *PendingDelivery, attempt DeliveryAttempt, retryAfter time.Duration) DeliveryAttempt {
+	attempts := delivery.Attempts + 1
+	if attempts >= d.config.MaxAttempts {
+		attempt.Status = StatusFailed
+		return attempt
+	}
+
+	delay := d.config.InitialBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+
+	attempt.Status = StatusPending
+	attempt.RetryAfter = delay
+	return attempt
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,406 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// mockRepository keeps subscriptions and deliveries in memory
+type mockRepository struct {
+	RepositoryInterface // Unused methods panic
+
+	mu            sync.Mutex
+	subscriptions []Subscription
+	deliveries    []Delivery
+}
+
+func (m *mockRepository) ListActiveSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []Subscription
+	for _, s := range m.subscriptions {
+		if s.ProgramID == programID && s.IsActive {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (m *mockRepository) GetSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.subscriptions {
+		if m.subscriptions[i].ProgramID == programID && m.subscriptions[i].SubscriptionID == subscriptionID {
+			s := m.subscriptions[i]
+			return &s, nil
+		}
+	}
+	return nil, errNotFound("webhook subscription")
+}
+
+func (m *mockRepository) EnqueueDeliveries(ctx context.Context, deliveries []Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		duplicate := false
+		for _, existing := range m.deliveries {
+			if !d.ReplayOf.Valid && !existing.ReplayOf.Valid &&
+				existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			m.deliveries = append(m.deliveries, d)
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]PendingDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []PendingDelivery
+	for i := range m.deliveries {
+		d := &m.deliveries[i]
+		if len(claimed) == limit || (d.Status != StatusPending && d.Status != StatusSending) || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		for _, s := range m.subscriptions {
+			if s.SubscriptionID == d.SubscriptionID && s.IsActive {
+				d.Status = StatusSending
+				d.NextAttemptAt = time.Now().Add(lease)
+				claimed = append(claimed, PendingDelivery{Delivery: *d, URL: s.URL, Secret: s.Secret})
+			}
+		}
+	}
+	return claimed, nil
+}
+
+func (m *mockRepository) RecordDeliveryAttempt(ctx context.Context, deliveryID uuid.UUID, attempt DeliveryAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		d := &m.deliveries[i]
+		if d.DeliveryID == deliveryID {
+			d.Status = attempt.Status
+			d.Attempts++
+			d.LastStatusCode.Int32, d.LastStatusCode.Valid = int32(attempt.StatusCode), attempt.StatusCode > 0
+			d.LastError.String, d.LastError.Valid = attempt.Error, attempt.Error != ""
+			d.NextAttemptAt = time.Now().Add(attempt.RetryAfter)
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) GetDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.DeliveryID == deliveryID {
+			return &d, nil
+		}
+	}
+	return nil, errNotFound("webhook delivery")
+}
+
+// delivery returns a snapshot of the delivery at index i
+func (m *mockRepository) delivery(i int) Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deliveries[i]
+}
+
+// makeDue makes every pending delivery due now, skipping the backoff
+func (m *mockRepository) makeDue() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		m.deliveries[i].NextAttemptAt = time.Now()
+	}
+}
+
+type errNotFound string
+
+func (e errNotFound) Error() string { return string(e) + " not found" }
+
+// receiver is a local webhook endpoint that records requests and answers with the queued statuses
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int // Answered in order; 200 once exhausted
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(`{"received":true}`))
+}
+
+// setupDispatcher creates a dispatcher, a repository with one subscription and a receiver for it
+func setupDispatcher(t *testing.T, eventTypes []string, statuses ...int) (*Dispatcher, *mockRepository, *receiver, Subscription) {
+	t.Helper()
+
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	subscription := Subscription{
+		SubscriptionID: uuid.New(),
+		ProgramID:      uuid.New(),
+		URL:            server.URL + "/hooks/cerberus",
+		Secret:         "whsec_test",
+		EventTypes:     eventTypes,
+		IsActive:       true,
+	}
+	repo := &mockRepository{subscriptions: []Subscription{subscription}}
+
+	config := DefaultDispatcherConfig()
+	config.MaxAttempts = 3
+	config.Timeout = 5 * time.Second
+	dispatcher := NewDispatcher(repo, config)
+
+	// The receiver listens on loopback, which deliveries are otherwise refused
+	dispatcher.client = newDeliveryClient(config.Timeout, func(net.IP) bool { return false })
+	return dispatcher, repo, rc, subscription
+}
+
+func riskIdentifiedEvent(t *testing.T, programID uuid.UUID) *events.Event {
+	t.Helper()
+	event, err := events.NewEventFromPayload(events.RiskIdentified, programID, "risk", events.RiskIdentifiedPayload{
+		RiskID:   uuid.New(),
+		Title:    "Vendor delivery slipping",
+		Severity: "high",
+	})
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	return event
+}
+
+func TestDispatcher_DeliversSignedEvent(t *testing.T) {
+	dispatcher, repo, rc, subscription := setupDispatcher(t, []string{string(events.RiskIdentified)})
+	ctx := context.Background()
+
+	event := riskIdentifiedEvent(t, subscription.ProgramID)
+	if err := dispatcher.HandleEvent(ctx, event); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	// A bus redelivery of the same event is not queued twice
+	if err := dispatcher.HandleEvent(ctx, event); err != nil {
+		t.Fatalf("HandleEvent() redelivery error = %v", err)
+	}
+
+	sent, err := dispatcher.DeliverBatch(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("DeliverBatch() = %d, %v; want 1, nil", sent, err)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(rc.requests))
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+	if req.URL.Path != "/hooks/cerberus" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected request %s %s (%s)", req.Method, req.URL.Path, req.Header.Get("Content-Type"))
+	}
+	if req.Header.Get(HeaderEventType) != string(events.RiskIdentified) || req.Header.Get(HeaderEventID) != event.ID.String() {
+		t.Errorf("unexpected event headers: %v", req.Header)
+	}
+	if err := VerifySignature(subscription.Secret, req.Header.Get(HeaderSignature), body, 5*time.Minute); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+	if err := VerifySignature("whsec_other", req.Header.Get(HeaderSignature), body, 5*time.Minute); err == nil {
+		t.Error("expected signature check with the wrong secret to fail")
+	}
+
+	var received events.Event
+	if err := json.Unmarshal(body, &received); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	var payload events.RiskIdentifiedPayload
+	if err := received.DecodePayload(&payload); err != nil || payload.Title != "Vendor delivery slipping" {
+		t.Errorf("unexpected payload %+v (%v)", payload, err)
+	}
+
+	delivery := repo.delivery(0)
+	if delivery.Status != StatusSucceeded || delivery.Attempts != 1 || delivery.LastStatusCode.Int32 != http.StatusOK {
+		t.Errorf("unexpected delivery state: %+v", delivery)
+	}
+}
+
+func TestDispatcher_FiltersByEventTypeAndProgram(t *testing.T) {
+	dispatcher, repo, _, subscription := setupDispatcher(t, []string{string(events.InvoiceProcessed)})
+	ctx := context.Background()
+
+	if err := dispatcher.HandleEvent(ctx, riskIdentifiedEvent(t, subscription.ProgramID)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	if err := dispatcher.HandleEvent(ctx, riskIdentifiedEvent(t, uuid.New())); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	if len(repo.deliveries) != 0 {
+		t.Fatalf("expected no deliveries, got %d", len(repo.deliveries))
+	}
+}
+
+func TestDispatcher_RetriesWithBackoffThenFails(t *testing.T) {
+	dispatcher, repo, rc, subscription := setupDispatcher(t, nil,
+		http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusBadGateway)
+	ctx := context.Background()
+
+	if err := dispatcher.HandleEvent(ctx, riskIdentifiedEvent(t, subscription.ProgramID)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	// First failure: retried after the initial backoff
+	dispatcher.DeliverBatch(ctx)
+	delivery := repo.delivery(0)
+	if delivery.Status != StatusPending || delivery.LastStatusCode.Int32 != http.StatusInternalServerError {
+		t.Fatalf("unexpected delivery after first failure: %+v", delivery)
+	}
+	if wait := time.Until(delivery.NextAttemptAt); wait < 25*time.Second || wait > 35*time.Second {
+		t.Errorf("expected ~30s backoff, got %s", wait)
+	}
+
+	// Not due yet
+	if sent, _ := dispatcher.DeliverBatch(ctx); sent != 0 {
+		t.Fatalf("expected delivery to wait for its backoff, sent %d", sent)
+	}
+
+	repo.makeDue()
+	dispatcher.DeliverBatch(ctx)
+	if wait := time.Until(repo.delivery(0).NextAttemptAt); wait < 55*time.Second || wait > 65*time.Second {
+		t.Errorf("expected backoff to double to ~60s, got %s", wait)
+	}
+
+	// Third attempt exhausts MaxAttempts
+	repo.makeDue()
+	dispatcher.DeliverBatch(ctx)
+	delivery = repo.delivery(0)
+	if delivery.Status != StatusFailed || delivery.Attempts != 3 {
+		t.Fatalf("expected delivery to fail after 3 attempts, got %+v", delivery)
+	}
+	if len(rc.requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(rc.requests))
+	}
+}
+
+func TestDispatcher_RefusesInternalAddressAtConnect(t *testing.T) {
+	dispatcher, repo, rc, subscription := setupDispatcher(t, nil)
+	ctx := context.Background()
+
+	// As if the endpoint's hostname resolved to a public address when it was saved and to
+	// loopback now
+	dispatcher.client = newDeliveryClient(5*time.Second, isInternalIP)
+
+	if err := dispatcher.HandleEvent(ctx, riskIdentifiedEvent(t, subscription.ProgramID)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	dispatcher.DeliverBatch(ctx)
+
+	if len(rc.requests) != 0 {
+		t.Fatalf("expected no request to reach a loopback endpoint, got %d", len(rc.requests))
+	}
+	delivery := repo.delivery(0)
+	if delivery.Status == StatusSucceeded || !strings.Contains(delivery.LastError.String, "not a public address") {
+		t.Errorf("unexpected delivery state: %+v", delivery)
+	}
+}
+
+func TestDispatcher_DoesNotFollowRedirects(t *testing.T) {
+	target := &receiver{}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	dispatcher, repo, _, subscription := setupDispatcher(t, nil)
+	ctx := context.Background()
+
+	redirector := httptest.NewServer(http.RedirectHandler(targetServer.URL+"/internal", http.StatusFound))
+	defer redirector.Close()
+	repo.subscriptions[0].URL = redirector.URL
+
+	if err := dispatcher.HandleEvent(ctx, riskIdentifiedEvent(t, subscription.ProgramID)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	dispatcher.DeliverBatch(ctx)
+
+	if len(target.requests) != 0 {
+		t.Fatalf("expected the redirect not to be followed, got %d requests", len(target.requests))
+	}
+	delivery := repo.delivery(0)
+	if delivery.Status != StatusPending || delivery.LastStatusCode.Int32 != http.StatusFound {
+		t.Errorf("expected the redirect to fail the attempt, got %+v", delivery)
+	}
+}
+
+func TestService_ReplayDelivery(t *testing.T) {
+	dispatcher, repo, rc, subscription := setupDispatcher(t, nil)
+	service := NewServiceWithMocks(repo)
+	ctx := context.Background()
+
+	event := riskIdentifiedEvent(t, subscription.ProgramID)
+	dispatcher.HandleEvent(ctx, event)
+	dispatcher.DeliverBatch(ctx)
+	original := repo.delivery(0)
+
+	replay, err := service.ReplayDelivery(ctx, subscription.ProgramID, subscription.SubscriptionID, original.DeliveryID)
+	if err != nil {
+		t.Fatalf("ReplayDelivery() error = %v", err)
+	}
+	if !replay.ReplayOf.Valid || replay.ReplayOf.UUID != original.DeliveryID || replay.EventID != event.ID {
+		t.Fatalf("unexpected replay: %+v", replay)
+	}
+
+	if sent, err := dispatcher.DeliverBatch(ctx); err != nil || sent != 1 {
+		t.Fatalf("DeliverBatch() = %d, %v; want 1, nil", sent, err)
+	}
+	if len(rc.requests) != 2 || string(rc.bodies[0]) != string(rc.bodies[1]) {
+		t.Fatalf("expected the original body to be sent again")
+	}
+	if rc.requests[1].Header.Get(HeaderDeliveryID) != replay.DeliveryID.String() ||
+		rc.requests[1].Header.Get(HeaderEventID) != event.ID.String() {
+		t.Errorf("unexpected replay headers: %v", rc.requests[1].Header)
+	}
+
+	// Replays are scoped to the program's own subscriptions
+	if _, err := service.ReplayDelivery(ctx, uuid.New(), subscription.SubscriptionID, original.DeliveryID); err == nil {
+		t.Error("expected replay from another program to fail")
+	}
+}
+
+func TestVerifySignature_RejectsStaleTimestamps(t *testing.T) {
+	body := []byte(`{"id":"evt"}`)
+	header := Sign("whsec_test", time.Now().Add(-10*time.Minute), body)
+
+	if err := VerifySignature("whsec_test", header, body, 5*time.Minute); err == nil {
+		t.Fatal("expected stale signature to be rejected")
+	}
+	if err := VerifySignature("whsec_test", header, body, 15*time.Minute); err != nil {
+		t.Fatalf("expected signature within tolerance to verify, got %v", err)
+	}
+	if err := VerifySignature("whsec_test", header, []byte(`{"id":"tampered"}`), 15*time.Minute); err == nil {
+		t.Fatal("expected tampered body to be rejected")
+	}
+}
@@ -0,0 +1,315 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cerberus/backend/internal/platform/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// RegisterRoutes registers all webhook endpoints. Managing webhooks exposes signing secrets,
+// so every route requires the admin role.
+func RegisterRoutes(r chi.Router, service *Service, authRepo *auth.Repository) {
+	r.Route("/programs/{programId}/webhooks", func(r chi.Router) {
+		r.Use(auth.RequireProgramAccess(auth.RoleAdmin, authRepo))
+		r.Post("/", handleCreateSubscription(service))
+		r.Get("/", handleListSubscriptions(service))
+
+		r.Route("/{webhookId}", func(r chi.Router) {
+			r.Get("/", handleGetSubscription(service))
+			r.Patch("/", handleUpdateSubscription(service))
+			r.Delete("/", handleDeleteSubscription(service))
+			r.Post("/rotate-secret", handleRotateSecret(service))
+
+			// Delivery log
+			r.Get("/deliveries", handleListDeliveries(service))
+			r.Get("/deliveries/{deliveryId}", handleGetDelivery(service))
+			r.Post("/deliveries/{deliveryId}/replay", handleReplayDelivery(service))
+		})
+	})
+}
+
+// subscriptionWithSecret is the response for requests that reveal the signing secret
+type subscriptionWithSecret struct {
+	*Subscription
+	Secret string `json:"secret"`
+}
+
+// handleCreateSubscription handles webhook subscription creation
+func handleCreateSubscription(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		var req CreateSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		req.ProgramID = programID
+		req.CreatedBy, _ = auth.GetUserID(r.Context())
+
+		subscription, err := service.CreateSubscription(r.Context(), req)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondCreated(w, subscriptionWithSecret{Subscription: subscription, Secret: subscription.Secret})
+	}
+}
+
+// handleListSubscriptions lists a program's webhook subscriptions
+func handleListSubscriptions(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid program ID")
+			return
+		}
+
+		subscriptions, err := service.ListSubscriptions(r.Context(), programID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"webhooks": subscriptions,
+			"count":    len(subscriptions),
+		})
+	}
+}
+
+// handleGetSubscription retrieves a webhook subscription
+func handleGetSubscription(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		subscription, err := service.GetSubscription(r.Context(), programID, subscriptionID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, subscription)
+	}
+}
+
+// handleUpdateSubscription applies a partial update to a webhook subscription
+func handleUpdateSubscription(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		var req UpdateSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		subscription, err := service.UpdateSubscription(r.Context(), programID, subscriptionID, req)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, subscription)
+	}
+}
+
+// handleDeleteSubscription deletes a webhook subscription
+func handleDeleteSubscription(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		if err := service.DeleteSubscription(r.Context(), programID, subscriptionID); err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]string{
+			"message": "Webhook deleted successfully",
+		})
+	}
+}
+
+// handleRotateSecret issues a new signing secret for a webhook subscription
+func handleRotateSecret(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		subscription, err := service.RotateSecret(r.Context(), programID, subscriptionID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, subscriptionWithSecret{Subscription: subscription, Secret: subscription.Secret})
+	}
+}
+
+// handleListDeliveries returns a webhook's delivery log
+func handleListDeliveries(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		filter := DeliveryFilter{
+			Status: r.URL.Query().Get("status"),
+			Limit:  parseIntParam(r, "limit", 50),
+			Offset: parseIntParam(r, "offset", 0),
+		}
+		if filter.Limit <= 0 || filter.Limit > 200 {
+			filter.Limit = 50
+		}
+		if filter.Offset < 0 {
+			filter.Offset = 0
+		}
+
+		deliveries, err := service.ListDeliveries(r.Context(), programID, subscriptionID, filter)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, map[string]interface{}{
+			"deliveries": deliveries,
+			"limit":      filter.Limit,
+			"offset":     filter.Offset,
+		})
+	}
+}
+
+// handleGetDelivery retrieves one webhook delivery
+func handleGetDelivery(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid delivery ID")
+			return
+		}
+
+		delivery, err := service.GetDelivery(r.Context(), programID, subscriptionID, deliveryID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondSuccess(w, delivery)
+	}
+}
+
+// handleReplayDelivery sends an earlier delivery again
+func handleReplayDelivery(service *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		programID, subscriptionID, ok := parseSubscriptionParams(w, r)
+		if !ok {
+			return
+		}
+
+		deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid delivery ID")
+			return
+		}
+
+		replay, err := service.ReplayDelivery(r.Context(), programID, subscriptionID, deliveryID)
+		if err != nil {
+			respondError(w, statusForError(err), err.Error())
+			return
+		}
+
+		respondCreated(w, replay)
+	}
+}
+
+// Helper functions
+
+// parseSubscriptionParams parses the program and webhook IDs from the URL, responding on failure
+func parseSubscriptionParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	programID, err := uuid.Parse(chi.URLParam(r, "programId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid program ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	subscriptionID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return programID, subscriptionID, true
+}
+
+// statusForError maps service errors to HTTP statuses
+func statusForError(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "required"), strings.HasPrefix(msg, "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func parseIntParam(r *http.Request, key string, defaultValue int) int {
+	if str := r.URL.Query().Get(key); str != "" {
+		if val, err := strconv.Atoi(str); err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func respondSuccess(w http.ResponseWriter, data interface{}) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func respondCreated(w http.ResponseWriter, data interface{}) {
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"   // Waiting for its next attempt
+	StatusSending   = "sending"   // Claimed by a dispatcher; reclaimed if the dispatcher dies
+	StatusSucceeded = "succeeded" // The endpoint answered 2xx
+	StatusFailed    = "failed"    // Retries exhausted
+)
+
+// Subscription is a program's webhook endpoint
+type Subscription struct {
+	SubscriptionID uuid.UUID      `json:"subscription_id"`
+	ProgramID      uuid.UUID      `json:"program_id"`
+	URL            string         `json:"url"`
+	Secret         string         `json:"-"`           // Only returned when created or rotated
+	EventTypes     []string       `json:"event_types"` // Empty means every event type
+	Description    sql.NullString `json:"description,omitempty"`
+	IsActive       bool           `json:"is_active"`
+	CreatedBy      uuid.NullUUID  `json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      sql.NullTime   `json:"deleted_at,omitempty"`
+}
+
+// Matches reports whether the subscription receives events of the given type
+func (s *Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event sent (or to be sent) to a subscription
+type Delivery struct {
+	DeliveryID       uuid.UUID       `json:"delivery_id"`
+	SubscriptionID   uuid.UUID       `json:"subscription_id"`
+	ProgramID        uuid.UUID       `json:"program_id"`
+	EventID          uuid.UUID       `json:"event_id"`
+	EventType        string          `json:"event_type"`
+	Payload          json.RawMessage `json:"payload"` // Request body
+	ReplayOf         uuid.NullUUID   `json:"replay_of,omitempty"`
+	Status           string          `json:"status"`
+	Attempts         int             `json:"attempts"`
+	NextAttemptAt    time.Time       `json:"next_attempt_at"`
+	LastStatusCode   sql.NullInt32   `json:"last_status_code,omitempty"`
+	LastError        sql.NullString  `json:"last_error,omitempty"`
+	LastResponseBody sql.NullString  `json:"last_response_body,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+	DeliveredAt      sql.NullTime    `json:"delivered_at,omitempty"`
+}
+
+// PendingDelivery is a claimed delivery together with the endpoint it goes to
+type PendingDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// DeliveryAttempt is the outcome of one POST to a webhook endpoint
+type DeliveryAttempt struct {
+	Status       string // StatusSucceeded, StatusPending (retry) or StatusFailed
+	StatusCode   int    // 0 if no response was received
+	Error        string
+	ResponseBody string
+	RetryAfter   time.Duration // Delay before the next attempt when Status is StatusPending
+}
+
+// CreateSubscriptionRequest represents a request to create a webhook subscription
+type CreateSubscriptionRequest struct {
+	ProgramID   uuid.UUID `json:"program_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"` // Generated when empty
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+}
+
+// UpdateSubscriptionRequest represents a partial update of a webhook subscription
+type UpdateSubscriptionRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	EventTypes  *[]string `json:"event_types,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	IsActive    *bool     `json:"is_active,omitempty"`
+}
+
+// DeliveryFilter represents filters for listing deliveries
+type DeliveryFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
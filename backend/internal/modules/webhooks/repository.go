@@ -0,0 +1,317 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// RepositoryInterface defines methods for webhook data access
+type RepositoryInterface interface {
+	// Subscriptions
+	CreateSubscription(ctx context.Context, subscription *Subscription) error
+	GetSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error)
+	ListActiveSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription *Subscription) error
+	DeleteSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) error
+
+	// Deliveries
+	EnqueueDeliveries(ctx context.Context, deliveries []Delivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]PendingDelivery, error)
+	RecordDeliveryAttempt(ctx context.Context, deliveryID uuid.UUID, attempt DeliveryAttempt) error
+	GetDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*Delivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, filter DeliveryFilter) ([]Delivery, error)
+}
+
+// Repository handles webhook data access
+type Repository struct {
+	db *db.DB
+}
+
+// NewRepository creates a new webhooks repository
+func NewRepository(database *db.DB) *Repository {
+	return &Repository{db: database}
+}
+
+const subscriptionColumns = `
+	subscription_id, program_id, url, secret, event_types, description,
+	is_active, created_by, created_at, updated_at, deleted_at`
+
+// scanSubscription scans a row selected with subscriptionColumns
+func scanSubscription(row interface{ Scan(...interface{}) error }, s *Subscription) error {
+	return row.Scan(
+		&s.SubscriptionID, &s.ProgramID, &s.URL, &s.Secret, pq.Array(&s.EventTypes), &s.Description,
+		&s.IsActive, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt, &s.DeletedAt,
+	)
+}
+
+// CreateSubscription inserts a new webhook subscription
+func (r *Repository) CreateSubscription(ctx context.Context, s *Subscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (
+			subscription_id, program_id, url, secret, event_types, description,
+			is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, s.SubscriptionID, s.ProgramID, s.URL, s.Secret, pq.Array(s.EventTypes), s.Description,
+		s.IsActive, s.CreatedBy, s.CreatedAt, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a program's webhook subscription
+func (r *Repository) GetSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) (*Subscription, error) {
+	var s Subscription
+	err := scanSubscription(r.db.QueryRowContext(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE program_id = $1 AND subscription_id = $2 AND deleted_at IS NULL
+	`, programID, subscriptionID), &s)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook subscription not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return &s, nil
+}
+
+// ListSubscriptions lists a program's webhook subscriptions
+func (r *Repository) ListSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error) {
+	return r.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE program_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at
+	`, programID)
+}
+
+// ListActiveSubscriptions lists the subscriptions of a program that receive events
+func (r *Repository) ListActiveSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error) {
+	return r.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE program_id = $1 AND is_active = true AND deleted_at IS NULL
+	`, programID)
+}
+
+func (r *Repository) listSubscriptions(ctx context.Context, query string, args ...interface{}) ([]Subscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		var s Subscription
+		if err := scanSubscription(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// UpdateSubscription saves a webhook subscription's mutable fields
+func (r *Repository) UpdateSubscription(ctx context.Context, s *Subscription) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $3, secret = $4, event_types = $5, description = $6, is_active = $7, updated_at = $8
+		WHERE program_id = $1 AND subscription_id = $2 AND deleted_at IS NULL
+	`, s.ProgramID, s.SubscriptionID, s.URL, s.Secret, pq.Array(s.EventTypes), s.Description, s.IsActive, s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+	return nil
+}
+
+// DeleteSubscription soft-deletes a webhook subscription. Its undelivered deliveries are never claimed again.
+func (r *Repository) DeleteSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET deleted_at = NOW(), is_active = false, updated_at = NOW()
+		WHERE program_id = $1 AND subscription_id = $2 AND deleted_at IS NULL
+	`, programID, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+	return nil
+}
+
+// EnqueueDeliveries inserts pending deliveries. An event already queued for a subscription
+// (a bus redelivery) is skipped; replays always insert.
+func (r *Repository) EnqueueDeliveries(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (
+				delivery_id, subscription_id, program_id, event_id, event_type, payload,
+				replay_of, status, next_attempt_at, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (subscription_id, event_id) WHERE replay_of IS NULL DO NOTHING
+		`, d.DeliveryID, d.SubscriptionID, d.ProgramID, d.EventID, d.EventType, []byte(d.Payload),
+			d.ReplayOf, StatusPending, d.NextAttemptAt, d.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries marks up to limit due deliveries as sending and returns them with their
+// endpoints. A claim lasts for lease: if the dispatcher dies mid-send, the delivery is due
+// again once the lease expires. Deliveries of paused or deleted subscriptions are left alone.
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]PendingDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET status = 'sending', next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM (
+			SELECT wd.delivery_id, s.url, s.secret
+			FROM webhook_deliveries wd
+			JOIN webhook_subscriptions s ON s.subscription_id = wd.subscription_id
+			WHERE wd.status IN ('pending', 'sending') AND wd.next_attempt_at <= NOW()
+			  AND s.is_active = true AND s.deleted_at IS NULL
+			ORDER BY wd.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF wd SKIP LOCKED
+		) due
+		WHERE d.delivery_id = due.delivery_id
+		RETURNING d.delivery_id, d.subscription_id, d.program_id, d.event_id, d.event_type, d.payload,
+			d.replay_of, d.status, d.attempts, d.next_attempt_at, d.created_at, due.url, due.secret
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []PendingDelivery
+	for rows.Next() {
+		var p PendingDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&p.DeliveryID, &p.SubscriptionID, &p.ProgramID, &p.EventID, &p.EventType, &payload,
+			&p.ReplayOf, &p.Status, &p.Attempts, &p.NextAttemptAt, &p.CreatedAt, &p.URL, &p.Secret,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		p.Payload = payload
+		claimed = append(claimed, p)
+	}
+	return claimed, rows.Err()
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt and schedules the next one if needed
+func (r *Repository) RecordDeliveryAttempt(ctx context.Context, deliveryID uuid.UUID, attempt DeliveryAttempt) error {
+	var statusCode sql.NullInt32
+	if attempt.StatusCode > 0 {
+		statusCode = sql.NullInt32{Int32: int32(attempt.StatusCode), Valid: true}
+	}
+	lastError := sql.NullString{String: attempt.Error, Valid: attempt.Error != ""}
+	responseBody := sql.NullString{String: attempt.ResponseBody, Valid: attempt.ResponseBody != ""}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2,
+		    attempts = attempts + 1,
+		    last_status_code = $3,
+		    last_error = $4,
+		    last_response_body = $5,
+		    next_attempt_at = NOW() + $6 * INTERVAL '1 millisecond',
+		    delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END
+		WHERE delivery_id = $1
+	`, deliveryID, attempt.Status, statusCode, lastError, responseBody, attempt.RetryAfter.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+const deliveryColumns = `
+	delivery_id, subscription_id, program_id, event_id, event_type, payload, replay_of,
+	status, attempts, next_attempt_at, last_status_code, last_error, last_response_body,
+	created_at, delivered_at`
+
+// scanDelivery scans a row selected with deliveryColumns
+func scanDelivery(row interface{ Scan(...interface{}) error }, d *Delivery) error {
+	var payload []byte
+	err := row.Scan(
+		&d.DeliveryID, &d.SubscriptionID, &d.ProgramID, &d.EventID, &d.EventType, &payload, &d.ReplayOf,
+		&d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.LastResponseBody,
+		&d.CreatedAt, &d.DeliveredAt,
+	)
+	d.Payload = payload
+	return err
+}
+
+// GetDelivery retrieves a delivery of a subscription
+func (r *Repository) GetDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	var d Delivery
+	err := scanDelivery(r.db.QueryRowContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND delivery_id = $2
+	`, subscriptionID, deliveryID), &d)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// ListDeliveries lists a subscription's deliveries, newest first
+func (r *Repository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, filter DeliveryFilter) ([]Delivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, subscriptionID, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
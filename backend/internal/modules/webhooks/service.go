@@ -0,0 +1,283 @@
+// Package webhooks delivers program events to external HTTP endpoints.
+// Programs register subscriptions (URL, signing secret, event-type filter); the Dispatcher
+// turns matching events into deliveries, POSTs them with an HMAC signature and retries
+// failures with backoff. Every delivery is kept as a log entry that can be replayed.
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/google/uuid"
+)
+
+// Service handles business logic for webhook subscriptions
+type Service struct {
+	repo RepositoryInterface
+}
+
+// NewService creates a new webhooks service
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// NewServiceWithMocks creates a service with mock dependencies (useful for testing)
+func NewServiceWithMocks(repo RepositoryInterface) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// CreateSubscription registers a webhook endpoint. The returned subscription carries the
+// signing secret, which is generated unless the request supplies one.
+func (s *Service) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*Subscription, error) {
+	if req.ProgramID == uuid.Nil {
+		return nil, fmt.Errorf("program_id is required")
+	}
+	if err := validateURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := GenerateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	now := time.Now()
+	subscription := &Subscription{
+		SubscriptionID: uuid.New(),
+		ProgramID:      req.ProgramID,
+		URL:            req.URL,
+		Secret:         secret,
+		EventTypes:     eventTypes,
+		Description:    sql.NullString{String: req.Description, Valid: req.Description != ""},
+		IsActive:       true,
+		CreatedBy:      uuid.NullUUID{UUID: req.CreatedBy, Valid: req.CreatedBy != uuid.Nil},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetSubscription retrieves a program's webhook subscription
+func (s *Service) GetSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) (*Subscription, error) {
+	return s.repo.GetSubscription(ctx, programID, subscriptionID)
+}
+
+// ListSubscriptions lists a program's webhook subscriptions
+func (s *Service) ListSubscriptions(ctx context.Context, programID uuid.UUID) ([]Subscription, error) {
+	return s.repo.ListSubscriptions(ctx, programID)
+}
+
+// UpdateSubscription applies a partial update to a webhook subscription
+func (s *Service) UpdateSubscription(ctx context.Context, programID, subscriptionID uuid.UUID, req UpdateSubscriptionRequest) (*Subscription, error) {
+	subscription, err := s.repo.GetSubscription(ctx, programID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(*req.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = *req.EventTypes
+		if subscription.EventTypes == nil {
+			subscription.EventTypes = []string{}
+		}
+	}
+	if req.Description != nil {
+		subscription.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// RotateSecret replaces a subscription's signing secret and returns the subscription with the new one
+func (s *Service) RotateSecret(ctx context.Context, programID, subscriptionID uuid.UUID) (*Subscription, error) {
+	subscription, err := s.repo.GetSubscription(ctx, programID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+	subscription.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a webhook subscription
+func (s *Service) DeleteSubscription(ctx context.Context, programID, subscriptionID uuid.UUID) error {
+	return s.repo.DeleteSubscription(ctx, programID, subscriptionID)
+}
+
+// ListDeliveries returns the delivery log of a program's subscription
+func (s *Service) ListDeliveries(ctx context.Context, programID, subscriptionID uuid.UUID, filter DeliveryFilter) ([]Delivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, programID, subscriptionID); err != nil {
+		return nil, err
+	}
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return nil, fmt.Errorf("invalid status: must be pending, sending, succeeded, or failed")
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, filter)
+}
+
+// GetDelivery retrieves one delivery of a program's subscription
+func (s *Service) GetDelivery(ctx context.Context, programID, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, programID, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDelivery(ctx, subscriptionID, deliveryID)
+}
+
+// ReplayDelivery queues the body of an earlier delivery to be sent again as a new delivery.
+// The event ID is unchanged, so receivers that dedupe on it can tell a replay from a new event.
+func (s *Service) ReplayDelivery(ctx context.Context, programID, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	original, err := s.GetDelivery(ctx, programID, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	replay := Delivery{
+		DeliveryID:     uuid.New(),
+		SubscriptionID: original.SubscriptionID,
+		ProgramID:      original.ProgramID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		ReplayOf:       uuid.NullUUID{UUID: original.DeliveryID, Valid: true},
+		Status:         StatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+
+	if err := s.repo.EnqueueDeliveries(ctx, []Delivery{replay}); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+// validateURL accepts absolute http and https URLs of public hosts, so deliveries cannot be
+// pointed at internal services
+func validateURL(ctx context.Context, rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("url is required")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid url: must be an absolute http or https URL")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("invalid url: %s is not a public address", host)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("invalid url: cannot resolve %s", host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return fmt.Errorf("invalid url: %s is not a public address", host)
+		}
+	}
+	return nil
+}
+
+// nonPublicNetworks are reserved IPv4 ranges the net.IP predicates do not cover
+var nonPublicNetworks = []net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},     // "This network"
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}, // Carrier-grade NAT shared space
+}
+
+// isInternalIP reports whether ip is a loopback, private, link-local or otherwise
+// non-routable address
+func isInternalIP(ip net.IP) bool {
+	// An IPv4-mapped IPv6 address (::ffff:10.0.0.1) reaches the IPv4 address it embeds
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEventTypes accepts event types that have a registered payload schema
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if _, ok := events.Payloads.Schema(events.EventType(eventType)); !ok {
+			return fmt.Errorf("invalid event type: %s", eventType)
+		}
+	}
+	return nil
+}
+
+func isValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusSending, StatusSucceeded, StatusFailed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+)
+
+// TestValidateURL refuses endpoints on loopback, private, link-local and other reserved addresses
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://203.0.113.10/hooks", true},
+		{"https://[2001:db8::1]:8443/hooks", true},
+		{"ftp://203.0.113.10/hooks", false},
+		{"http://localhost:8080/hooks", false},
+		{"http://127.0.0.1/hooks", false},
+		{"http://[::1]/hooks", false},
+		{"http://10.0.0.5/hooks", false},
+		{"http://172.16.3.4/hooks", false},
+		{"http://192.168.1.20/hooks", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[fe80::1]/hooks", false},
+		{"http://0.0.0.0/hooks", false},
+		{"http://0.1.2.3/hooks", false},
+		{"http://100.64.0.1/hooks", false},
+		{"http://100.127.255.254/hooks", false},
+		{"https://100.128.0.1/hooks", true},
+		{"http://[::ffff:127.0.0.1]/hooks", false},
+		{"http://[::ffff:10.0.0.5]/hooks", false},
+		{"http://[::ffff:169.254.169.254]/latest/meta-data", false},
+	}
+
+	for _, tt := range tests {
+		err := validateURL(context.Background(), tt.url)
+		if tt.valid && err != nil {
+			t.Errorf("validateURL(%q) = %v, want valid", tt.url, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("validateURL(%q) accepted an invalid url", tt.url)
+		}
+	}
+}
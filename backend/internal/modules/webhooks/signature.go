@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderSignature  = "X-Cerberus-Signature" // t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+	HeaderEventType  = "X-Cerberus-Event"
+	HeaderEventID    = "X-Cerberus-Event-Id"    // Stable across retries and replays; receivers dedupe on it
+	HeaderDeliveryID = "X-Cerberus-Delivery-Id" // Unique per delivery (a replay gets a new one)
+)
+
+// secretPrefix marks generated signing secrets
+const secretPrefix = "whsec_"
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for a request body sent at timestamp.
+// The timestamp is signed with the body so a captured request cannot be replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, computeSignature(secret, t, body))
+}
+
+// VerifySignature checks a signature header against the body, rejecting timestamps more than
+// tolerance away from now. Receivers written in Go can use it directly.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if t == "" || len(signatures) == 0 {
+		return fmt.Errorf("malformed signature header")
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed signature timestamp: %w", err)
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature timestamp outside tolerance")
+	}
+
+	expected := computeSignature(secret, t, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature mismatch")
+}
+
+// computeSignature returns the hex HMAC-SHA256 of "<t>.<body>"
+func computeSignature(secret, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+// Package aitest runs code that calls the model against recorded responses and compares its
+// output with golden files.
+//
+// Tests replay recordings by default. To record again after a prompt or parsing change, point
+// the LLM_* variables at a live model and run the package's tests with -ai.record; add
+// -ai.update to rewrite the golden files from the new output:
+//
+//	LLM_PROVIDER=anthropic LLM_API_KEY=... go test ./internal/modules/artifacts -run Golden -ai.record -ai.update
+package aitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cerberus/backend/internal/platform/ai"
+)
+
+var (
+	record = flag.Bool("ai.record", false, "call the model configured by LLM_* and record its responses")
+	update = flag.Bool("ai.update", false, "rewrite golden files with the current output")
+)
+
+// Provider returns a provider that replays the recordings in dir, or records into dir when
+// the tests run with -ai.record
+func Provider(t testing.TB, dir string) ai.Provider {
+	t.Helper()
+
+	if !*record {
+		return ai.NewRecordingProvider(nil, dir, ai.RecordModeReplay)
+	}
+
+	apiKey := os.Getenv("LLM_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	live, err := ai.NewProvider(ai.ProviderConfig{
+		Name:        os.Getenv("LLM_PROVIDER"),
+		BaseURL:     os.Getenv("LLM_BASE_URL"),
+		APIKey:      apiKey,
+		Model:       os.Getenv("LLM_MODEL"),
+		FixturesDir: os.Getenv("LLM_FAKE_FIXTURES"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider to record from: %v", err)
+	}
+	return ai.NewRecordingProvider(live, dir, ai.RecordModeRecord)
+}
+
+// AssertGolden compares got, encoded as indented JSON, with the golden file at path, or
+// rewrites the file when the tests run with -ai.update
+func AssertGolden(t testing.TB, path string, got interface{}) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal output: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -ai.update to create it): %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("output differs from %s (run with -ai.update to accept it)\ngot:\n%s\nwant:\n%s", path, data, want)
+	}
+}
@@ -0,0 +1,290 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAnthropicBaseURL is the public Anthropic API
+const DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+
+// AnthropicProvider calls the Anthropic Messages API
+type AnthropicProvider struct {
+	apiKey       string
+	baseURL      string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// NewAnthropicProvider creates an Anthropic provider. An empty baseURL uses the public API.
+func NewAnthropicProvider(apiKey, baseURL string) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+
+	return &AnthropicProvider{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+		streamClient: newStreamingHTTPClient(),
+	}
+}
+
+// Request sends a request to the Messages API. Requests with Stream set are streamed and
+// returned once complete, which avoids the timeout on long responses.
+func (p *AnthropicProvider) Request(ctx context.Context, req *Request) (*Response, error) {
+	if req.Stream {
+		return p.Stream(ctx, req, nil)
+	}
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("Anthropic API key not configured")
+	}
+
+	// Marshal request body
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+
+	// Send request
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Check for errors
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, anthropicError(httpResp, respBody)
+	}
+
+	// Parse response
+	var apiResp Response
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	apiResp.RateLimit = parseRateLimitHeaders(httpResp.Header, time.Now())
+
+	return &apiResp, nil
+}
+
+// Stream sends a streaming request to the Messages API, passing text deltas to handler as
+// they arrive
+func (p *AnthropicProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("Anthropic API key not configured")
+	}
+
+	streamed := *req
+	streamed.Stream = true
+	body, err := json.Marshal(&streamed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+
+	httpResp, err := p.streamClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, anthropicError(httpResp, respBody)
+	}
+
+	stream := &anthropicStream{handler: handler}
+	if err := readStream(ctx, httpResp.Body, stream.apply); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.response()
+	if err != nil {
+		return nil, err
+	}
+	resp.RateLimit = parseRateLimitHeaders(httpResp.Header, time.Now())
+	return resp, nil
+}
+
+// anthropicError converts an error response to an *APIError
+func anthropicError(httpResp *http.Response, body []byte) error {
+	message := string(body)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		message = errResp.Error.Message
+	}
+
+	return &APIError{
+		StatusCode: httpResp.StatusCode,
+		Message:    message,
+		RateLimit:  parseRateLimitHeaders(httpResp.Header, time.Now()),
+	}
+}
+
+// anthropicStream assembles a Response from the events of a Messages API stream
+type anthropicStream struct {
+	handler StreamHandler
+	resp    *Response
+	blocks  []*streamBlock
+	done    bool
+}
+
+// streamBlock is a content block being streamed
+type streamBlock struct {
+	content Content
+	text    strings.Builder
+	input   strings.Builder // Tool use input JSON
+}
+
+func (s *anthropicStream) apply(event string, data []byte) error {
+	switch event {
+	case "message_start", "ping", "error":
+	default:
+		if s.resp == nil {
+			return fmt.Errorf("failed to parse stream: %s event before message_start", event)
+		}
+	}
+
+	switch event {
+	case "message_start":
+		var payload struct {
+			Message Response `json:"message"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("failed to parse stream event %s: %w", event, err)
+		}
+		s.resp = &payload.Message
+
+	case "content_block_start":
+		var payload struct {
+			Index        int     `json:"index"`
+			ContentBlock Content `json:"content_block"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("failed to parse stream event %s: %w", event, err)
+		}
+		for len(s.blocks) <= payload.Index {
+			s.blocks = append(s.blocks, &streamBlock{})
+		}
+		s.blocks[payload.Index].content = payload.ContentBlock
+
+	case "content_block_delta":
+		var payload struct {
+			Index int `json:"index"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("failed to parse stream event %s: %w", event, err)
+		}
+		if payload.Index < 0 || payload.Index >= len(s.blocks) {
+			return fmt.Errorf("failed to parse stream: delta for unknown content block %d", payload.Index)
+		}
+
+		block := s.blocks[payload.Index]
+		switch payload.Delta.Type {
+		case "text_delta":
+			block.text.WriteString(payload.Delta.Text)
+			if s.handler != nil && payload.Delta.Text != "" {
+				if err := s.handler(payload.Delta.Text); err != nil {
+					return err
+				}
+			}
+		case "input_json_delta":
+			block.input.WriteString(payload.Delta.PartialJSON)
+		}
+
+	case "message_delta":
+		var payload struct {
+			Delta struct {
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("failed to parse stream event %s: %w", event, err)
+		}
+		s.resp.StopReason = payload.Delta.StopReason
+		s.resp.Usage.OutputTokens = payload.Usage.OutputTokens
+
+	case "message_stop":
+		s.done = true
+		return io.EOF
+
+	case "error":
+		var errResp ErrorResponse
+		if err := json.Unmarshal(data, &errResp); err != nil {
+			return fmt.Errorf("API stream error: %s", string(data))
+		}
+		return fmt.Errorf("API stream error (%s): %s", errResp.Error.Type, errResp.Error.Message)
+	}
+
+	return nil
+}
+
+// response returns the assembled response, failing if the stream ended early
+func (s *anthropicStream) response() (*Response, error) {
+	if !s.done {
+		return nil, errStreamInterrupted
+	}
+
+	s.resp.Content = make([]Content, 0, len(s.blocks))
+	for _, block := range s.blocks {
+		content := block.content
+		switch content.Type {
+		case "text":
+			content.Text = block.text.String()
+		case "tool_use":
+			// The input in content_block_start is always empty; it arrives in deltas
+			content.Input = json.RawMessage("{}")
+			if block.input.Len() > 0 {
+				content.Input = json.RawMessage(block.input.String())
+			}
+		}
+		s.resp.Content = append(s.resp.Content, content)
+	}
+	return s.resp, nil
+}
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is an error response from a model API
+type APIError struct {
+	StatusCode int
+	Message    string
+	RateLimit  *RateLimitInfo // Rate limit headers of the response, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the request may succeed if sent again: rate limits, overload
+// and server errors
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Overloaded reports whether the model is temporarily overloaded (Anthropic's 529, or 503
+// from OpenAI-compatible servers)
+func (e *APIError) Overloaded() bool {
+	return e.StatusCode == 529 || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// RateLimitInfo is what a model API reported about its rate limits with a response
+type RateLimitInfo struct {
+	RequestsRemaining int           // -1 when not reported
+	TokensRemaining   int           // -1 when not reported
+	Reset             time.Time     // When the exhausted limits refill; zero if none is exhausted
+	RetryAfter        time.Duration // How long the API asked clients to wait
+}
+
+// Exhausted reports whether the API asked clients to wait, and until when
+func (r *RateLimitInfo) Exhausted(now time.Time) (time.Time, bool) {
+	if r == nil {
+		return time.Time{}, false
+	}
+
+	until := r.Reset
+	if r.RetryAfter > 0 && now.Add(r.RetryAfter).After(until) {
+		until = now.Add(r.RetryAfter)
+	}
+	return until, until.After(now)
+}
+
+// Rate limit headers of the Anthropic and OpenAI APIs
+var (
+	requestsRemainingHeaders = []string{"anthropic-ratelimit-requests-remaining", "x-ratelimit-remaining-requests"}
+	requestsResetHeaders     = []string{"anthropic-ratelimit-requests-reset", "x-ratelimit-reset-requests"}
+	tokensRemainingHeaders   = []string{"anthropic-ratelimit-tokens-remaining", "x-ratelimit-remaining-tokens"}
+	tokensResetHeaders       = []string{"anthropic-ratelimit-tokens-reset", "x-ratelimit-reset-tokens"}
+)
+
+// parseRateLimitHeaders reads the rate limit headers of a response, or returns nil if it has
+// none. Resets are RFC 3339 times (Anthropic) or durations such as 6m0s (OpenAI).
+func parseRateLimitHeaders(header http.Header, now time.Time) *RateLimitInfo {
+	info := &RateLimitInfo{
+		RequestsRemaining: headerInt(header, requestsRemainingHeaders),
+		TokensRemaining:   headerInt(header, tokensRemainingHeaders),
+	}
+
+	if info.RequestsRemaining == 0 {
+		info.Reset = latest(info.Reset, headerTime(header, requestsResetHeaders, now))
+	}
+	if info.TokensRemaining == 0 {
+		info.Reset = latest(info.Reset, headerTime(header, tokensResetHeaders, now))
+	}
+
+	if ms, err := strconv.Atoi(header.Get("retry-after-ms")); err == nil && ms > 0 {
+		info.RetryAfter = time.Duration(ms) * time.Millisecond
+	} else if retryAfter := header.Get("retry-after"); retryAfter != "" {
+		if seconds, err := strconv.ParseFloat(retryAfter, 64); err == nil && seconds > 0 {
+			info.RetryAfter = time.Duration(seconds * float64(time.Second))
+		} else if at, err := http.ParseTime(retryAfter); err == nil && at.After(now) {
+			info.RetryAfter = at.Sub(now)
+		}
+	}
+
+	if info.RequestsRemaining < 0 && info.TokensRemaining < 0 && info.RetryAfter == 0 {
+		return nil
+	}
+	return info
+}
+
+// rateLimitInfo returns the rate limit information of a request's outcome
+func rateLimitInfo(resp *Response, err error) *RateLimitInfo {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RateLimit
+	}
+	if resp != nil {
+		return resp.RateLimit
+	}
+	return nil
+}
+
+func headerInt(header http.Header, names []string) int {
+	for _, name := range names {
+		if value, err := strconv.Atoi(header.Get(name)); err == nil {
+			return value
+		}
+	}
+	return -1
+}
+
+func headerTime(header http.Header, names []string, now time.Time) time.Time {
+	for _, name := range names {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if at, err := time.Parse(time.RFC3339, value); err == nil {
+			return at
+		}
+		if d, err := time.ParseDuration(value); err == nil {
+			return now.Add(d)
+		}
+	}
+	return time.Time{}
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
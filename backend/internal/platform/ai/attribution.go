@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Attribution identifies what an AI request is spent on. It is recorded with the request's
+// usage metrics and selects the program budget the request is checked against.
+type Attribution struct {
+	ProgramID  uuid.UUID
+	ArtifactID uuid.UUID
+	Module     string // Module making the request, e.g. "artifacts" or "financial"
+	JobType    string // What the request is for, e.g. "artifact_analysis"
+	Prompt     PromptRef
+}
+
+// PromptRef identifies the prompt version a request was built from
+type PromptRef struct {
+	ID      string
+	Version string
+}
+
+type attributionKey struct{}
+
+// WithAttribution returns a context whose AI requests are attributed to a. Zero fields keep the
+// value already on ctx, so each layer can add what it knows: the pipeline sets the program and
+// artifact, an analyzer the module, job type and prompt.
+func WithAttribution(ctx context.Context, a Attribution) context.Context {
+	current := AttributionFromContext(ctx)
+	if a.ProgramID != uuid.Nil {
+		current.ProgramID = a.ProgramID
+	}
+	if a.ArtifactID != uuid.Nil {
+		current.ArtifactID = a.ArtifactID
+	}
+	if a.Module != "" {
+		current.Module = a.Module
+	}
+	if a.JobType != "" {
+		current.JobType = a.JobType
+	}
+	if a.Prompt.ID != "" {
+		current.Prompt = a.Prompt
+	}
+	return context.WithValue(ctx, attributionKey{}, current)
+}
+
+// AttributionFromContext returns the attribution set on ctx, or a zero Attribution
+func AttributionFromContext(ctx context.Context) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	return a
+}
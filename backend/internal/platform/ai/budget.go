@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/google/uuid"
+)
+
+// ErrAIBudgetExceeded is matched (with errors.Is) by the *BudgetExceededError returned when a
+// program has spent its AI budget for the day or month
+var ErrAIBudgetExceeded = errors.New("AI budget exceeded")
+
+// BudgetExceededError reports which of a program's AI spend limits was reached
+type BudgetExceededError struct {
+	ProgramID uuid.UUID
+	Period    string // "daily" or "monthly"
+	LimitUSD  float64
+	SpentUSD  float64
+	ResetsAt  time.Time // Start of the next period, when requests may resume
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s AI budget exceeded for program %s: spent $%.2f of $%.2f, resets at %s",
+		e.Period, e.ProgramID, e.SpentUSD, e.LimitUSD, e.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrAIBudgetExceeded
+}
+
+// BudgetChecker decides whether a program may make another AI request
+type BudgetChecker interface {
+	CheckBudget(ctx context.Context, programID uuid.UUID) error
+}
+
+// ProgramConfigSource loads program configuration; *programs.ConfigService implements it
+type ProgramConfigSource interface {
+	GetProgramConfig(ctx context.Context, programID uuid.UUID) (*programs.ProgramConfig, error)
+}
+
+// SpendTracker reports a program's AI spend; *DBMetricsTracker implements it
+type SpendTracker interface {
+	GetDailyCost(ctx context.Context, programID uuid.UUID) (float64, error)
+	GetMonthlyCost(ctx context.Context, programID uuid.UUID) (float64, error)
+}
+
+// BudgetGuard enforces the daily and monthly AI spend limits in each program's configuration.
+// Spend is checked before a request is sent, so requests already in flight when a limit is
+// reached can overshoot it by their own cost.
+type BudgetGuard struct {
+	configs ProgramConfigSource
+	spend   SpendTracker
+	now     func() time.Time
+}
+
+// NewBudgetGuard creates a budget guard
+func NewBudgetGuard(configs ProgramConfigSource, spend SpendTracker) *BudgetGuard {
+	return &BudgetGuard{
+		configs: configs,
+		spend:   spend,
+		now:     time.Now,
+	}
+}
+
+// CheckBudget returns a *BudgetExceededError if the program has reached a spend limit.
+// Programs without limits are never blocked.
+func (g *BudgetGuard) CheckBudget(ctx context.Context, programID uuid.UUID) error {
+	config, err := g.configs.GetProgramConfig(ctx, programID)
+	if err != nil {
+		return fmt.Errorf("failed to load AI budget: %w", err)
+	}
+	if config.AIBudget == nil {
+		return nil
+	}
+	budget := config.AIBudget
+
+	now := g.now().UTC()
+	if budget.DailyLimitUSD > 0 {
+		spent, err := g.spend.GetDailyCost(ctx, programID)
+		if err != nil {
+			return fmt.Errorf("failed to check AI budget: %w", err)
+		}
+		if spent >= budget.DailyLimitUSD {
+			return &BudgetExceededError{
+				ProgramID: programID,
+				Period:    "daily",
+				LimitUSD:  budget.DailyLimitUSD,
+				SpentUSD:  spent,
+				ResetsAt:  time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+			}
+		}
+	}
+
+	if budget.MonthlyLimitUSD > 0 {
+		spent, err := g.spend.GetMonthlyCost(ctx, programID)
+		if err != nil {
+			return fmt.Errorf("failed to check AI budget: %w", err)
+		}
+		if spent >= budget.MonthlyLimitUSD {
+			return &BudgetExceededError{
+				ProgramID: programID,
+				Period:    "monthly",
+				LimitUSD:  budget.MonthlyLimitUSD,
+				SpentUSD:  spent,
+				ResetsAt:  time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC),
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/google/uuid"
+)
+
+type fakeConfigSource struct {
+	config *programs.ProgramConfig
+}
+
+func (f *fakeConfigSource) GetProgramConfig(ctx context.Context, programID uuid.UUID) (*programs.ProgramConfig, error) {
+	return f.config, nil
+}
+
+type fakeSpend struct {
+	daily, monthly float64
+}
+
+func (f *fakeSpend) GetDailyCost(ctx context.Context, programID uuid.UUID) (float64, error) {
+	return f.daily, nil
+}
+
+func (f *fakeSpend) GetMonthlyCost(ctx context.Context, programID uuid.UUID) (float64, error) {
+	return f.monthly, nil
+}
+
+func TestBudgetGuard_CheckBudget(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 30, 0, 0, time.UTC)
+	budget := &programs.AIBudgetConfig{DailyLimitUSD: 10, MonthlyLimitUSD: 100}
+
+	tests := []struct {
+		name       string
+		budget     *programs.AIBudgetConfig
+		spend      fakeSpend
+		wantPeriod string
+		wantResets time.Time
+	}{
+		{name: "no budget configured", budget: nil, spend: fakeSpend{daily: 500, monthly: 5000}},
+		{name: "under both limits", budget: budget, spend: fakeSpend{daily: 9.99, monthly: 50}},
+		{name: "daily limit reached", budget: budget, spend: fakeSpend{daily: 10, monthly: 50},
+			wantPeriod: "daily", wantResets: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "monthly limit reached", budget: budget, spend: fakeSpend{daily: 1, monthly: 120},
+			wantPeriod: "monthly", wantResets: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "zero limit is not enforced", budget: &programs.AIBudgetConfig{MonthlyLimitUSD: 100}, spend: fakeSpend{daily: 90, monthly: 90}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spend := tt.spend
+			guard := NewBudgetGuard(&fakeConfigSource{config: &programs.ProgramConfig{AIBudget: tt.budget}}, &spend)
+			guard.now = func() time.Time { return now }
+
+			err := guard.CheckBudget(context.Background(), uuid.New())
+			if tt.wantPeriod == "" {
+				if err != nil {
+					t.Fatalf("CheckBudget() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrAIBudgetExceeded) {
+				t.Fatalf("CheckBudget() error = %v, want ErrAIBudgetExceeded", err)
+			}
+			var budgetErr *BudgetExceededError
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("CheckBudget() error is %T, want *BudgetExceededError", err)
+			}
+			if budgetErr.Period != tt.wantPeriod || !budgetErr.ResetsAt.Equal(tt.wantResets) {
+				t.Errorf("got %s budget resetting at %v, want %s at %v", budgetErr.Period, budgetErr.ResetsAt, tt.wantPeriod, tt.wantResets)
+			}
+		})
+	}
+}
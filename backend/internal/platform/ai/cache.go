@@ -0,0 +1,191 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// responseCachePrefix namespaces cached responses: ai:response:<program>:<job type>:<hash>
+	responseCachePrefix = "ai:response:"
+
+	// cacheGenerationPrefix holds the counters that invalidate a program's or artifact's
+	// cached responses when incremented
+	cacheGenerationPrefix = "ai:cache:generation:"
+
+	// generationTTL keeps an invalidation counter until every response cached before it was
+	// incremented has expired; longer response TTLs are capped to it
+	generationTTL = 30 * 24 * time.Hour
+)
+
+// ResponseCacheConfig sets how long API responses are cached
+type ResponseCacheConfig struct {
+	TTL     time.Duration            // Zero disables the cache
+	JobTTLs map[string]time.Duration // By attribution job type, overriding TTL; zero disables a job's cache
+}
+
+// DefaultResponseCacheConfig caches every response for an hour
+func DefaultResponseCacheConfig() ResponseCacheConfig {
+	return ResponseCacheConfig{TTL: time.Hour}
+}
+
+// ResponseCache stores API responses in Redis, namespaced by the program and job type of the
+// request's Attribution. A program's responses are invalidated when its configuration changes
+// (InvalidateProgram) and an artifact's when it is reanalyzed (InvalidateArtifact). Programs
+// that set disable_ai_cache in their configuration are never cached.
+type ResponseCache struct {
+	client  *redis.Client
+	config  ResponseCacheConfig
+	configs ProgramConfigSource
+}
+
+// NewResponseCache creates a response cache
+func NewResponseCache(client *redis.Client, config ResponseCacheConfig) *ResponseCache {
+	return &ResponseCache{client: client, config: config}
+}
+
+// SetProgramConfigs lets the cache skip programs that disable it
+func (c *ResponseCache) SetProgramConfigs(configs ProgramConfigSource) {
+	c.configs = configs
+}
+
+// InvalidateProgram drops every cached response of a program
+func (c *ResponseCache) InvalidateProgram(ctx context.Context, programID uuid.UUID) error {
+	return c.invalidate(ctx, "program:"+programID.String())
+}
+
+// InvalidateArtifact drops the cached responses of requests made for an artifact
+func (c *ResponseCache) InvalidateArtifact(ctx context.Context, artifactID uuid.UUID) error {
+	return c.invalidate(ctx, "artifact:"+artifactID.String())
+}
+
+func (c *ResponseCache) invalidate(ctx context.Context, scope string) error {
+	key := cacheGenerationPrefix + scope
+
+	pipe := c.client.TxPipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, generationTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to invalidate AI response cache: %w", err)
+	}
+	return nil
+}
+
+// key returns the cache key of a request and how long its response is kept, or "" if the
+// response is not cached
+func (c *ResponseCache) key(ctx context.Context, req *Request) (string, time.Duration) {
+	attribution := AttributionFromContext(ctx)
+
+	ttl, ok := c.config.JobTTLs[attribution.JobType]
+	if !ok {
+		ttl = c.config.TTL
+	}
+	if ttl <= 0 || (attribution.ProgramID != uuid.Nil && c.disabled(ctx, attribution.ProgramID)) {
+		return "", 0
+	}
+	if ttl > generationTTL {
+		ttl = generationTTL
+	}
+
+	namespace := "global"
+	var scopes []string
+	if attribution.ProgramID != uuid.Nil {
+		namespace = attribution.ProgramID.String()
+		scopes = append(scopes, "program:"+namespace)
+	}
+	if attribution.ArtifactID != uuid.Nil {
+		scopes = append(scopes, "artifact:"+attribution.ArtifactID.String())
+	}
+
+	// The current generations of the request's scopes are part of the key, so incrementing
+	// one leaves the responses cached before it unreachable
+	var generations []string
+	if len(scopes) > 0 {
+		keys := make([]string, len(scopes))
+		for i, scope := range scopes {
+			keys[i] = cacheGenerationPrefix + scope
+		}
+		values, err := c.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			log.Printf("Warning: AI response cache unavailable: %v", err)
+			return "", 0
+		}
+		for i, value := range values {
+			generation, _ := value.(string)
+			generations = append(generations, scopes[i]+"="+generation)
+		}
+	}
+
+	jobType := attribution.JobType
+	if jobType == "" {
+		jobType = "default"
+	}
+	return responseCachePrefix + namespace + ":" + jobType + ":" + responseHash(req, generations), ttl
+}
+
+// disabled reports whether a program keeps its responses out of the cache. Programs whose
+// configuration cannot be loaded are not cached.
+func (c *ResponseCache) disabled(ctx context.Context, programID uuid.UUID) bool {
+	if c.configs == nil {
+		return false
+	}
+
+	config, err := c.configs.GetProgramConfig(ctx, programID)
+	if err != nil {
+		log.Printf("Warning: Failed to load program %s configuration, not caching its AI responses: %v", programID, err)
+		return true
+	}
+	return config.DisableAICache
+}
+
+// get returns the cached response for key, or nil
+func (c *ResponseCache) get(ctx context.Context, key string) *Response {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Warning: Failed to read AI response cache: %v", err)
+		}
+		return nil
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// set caches a response under key for ttl
+func (c *ResponseCache) set(ctx context.Context, key string, ttl time.Duration, resp *Response) {
+	data, err := json.Marshal(resp)
+	if err == nil {
+		err = c.client.Set(ctx, key, data, ttl).Err()
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to cache AI response: %v", err)
+	}
+}
+
+// responseHash hashes what a response depends on: the request's CacheKey, model and
+// instructions when it has a CacheKey, the whole request otherwise, and the generations of
+// its cache scopes. Streamed and unstreamed requests share responses.
+func responseHash(req *Request, generations []string) string {
+	keyed := *req
+	keyed.Stream = false
+	if req.CacheKey != "" {
+		keyed.Messages = nil
+	}
+
+	h := sha256.New()
+	json.NewEncoder(h).Encode(&keyed)
+	fmt.Fprintf(h, "%s\n%s", req.CacheKey, strings.Join(generations, ","))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
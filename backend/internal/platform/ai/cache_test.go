@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/google/uuid"
+)
+
+func TestResponseHash_CacheKeyIgnoresMessages(t *testing.T) {
+	first := NewContextRequest(ModelSonnet4, "Analyze", "Program context", "Related artifacts: none", 100)
+	second := NewContextRequest(ModelSonnet4, "Analyze", "Program context", "Related artifacts: kickoff notes", 100)
+	second.Stream = true
+
+	if responseHash(first, nil) == responseHash(second, nil) {
+		t.Errorf("requests with different messages should not share a response without a CacheKey")
+	}
+
+	first.CacheKey = "artifact:abc"
+	second.CacheKey = "artifact:abc"
+	if responseHash(first, nil) != responseHash(second, nil) {
+		t.Errorf("requests with the same CacheKey should share a response")
+	}
+	if responseHash(first, nil) == responseHash(first, []string{"artifact:1="}) {
+		t.Errorf("invalidating a scope should change the hash")
+	}
+}
+
+func TestResponseCache_Key(t *testing.T) {
+	cache := NewResponseCache(nil, ResponseCacheConfig{
+		TTL:     time.Hour,
+		JobTTLs: map[string]time.Duration{"ocr": 24 * time.Hour, "risk_chat": 0},
+	})
+	req := NewSimpleRequest(ModelSonnet4, "", "extract the text", 100)
+
+	key, ttl := cache.key(WithAttribution(context.Background(), Attribution{JobType: "ocr"}), req)
+	if !strings.HasPrefix(key, "ai:response:global:ocr:") || ttl != 24*time.Hour {
+		t.Errorf("key() = %q, %v, want the ocr namespace and TTL", key, ttl)
+	}
+
+	if key, _ := cache.key(WithAttribution(context.Background(), Attribution{JobType: "risk_chat"}), req); key != "" {
+		t.Errorf("key() = %q, want no caching for a job with a zero TTL", key)
+	}
+
+	cache.SetProgramConfigs(&fakeConfigSource{config: &programs.ProgramConfig{DisableAICache: true}})
+	ctx := WithAttribution(context.Background(), Attribution{ProgramID: uuid.New(), JobType: "ocr"})
+	if key, _ := cache.key(ctx, req); key != "" {
+		t.Errorf("key() = %q, want no caching for a program that disables it", key)
+	}
+}
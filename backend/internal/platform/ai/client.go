@@ -1,26 +1,26 @@
 package ai
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
-// Client is the Claude API client
+// Client sends requests through a Provider, adding response caching, retries, budget checks
+// and usage tracking. It is itself a StreamingProvider.
 type Client struct {
-	apiKey         string
-	baseURL        string
-	httpClient     *http.Client
-	cache          *redis.Client
+	provider       Provider
+	cache          *ResponseCache
 	costCalculator *CostCalculator
 	metricsTracker MetricsTracker
+	budgetChecker  BudgetChecker
+	rateLimiter    *RateLimiter
+	models         *ModelPolicy
 }
 
 // MetricsTracker defines interface for tracking AI usage metrics
@@ -28,182 +28,252 @@ type MetricsTracker interface {
 	Track(ctx context.Context, metrics *Metrics) error
 }
 
-// ClientConfig contains configuration for the Claude API client
+// ClientConfig contains configuration for the AI client
 type ClientConfig struct {
-	APIKey         string
-	RedisClient    *redis.Client
+	APIKey         string   // Used for the default Anthropic provider when Provider is nil
+	Provider       Provider // Optional; the model API requests are sent to
+	RedisClient    *redis.Client  // Caches responses with DefaultResponseCacheConfig unless ResponseCache is set
+	ResponseCache  *ResponseCache // Optional
 	MetricsTracker MetricsTracker
+	BudgetChecker  BudgetChecker // Optional; enforces per-program spend limits
+	RateLimiter    *RateLimiter  // Optional; paces requests to the API's rate limits
+	ModelPolicy    *ModelPolicy  // Optional; routes requests among models and prices them
 }
 
-// NewClient creates a new Claude API client
+// NewClient creates a new AI client
 func NewClient(config *ClientConfig) *Client {
+	provider := config.Provider
+	if provider == nil {
+		provider = NewAnthropicProvider(config.APIKey, "")
+	}
+	models := config.ModelPolicy
+	if models == nil {
+		models = defaultModelPolicy
+	}
+	cache := config.ResponseCache
+	if cache == nil && config.RedisClient != nil {
+		cache = NewResponseCache(config.RedisClient, DefaultResponseCacheConfig())
+	}
+
 	return &Client{
-		apiKey:  config.APIKey,
-		baseURL: "https://api.anthropic.com/v1",
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
-		cache:          config.RedisClient,
-		costCalculator: NewCostCalculator(),
+		provider:       provider,
+		cache:          cache,
+		costCalculator: NewPolicyCostCalculator(models),
 		metricsTracker: config.MetricsTracker,
+		budgetChecker:  config.BudgetChecker,
+		rateLimiter:    config.RateLimiter,
+		models:         models,
 	}
 }
 
-// Request sends a request to Claude API with retry logic
+// Request sends a request to the provider with retry logic. Usage is attributed to the
+// Attribution on ctx (see WithAttribution), which also selects the response cache namespace
+// (see ResponseCache), and a request for a program that has spent its
+// AI budget fails with ErrAIBudgetExceeded before reaching the API. With a rate limiter,
+// each attempt waits its turn at the priority set by WithPriority. The model policy may send
+// the request to another model than it names, and moves it to the model's fallback while the
+// model is overloaded. Requests with Stream set are streamed from the provider and returned
+// once complete.
 func (c *Client) Request(ctx context.Context, req *Request) (*Response, error) {
-	// Check cache first (if not streaming)
-	if !req.Stream && c.cache != nil {
-		cacheKey := c.generateCacheKey(req)
-		if cached, err := c.getFromCache(ctx, cacheKey); err == nil && cached != nil {
+	return c.send(ctx, req, nil)
+}
+
+// Stream sends a request as a stream, calling handler with each text delta as it arrives, and
+// returns the complete response with its usage once the stream ends. Cancelling ctx stops the
+// stream. Failures before any text is delivered are retried as in Request; later failures are
+// returned as they are, since the delivered text cannot be taken back.
+func (c *Client) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	streamed := *req
+	streamed.Stream = true
+	return c.send(ctx, &streamed, handler)
+}
+
+// send implements Request and Stream
+func (c *Client) send(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	attribution := AttributionFromContext(ctx)
+
+	if model := c.models.Route(ctx, req); model != req.Model {
+		routed := *req
+		routed.Model = model
+		req = &routed
+	}
+
+	// Check cache first
+	var cacheKey string
+	var cacheTTL time.Duration
+	if c.cache != nil {
+		cacheKey, cacheTTL = c.cache.key(ctx, req)
+	}
+	if cacheKey != "" {
+		start := time.Now()
+		if cached := c.cache.get(ctx, cacheKey); cached != nil {
+			if err := deliverText(cached, handler); err != nil {
+				return nil, err
+			}
+			c.track(ctx, req.Model, nil, time.Since(start))
 			return cached, nil
 		}
 	}
 
+	// Cached responses are free; only API calls count against the budget
+	if c.budgetChecker != nil && attribution.ProgramID != uuid.Nil {
+		if err := c.budgetChecker.CheckBudget(ctx, attribution.ProgramID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Text already passed to the handler cannot be retried
+	delivered := false
+	deliver := handler
+	if handler != nil {
+		deliver = func(delta string) error {
+			delivered = true
+			return handler(delta)
+		}
+	}
+
 	// Make request with retry logic
+	start := time.Now()
 	var resp *Response
 	var lastErr error
+	sent := req
+	fellBack := false
 
 	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+		if attempt > 0 && !fellBack {
 			select {
-			case <-time.After(backoff):
+			case <-time.After(retryDelay(attempt, lastErr)):
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			}
 		}
 
-		resp, lastErr = c.makeRequest(ctx, req)
+		resp, lastErr = c.attempt(ctx, sent, deliver)
 		if lastErr == nil {
 			break
 		}
 
 		// Don't retry on non-transient errors
-		if !c.isRetryableError(lastErr) {
+		if delivered || !c.isRetryableError(lastErr) {
 			return nil, lastErr
 		}
+
+		// Retry an overloaded model's request on its fallback model at once
+		fellBack = false
+		if fallback := c.models.Fallback(sent.Model); fallback != "" && isOverloaded(lastErr) {
+			log.Printf("Warning: AI model %s overloaded, falling back to %s", sent.Model, fallback)
+			next := *sent
+			next.Model = fallback
+			sent = &next
+			fellBack = true
+		}
 	}
 
 	if lastErr != nil {
 		return nil, fmt.Errorf("failed after 3 attempts: %w", lastErr)
 	}
 
-	// Cache response (1 hour TTL)
-	if !req.Stream && c.cache != nil {
-		cacheKey := c.generateCacheKey(req)
-		c.cacheResponse(ctx, cacheKey, resp)
+	if cacheKey != "" {
+		c.cache.set(ctx, cacheKey, cacheTTL, resp)
 	}
 
-	// Track metrics
-	if c.metricsTracker != nil {
-		cost := c.costCalculator.CalculateCost(req.Model, &resp.Usage)
-		metrics := &Metrics{
-			Model:        req.Model,
-			InputTokens:  resp.Usage.InputTokens,
-			OutputTokens: resp.Usage.OutputTokens,
-			CachedTokens: resp.Usage.CacheReadInputTokens,
-			TotalTokens:  resp.Usage.InputTokens + resp.Usage.OutputTokens,
-			Cost:         cost,
-			CacheHit:     false,
-			Timestamp:    time.Now(),
-		}
-		c.metricsTracker.Track(ctx, metrics)
-	}
+	c.track(ctx, sent.Model, &resp.Usage, time.Since(start))
 
 	return resp, nil
 }
 
-// makeRequest performs the actual HTTP request to Claude API
-func (c *Client) makeRequest(ctx context.Context, req *Request) (*Response, error) {
-	// Marshal request body
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// track records the usage of a request, or a cache hit when usage is nil
+func (c *Client) track(ctx context.Context, model string, usage *Usage, duration time.Duration) {
+	if c.metricsTracker == nil {
+		return
 	}
 
-	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	attribution := AttributionFromContext(ctx)
+	metrics := &Metrics{
+		Module:    attribution.Module,
+		JobType:   attribution.JobType,
+		Prompt:    attribution.Prompt,
+		Model:     model,
+		Duration:  duration,
+		CacheHit:  usage == nil,
+		Timestamp: time.Now(),
 	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-
-	// Send request
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	if usage != nil {
+		metrics.InputTokens = usage.InputTokens
+		metrics.OutputTokens = usage.OutputTokens
+		metrics.CachedTokens = usage.CacheReadInputTokens
+		metrics.TotalTokens = usage.InputTokens + usage.OutputTokens
+		metrics.Cost = c.costCalculator.CalculateCost(model, usage)
 	}
-	defer httpResp.Body.Close()
-
-	// Read response body
-	respBody, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	if attribution.ProgramID != uuid.Nil {
+		metrics.ProgramID = attribution.ProgramID.String()
 	}
-
-	// Check for errors
-	if httpResp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil {
-			return nil, fmt.Errorf("API error (%d): %s", httpResp.StatusCode, errResp.Error.Message)
-		}
-		return nil, fmt.Errorf("API error (%d): %s", httpResp.StatusCode, string(respBody))
+	if attribution.ArtifactID != uuid.Nil {
+		metrics.ArtifactID = attribution.ArtifactID.String()
 	}
-
-	// Parse response
-	var apiResp Response
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.metricsTracker.Track(ctx, metrics); err != nil {
+		log.Printf("Warning: Failed to track AI usage: %v", err)
 	}
-
-	return &apiResp, nil
 }
 
-// generateCacheKey generates a cache key for a request
-func (c *Client) generateCacheKey(req *Request) string {
-	// Hash the request to generate a unique key
-	h := sha256.New()
-	json.NewEncoder(h).Encode(req)
-	return fmt.Sprintf("ai:response:%x", h.Sum(nil))
-}
+// attempt sends a request once, within the rate limits
+func (c *Client) attempt(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	if c.rateLimiter != nil {
+		release, err := c.rateLimiter.Acquire(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 
-// getFromCache retrieves a cached response
-func (c *Client) getFromCache(ctx context.Context, key string) (*Response, error) {
-	data, err := c.cache.Get(ctx, key).Result()
-	if err != nil {
-		return nil, err
-	}
+		var usage *Usage
+		defer func() { release(usage) }()
 
-	var resp Response
-	if err := json.Unmarshal([]byte(data), &resp); err != nil {
-		return nil, err
+		resp, err := c.call(ctx, req, handler)
+		c.rateLimiter.Observe(ctx, rateLimitInfo(resp, err))
+		if resp != nil {
+			usage = &resp.Usage
+		}
+		return resp, err
 	}
 
-	return &resp, nil
+	return c.call(ctx, req, handler)
 }
 
-// cacheResponse stores a response in cache
-func (c *Client) cacheResponse(ctx context.Context, key string, resp *Response) error {
-	data, err := json.Marshal(resp)
-	if err != nil {
-		return err
+// call sends a request to the provider once
+func (c *Client) call(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	if req.Stream {
+		return streamFrom(ctx, c.provider, req, handler)
 	}
+	return c.provider.Request(ctx, req)
+}
+
+// retryDelay returns the wait before a retry: exponential backoff (1s, 2s, 4s), or longer if
+// the API asked for it, up to maxRetryAfter
+func retryDelay(attempt int, err error) time.Duration {
+	backoff := time.Duration(1<<uint(attempt-1)) * time.Second
 
-	// Cache for 1 hour
-	return c.cache.Set(ctx, key, data, time.Hour).Err()
+	if info := rateLimitInfo(nil, err); info != nil && info.RetryAfter > backoff {
+		if info.RetryAfter > maxRetryAfter {
+			return maxRetryAfter
+		}
+		return info.RetryAfter
+	}
+	return backoff
 }
 
+// maxRetryAfter bounds how long a retry waits on the API's retry-after header
+const maxRetryAfter = time.Minute
+
 // isRetryableError determines if an error should trigger a retry
 func (c *Client) isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
 	errMsg := err.Error()
 
 	// Retry on rate limits, timeouts, and 5xx errors
@@ -216,6 +286,7 @@ func (c *Client) isRetryableError(err error) bool {
 		"504",
 		"connection refused",
 		"connection reset",
+		"stream interrupted",
 	}
 
 	for _, pattern := range retryablePatterns {
@@ -257,9 +328,20 @@ func toLower(s string) string {
 	return string(b)
 }
 
-// RequestWithContext creates a request with program context for prompt caching
+// RequestWithContext sends a request with program context for prompt caching
 func (c *Client) RequestWithContext(ctx context.Context, model string, systemPrompt string, staticContext string, dynamicContent string, maxTokens int) (*Response, error) {
-	req := &Request{
+	return c.Request(ctx, NewContextRequest(model, systemPrompt, staticContext, dynamicContent, maxTokens))
+}
+
+// SimpleRequest sends a single-prompt request without prompt caching
+func (c *Client) SimpleRequest(ctx context.Context, model string, systemPrompt string, userPrompt string, maxTokens int) (*Response, error) {
+	return c.Request(ctx, NewSimpleRequest(model, systemPrompt, userPrompt, maxTokens))
+}
+
+// NewContextRequest builds a request whose static context (program context, shared across
+// requests) is marked for prompt caching, followed by the request-specific content
+func NewContextRequest(model string, systemPrompt string, staticContext string, dynamicContent string, maxTokens int) *Request {
+	return &Request{
 		Model:       model,
 		MaxTokens:   maxTokens,
 		System:      systemPrompt,
@@ -283,13 +365,11 @@ func (c *Client) RequestWithContext(ctx context.Context, model string, systemPro
 			},
 		},
 	}
-
-	return c.Request(ctx, req)
 }
 
-// SimpleRequest creates a simple request without caching
-func (c *Client) SimpleRequest(ctx context.Context, model string, systemPrompt string, userPrompt string, maxTokens int) (*Response, error) {
-	req := &Request{
+// NewSimpleRequest builds a request with a single user prompt
+func NewSimpleRequest(model string, systemPrompt string, userPrompt string, maxTokens int) *Request {
+	return &Request{
 		Model:       model,
 		MaxTokens:   maxTokens,
 		System:      systemPrompt,
@@ -306,6 +386,4 @@ func (c *Client) SimpleRequest(ctx context.Context, model string, systemPrompt s
 			},
 		},
 	}
-
-	return c.Request(ctx, req)
 }
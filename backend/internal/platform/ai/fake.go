@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FakeRule scripts the fake provider's answer to requests whose prompt contains Match
+type FakeRule struct {
+	Match    string `json:"match"`    // Substring of the system prompt or message text; empty matches every request
+	Response string `json:"response"` // Text of the reply
+	Usage    Usage  `json:"usage"`
+	Error    string `json:"error,omitempty"` // Returned as the request error instead of a reply
+
+	// ToolInput, if set, answers requests that force a tool with a call to that tool
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+}
+
+// FakeProvider is a deterministic, offline Provider for tests and local runs. Each request is
+// answered by the first rule that matches it; unmatched requests fail so missing fixtures
+// surface instead of silently returning empty analysis.
+type FakeProvider struct {
+	mu       sync.Mutex
+	rules    []FakeRule
+	requests []*Request
+}
+
+// NewFakeProvider creates a fake provider with the given rules
+func NewFakeProvider(rules ...FakeRule) *FakeProvider {
+	return &FakeProvider{rules: rules}
+}
+
+// LoadFakeProvider creates a fake provider from the *.json files in fsys, read in name order.
+// Each file holds a single FakeRule or an array of them.
+func LoadFakeProvider(fsys fs.FS) (*FakeProvider, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	sort.Strings(names)
+
+	provider := NewFakeProvider()
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", name, err)
+		}
+
+		var rules []FakeRule
+		if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+			err = json.Unmarshal(data, &rules)
+		} else {
+			var rule FakeRule
+			err = json.Unmarshal(data, &rule)
+			rules = []FakeRule{rule}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path.Base(name), err)
+		}
+
+		provider.Add(rules...)
+	}
+
+	return provider, nil
+}
+
+// Add appends rules; earlier rules take precedence
+func (f *FakeProvider) Add(rules ...FakeRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rules...)
+}
+
+// Requests returns the requests received so far
+func (f *FakeProvider) Requests() []*Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Request(nil), f.requests...)
+}
+
+// Request answers from the first matching rule
+func (f *FakeProvider) Request(ctx context.Context, req *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+
+	prompt := requestText(req)
+	for _, rule := range f.rules {
+		if !strings.Contains(prompt, rule.Match) {
+			continue
+		}
+		if rule.Error != "" {
+			return nil, fmt.Errorf("%s", rule.Error)
+		}
+
+		content := []Content{{Type: "text", Text: rule.Response}}
+		if len(rule.ToolInput) > 0 && req.ToolChoice != nil && req.ToolChoice.Name != "" {
+			content = []Content{{
+				Type:  "tool_use",
+				ID:    fmt.Sprintf("fake-tool-%d", len(f.requests)),
+				Name:  req.ToolChoice.Name,
+				Input: rule.ToolInput,
+			}}
+		}
+
+		return &Response{
+			ID:         fmt.Sprintf("fake-%d", len(f.requests)),
+			Type:       "message",
+			Role:       "assistant",
+			Content:    content,
+			Model:      req.Model,
+			StopReason: "end_turn",
+			Usage:      rule.Usage,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("fake provider: no rule matches request")
+}
+
+// requestText concatenates the system prompt and message text a rule is matched against
+func requestText(req *Request) string {
+	var b strings.Builder
+	b.WriteString(req.System)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			b.WriteString("\n")
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
+
+// Stream answers like Request, delivering the reply's text a word at a time
+func (f *FakeProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	resp, err := f.Request(ctx, req)
+	if err != nil || handler == nil {
+		return resp, err
+	}
+
+	for _, block := range resp.Content {
+		if block.Type != "text" {
+			continue
+		}
+		for _, word := range strings.SplitAfter(block.Text, " ") {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if word == "" {
+				continue
+			}
+			if err := handler(word); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFakeProvider(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"01-invoice.json": {Data: []byte(`{"match": "INV-42", "response": "{\"invoice_number\": \"INV-42\"}", "usage": {"input_tokens": 10, "output_tokens": 5}}`)},
+		"02-rest.json":    {Data: []byte(`[{"match": "outage", "error": "API error (529): overloaded"}, {"response": "default"}]`)},
+		"README.md":       {Data: []byte("not a fixture")},
+	}
+
+	provider, err := LoadFakeProvider(fixtures)
+	if err != nil {
+		t.Fatalf("LoadFakeProvider() error = %v", err)
+	}
+
+	resp, err := provider.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "", "Extract INV-42", 100))
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if resp.GetExtractedText() != `{"invoice_number": "INV-42"}` || resp.Usage.OutputTokens != 5 || resp.Model != ModelSonnet4 {
+		t.Errorf("Request() = %+v, want the invoice fixture", resp)
+	}
+
+	if _, err := provider.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "", "during an outage", 100)); err == nil {
+		t.Errorf("Request() should return the scripted error")
+	}
+
+	resp, err = provider.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "", "anything else", 100))
+	if err != nil || resp.GetExtractedText() != "default" {
+		t.Errorf("Request() = %v, %v, want the catch-all rule", resp, err)
+	}
+
+	if n := len(provider.Requests()); n != 3 {
+		t.Errorf("recorded %d requests, want 3", n)
+	}
+}
+
+func TestFakeProvider_UnmatchedRequestFails(t *testing.T) {
+	provider := NewFakeProvider(FakeRule{Match: "invoice", Response: "{}"})
+	if _, err := provider.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "", "meeting notes", 100)); err == nil {
+		t.Errorf("Request() should fail when no rule matches")
+	}
+}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/cerberus/backend/internal/platform/db"
@@ -22,22 +23,15 @@ func NewDBMetricsTracker(database *db.DB) *DBMetricsTracker {
 func (t *DBMetricsTracker) Track(ctx context.Context, metrics *Metrics) error {
 	query := `
 		INSERT INTO ai_usage (
-			program_id, module, job_type, model,
+			program_id, artifact_id, module, job_type, model,
 			tokens_input, tokens_output, tokens_cached, tokens_total,
-			cost_usd, duration_ms, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			cost_usd, duration_ms, created_at, prompt_id, prompt_version, cache_hit
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	programID := uuid.Nil
-	if metrics.ProgramID != "" {
-		parsed, err := uuid.Parse(metrics.ProgramID)
-		if err == nil {
-			programID = parsed
-		}
-	}
-
 	_, err := t.db.ExecContext(ctx, query,
-		programID,
+		parseNullUUID(metrics.ProgramID),
+		parseNullUUID(metrics.ArtifactID),
 		metrics.Module,
 		metrics.JobType,
 		metrics.Model,
@@ -48,6 +42,9 @@ func (t *DBMetricsTracker) Track(ctx context.Context, metrics *Metrics) error {
 		metrics.Cost,
 		metrics.Duration.Milliseconds(),
 		metrics.Timestamp,
+		nullString(metrics.Prompt.ID),
+		nullString(metrics.Prompt.Version),
+		metrics.CacheHit,
 	)
 
 	if err != nil {
@@ -57,14 +54,28 @@ func (t *DBMetricsTracker) Track(ctx context.Context, metrics *Metrics) error {
 	return nil
 }
 
-// GetDailyCost retrieves total AI cost for a program today
+// parseNullUUID parses an optional ID; usage without a program or artifact is stored with NULL
+func parseNullUUID(id string) uuid.NullUUID {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: parsed, Valid: true}
+}
+
+// nullString stores empty values as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetDailyCost retrieves total AI cost for a program today (UTC)
 func (t *DBMetricsTracker) GetDailyCost(ctx context.Context, programID uuid.UUID) (float64, error) {
 	var cost float64
 	query := `
 		SELECT COALESCE(SUM(cost_usd), 0)
 		FROM ai_usage
 		WHERE program_id = $1
-		  AND created_at >= CURRENT_DATE
+		  AND created_at >= date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
 	`
 
 	err := t.db.QueryRowContext(ctx, query, programID).Scan(&cost)
@@ -75,14 +86,14 @@ func (t *DBMetricsTracker) GetDailyCost(ctx context.Context, programID uuid.UUID
 	return cost, nil
 }
 
-// GetMonthlyCost retrieves total AI cost for a program this month
+// GetMonthlyCost retrieves total AI cost for a program this month (UTC)
 func (t *DBMetricsTracker) GetMonthlyCost(ctx context.Context, programID uuid.UUID) (float64, error) {
 	var cost float64
 	query := `
 		SELECT COALESCE(SUM(cost_usd), 0)
 		FROM ai_usage
 		WHERE program_id = $1
-		  AND created_at >= date_trunc('month', CURRENT_DATE)
+		  AND created_at >= date_trunc('month', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
 	`
 
 	err := t.db.QueryRowContext(ctx, query, programID).Scan(&cost)
@@ -0,0 +1,225 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ModelSpec is a model's price and the model its requests move to while it is overloaded
+type ModelSpec struct {
+	InputPerMTok       float64 `json:"input_per_mtok"` // USD per million tokens
+	OutputPerMTok      float64 `json:"output_per_mtok"`
+	CachedInputPerMTok float64 `json:"cached_input_per_mtok,omitempty"` // Defaults to 10% of the input price
+	Fallback           string  `json:"fallback,omitempty"`
+}
+
+// ModelRoute sends the requests it matches to Model. Conditions left empty match every request.
+type ModelRoute struct {
+	JobType        string    `json:"job_type,omitempty"` // Attribution job type, e.g. "invoice_extraction"
+	ProgramID      uuid.UUID `json:"program_id,omitempty"`
+	Tier           string    `json:"tier,omitempty"`             // The program's ai_tier setting
+	MinInputTokens int       `json:"min_input_tokens,omitempty"` // Estimated from the request's text
+	MaxInputTokens int       `json:"max_input_tokens,omitempty"` // Zero for no maximum
+	Model          string    `json:"model"`
+}
+
+// ModelPolicyConfig lists the models requests may use and the routes between them. Routes are
+// tried in order; requests no route matches keep the model their caller chose.
+type ModelPolicyConfig struct {
+	Models map[string]ModelSpec `json:"models"`
+	Routes []ModelRoute         `json:"routes,omitempty"`
+}
+
+// DefaultModelPolicyConfig prices the Claude models at list price, with Opus falling back to
+// Sonnet, and routes nothing
+func DefaultModelPolicyConfig() ModelPolicyConfig {
+	return ModelPolicyConfig{
+		Models: map[string]ModelSpec{
+			ModelOpus4:   {InputPerMTok: 15, OutputPerMTok: 75, Fallback: ModelSonnet4},
+			ModelSonnet4: {InputPerMTok: 3, OutputPerMTok: 15},
+			ModelHaiku4:  {InputPerMTok: 1, OutputPerMTok: 5},
+		},
+	}
+}
+
+// ModelPolicy chooses the model for each request, prices usage and names fallback models
+type ModelPolicy struct {
+	models  map[string]ModelSpec
+	routes  []ModelRoute
+	configs ProgramConfigSource
+
+	// unknown prices models missing from the catalog: the most expensive known model, so
+	// budgets are not undercounted
+	unknown ModelSpec
+	warned  sync.Map
+}
+
+// NewModelPolicy creates a model policy, checking that routes and fallbacks name known models
+func NewModelPolicy(config ModelPolicyConfig) (*ModelPolicy, error) {
+	if len(config.Models) == 0 {
+		return nil, fmt.Errorf("model policy lists no models")
+	}
+
+	policy := &ModelPolicy{models: config.Models, routes: config.Routes}
+	for name, spec := range config.Models {
+		if spec.InputPerMTok < 0 || spec.OutputPerMTok < 0 || spec.CachedInputPerMTok < 0 {
+			return nil, fmt.Errorf("model %s has a negative price", name)
+		}
+		if _, ok := config.Models[spec.Fallback]; spec.Fallback != "" && !ok {
+			return nil, fmt.Errorf("model %s falls back to unknown model %s", name, spec.Fallback)
+		}
+		if spec.InputPerMTok+spec.OutputPerMTok > policy.unknown.InputPerMTok+policy.unknown.OutputPerMTok {
+			policy.unknown = ModelSpec{InputPerMTok: spec.InputPerMTok, OutputPerMTok: spec.OutputPerMTok}
+		}
+	}
+	for i, route := range config.Routes {
+		if _, ok := config.Models[route.Model]; !ok {
+			return nil, fmt.Errorf("route %d uses unknown model %s", i+1, route.Model)
+		}
+	}
+
+	return policy, nil
+}
+
+// LoadModelPolicy reads a model policy from a JSON file, or returns the default policy when
+// path is empty
+func LoadModelPolicy(path string) (*ModelPolicy, error) {
+	if path == "" {
+		return NewModelPolicy(DefaultModelPolicyConfig())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model policy: %w", err)
+	}
+
+	var config ModelPolicyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse model policy: %w", err)
+	}
+
+	return NewModelPolicy(config)
+}
+
+// defaultModelPolicy serves callers that are not given a policy
+var defaultModelPolicy, _ = NewModelPolicy(DefaultModelPolicyConfig())
+
+// SetProgramConfigs lets routes match on each program's AI tier
+func (p *ModelPolicy) SetProgramConfigs(configs ProgramConfigSource) {
+	p.configs = configs
+}
+
+// Route returns the model for a request: that of the first route matching the request's
+// attribution (see WithAttribution) and size, or the model the request names
+func (p *ModelPolicy) Route(ctx context.Context, req *Request) string {
+	if len(p.routes) == 0 {
+		return req.Model
+	}
+
+	attribution := AttributionFromContext(ctx)
+	inputTokens := estimateInputTokens(req)
+	tier, tierLoaded := "", false
+
+	for _, route := range p.routes {
+		if route.JobType != "" && route.JobType != attribution.JobType {
+			continue
+		}
+		if route.ProgramID != uuid.Nil && route.ProgramID != attribution.ProgramID {
+			continue
+		}
+		if inputTokens < route.MinInputTokens || (route.MaxInputTokens > 0 && inputTokens > route.MaxInputTokens) {
+			continue
+		}
+		if route.Tier != "" {
+			if !tierLoaded {
+				tier = p.programTier(ctx, attribution.ProgramID)
+				tierLoaded = true
+			}
+			if route.Tier != tier {
+				continue
+			}
+		}
+		return route.Model
+	}
+
+	return req.Model
+}
+
+// programTier returns a program's AI tier, or "" if it cannot be loaded
+func (p *ModelPolicy) programTier(ctx context.Context, programID uuid.UUID) string {
+	if p.configs == nil || programID == uuid.Nil {
+		return ""
+	}
+
+	config, err := p.configs.GetProgramConfig(ctx, programID)
+	if err != nil {
+		log.Printf("Warning: Failed to load AI tier of program %s, skipping tier routes: %v", programID, err)
+		return ""
+	}
+	return config.AITier
+}
+
+// Fallback returns the model to use while model is overloaded, or "" if it has none
+func (p *ModelPolicy) Fallback(model string) string {
+	return p.models[model].Fallback
+}
+
+// Cost returns the price in USD of a request's usage. Models missing from the catalog are
+// priced as the most expensive listed model.
+func (p *ModelPolicy) Cost(model string, usage *Usage) float64 {
+	spec, ok := p.models[model]
+	if !ok {
+		if _, warned := p.warned.LoadOrStore(model, true); !warned {
+			log.Printf("Warning: No price for AI model %q, charging the most expensive model's rates", model)
+		}
+		spec = p.unknown
+	}
+
+	cachedRate := spec.CachedInputPerMTok
+	if cachedRate == 0 {
+		cachedRate = spec.InputPerMTok * 0.1
+	}
+
+	cachedCost := float64(usage.CacheReadInputTokens) * cachedRate
+	inputCost := float64(usage.InputTokens-usage.CacheReadInputTokens) * spec.InputPerMTok
+	outputCost := float64(usage.OutputTokens) * spec.OutputPerMTok
+
+	return (cachedCost + inputCost + outputCost) / 1_000_000
+}
+
+// CostCalculator calculates API costs
+type CostCalculator struct {
+	policy *ModelPolicy
+}
+
+// NewCostCalculator creates a cost calculator with the default model prices
+func NewCostCalculator() *CostCalculator {
+	return &CostCalculator{policy: defaultModelPolicy}
+}
+
+// NewPolicyCostCalculator creates a cost calculator with the prices of a model policy
+func NewPolicyCostCalculator(policy *ModelPolicy) *CostCalculator {
+	return &CostCalculator{policy: policy}
+}
+
+// CalculateCost calculates the cost of an API request
+func (c *CostCalculator) CalculateCost(model string, usage *Usage) float64 {
+	return c.policy.Cost(model, usage)
+}
+
+// isOverloaded reports whether err means the model is overloaded, so another model may serve
+// the request
+func isOverloaded(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Overloaded()
+	}
+	return err != nil && contains(err.Error(), "overloaded")
+}
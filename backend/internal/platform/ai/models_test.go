@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/google/uuid"
+)
+
+func TestModelPolicy_Route(t *testing.T) {
+	premium := uuid.New()
+	config := DefaultModelPolicyConfig()
+	config.Routes = []ModelRoute{
+		{JobType: "invoice_extraction", Tier: "premium", Model: ModelOpus4},
+		{JobType: "artifact_analysis", MaxInputTokens: 1000, Model: ModelHaiku4},
+	}
+	policy, err := NewModelPolicy(config)
+	if err != nil {
+		t.Fatalf("NewModelPolicy() error = %v", err)
+	}
+	policy.SetProgramConfigs(&fakeConfigSource{config: &programs.ProgramConfig{AITier: "premium"}})
+
+	small := NewSimpleRequest(ModelSonnet4, "", "short memo", 100)
+	large := NewSimpleRequest(ModelSonnet4, "", strings.Repeat("long report ", 1000), 100)
+
+	tests := []struct {
+		name        string
+		attribution Attribution
+		req         *Request
+		want        string
+	}{
+		{"tier", Attribution{ProgramID: premium, JobType: "invoice_extraction"}, small, ModelOpus4},
+		{"tier unknown without program", Attribution{JobType: "invoice_extraction"}, small, ModelSonnet4},
+		{"small document", Attribution{JobType: "artifact_analysis"}, small, ModelHaiku4},
+		{"large document", Attribution{JobType: "artifact_analysis"}, large, ModelSonnet4},
+		{"unrouted job", Attribution{JobType: "ocr"}, small, ModelSonnet4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithAttribution(context.Background(), tt.attribution)
+			if got := policy.Route(ctx, tt.req); got != tt.want {
+				t.Errorf("Route() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewModelPolicy_RejectsUnknownModels(t *testing.T) {
+	config := DefaultModelPolicyConfig()
+	config.Routes = []ModelRoute{{JobType: "ocr", Model: "gpt-unknown"}}
+	if _, err := NewModelPolicy(config); err == nil {
+		t.Errorf("NewModelPolicy() should reject a route to an unlisted model")
+	}
+}
+
+func TestModelPolicy_Cost(t *testing.T) {
+	policy, _ := NewModelPolicy(DefaultModelPolicyConfig())
+	usage := &Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadInputTokens: 500_000}
+
+	// 500k uncached input at $3, 500k cached at $0.30 and 100k output at $15
+	if got := policy.Cost(ModelSonnet4, usage); math.Abs(got-3.15) > 1e-9 {
+		t.Errorf("Cost(sonnet) = %v, want 3.15", got)
+	}
+	// Unlisted models are charged Opus rates rather than undercounted
+	if got, want := policy.Cost("some-new-model", usage), policy.Cost(ModelOpus4, usage); got != want {
+		t.Errorf("Cost(unknown) = %v, want the most expensive model's %v", got, want)
+	}
+}
+
+// overloadedProvider fails every request for one model as overloaded
+type overloadedProvider struct {
+	model  string
+	models []string
+}
+
+func (p *overloadedProvider) Request(ctx context.Context, req *Request) (*Response, error) {
+	p.models = append(p.models, req.Model)
+	if req.Model == p.model {
+		return nil, &APIError{StatusCode: 529, Message: "Overloaded"}
+	}
+	return &Response{Model: req.Model, Content: []Content{{Type: "text", Text: "ok"}}, Usage: Usage{InputTokens: 10}}, nil
+}
+
+func TestClient_FallsBackWhenModelOverloaded(t *testing.T) {
+	provider := &overloadedProvider{model: ModelOpus4}
+	tracker := &recordingTracker{}
+	client := NewClient(&ClientConfig{Provider: provider, MetricsTracker: tracker})
+
+	if _, err := client.Request(context.Background(), NewSimpleRequest(ModelOpus4, "", "analyze", 100)); err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	if len(provider.models) != 2 || provider.models[1] != ModelSonnet4 {
+		t.Errorf("requested models %v, want Opus then its Sonnet fallback", provider.models)
+	}
+	if tracker.metrics[0].Model != ModelSonnet4 {
+		t.Errorf("tracked model = %s, want the fallback that served the request", tracker.metrics[0].Model)
+	}
+}
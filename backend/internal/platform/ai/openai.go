@@ -0,0 +1,459 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider calls an OpenAI-compatible chat completions API, such as a self-hosted
+// vLLM, Ollama or LiteLLM server
+type OpenAIProvider struct {
+	baseURL      string
+	apiKey       string
+	model        string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// NewOpenAIProvider creates an OpenAI-compatible provider. baseURL is the API root (e.g.
+// http://localhost:8000/v1); apiKey may be empty for servers without auth. A non-empty model
+// replaces the model each request names, since Claude model IDs mean nothing to these servers.
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 300 * time.Second,
+		},
+		streamClient: newStreamingHTTPClient(),
+	}
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Temperature float64         `json:"temperature"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	ToolChoice  interface{}     `json:"tool_choice,omitempty"` // "auto", "required" or a named function
+
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"` // string, or []openAIPart when the message has images
+}
+
+type openAIPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"` // "function"
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// usage converts to the Anthropic usage fields
+func (u openAIUsage) usage() Usage {
+	return Usage{
+		InputTokens:          u.PromptTokens,
+		OutputTokens:         u.CompletionTokens,
+		CacheReadInputTokens: u.PromptTokensDetails.CachedTokens,
+	}
+}
+
+// openAIChunk is one event of a streamed chat completion
+type openAIChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Request translates the request to a chat completion and the completion back to a Response.
+// Requests with Stream set are streamed and returned once complete.
+func (p *OpenAIProvider) Request(ctx context.Context, req *Request) (*Response, error) {
+	if req.Stream {
+		return p.Stream(ctx, req, nil)
+	}
+
+	body, err := json.Marshal(p.toOpenAI(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, openAIError(httpResp, respBody)
+	}
+
+	var apiResp openAIResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return nil, fmt.Errorf("API returned no choices")
+	}
+
+	choice := apiResp.Choices[0]
+	content := []Content{{Type: "text", Text: choice.Message.Content}}
+	for _, call := range choice.Message.ToolCalls {
+		content = append(content, Content{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
+		})
+	}
+
+	return &Response{
+		ID:         apiResp.ID,
+		Type:       "message",
+		Role:       "assistant",
+		Content:    content,
+		Model:      apiResp.Model,
+		StopReason: stopReasonFromOpenAI(choice.FinishReason),
+		Usage:      apiResp.Usage.usage(),
+		RateLimit:  parseRateLimitHeaders(httpResp.Header, time.Now()),
+	}, nil
+}
+
+// Stream sends a streaming chat completion, passing text deltas to handler as they arrive
+func (p *OpenAIProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	completion := p.toOpenAI(req)
+	completion.Stream = true
+	completion.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+
+	body, err := json.Marshal(completion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.streamClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, openAIError(httpResp, respBody)
+	}
+
+	stream := &openAIStream{handler: handler}
+	if err := readStream(ctx, httpResp.Body, stream.apply); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.response()
+	if err != nil {
+		return nil, err
+	}
+	resp.RateLimit = parseRateLimitHeaders(httpResp.Header, time.Now())
+	return resp, nil
+}
+
+// openAIError converts an error response to an *APIError, as the Anthropic provider does
+func openAIError(httpResp *http.Response, body []byte) error {
+	message := string(body)
+	var errResp openAIResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		message = errResp.Error.Message
+	}
+
+	return &APIError{
+		StatusCode: httpResp.StatusCode,
+		Message:    message,
+		RateLimit:  parseRateLimitHeaders(httpResp.Header, time.Now()),
+	}
+}
+
+// openAIStream assembles a Response from the chunks of a streamed chat completion
+type openAIStream struct {
+	handler      StreamHandler
+	id           string
+	model        string
+	text         strings.Builder
+	calls        []*openAIToolCall
+	finishReason string
+	usage        Usage
+	done         bool
+}
+
+func (s *openAIStream) apply(_ string, data []byte) error {
+	if string(data) == "[DONE]" {
+		s.done = true
+		return io.EOF
+	}
+
+	var chunk openAIChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return fmt.Errorf("failed to parse stream chunk: %w", err)
+	}
+	if chunk.Error != nil {
+		return fmt.Errorf("API stream error: %s", chunk.Error.Message)
+	}
+
+	if chunk.ID != "" {
+		s.id = chunk.ID
+	}
+	if chunk.Model != "" {
+		s.model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		s.usage = chunk.Usage.usage()
+	}
+
+	for _, choice := range chunk.Choices {
+		if choice.FinishReason != "" {
+			s.finishReason = choice.FinishReason
+		}
+
+		for _, delta := range choice.Delta.ToolCalls {
+			for len(s.calls) <= delta.Index {
+				s.calls = append(s.calls, &openAIToolCall{Type: "function"})
+			}
+			call := s.calls[delta.Index]
+			if delta.ID != "" {
+				call.ID = delta.ID
+			}
+			call.Function.Name += delta.Function.Name
+			call.Function.Arguments += delta.Function.Arguments
+		}
+
+		if text := choice.Delta.Content; text != "" {
+			s.text.WriteString(text)
+			if s.handler != nil {
+				if err := s.handler(text); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// response returns the assembled response, failing if the stream ended early. Servers that
+// omit the closing [DONE] are accepted once a finish reason has arrived.
+func (s *openAIStream) response() (*Response, error) {
+	if !s.done && s.finishReason == "" {
+		return nil, errStreamInterrupted
+	}
+
+	content := []Content{{Type: "text", Text: s.text.String()}}
+	for _, call := range s.calls {
+		content = append(content, Content{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: json.RawMessage(call.Function.Arguments),
+		})
+	}
+
+	return &Response{
+		ID:         s.id,
+		Type:       "message",
+		Role:       "assistant",
+		Content:    content,
+		Model:      s.model,
+		StopReason: stopReasonFromOpenAI(s.finishReason),
+		Usage:      s.usage,
+	}, nil
+}
+
+func (p *OpenAIProvider) toOpenAI(req *Request) openAIRequest {
+	model := req.Model
+	if p.model != "" {
+		model = p.model
+	}
+
+	out := openAIRequest{
+		Model:       model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+
+	if req.System != "" {
+		out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: req.System})
+	}
+
+	for _, msg := range req.Messages {
+		out.Messages = append(out.Messages, openAIMessage{Role: msg.Role, Content: openAIContent(msg.Content)})
+	}
+
+	for _, tool := range req.Tools {
+		out.Tools = append(out.Tools, openAITool{
+			Type:     "function",
+			Function: openAIFunction{Name: tool.Name, Description: tool.Description, Parameters: tool.InputSchema},
+		})
+	}
+	if req.ToolChoice != nil {
+		switch req.ToolChoice.Type {
+		case "tool":
+			out.ToolChoice = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": req.ToolChoice.Name},
+			}
+		case "any":
+			out.ToolChoice = "required"
+		default:
+			out.ToolChoice = req.ToolChoice.Type
+		}
+	}
+
+	return out
+}
+
+// openAIContent joins text blocks into a plain string, or returns content parts when the
+// message carries images
+func openAIContent(blocks []ContentBlock) interface{} {
+	hasImage := false
+	for _, block := range blocks {
+		if block.Type == "image" && block.Source != nil {
+			hasImage = true
+			break
+		}
+	}
+
+	if !hasImage {
+		texts := make([]string, 0, len(blocks))
+		for _, block := range blocks {
+			if block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	}
+
+	parts := make([]openAIPart, 0, len(blocks))
+	for _, block := range blocks {
+		switch {
+		case block.Type == "image" && block.Source != nil:
+			url := fmt.Sprintf("data:%s;base64,%s", block.Source.MediaType, block.Source.Data)
+			parts = append(parts, openAIPart{Type: "image_url", ImageURL: &openAIImageURL{URL: url}})
+		case block.Text != "":
+			parts = append(parts, openAIPart{Type: "text", Text: block.Text})
+		}
+	}
+	return parts
+}
+
+func stopReasonFromOpenAI(reason string) string {
+	switch reason {
+	case "stop":
+		return "end_turn"
+	case "length":
+		return "max_tokens"
+	default:
+		return reason
+	}
+}
@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIProvider_Request(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+
+		w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"model": "llama-3.1-70b",
+			"choices": [{"message": {"role": "assistant", "content": "{\"ok\": true}"}, "finish_reason": "length"}],
+			"usage": {"prompt_tokens": 120, "completion_tokens": 30, "prompt_tokens_details": {"cached_tokens": 100}}
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "secret", "llama-3.1-70b")
+	resp, err := provider.Request(context.Background(), NewContextRequest(ModelSonnet4, "Be terse.", "Program context", "Analyze this", 512))
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", auth)
+	}
+	if got["model"] != "llama-3.1-70b" {
+		t.Errorf("model = %v, want the configured model", got["model"])
+	}
+	messages := got["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages, want system and user", len(messages))
+	}
+	if system := messages[0].(map[string]interface{}); system["role"] != "system" || system["content"] != "Be terse." {
+		t.Errorf("system message = %v", system)
+	}
+	if user := messages[1].(map[string]interface{}); user["content"] != "Program context\n\nAnalyze this" {
+		t.Errorf("user content = %q", user["content"])
+	}
+
+	if resp.GetExtractedText() != `{"ok": true}` {
+		t.Errorf("text = %q", resp.GetExtractedText())
+	}
+	if resp.StopReason != "max_tokens" {
+		t.Errorf("StopReason = %q, want max_tokens", resp.StopReason)
+	}
+	if resp.Usage.InputTokens != 120 || resp.Usage.OutputTokens != 30 || resp.Usage.CacheReadInputTokens != 100 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProvider_ImageContent(t *testing.T) {
+	content := openAIContent([]ContentBlock{
+		{Type: "image", Source: &ImageSource{Type: "base64", MediaType: "image/png", Data: "aGVsbG8="}},
+		{Type: "text", Text: "Extract the text"},
+	})
+
+	parts, ok := content.([]openAIPart)
+	if !ok || len(parts) != 2 {
+		t.Fatalf("content = %#v, want two parts", content)
+	}
+	if parts[0].ImageURL == nil || parts[0].ImageURL.URL != "data:image/png;base64,aGVsbG8=" {
+		t.Errorf("image part = %+v", parts[0])
+	}
+	if parts[1].Type != "text" || parts[1].Text != "Extract the text" {
+		t.Errorf("text part = %+v", parts[1])
+	}
+}
+
+func TestOpenAIProvider_ErrorIsRetryable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": {"message": "model is loading"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider(server.URL, "", "").Request(context.Background(), NewSimpleRequest("m", "", "hi", 10))
+	if err == nil || !strings.Contains(err.Error(), "API error (503): model is loading") {
+		t.Fatalf("Request() error = %v, want the API error", err)
+	}
+	if !(&Client{}).isRetryableError(err) {
+		t.Errorf("unavailable error should be retryable")
+	}
+}
+
+func TestOpenAIProvider_ToolCalls(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{
+			"id": "chatcmpl-2",
+			"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "record", "arguments": "{\"ok\": true}"}}
+			]}, "finish_reason": "tool_calls"}]
+		}`))
+	}))
+	defer server.Close()
+
+	req := NewSimpleRequest(ModelSonnet4, "system", "extract", 100)
+	req.Tools = []Tool{{Name: "record", InputSchema: &Schema{Type: "object"}}}
+	req.ToolChoice = &ToolChoice{Type: "tool", Name: "record"}
+
+	resp, err := NewOpenAIProvider(server.URL, "", "").Request(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	choice, _ := json.Marshal(got["tool_choice"])
+	if string(choice) != `{"function":{"name":"record"},"type":"function"}` {
+		t.Errorf("tool_choice = %s, want the named function", choice)
+	}
+	if tools := got["tools"].([]interface{}); len(tools) != 1 {
+		t.Errorf("sent %d tools, want 1", len(tools))
+	}
+
+	call := resp.Content[len(resp.Content)-1]
+	if call.Type != "tool_use" || call.Name != "record" || string(call.Input) != `{"ok": true}` {
+		t.Errorf("tool call = %+v", call)
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of stored prompt versions
+const (
+	PromptStatusDraft     = "draft"
+	PromptStatusActive    = "active"
+	PromptStatusCandidate = "candidate"
+	PromptStatusRetired   = "retired"
+)
+
+// PromptStore supplies stored prompt versions
+type PromptStore interface {
+	// GetServingVersions returns the active version of a prompt and its candidate, if any, in
+	// effect for a program: the program's override when it has one, the global versions otherwise
+	GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]*PromptTemplate, error)
+}
+
+// SetStore makes Resolve serve stored prompt versions
+func (lib *PromptLibrary) SetStore(store PromptStore) {
+	lib.store = store
+}
+
+// Templates returns the built-in templates, ordered by ID
+func (lib *PromptLibrary) Templates() []*PromptTemplate {
+	templates := make([]*PromptTemplate, 0, len(lib.templates))
+	for _, tmpl := range lib.templates {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
+
+// Resolve returns the version of prompt id to use for a program's request about subjectID
+// (usually the artifact being analyzed). A candidate version serves its traffic share of
+// subjects; everything else gets the active version. Without a store, or when nothing is
+// stored for id, the built-in template is used.
+func (lib *PromptLibrary) Resolve(ctx context.Context, id string, programID, subjectID uuid.UUID) (*PromptTemplate, error) {
+	if lib.store != nil {
+		versions, err := lib.store.GetServingVersions(ctx, id, programID)
+		if err != nil {
+			log.Printf("Warning: Failed to load prompt %s, using built-in version: %v", id, err)
+		} else if tmpl := pickVersion(id, versions, subjectID); tmpl != nil {
+			return tmpl, nil
+		}
+	}
+
+	return lib.Get(id)
+}
+
+func pickVersion(id string, versions []*PromptTemplate, subjectID uuid.UUID) *PromptTemplate {
+	var active, candidate *PromptTemplate
+	for _, v := range versions {
+		switch v.Status {
+		case PromptStatusActive:
+			active = v
+		case PromptStatusCandidate:
+			candidate = v
+		}
+	}
+
+	if candidate != nil && PromptBucket(id, subjectID) < candidate.TrafficPercent {
+		return candidate
+	}
+	return active
+}
+
+// PromptBucket places subjectID in one of 100 buckets for prompt id. A subject keeps its
+// bucket, so reanalyzing an artifact uses the same version while the traffic split is unchanged.
+func PromptBucket(id string, subjectID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	h.Write(subjectID[:])
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type stubPromptStore struct {
+	versions []*PromptTemplate
+	err      error
+}
+
+func (s *stubPromptStore) GetServingVersions(ctx context.Context, promptID string, programID uuid.UUID) ([]*PromptTemplate, error) {
+	return s.versions, s.err
+}
+
+func TestResolve(t *testing.T) {
+	const id = "artifact_analysis_v1"
+	active := &PromptTemplate{ID: id, Version: "2.0", Status: PromptStatusActive}
+	candidate := &PromptTemplate{ID: id, Version: "2.1", Status: PromptStatusCandidate}
+
+	builtin, err := NewPromptLibrary().Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		store   PromptStore
+		traffic int
+		want    string
+	}{
+		{name: "no store serves the built-in version", want: builtin.Version},
+		{name: "nothing stored serves the built-in version", store: &stubPromptStore{}, want: builtin.Version},
+		{name: "store error serves the built-in version", store: &stubPromptStore{err: errors.New("db down")}, want: builtin.Version},
+		{name: "active version", store: &stubPromptStore{versions: []*PromptTemplate{active}}, want: "2.0"},
+		{name: "candidate with no traffic", store: &stubPromptStore{versions: []*PromptTemplate{active, candidate}}, traffic: 0, want: "2.0"},
+		{name: "candidate with all traffic", store: &stubPromptStore{versions: []*PromptTemplate{active, candidate}}, traffic: 100, want: "2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate.TrafficPercent = tt.traffic
+			lib := NewPromptLibrary()
+			if tt.store != nil {
+				lib.SetStore(tt.store)
+			}
+
+			got, err := lib.Resolve(context.Background(), id, uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got.Version != tt.want {
+				t.Errorf("Resolve() version = %s, want %s", got.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptBucket_SplitsTraffic(t *testing.T) {
+	subject := uuid.New()
+	if PromptBucket("artifact_analysis_v1", subject) != PromptBucket("artifact_analysis_v1", subject) {
+		t.Fatal("PromptBucket() is not stable for a subject")
+	}
+
+	const subjects = 10000
+	inCandidate := 0
+	for i := 0; i < subjects; i++ {
+		if PromptBucket("artifact_analysis_v1", uuid.New()) < 20 {
+			inCandidate++
+		}
+	}
+	if share := float64(inCandidate) / subjects; share < 0.17 || share > 0.23 {
+		t.Errorf("a 20%% candidate received %.1f%% of subjects", share*100)
+	}
+}
@@ -18,11 +18,16 @@ type PromptTemplate struct {
 	Temperature     float64
 	MaxTokens       int
 	RequiredContext []string
+
+	// Status and TrafficPercent are set on stored versions (see PromptStore)
+	Status         string
+	TrafficPercent int
 }
 
 // PromptLibrary manages AI prompt templates
 type PromptLibrary struct {
 	templates map[string]*PromptTemplate
+	store     PromptStore // Optional; stored versions take precedence over built-in templates
 }
 
 // NewPromptLibrary creates a new prompt library with default templates
@@ -52,6 +57,11 @@ func (lib *PromptLibrary) Register(tmpl *PromptTemplate) {
 	lib.templates[tmpl.ID] = tmpl
 }
 
+// Ref identifies this template version
+func (pt *PromptTemplate) Ref() PromptRef {
+	return PromptRef{ID: pt.ID, Version: pt.Version}
+}
+
 // CompileUserPrompt compiles the user prompt template with variables
 func (pt *PromptTemplate) CompileUserPrompt(vars map[string]interface{}) (string, error) {
 	tmpl, err := template.New("prompt").Parse(pt.UserPromptTmpl)
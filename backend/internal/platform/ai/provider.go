@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Provider sends a message request to a language model. Analyzers depend on Provider rather
+// than a concrete API; *Client is itself a Provider that adds caching, retries, budgets and
+// usage tracking on top of the provider it wraps.
+type Provider interface {
+	Request(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Provider names accepted by NewProvider
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai" // Any OpenAI-compatible chat completions API
+	ProviderFake      = "fake"
+)
+
+// ProviderConfig selects and configures the model API
+type ProviderConfig struct {
+	Name    string // anthropic (default), openai or fake
+	BaseURL string // API base URL; defaults to the provider's public endpoint
+	APIKey  string
+	Model   string // openai only: model to use instead of the one each request names
+
+	// FixturesDir holds the fake provider's scripted responses (see LoadFakeProvider)
+	FixturesDir string
+
+	// RecordDir, when set, saves every response there for replay in tests (see RecordingProvider)
+	RecordDir string
+}
+
+// NewProvider creates the provider named in config
+func NewProvider(config ProviderConfig) (Provider, error) {
+	provider, err := newNamedProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RecordDir != "" {
+		return NewRecordingProvider(provider, config.RecordDir, RecordModeRecord), nil
+	}
+	return provider, nil
+}
+
+func newNamedProvider(config ProviderConfig) (Provider, error) {
+	switch config.Name {
+	case "", ProviderAnthropic:
+		return NewAnthropicProvider(config.APIKey, config.BaseURL), nil
+	case ProviderOpenAI:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("openai provider requires a base URL")
+		}
+		return NewOpenAIProvider(config.BaseURL, config.APIKey, config.Model), nil
+	case ProviderFake:
+		if config.FixturesDir == "" {
+			return nil, fmt.Errorf("fake provider requires a fixtures directory")
+		}
+		return LoadFakeProvider(os.DirFS(config.FixturesDir))
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", config.Name)
+	}
+}
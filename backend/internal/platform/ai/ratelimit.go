@@ -0,0 +1,314 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Priority orders requests waiting on a RateLimiter; lower values go first
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota // A user is waiting on the response
+	PriorityNormal                      // Artifact pipeline; the default
+	PriorityBatch                       // Reanalysis, requeues and other bulk work
+)
+
+var priorityNames = map[Priority]string{
+	PriorityInteractive: "interactive",
+	PriorityNormal:      "normal",
+	PriorityBatch:       "batch",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// priorityReserve is the share of each budget a priority leaves unused for higher priorities.
+// Queues are per process, so this keeps bulk work on one replica from starving interactive
+// requests on another.
+var priorityReserve = map[Priority]float64{
+	PriorityInteractive: 0,
+	PriorityNormal:      0.1,
+	PriorityBatch:       0.25,
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose AI requests wait on the rate limiter at priority p
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set by WithPriority, or PriorityNormal
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+const (
+	// rateLimitKeyPrefix namespaces the Redis keys of shared rate limits
+	rateLimitKeyPrefix = "ai:ratelimit:"
+
+	// statsInterval is how often a limiter publishes its queue to Redis
+	statsInterval = 5 * time.Second
+)
+
+// RateLimitConfig sets the budgets a RateLimiter enforces. Zero disables a limit.
+type RateLimitConfig struct {
+	Name              string // Limiters with the same name share their budgets across replicas
+	RequestsPerMinute int
+	TokensPerMinute   int    // Estimated input plus maximum output tokens, settled on completion
+	MaxConcurrent     int    // Requests in flight at once in this process
+	Replica           string // Names this process in queue reports; defaults to hostname:pid
+}
+
+// RateLimiter holds AI requests back to stay within requests-per-minute and tokens-per-minute
+// budgets and a concurrency limit. Waiting requests are served in priority order. With a
+// Redis client the budgets, and pauses requested by the API's retry-after headers, are shared
+// by every replica; otherwise they are local to the process.
+type RateLimiter struct {
+	config RateLimitConfig
+	store  bucketStore
+	redis  *redis.Client
+
+	mu       sync.Mutex
+	queue    []*rateLimitWaiter
+	seq      uint64
+	inFlight int
+	changed  chan struct{} // Closed and replaced whenever the queue or slots change
+}
+
+type rateLimitWaiter struct {
+	priority Priority
+	seq      uint64
+}
+
+// NewRateLimiter creates a rate limiter. redisClient may be nil for limits local to the process.
+func NewRateLimiter(config RateLimitConfig, redisClient *redis.Client) *RateLimiter {
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Replica == "" {
+		hostname, _ := os.Hostname()
+		config.Replica = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+
+	var store bucketStore = newMemoryBuckets(config)
+	if redisClient != nil {
+		store = newRedisBuckets(redisClient, config)
+	}
+
+	return &RateLimiter{
+		config:  config,
+		store:   store,
+		redis:   redisClient,
+		changed: make(chan struct{}),
+	}
+}
+
+// Acquire waits until req may be sent: no request of higher priority is waiting in this
+// process, a concurrency slot is free and the budgets cover it. The returned release must be
+// called once the request completes, with its usage (nil if it failed) so the token estimate
+// can be settled. Budgets fail open: if Redis is unavailable, requests are not held back.
+func (l *RateLimiter) Acquire(ctx context.Context, req *Request) (func(usage *Usage), error) {
+	priority := PriorityFromContext(ctx)
+	estimate := EstimateTokens(req)
+	reserve := priorityReserve[priority]
+
+	w := l.enqueue(priority)
+	for {
+		l.mu.Lock()
+		first := l.queue[0] == w
+		slot := l.config.MaxConcurrent <= 0 || l.inFlight < l.config.MaxConcurrent
+		changed := l.changed
+		l.mu.Unlock()
+
+		var wait <-chan time.Time
+		if first && slot {
+			delay, err := l.store.take(ctx, estimate, reserve)
+			if err != nil {
+				log.Printf("Warning: AI rate limit %s unavailable, not limiting: %v", l.config.Name, err)
+				delay = 0
+			}
+			if delay <= 0 {
+				l.dequeue(w, true)
+				return l.releaser(estimate), nil
+			}
+			wait = time.After(delay)
+		}
+
+		select {
+		case <-wait:
+		case <-changed:
+		case <-ctx.Done():
+			l.dequeue(w, false)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Observe applies what the API reported about its limits. When it asks clients to wait (a
+// retry-after header or an exhausted limit), every limiter sharing the budgets pauses.
+func (l *RateLimiter) Observe(ctx context.Context, info *RateLimitInfo) {
+	until, exhausted := info.Exhausted(time.Now())
+	if !exhausted {
+		return
+	}
+
+	if err := l.store.pause(ctx, time.Until(until)); err != nil {
+		log.Printf("Warning: Failed to pause AI rate limit %s: %v", l.config.Name, err)
+	}
+}
+
+// QueueDepth returns how many requests are waiting in this process
+func (l *RateLimiter) QueueDepth() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queue)
+}
+
+// Stats returns a snapshot of this process's queue
+func (l *RateLimiter) Stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := RateLimitStats{
+		Replica:   l.config.Replica,
+		Waiting:   make(map[string]int),
+		InFlight:  l.inFlight,
+		UpdatedAt: time.Now(),
+	}
+	for _, w := range l.queue {
+		stats.Waiting[w.priority.String()]++
+		stats.QueueDepth++
+	}
+	return stats
+}
+
+// ReportStats publishes Stats to Redis every few seconds until ctx is done, so the queues of
+// every replica can be read with ReadRateLimitStatus. It returns at once without Redis.
+func (l *RateLimiter) ReportStats(ctx context.Context) {
+	if l.redis == nil {
+		return
+	}
+
+	key := rateLimitKeyPrefix + l.config.Name + ":replicas"
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		data, err := json.Marshal(l.Stats())
+		if err == nil {
+			err = l.redis.HSet(ctx, key, l.config.Replica, data).Err()
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Warning: Failed to publish AI rate limit stats: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			// Leave the entry to expire from readers' view rather than report an empty queue
+			return
+		}
+	}
+}
+
+func (l *RateLimiter) enqueue(priority Priority) *rateLimitWaiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	w := &rateLimitWaiter{priority: priority, seq: l.seq}
+	l.queue = append(l.queue, w)
+	sort.SliceStable(l.queue, func(i, j int) bool {
+		if l.queue[i].priority != l.queue[j].priority {
+			return l.queue[i].priority < l.queue[j].priority
+		}
+		return l.queue[i].seq < l.queue[j].seq
+	})
+	l.notifyLocked()
+	return w
+}
+
+// dequeue removes w from the queue, taking a concurrency slot if it was granted one
+func (l *RateLimiter) dequeue(w *rateLimitWaiter, granted bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.queue {
+		if l.queue[i] == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			break
+		}
+	}
+	if granted {
+		l.inFlight++
+	}
+	l.notifyLocked()
+}
+
+func (l *RateLimiter) releaser(estimate int) func(usage *Usage) {
+	var once sync.Once
+	return func(usage *Usage) {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inFlight--
+			l.notifyLocked()
+			l.mu.Unlock()
+
+			// Failed requests are not counted by the API
+			used := 0
+			if usage != nil {
+				used = usage.InputTokens + usage.OutputTokens
+			}
+			if used != estimate {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := l.store.adjust(ctx, used-estimate); err != nil {
+					log.Printf("Warning: Failed to settle AI rate limit %s: %v", l.config.Name, err)
+				}
+			}
+		})
+	}
+}
+
+func (l *RateLimiter) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// EstimateTokens estimates the tokens a request counts against a tokens-per-minute budget: its
+// input plus its maximum output
+func EstimateTokens(req *Request) int {
+	return estimateInputTokens(req) + req.MaxTokens
+}
+
+// estimateInputTokens estimates a request's input tokens at about four characters per token
+func estimateInputTokens(req *Request) int {
+	chars := len(req.System)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			chars += len(block.Text)
+			if block.Source != nil {
+				// Images are billed by size, but roughly a few thousand tokens at most
+				chars += 4 * 1600
+			}
+		}
+	}
+	return chars / 4
+}
@@ -0,0 +1,306 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bucketTTL keeps idle bucket state in Redis long enough for the buckets to refill
+const bucketTTL = 2 * time.Minute
+
+// bucketStore holds the token buckets of a rate limit
+type bucketStore interface {
+	// take removes a request and tokens from the buckets, leaving reserve (a share of each
+	// budget) untouched, or returns how long to wait before trying again
+	take(ctx context.Context, tokens int, reserve float64) (time.Duration, error)
+
+	// adjust charges (or with a negative count refunds) tokens once the real usage is known
+	adjust(ctx context.Context, tokens int) error
+
+	// pause holds every request back for d
+	pause(ctx context.Context, d time.Duration) error
+}
+
+// RateLimitStats is a snapshot of one process's rate limiter queue
+type RateLimitStats struct {
+	Replica    string         `json:"replica"`
+	QueueDepth int            `json:"queue_depth"`
+	Waiting    map[string]int `json:"waiting"` // By priority
+	InFlight   int            `json:"in_flight"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// RateLimitStatus is the shared state of a rate limit across replicas
+type RateLimitStatus struct {
+	Name              string           `json:"name"`
+	RequestsPerMinute int              `json:"requests_per_minute"`
+	TokensPerMinute   int              `json:"tokens_per_minute"`
+	RequestsAvailable float64          `json:"requests_available"`
+	TokensAvailable   float64          `json:"tokens_available"`
+	PausedUntil       *time.Time       `json:"paused_until,omitempty"`
+	QueueDepth        int              `json:"queue_depth"`
+	InFlight          int              `json:"in_flight"`
+	Replicas          []RateLimitStats `json:"replicas"`
+}
+
+// ReadRateLimitStatus reads the budgets and queues that the replicas sharing a rate limit
+// publish to Redis. Replicas that have stopped reporting are left out and forgotten.
+func ReadRateLimitStatus(ctx context.Context, client *redis.Client, name string) (*RateLimitStatus, error) {
+	bucket, err := client.HGetAll(ctx, rateLimitKeyPrefix+name).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit: %w", err)
+	}
+	replicas, err := client.HGetAll(ctx, rateLimitKeyPrefix+name+":replicas").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit queues: %w", err)
+	}
+
+	status := &RateLimitStatus{Name: name, Replicas: []RateLimitStats{}}
+
+	now := time.Now()
+	if len(bucket) > 0 {
+		status.RequestsPerMinute = int(parseFloat(bucket["rpm"]))
+		status.TokensPerMinute = int(parseFloat(bucket["tpm"]))
+		elapsed := now.Sub(time.UnixMilli(int64(parseFloat(bucket["updated_ms"]))))
+		status.RequestsAvailable = refill(parseFloat(bucket["requests"]), float64(status.RequestsPerMinute), elapsed)
+		status.TokensAvailable = refill(parseFloat(bucket["tokens"]), float64(status.TokensPerMinute), elapsed)
+		if paused := time.UnixMilli(int64(parseFloat(bucket["paused_until_ms"]))); paused.After(now) {
+			status.PausedUntil = &paused
+		}
+	}
+
+	var stale []string
+	for replica, data := range replicas {
+		var stats RateLimitStats
+		if err := json.Unmarshal([]byte(data), &stats); err != nil || now.Sub(stats.UpdatedAt) > 3*statsInterval {
+			stale = append(stale, replica)
+			continue
+		}
+		status.Replicas = append(status.Replicas, stats)
+		status.QueueDepth += stats.QueueDepth
+		status.InFlight += stats.InFlight
+	}
+	sort.Slice(status.Replicas, func(i, j int) bool { return status.Replicas[i].Replica < status.Replicas[j].Replica })
+
+	// Replicas that stopped reporting have shut down
+	if len(stale) > 0 {
+		client.HDel(ctx, rateLimitKeyPrefix+name+":replicas", stale...)
+	}
+
+	return status, nil
+}
+
+// refill returns a bucket's level after elapsed, given its refill rate per minute
+func refill(level, perMinute float64, elapsed time.Duration) float64 {
+	if perMinute <= 0 {
+		return 0
+	}
+	return math.Min(perMinute, level+elapsed.Minutes()*perMinute)
+}
+
+// bucketWait returns how long until a bucket refilled at perMinute holds need
+func bucketWait(level, need, perMinute float64) time.Duration {
+	if perMinute <= 0 || level >= need {
+		return 0
+	}
+	return time.Duration((need - level) / perMinute * float64(time.Minute))
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// memoryBuckets keeps a rate limit's buckets in the process
+type memoryBuckets struct {
+	rpm, tpm float64
+
+	mu          sync.Mutex
+	requests    float64
+	tokens      float64
+	updated     time.Time
+	pausedUntil time.Time
+}
+
+func newMemoryBuckets(config RateLimitConfig) *memoryBuckets {
+	return &memoryBuckets{
+		rpm:      float64(config.RequestsPerMinute),
+		tpm:      float64(config.TokensPerMinute),
+		requests: float64(config.RequestsPerMinute),
+		tokens:   float64(config.TokensPerMinute),
+		updated:  time.Now(),
+	}
+}
+
+func (b *memoryBuckets) take(ctx context.Context, tokens int, reserve float64) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.pausedUntil.After(now) {
+		return b.pausedUntil.Sub(now), nil
+	}
+
+	b.refillLocked(now)
+	cost := float64(tokens)
+	wait := bucketWait(b.requests, math.Min(b.rpm, 1+reserve*b.rpm), b.rpm)
+	if tokenWait := bucketWait(b.tokens, math.Min(b.tpm, cost+reserve*b.tpm), b.tpm); tokenWait > wait {
+		wait = tokenWait
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+
+	b.requests--
+	b.tokens -= cost
+	return 0, nil
+}
+
+func (b *memoryBuckets) adjust(ctx context.Context, tokens int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refillLocked(time.Now())
+	b.tokens = math.Min(b.tpm, b.tokens-float64(tokens))
+	return nil
+}
+
+func (b *memoryBuckets) pause(ctx context.Context, d time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	return nil
+}
+
+func (b *memoryBuckets) refillLocked(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	b.requests = refill(b.requests, b.rpm, elapsed)
+	b.tokens = refill(b.tokens, b.tpm, elapsed)
+	b.updated = now
+}
+
+// redisBuckets keeps a rate limit's buckets in a Redis hash shared by every replica. The
+// scripts use the Redis clock so replicas with skewed clocks agree on refills.
+type redisBuckets struct {
+	client   *redis.Client
+	key      string
+	rpm, tpm int
+}
+
+func newRedisBuckets(client *redis.Client, config RateLimitConfig) *redisBuckets {
+	return &redisBuckets{
+		client: client,
+		key:    rateLimitKeyPrefix + config.Name,
+		rpm:    config.RequestsPerMinute,
+		tpm:    config.TokensPerMinute,
+	}
+}
+
+// takeScript refills the buckets and takes a request and tokens from them, returning 0, or
+// returns the milliseconds to wait. KEYS[1] is the bucket hash; ARGV is rpm, tpm, tokens,
+// reserve and the TTL in milliseconds.
+var takeScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rpm = tonumber(ARGV[1])
+local tpm = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local reserve = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'requests', 'tokens', 'updated_ms', 'paused_until_ms')
+local paused = tonumber(state[4]) or 0
+if paused > now then
+  return paused - now
+end
+
+local elapsed = math.max(0, now - (tonumber(state[3]) or now))
+local function level(stored, limit)
+  if limit <= 0 then return 0 end
+  local current = tonumber(stored)
+  if current == nil then return limit end
+  return math.min(limit, current + elapsed * limit / 60000)
+end
+local function wait(current, need, limit)
+  if limit <= 0 or current >= need then return 0 end
+  return math.ceil((need - current) * 60000 / limit)
+end
+
+local requests = level(state[1], rpm)
+local tokens = level(state[2], tpm)
+local delay = math.max(
+  wait(requests, math.min(rpm, 1 + reserve * rpm), rpm),
+  wait(tokens, math.min(tpm, cost + reserve * tpm), tpm))
+if delay == 0 then
+  requests = requests - 1
+  tokens = tokens - cost
+end
+
+redis.call('HSET', KEYS[1], 'requests', tostring(requests), 'tokens', tostring(tokens),
+  'updated_ms', tostring(now), 'rpm', tostring(rpm), 'tpm', tostring(tpm))
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return delay
+`)
+
+// adjustScript charges ARGV[1] tokens (refunds when negative) to an existing bucket hash
+var adjustScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  redis.call('HINCRBYFLOAT', KEYS[1], 'tokens', -tonumber(ARGV[1]))
+end
+return 0
+`)
+
+// pauseScript holds requests back for ARGV[1] milliseconds, unless already paused for longer
+var pauseScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local pausedUntil = now + tonumber(ARGV[1])
+if pausedUntil > (tonumber(redis.call('HGET', KEYS[1], 'paused_until_ms')) or 0) then
+  redis.call('HSET', KEYS[1], 'paused_until_ms', tostring(pausedUntil))
+  redis.call('PEXPIRE', KEYS[1], tonumber(ARGV[1]) + tonumber(ARGV[2]))
+end
+return 0
+`)
+
+func (b *redisBuckets) take(ctx context.Context, tokens int, reserve float64) (time.Duration, error) {
+	if b.rpm <= 0 && b.tpm <= 0 {
+		// Still honor pauses requested by the API
+		paused, err := b.client.HGet(ctx, b.key, "paused_until_ms").Result()
+		if err == redis.Nil {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		until := time.UnixMilli(int64(parseFloat(paused)))
+		return time.Until(until), nil
+	}
+
+	ms, err := takeScript.Run(ctx, b.client, []string{b.key},
+		b.rpm, b.tpm, tokens, reserve, bucketTTL.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (b *redisBuckets) adjust(ctx context.Context, tokens int) error {
+	if b.tpm <= 0 {
+		return nil
+	}
+	return adjustScript.Run(ctx, b.client, []string{b.key}, tokens).Err()
+}
+
+func (b *redisBuckets) pause(ctx context.Context, d time.Duration) error {
+	return pauseScript.Run(ctx, b.client, []string{b.key}, d.Milliseconds(), bucketTTL.Milliseconds()).Err()
+}
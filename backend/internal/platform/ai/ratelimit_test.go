@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_ServesHigherPriorityFirst(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{MaxConcurrent: 1}, nil)
+	req := NewSimpleRequest(ModelSonnet4, "", "analyze", 100)
+
+	release, err := limiter.Acquire(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	order := make(chan Priority, 2)
+	for i, p := range []Priority{PriorityBatch, PriorityInteractive} {
+		p := p
+		go func() {
+			release, err := limiter.Acquire(WithPriority(context.Background(), p), req)
+			if err != nil {
+				t.Errorf("Acquire(%s) error = %v", p, err)
+				return
+			}
+			order <- p
+			release(nil)
+		}()
+		waitFor(t, func() bool { return limiter.QueueDepth() == i+1 })
+	}
+
+	stats := limiter.Stats()
+	if stats.QueueDepth != 2 || stats.Waiting["batch"] != 1 || stats.Waiting["interactive"] != 1 || stats.InFlight != 1 {
+		t.Errorf("Stats() = %+v, want one batch and one interactive request waiting", stats)
+	}
+
+	release(nil)
+	if first, second := <-order, <-order; first != PriorityInteractive || second != PriorityBatch {
+		t.Errorf("served %s then %s, want interactive before batch", first, second)
+	}
+}
+
+func TestMemoryBuckets_ReserveHeadroomForHigherPriorities(t *testing.T) {
+	buckets := newMemoryBuckets(RateLimitConfig{RequestsPerMinute: 2, TokensPerMinute: 1000})
+	ctx := context.Background()
+
+	if wait, _ := buckets.take(ctx, 400, priorityReserve[PriorityNormal]); wait != 0 {
+		t.Fatalf("first take waited %v, want none", wait)
+	}
+	if wait, _ := buckets.take(ctx, 400, priorityReserve[PriorityBatch]); wait <= 0 {
+		t.Errorf("batch take should wait while only the reserved share is left")
+	}
+	if wait, _ := buckets.take(ctx, 400, priorityReserve[PriorityInteractive]); wait != 0 {
+		t.Errorf("interactive take waited %v, want the reserve", wait)
+	}
+	if wait, _ := buckets.take(ctx, 100, priorityReserve[PriorityInteractive]); wait < 20*time.Second {
+		t.Errorf("take waited %v with no requests left, want about 30s", wait)
+	}
+
+	// Settling a smaller actual usage refunds the estimate
+	buckets.adjust(ctx, -500)
+	if buckets.tokens < 700 {
+		t.Errorf("tokens = %v after the refund, want at least 700", buckets.tokens)
+	}
+}
+
+func TestRateLimiter_PausesOnRetryAfter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerMinute: 100}, nil)
+	limiter.Observe(context.Background(), &RateLimitInfo{RequestsRemaining: -1, TokensRemaining: -1, RetryAfter: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, NewSimpleRequest(ModelSonnet4, "", "analyze", 100)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() error = %v, want to wait out the pause", err)
+	}
+	if limiter.QueueDepth() != 0 {
+		t.Errorf("cancelled request should leave the queue")
+	}
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-requests-remaining", "12")
+	anthropic.Set("anthropic-ratelimit-tokens-remaining", "0")
+	anthropic.Set("anthropic-ratelimit-tokens-reset", "2026-04-01T12:00:30Z")
+	anthropic.Set("retry-after", "20")
+
+	info := parseRateLimitHeaders(anthropic, now)
+	if info == nil || info.RequestsRemaining != 12 || info.TokensRemaining != 0 || info.RetryAfter != 20*time.Second {
+		t.Fatalf("parseRateLimitHeaders() = %+v", info)
+	}
+	if until, exhausted := info.Exhausted(now); !exhausted || !until.Equal(now.Add(30*time.Second)) {
+		t.Errorf("Exhausted() = %v, %v, want the token reset", until, exhausted)
+	}
+
+	openAI := http.Header{}
+	openAI.Set("x-ratelimit-remaining-requests", "0")
+	openAI.Set("x-ratelimit-reset-requests", "1m30s")
+	openAI.Set("x-ratelimit-remaining-tokens", "5000")
+
+	info = parseRateLimitHeaders(openAI, now)
+	if until, exhausted := info.Exhausted(now); !exhausted || !until.Equal(now.Add(90*time.Second)) {
+		t.Errorf("Exhausted() = %v, %v, want the request reset", until, exhausted)
+	}
+
+	if info := parseRateLimitHeaders(http.Header{}, now); info != nil {
+		t.Errorf("parseRateLimitHeaders() = %+v, want nil without headers", info)
+	}
+}
+
+func TestClient_RateLimitErrorIsRetryable(t *testing.T) {
+	client := &Client{}
+	if !client.isRetryableError(&APIError{StatusCode: http.StatusTooManyRequests, Message: "slow down"}) {
+		t.Errorf("429 should be retryable")
+	}
+	if client.isRetryableError(&APIError{StatusCode: http.StatusBadRequest, Message: "prompt is too long"}) {
+		t.Errorf("400 should not be retryable")
+	}
+
+	err := &APIError{StatusCode: http.StatusTooManyRequests, RateLimit: &RateLimitInfo{RetryAfter: 10 * time.Second}}
+	if d := retryDelay(1, err); d != 10*time.Second {
+		t.Errorf("retryDelay() = %v, want the API's retry-after", d)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
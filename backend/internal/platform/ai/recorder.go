@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoRecording is returned in replay mode when no response was recorded for a request
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// RecordMode selects whether a RecordingProvider calls the model or replays recordings
+type RecordMode string
+
+const (
+	// RecordModeRecord forwards every request and saves the response
+	RecordModeRecord RecordMode = "record"
+
+	// RecordModeReplay answers only from saved responses and never calls the model
+	RecordModeReplay RecordMode = "replay"
+)
+
+// Recording is the fixture file saved for one request. The request is kept so fixtures can
+// be reviewed and diffed; lookups use only the file name.
+type Recording struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// RecordingProvider saves responses to dir keyed by request hash, or replays them, so the
+// client's callers can be tested against real model output without network access.
+type RecordingProvider struct {
+	next Provider
+	dir  string
+	mode RecordMode
+}
+
+// NewRecordingProvider creates a recording provider. next may be nil in replay mode.
+func NewRecordingProvider(next Provider, dir string, mode RecordMode) *RecordingProvider {
+	return &RecordingProvider{
+		next: next,
+		dir:  dir,
+		mode: mode,
+	}
+}
+
+// RequestHash identifies a request by the SHA-256 of its JSON encoding
+func RequestHash(req *Request) string {
+	h := sha256.New()
+	json.NewEncoder(h).Encode(req)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Request replays the recorded response, or forwards the request and records the response
+func (p *RecordingProvider) Request(ctx context.Context, req *Request) (*Response, error) {
+	if p.mode == RecordModeReplay {
+		return p.replay(req)
+	}
+	if p.next == nil {
+		return nil, fmt.Errorf("recording provider has no provider to record from")
+	}
+
+	resp, err := p.next.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.record(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Stream replays the recorded response as a single delta, or streams the request from the
+// wrapped provider and records the assembled response
+func (p *RecordingProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	if p.mode == RecordModeReplay {
+		resp, err := p.replay(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := deliverText(resp, handler); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	if p.next == nil {
+		return nil, fmt.Errorf("recording provider has no provider to record from")
+	}
+
+	resp, err := streamFrom(ctx, p.next, req, handler)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.record(req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay reads the response recorded for req
+func (p *RecordingProvider) replay(req *Request) (*Response, error) {
+	path := filepath.Join(p.dir, RequestHash(req)+".json")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w %s: record it again against a live model", ErrNoRecording, filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", filepath.Base(path), err)
+	}
+	return recording.Response, nil
+}
+
+// record saves the response to req
+func (p *RecordingProvider) record(req *Request, resp *Response) error {
+	path := filepath.Join(p.dir, RequestHash(req)+".json")
+
+	data, err := json.MarshalIndent(Recording{Request: req, Response: resp}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
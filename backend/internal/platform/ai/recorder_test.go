@@ -0,0 +1,34 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRecordingProvider_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	live := NewFakeProvider(FakeRule{Response: "recorded answer", Usage: Usage{InputTokens: 7}})
+	req := NewSimpleRequest(ModelSonnet4, "system", "question", 100)
+
+	if _, err := NewRecordingProvider(live, dir, RecordModeRecord).Request(context.Background(), req); err != nil {
+		t.Fatalf("record: Request() error = %v", err)
+	}
+
+	replay := NewRecordingProvider(nil, dir, RecordModeReplay)
+	resp, err := replay.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "system", "question", 100))
+	if err != nil {
+		t.Fatalf("replay: Request() error = %v", err)
+	}
+	if resp.GetExtractedText() != "recorded answer" || resp.Usage.InputTokens != 7 {
+		t.Errorf("replay: Request() = %+v, want the recorded response", resp)
+	}
+
+	_, err = replay.Request(context.Background(), NewSimpleRequest(ModelSonnet4, "system", "another question", 100))
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("replay of an unrecorded request: error = %v, want ErrNoRecording", err)
+	}
+	if len(live.Requests()) != 1 {
+		t.Errorf("live provider received %d requests, want 1", len(live.Requests()))
+	}
+}
@@ -0,0 +1,247 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used to describe and validate structured model output
+type Schema struct {
+	Type        string             `json:"type,omitempty"` // object, array, string, number, integer or boolean; empty accepts anything
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+}
+
+// SchemaFor derives a schema from the Go type of v, following its json tags. Fields without
+// omitempty are required. A jsonschema tag adds constraints as comma-separated key=value pairs:
+// enum (values separated by |), minimum, maximum and description, which must come last.
+//
+//	Severity string `json:"severity" jsonschema:"enum=low|medium|high|critical"`
+func SchemaFor(v interface{}) *Schema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return schemaForStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func schemaForStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaForType(field.Type)
+		applySchemaTag(prop, field.Tag.Get("jsonschema"))
+		schema.Properties[name] = prop
+
+		if !strings.Contains(","+opts+",", ",omitempty,") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func applySchemaTag(schema *Schema, tag string) {
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "description=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "enum":
+			schema.Enum = strings.Split(value, "|")
+		case "minimum":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Minimum = &f
+			}
+		case "maximum":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Maximum = &f
+			}
+		case "description":
+			schema.Description = value
+		}
+	}
+}
+
+// Validate checks a JSON document against the schema and returns every violation found, each
+// prefixed with the path of the offending value. Properties the schema does not describe are
+// allowed, as are nulls for properties that are not required.
+func (s *Schema) Validate(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("not valid JSON: %v", err)}
+	}
+	if decoder.More() {
+		return []string{"not valid JSON: unexpected content after the document"}
+	}
+
+	var problems []string
+	s.validate("$", value, &problems)
+	return problems
+}
+
+func (s *Schema) validate(path string, value interface{}, problems *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %s", jsonTypeName(value))
+			return
+		}
+		for _, name := range s.Required {
+			if v, present := obj[name]; !present || v == nil {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if v, present := obj[name]; present && v != nil {
+				s.Properties[name].validate(path+"."+name, v, problems)
+			}
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("expected an array, got %s", jsonTypeName(value))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("expected a string, got %s", jsonTypeName(value))
+			return
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			fail("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+
+	case "number", "integer":
+		num, ok := value.(json.Number)
+		if !ok {
+			fail("expected a %s, got %s", s.Type, jsonTypeName(value))
+			return
+		}
+		f, err := num.Float64()
+		if err != nil {
+			fail("invalid number %s", num)
+			return
+		}
+		if s.Type == "integer" && f != float64(int64(f)) {
+			fail("expected an integer, got %s", num)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("%s is less than the minimum %v", num, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("%s is greater than the maximum %v", num, *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected a boolean, got %s", jsonTypeName(value))
+		}
+	}
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
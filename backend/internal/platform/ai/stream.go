@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// streamHeaderTimeout bounds the wait for a streaming response to start
+	streamHeaderTimeout = 120 * time.Second
+
+	// streamIdleTimeout is how long a stream may go without an event before it is abandoned.
+	// The API sends pings while the model works, so a silent stream has stalled.
+	streamIdleTimeout = 90 * time.Second
+
+	// maxStreamLineSize bounds a single line of a Server-Sent Events stream
+	maxStreamLineSize = 4 * 1024 * 1024
+)
+
+// ErrStreamIdle is returned when a stream receives nothing for too long
+var ErrStreamIdle = errors.New("stream timeout: no data received")
+
+// errStreamInterrupted is returned when a stream ends before the message is complete
+var errStreamInterrupted = errors.New("API stream interrupted before the message was complete")
+
+// StreamHandler receives the text of a response as it is generated. Returning an error stops
+// the stream and fails the request with that error.
+type StreamHandler func(delta string) error
+
+// StreamingProvider is a Provider that can deliver a response incrementally
+type StreamingProvider interface {
+	Provider
+
+	// Stream sends req and calls handler with each text delta. The complete response,
+	// including its usage, is returned once the stream ends. handler may be nil.
+	Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error)
+}
+
+// streamFrom streams req from provider, or, when provider cannot stream, delivers the text of
+// its complete response as a single delta
+func streamFrom(ctx context.Context, provider Provider, req *Request, handler StreamHandler) (*Response, error) {
+	if streaming, ok := provider.(StreamingProvider); ok {
+		return streaming.Stream(ctx, req, handler)
+	}
+
+	resp, err := provider.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := deliverText(resp, handler); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// deliverText passes the text of a complete response to handler
+func deliverText(resp *Response, handler StreamHandler) error {
+	if handler == nil {
+		return nil
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" && block.Text != "" {
+			if err := handler(block.Text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newStreamingHTTPClient returns an HTTP client for streaming requests. It has no overall
+// timeout, which would cut off long responses; stalls are caught by readStream instead.
+func newStreamingHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = streamHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// readStream reads the Server-Sent Events in body, calling fn with each event's type and
+// data. fn returning io.EOF ends the stream without error. The stream is abandoned with
+// ErrStreamIdle when no event arrives within streamIdleTimeout, and stops when ctx is done.
+func readStream(ctx context.Context, body io.ReadCloser, fn func(event string, data []byte) error) error {
+	idle := false
+	watchdog := time.NewTimer(streamIdleTimeout)
+	defer watchdog.Stop()
+
+	events := make(chan struct{}, 1)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		// Closing the body unblocks the read below
+		for {
+			select {
+			case <-events:
+				watchdog.Reset(streamIdleTimeout)
+			case <-watchdog.C:
+				idle = true
+				body.Close()
+				return
+			case <-ctx.Done():
+				body.Close()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := readSSE(body, func(event string, data []byte) error {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+		return fn(event, data)
+	})
+
+	close(done)
+	<-finished
+
+	switch {
+	case idle:
+		return ErrStreamIdle
+	case ctx.Err() != nil:
+		return ctx.Err()
+	}
+	return err
+}
+
+// readSSE parses a Server-Sent Events stream, calling fn once per event
+func readSSE(r io.Reader, fn func(event string, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLineSize)
+
+	var event string
+	var data bytes.Buffer
+	dispatch := func() error {
+		defer func() {
+			event = ""
+			data.Reset()
+		}()
+		if data.Len() == 0 {
+			return nil
+		}
+		return fn(event, data.Bytes())
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment, used as a keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// An event without its closing blank line was cut off and is discarded
+	return nil
+}
@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// anthropicEvents is a Messages API stream with a text block and a tool call
+const anthropicEvents = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":250,"output_tokens":1,"cache_read_input_tokens":200}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"The schedule "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"is at risk."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"record_risk","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"severity\": "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"high\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":42}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestAnthropicProvider_Stream(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(anthropicEvents))
+	}))
+	defer server.Close()
+
+	var deltas []string
+	provider := NewAnthropicProvider("key", server.URL)
+	resp, err := provider.Stream(context.Background(), NewSimpleRequest(ModelSonnet4, "", "Assess the schedule", 512), func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	if got["stream"] != true {
+		t.Errorf("stream = %v, want true", got["stream"])
+	}
+	if strings.Join(deltas, "|") != "The schedule |is at risk." {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if resp.GetExtractedText() != "The schedule is at risk." {
+		t.Errorf("text = %q", resp.GetExtractedText())
+	}
+	if len(resp.Content) != 2 || string(resp.Content[1].Input) != `{"severity": "high"}` || resp.Content[1].Name != "record_risk" {
+		t.Errorf("tool use = %+v", resp.Content)
+	}
+	if resp.StopReason != "tool_use" || resp.Usage.InputTokens != 250 || resp.Usage.OutputTokens != 42 || resp.Usage.CacheReadInputTokens != 200 {
+		t.Errorf("StopReason = %q, Usage = %+v", resp.StopReason, resp.Usage)
+	}
+}
+
+func TestAnthropicProvider_StreamInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection drops after the first text delta
+		w.Write([]byte(anthropicEvents[:strings.Index(anthropicEvents, "is at risk")]))
+	}))
+	defer server.Close()
+
+	_, err := NewAnthropicProvider("key", server.URL).Stream(context.Background(), NewSimpleRequest(ModelSonnet4, "", "Assess", 512), nil)
+	if err == nil {
+		t.Fatalf("Stream() should fail when the stream ends early")
+	}
+	if !(&Client{}).isRetryableError(err) {
+		t.Errorf("error %q should be retryable", err)
+	}
+}
+
+func TestOpenAIProvider_Stream(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		for _, chunk := range []string{
+			`{"id":"chatcmpl-1","model":"llama-3.1-70b","choices":[{"delta":{"role":"assistant","content":"Two "}}]}`,
+			`{"id":"chatcmpl-1","model":"llama-3.1-70b","choices":[{"delta":{"content":"risks."},"finish_reason":"stop"}]}`,
+			`{"id":"chatcmpl-1","model":"llama-3.1-70b","choices":[],"usage":{"prompt_tokens":80,"completion_tokens":3}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer server.Close()
+
+	var text strings.Builder
+	resp, err := NewOpenAIProvider(server.URL, "", "").Stream(context.Background(), NewSimpleRequest(ModelSonnet4, "", "Count the risks", 64), func(delta string) error {
+		text.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	if got["stream"] != true || got["stream_options"] == nil {
+		t.Errorf("request = %v, want a stream with usage", got)
+	}
+	if text.String() != "Two risks." || resp.GetExtractedText() != "Two risks." {
+		t.Errorf("streamed %q, response text %q", text.String(), resp.GetExtractedText())
+	}
+	if resp.StopReason != "end_turn" || resp.Usage.InputTokens != 80 || resp.Usage.OutputTokens != 3 {
+		t.Errorf("StopReason = %q, Usage = %+v", resp.StopReason, resp.Usage)
+	}
+}
+
+func TestClient_Stream(t *testing.T) {
+	tracker := &recordingTracker{}
+	provider := NewFakeProvider(FakeRule{Response: "Mitigate the vendor risk now", Usage: Usage{InputTokens: 10, OutputTokens: 5}})
+	client := NewClient(&ClientConfig{Provider: provider, MetricsTracker: tracker})
+
+	var deltas []string
+	resp, err := client.Stream(context.Background(), NewSimpleRequest(ModelSonnet4, "", "Suggest a mitigation", 64), func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	if len(deltas) != 5 || strings.Join(deltas, "") != resp.GetExtractedText() {
+		t.Errorf("deltas = %q, want the reply a word at a time", deltas)
+	}
+	if !provider.Requests()[0].Stream {
+		t.Errorf("request should be marked as streamed")
+	}
+	if len(tracker.metrics) != 1 || tracker.metrics[0].TotalTokens != 15 {
+		t.Errorf("tracked %+v, want the stream's usage", tracker.metrics)
+	}
+}
+
+func TestClient_StreamStopsOnHandlerError(t *testing.T) {
+	provider := NewFakeProvider(FakeRule{Response: "one two three"})
+	client := NewClient(&ClientConfig{Provider: provider})
+
+	stop := errors.New("client went away")
+	calls := 0
+	_, err := client.Stream(context.Background(), NewSimpleRequest(ModelSonnet4, "", "count", 64), func(string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Stream() error = %v, want the handler's error", err)
+	}
+	if calls != 1 || len(provider.Requests()) != 1 {
+		t.Errorf("handler called %d times over %d requests, want 1 and no retry", calls, len(provider.Requests()))
+	}
+}
+
+type recordingTracker struct {
+	metrics []*Metrics
+}
+
+func (r *recordingTracker) Track(ctx context.Context, metrics *Metrics) error {
+	r.metrics = append(r.metrics, metrics)
+	return nil
+}
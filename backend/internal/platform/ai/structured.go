@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredOutput describes the JSON a structured request must produce. The model is made to
+// return it as the input of a tool named Name whose input schema is Schema.
+type StructuredOutput struct {
+	Name        string
+	Description string
+	Schema      *Schema // Must describe an object
+}
+
+// StructuredResponse is the outcome of a structured request
+type StructuredResponse struct {
+	*Response        // Response the output was taken from
+	Output    []byte // Output that passed validation
+	Repaired  bool   // The first output was invalid and the model corrected it
+}
+
+// OutputValidationError reports model output that did not match its schema, even after the
+// model was asked to repair it
+type OutputValidationError struct {
+	Output   string   // Name of the structured output
+	Problems []string // Violations found in the last output
+	Raw      string   // Last output received
+}
+
+func (e *OutputValidationError) Error() string {
+	return fmt.Sprintf("AI output %s failed schema validation: %s", e.Output, strings.Join(e.Problems, "; "))
+}
+
+// maxRepairProblems bounds the violations quoted back to the model in a repair request
+const maxRepairProblems = 20
+
+// RequestStructured sends req through provider with output's tool forced, validates the tool
+// input against output.Schema and decodes it into dest. Models that answer in text instead are
+// tolerated: the JSON object in the text is used. If the output is invalid, the model is
+// shown the violations and asked once to correct it; an output that is still invalid fails
+// with *OutputValidationError.
+func RequestStructured(ctx context.Context, provider Provider, req *Request, output StructuredOutput, dest interface{}) (*StructuredResponse, error) {
+	structured := *req
+	structured.Tools = []Tool{{Name: output.Name, Description: output.Description, InputSchema: output.Schema}}
+	structured.ToolChoice = &ToolChoice{Type: "tool", Name: output.Name}
+
+	resp, err := provider.Request(ctx, &structured)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := structuredOutput(resp, output.Name)
+	problems := output.Schema.Validate(raw)
+	repaired := false
+
+	if len(problems) > 0 {
+		previous := string(raw)
+		if strings.TrimSpace(previous) == "" {
+			previous = "(no output)"
+		}
+
+		repair := structured
+		repair.CacheKey = "" // The repair differs from the first request only in its messages
+		repair.Messages = append(append([]Message(nil), structured.Messages...),
+			Message{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: previous}}},
+			Message{Role: "user", Content: []ContentBlock{{Type: "text", Text: repairPrompt(output.Name, problems)}}},
+		)
+
+		resp, err = provider.Request(ctx, &repair)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = structuredOutput(resp, output.Name)
+		if problems = output.Schema.Validate(raw); len(problems) > 0 {
+			return nil, &OutputValidationError{Output: output.Name, Problems: problems, Raw: string(raw)}
+		}
+		repaired = true
+	}
+
+	if dest != nil {
+		if err := json.Unmarshal(raw, dest); err != nil {
+			return nil, &OutputValidationError{Output: output.Name, Problems: []string{err.Error()}, Raw: string(raw)}
+		}
+	}
+
+	return &StructuredResponse{Response: resp, Output: raw, Repaired: repaired}, nil
+}
+
+// structuredOutput returns the input of the response's call to tool, or failing that the JSON
+// object in its text
+func structuredOutput(resp *Response, tool string) []byte {
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "tool_use":
+			if block.Name == tool && len(block.Input) > 0 {
+				return block.Input
+			}
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+
+	// Models without tool support often wrap the JSON in prose or a markdown code block
+	s := text.String()
+	if start, end := strings.Index(s, "{"), strings.LastIndex(s, "}"); start >= 0 && end > start {
+		return []byte(s[start : end+1])
+	}
+	return []byte(strings.TrimSpace(s))
+}
+
+func repairPrompt(tool string, problems []string) string {
+	if len(problems) > maxRepairProblems {
+		problems = append(problems[:maxRepairProblems:maxRepairProblems],
+			fmt.Sprintf("... and %d more", len(problems)-maxRepairProblems))
+	}
+
+	return fmt.Sprintf(`Your %s output does not match the required schema:
+- %s
+
+Call %s again with the complete, corrected output. Keep every value that was already valid.`,
+		tool, strings.Join(problems, "\n- "), tool)
+}
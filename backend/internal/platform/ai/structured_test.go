@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testExtraction struct {
+	Title    string   `json:"title"`
+	Severity string   `json:"severity" jsonschema:"enum=low|medium|high"`
+	Score    float64  `json:"score" jsonschema:"minimum=0,maximum=1"`
+	Tags     []string `json:"tags,omitempty"`
+	Items    []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	} `json:"items"`
+}
+
+var testOutput = StructuredOutput{Name: "record_extraction", Schema: SchemaFor(testExtraction{})}
+
+func TestSchemaFor(t *testing.T) {
+	schema := testOutput.Schema
+	if schema.Type != "object" {
+		t.Fatalf("Type = %s, want object", schema.Type)
+	}
+	if got := strings.Join(schema.Required, ","); got != "title,severity,score,items" {
+		t.Errorf("Required = %s, want the fields without omitempty", got)
+	}
+	if got := strings.Join(schema.Properties["severity"].Enum, ","); got != "low,medium,high" {
+		t.Errorf("severity Enum = %s", got)
+	}
+	if items := schema.Properties["items"]; items.Type != "array" || items.Items.Properties["count"].Type != "integer" {
+		t.Errorf("items = %+v, want an array of objects with an integer count", items)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			input: `{"title": "Overrun", "severity": "high", "score": 0.8, "items": [{"name": "a", "count": 2}], "extra": 1}`,
+		},
+		{
+			name:  "optional property may be null",
+			input: `{"title": "Overrun", "severity": "low", "score": 1, "items": [], "tags": null}`,
+		},
+		{
+			name:  "violations are reported with their paths",
+			input: `{"severity": "severe", "score": 1.5, "items": [{"name": "a", "count": 2.5}]}`,
+			want: []string{
+				`$: missing required property "title"`,
+				`$.items[0].count: expected an integer, got 2.5`,
+				`$.score: 1.5 is greater than the maximum 1`,
+				`$.severity: "severe" is not one of low, medium, high`,
+			},
+		},
+		{
+			name:  "not JSON",
+			input: `Here is the analysis you asked for.`,
+			want:  []string{"not valid JSON: invalid character 'H' looking for beginning of value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testOutput.Schema.Validate([]byte(tt.input))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRequestStructured_ToolOutput(t *testing.T) {
+	provider := NewFakeProvider(FakeRule{
+		Response:  "ignored",
+		ToolInput: json.RawMessage(`{"title": "Overrun", "severity": "high", "score": 0.9, "items": []}`),
+	})
+
+	var out testExtraction
+	resp, err := RequestStructured(context.Background(), provider, NewSimpleRequest(ModelSonnet4, "system", "extract", 100), testOutput, &out)
+	if err != nil {
+		t.Fatalf("RequestStructured() error = %v", err)
+	}
+	if out.Title != "Overrun" || resp.Repaired {
+		t.Errorf("output = %+v, repaired = %v", out, resp.Repaired)
+	}
+
+	req := provider.Requests()[0]
+	if len(req.Tools) != 1 || req.ToolChoice == nil || req.ToolChoice.Name != "record_extraction" {
+		t.Errorf("request tools = %+v, choice = %+v, want the output tool forced", req.Tools, req.ToolChoice)
+	}
+}
+
+func TestRequestStructured_RepairsInvalidOutput(t *testing.T) {
+	provider := NewFakeProvider(
+		// The repair request quotes the violations back to the model
+		FakeRule{Match: `"severe" is not one of`, Response: `{"title": "Overrun", "severity": "high", "score": 0.9, "items": []}`},
+		FakeRule{Response: "Sure! ```json\n{\"title\": \"Overrun\", \"severity\": \"severe\", \"score\": 0.9, \"items\": []}\n```"},
+	)
+
+	var out testExtraction
+	resp, err := RequestStructured(context.Background(), provider, NewSimpleRequest(ModelSonnet4, "system", "extract", 100), testOutput, &out)
+	if err != nil {
+		t.Fatalf("RequestStructured() error = %v", err)
+	}
+	if out.Severity != "high" || !resp.Repaired {
+		t.Errorf("output = %+v, repaired = %v, want the repaired output", out, resp.Repaired)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 || len(requests[1].Messages) != 3 {
+		t.Fatalf("sent %d requests, want the original and one repair with the invalid output in context", len(requests))
+	}
+}
+
+func TestRequestStructured_FailsWhenRepairIsInvalid(t *testing.T) {
+	provider := NewFakeProvider(FakeRule{Response: `{"title": "Overrun"}`})
+
+	_, err := RequestStructured(context.Background(), provider, NewSimpleRequest(ModelSonnet4, "system", "extract", 100), testOutput, &testExtraction{})
+
+	var validationErr *OutputValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("RequestStructured() error = %v, want *OutputValidationError", err)
+	}
+	if len(validationErr.Problems) != 3 || validationErr.Raw != `{"title": "Overrun"}` {
+		t.Errorf("error = %+v", validationErr)
+	}
+	if len(provider.Requests()) != 2 {
+		t.Errorf("sent %d requests, want exactly one repair attempt", len(provider.Requests()))
+	}
+}
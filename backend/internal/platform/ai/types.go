@@ -1,11 +1,15 @@
 package ai
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Model constants for Claude API
 const (
 	ModelOpus4   = "claude-opus-4-5-20251101"
 	ModelSonnet4 = "claude-sonnet-4-5-20250929"
+	ModelHaiku4  = "claude-haiku-4-5-20251001"
 )
 
 // Request represents a Claude API request
@@ -16,6 +20,14 @@ type Request struct {
 	Messages    []Message       `json:"messages"`
 	Temperature float64         `json:"temperature,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+	Tools       []Tool          `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice     `json:"tool_choice,omitempty"`
+
+	// CacheKey names what the response depends on, such as an artifact's content, when the
+	// messages also carry context that changes between otherwise equivalent requests. The
+	// response cache then keys the request on it and the model and instructions, ignoring the
+	// messages.
+	CacheKey string `json:"-"`
 }
 
 // Message represents a message in the conversation
@@ -28,14 +40,35 @@ type Message struct {
 type ContentBlock struct {
 	Type         string        `json:"type"` // "text" or "image"
 	Text         string        `json:"text,omitempty"`
+	Source       *ImageSource  `json:"source,omitempty"` // Image blocks only
 	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
+// ImageSource is the data of an image content block
+type ImageSource struct {
+	Type      string `json:"type"` // "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
 // CacheControl marks content for prompt caching
 type CacheControl struct {
 	Type string `json:"type"` // "ephemeral"
 }
 
+// Tool describes a tool the model can call with input matching InputSchema
+type Tool struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	InputSchema *Schema `json:"input_schema"`
+}
+
+// ToolChoice controls whether the model must call a tool
+type ToolChoice struct {
+	Type string `json:"type"`           // "auto", "any" or "tool"
+	Name string `json:"name,omitempty"` // Tool to call when Type is "tool"
+}
+
 // Response represents a Claude API response
 type Response struct {
 	ID         string    `json:"id"`
@@ -45,12 +78,20 @@ type Response struct {
 	Model      string    `json:"model"`
 	StopReason string    `json:"stop_reason"`
 	Usage      Usage     `json:"usage"`
+
+	// Rate limit headers the API sent with the response
+	RateLimit *RateLimitInfo `json:"-"`
 }
 
 // Content represents response content
 type Content struct {
-	Type string `json:"type"`
+	Type string `json:"type"` // "text" or "tool_use"
 	Text string `json:"text"`
+
+	// Tool use blocks only
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
 }
 
 // Usage represents token usage in a response
@@ -73,8 +114,10 @@ type ErrorResponse struct {
 // Metrics tracks AI usage metrics
 type Metrics struct {
 	ProgramID    string
+	ArtifactID   string
 	Module       string
 	JobType      string
+	Prompt       PromptRef
 	Model        string
 	InputTokens  int
 	OutputTokens int
@@ -86,44 +129,6 @@ type Metrics struct {
 	Timestamp    time.Time
 }
 
-// CostCalculator calculates API costs
-type CostCalculator struct{}
-
-// NewCostCalculator creates a new cost calculator
-func NewCostCalculator() *CostCalculator {
-	return &CostCalculator{}
-}
-
-// CalculateCost calculates the cost of an API request
-func (c *CostCalculator) CalculateCost(model string, usage *Usage) float64 {
-	var inputCost, outputCost float64
-
-	switch model {
-	case ModelOpus4:
-		inputCost = 15.0 / 1_000_000  // $15 per MTok
-		outputCost = 75.0 / 1_000_000 // $75 per MTok
-	case ModelSonnet4:
-		inputCost = 3.0 / 1_000_000   // $3 per MTok
-		outputCost = 15.0 / 1_000_000 // $15 per MTok
-	default:
-		// Default to Sonnet pricing
-		inputCost = 3.0 / 1_000_000
-		outputCost = 15.0 / 1_000_000
-	}
-
-	// Cached tokens cost 10% of normal input cost
-	cachedCost := float64(usage.CacheReadInputTokens) * inputCost * 0.1
-
-	// Normal input tokens (excluding cached)
-	normalInputTokens := usage.InputTokens - usage.CacheReadInputTokens
-	normalInputCost := float64(normalInputTokens) * inputCost
-
-	// Output tokens
-	outputCostTotal := float64(usage.OutputTokens) * outputCost
-
-	return cachedCost + normalInputCost + outputCostTotal
-}
-
 // GetExtractedText returns the text content from a response
 func (r *Response) GetExtractedText() string {
 	if len(r.Content) == 0 {
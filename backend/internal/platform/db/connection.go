@@ -57,3 +57,8 @@ func (db *DB) EnableExtensions() error {
 
 	return nil
 }
+
+// Execer is satisfied by *DB, *sql.DB and *sql.Tx, so a write can join the caller's transaction
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
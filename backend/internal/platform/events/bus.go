@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Bus is the event bus used by modules and the worker.
+// NATSBus is the production implementation; MemoryBus runs everything in-process.
+type Bus interface {
+	// Publish sends an event to all subscribers of its type. Events whose payload does not
+	// match the registered schema are rejected.
+	Publish(ctx context.Context, event *Event) error
+
+	// Subscribe registers a handler for an event type, or for every type with AllEvents.
+	// Must be called before Start.
+	Subscribe(eventType EventType, handler EventHandler) error
+
+	// Listen passes every event published from now on to handler, in every process that listens,
+	// until ctx is cancelled. Unlike Subscribe there is no durable consumer: handler errors are
+	// only logged, and events published while nobody listens are not kept. Dead letters are
+	// not included. May be called before or after Start.
+	Listen(ctx context.Context, handler EventHandler) error
+
+	// ConfigureConsumer overrides delivery settings for an event type. Must be called before Start.
+	ConfigureConsumer(eventType EventType, config ConsumerConfig)
+
+	// Start begins delivering events to handlers and blocks until ctx is cancelled
+	Start(ctx context.Context) error
+
+	// Close releases the bus's resources
+	Close() error
+}
+
+// AllEvents subscribes a handler to every event type (events.>, excluding dead letters).
+// It is a consumer of its own, so its acks and redeliveries do not affect per-type subscribers.
+const AllEvents EventType = ">"
+
+// ConsumerConfig controls delivery of one event type to its subscribers
+type ConsumerConfig struct {
+	// MaxDeliver is the number of deliveries before the event is moved to the dead-letter subject
+	MaxDeliver int
+
+	// AckWait is how long the server waits for an ack (or progress) before redelivering.
+	// Only meaningful for NATS; in-process handlers cannot be lost mid-flight.
+	AckWait time.Duration
+
+	// NakDelay is the redelivery delay after the first failure; it doubles per attempt up to MaxNakDelay
+	NakDelay    time.Duration
+	MaxNakDelay time.Duration
+}
+
+// DefaultConsumerConfig returns the delivery settings used when an event type has no explicit config
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		MaxDeliver:  5,
+		AckWait:     30 * time.Second,
+		NakDelay:    5 * time.Second,
+		MaxNakDelay: 5 * time.Minute,
+	}
+}
+
+// nakDelay returns the backoff before redelivering after a failed attempt
+func (c ConsumerConfig) nakDelay(attempt int) time.Duration {
+	delay := c.NakDelay
+	for i := 1; i < attempt && delay < c.MaxNakDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxNakDelay {
+		delay = c.MaxNakDelay
+	}
+	return delay
+}
+
+// withDefaults fills zero fields from DefaultConsumerConfig
+func (c ConsumerConfig) withDefaults() ConsumerConfig {
+	defaults := DefaultConsumerConfig()
+	if c.MaxDeliver <= 0 {
+		c.MaxDeliver = defaults.MaxDeliver
+	}
+	if c.AckWait <= 0 {
+		c.AckWait = defaults.AckWait
+	}
+	if c.NakDelay <= 0 {
+		c.NakDelay = defaults.NakDelay
+	}
+	if c.MaxNakDelay <= 0 {
+		c.MaxNakDelay = defaults.MaxNakDelay
+	}
+	if c.MaxNakDelay < c.NakDelay {
+		c.MaxNakDelay = c.NakDelay
+	}
+	return c
+}
@@ -0,0 +1,299 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compile-time check that MemoryBus satisfies Bus
+var _ Bus = (*MemoryBus)(nil)
+
+// DeadLetter is an event that exhausted its deliveries on a MemoryBus
+type DeadLetter struct {
+	Event    *Event    `json:"event"`
+	Subject  string    `json:"subject"` // Original subject, e.g. events.artifact.uploaded
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// MemoryBus is an in-process event bus with the same delivery semantics as NATSBus:
+// events published before Start are retained and delivered once it starts, each event is
+// handled concurrently and settled only after all handlers return, failures are redelivered
+// with backoff up to MaxDeliver, and exhausted events are dead-lettered.
+// Events are round-tripped through JSON so handlers see exactly what they would over NATS.
+//
+// Nothing is persisted: undelivered events are lost when the process exits.
+type MemoryBus struct {
+	mu          sync.RWMutex
+	handlers    map[EventType][]EventHandler
+	consumers   map[EventType]ConsumerConfig
+	backlog     [][]byte
+	ctx         context.Context
+	sequence    uint64
+	listeners   []listener
+	deadLetters []DeadLetter
+	closed      bool
+	inFlight    sync.WaitGroup
+}
+
+// NewMemoryBus creates a new in-process event bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers:  make(map[EventType][]EventHandler),
+		consumers: make(map[EventType]ConsumerConfig),
+	}
+}
+
+// Publish delivers an event to subscribers of its type, or retains it until Start
+func (b *MemoryBus) Publish(ctx context.Context, event *Event) error {
+	if err := ValidateEvent(event); err != nil {
+		return err
+	}
+
+	// Marshal event to JSON
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("failed to publish event: bus is closed")
+	}
+
+	if b.ctx == nil {
+		b.backlog = append(b.backlog, data)
+	} else {
+		b.enqueue(data)
+	}
+
+	log.Printf("Published event: %s (ID: %s)", event.Type, event.ID)
+	return nil
+}
+
+// Subscribe registers a handler for an event type
+func (b *MemoryBus) Subscribe(eventType EventType, handler EventHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+	return nil
+}
+
+// listener is a handler registered with Listen
+type listener struct {
+	ctx     context.Context
+	handler EventHandler
+}
+
+// Listen passes every event published after Start to handler until ctx is cancelled
+func (b *MemoryBus) Listen(ctx context.Context, handler EventHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, listener{ctx: ctx, handler: handler})
+	return nil
+}
+
+// ConfigureConsumer overrides delivery settings for an event type. Must be called before Start.
+// Zero fields fall back to DefaultConsumerConfig.
+func (b *MemoryBus) ConfigureConsumer(eventType EventType, config ConsumerConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consumers[eventType] = config.withDefaults()
+}
+
+// Start delivers retained events, then blocks until ctx is cancelled
+func (b *MemoryBus) Start(ctx context.Context) error {
+	b.mu.Lock()
+	if b.ctx != nil {
+		b.mu.Unlock()
+		return fmt.Errorf("event bus already started")
+	}
+	b.ctx = ctx
+	for _, data := range b.backlog {
+		b.enqueue(data)
+	}
+	b.backlog = nil
+	b.mu.Unlock()
+
+	// Wait for context cancellation
+	<-ctx.Done()
+	return nil
+}
+
+// Close stops accepting new events
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+// Wait blocks until every delivered event has been settled, including scheduled redeliveries.
+// Useful in tests to wait for a publish to be fully handled.
+func (b *MemoryBus) Wait() {
+	b.inFlight.Wait()
+}
+
+// DeadLetters returns the events that exhausted their deliveries
+func (b *MemoryBus) DeadLetters() []DeadLetter {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	deadLetters := make([]DeadLetter, len(b.deadLetters))
+	copy(deadLetters, b.deadLetters)
+	return deadLetters
+}
+
+// enqueue starts delivery of a published event to each consumer with handlers: the event's
+// own type and AllEvents, mirroring the separate durable consumers of NATSBus. Caller must hold b.mu.
+func (b *MemoryBus) enqueue(data []byte) {
+	b.sequence++
+	delivery := DeliveryInfo{
+		Attempt:        1,
+		StreamSequence: b.sequence,
+		PublishedAt:    time.Now(),
+	}
+
+	// Parse event; a malformed message will never succeed, so dead-letter it right away
+	var envelope struct {
+		Type EventType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		b.deadLetters = append(b.deadLetters, DeadLetter{
+			Subject:  "events.unknown",
+			Error:    fmt.Sprintf("failed to unmarshal event: %v", err),
+			Attempts: delivery.Attempt,
+			FailedAt: time.Now(),
+		})
+		log.Printf("Dead-lettered malformed message: %v", err)
+		return
+	}
+
+	b.notifyListeners(data)
+
+	// No subscribers: NATS has no consumer for the subject either, so the event is simply dropped
+	for _, consumer := range []EventType{envelope.Type, AllEvents} {
+		if len(b.handlers[consumer]) == 0 {
+			continue
+		}
+		b.inFlight.Add(1)
+		go b.dispatch(data, consumer, delivery)
+	}
+}
+
+// notifyListeners hands an event to each active listener. Caller must hold b.mu.
+func (b *MemoryBus) notifyListeners(data []byte) {
+	active := b.listeners[:0]
+	for _, l := range b.listeners {
+		if l.ctx.Err() == nil {
+			active = append(active, l)
+		}
+	}
+	b.listeners = active
+
+	for _, l := range active {
+		l := l
+		b.inFlight.Add(1)
+		go func() {
+			defer b.inFlight.Done()
+
+			var event Event
+			if err := json.Unmarshal(data, &event); err != nil {
+				return
+			}
+			event.Delivery = DeliveryInfo{Attempt: 1, MaxAttempts: 1}
+
+			if err := l.handler(l.ctx, &event); err != nil {
+				log.Printf("Event listener error for %s: %v", event.Type, err)
+			}
+		}()
+	}
+}
+
+// consumerConfig returns the delivery settings for an event type
+func (b *MemoryBus) consumerConfig(eventType EventType) ConsumerConfig {
+	if config, ok := b.consumers[eventType]; ok {
+		return config
+	}
+	return DefaultConsumerConfig()
+}
+
+// dispatch runs a consumer's handlers for one delivery of an event, then acks,
+// schedules a redelivery, or dead-letters it. Each call owns one inFlight slot.
+func (b *MemoryBus) dispatch(data []byte, consumer EventType, delivery DeliveryInfo) {
+	defer b.inFlight.Done()
+
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		b.deadLetter(nil, "events.unknown", delivery.Attempt, fmt.Errorf("failed to unmarshal event: %w", err))
+		return
+	}
+
+	b.mu.RLock()
+	ctx := b.ctx
+	handlers := b.handlers[consumer]
+	config := b.consumerConfig(consumer)
+	b.mu.RUnlock()
+
+	delivery.MaxAttempts = config.MaxDeliver
+	event.Delivery = delivery
+
+	var handlerErrs []string
+	for _, handler := range handlers {
+		if err := handler(ctx, &event); err != nil {
+			log.Printf("Event handler error for %s (attempt %d/%d): %v", event.Type, delivery.Attempt, config.MaxDeliver, err)
+			handlerErrs = append(handlerErrs, err.Error())
+		}
+	}
+
+	// Shutting down: in-process events cannot outlive the process
+	if ctx.Err() != nil || len(handlerErrs) == 0 {
+		return
+	}
+
+	handlerErr := fmt.Errorf("%s", strings.Join(handlerErrs, "; "))
+	if delivery.IsFinalAttempt() {
+		b.deadLetter(&event, fmt.Sprintf("events.%s", event.Type), delivery.Attempt, handlerErr)
+		return
+	}
+
+	// Redeliver after backoff, holding an inFlight slot so Wait covers the retry
+	delay := config.nakDelay(delivery.Attempt)
+	next := delivery
+	next.Attempt++
+	b.inFlight.Add(1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.inFlight.Done()
+		case <-time.After(delay):
+			b.dispatch(data, consumer, next)
+		}
+	}()
+}
+
+// deadLetter records an event that will not be delivered again
+func (b *MemoryBus) deadLetter(event *Event, subject string, attempts int, cause error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deadLetters = append(b.deadLetters, DeadLetter{
+		Event:    event,
+		Subject:  subject,
+		Error:    cause.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	})
+
+	log.Printf("Dead-lettered message from %s after %d attempts: %v", subject, attempts, cause)
+}
@@ -0,0 +1,237 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// startMemoryBus starts the bus in the background and stops it when the test ends
+func startMemoryBus(t *testing.T, bus *MemoryBus) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	go bus.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		bus.Close()
+	})
+}
+
+func TestMemoryBus_DeliversEventsPublishedBeforeStart(t *testing.T) {
+	bus := NewMemoryBus()
+
+	var mu sync.Mutex
+	var received []*Event
+	bus.Subscribe(ArtifactUploaded, func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event)
+		return nil
+	})
+
+	artifactID := uuid.New()
+	event := NewEvent(ArtifactUploaded, uuid.New(), "artifacts", map[string]interface{}{
+		"artifact_id": artifactID.String(),
+		"size":        42,
+	})
+	if err := bus.Publish(context.Background(), event); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	startMemoryBus(t, bus)
+	waitForBus(t, bus)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(received))
+	}
+	if received[0].ID != event.ID {
+		t.Fatalf("expected event %s, got %s", event.ID, received[0].ID)
+	}
+	if received[0].Payload["artifact_id"] != artifactID.String() {
+		t.Fatalf("unexpected artifact_id: %v", received[0].Payload["artifact_id"])
+	}
+	// Payload is JSON round-tripped, exactly as over NATS
+	if _, ok := received[0].Payload["size"].(float64); !ok {
+		t.Fatalf("expected numeric payload to decode as float64, got %T", received[0].Payload["size"])
+	}
+	if received[0].Delivery.Attempt != 1 || received[0].Delivery.IsRedelivery() {
+		t.Fatalf("expected first delivery, got attempt %d", received[0].Delivery.Attempt)
+	}
+}
+
+func TestMemoryBus_RedeliversUntilHandlerSucceeds(t *testing.T) {
+	bus := NewMemoryBus()
+	bus.ConfigureConsumer(ArtifactUploaded, ConsumerConfig{MaxDeliver: 3, NakDelay: time.Millisecond})
+
+	var mu sync.Mutex
+	var attempts []int
+	bus.Subscribe(ArtifactUploaded, func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, event.Delivery.Attempt)
+		if event.Delivery.Attempt < 2 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+
+	startMemoryBus(t, bus)
+	bus.Publish(context.Background(), newUploadEvent(t))
+	waitForBus(t, bus)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Fatalf("expected attempts [1 2], got %v", attempts)
+	}
+	if len(bus.DeadLetters()) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(bus.DeadLetters()))
+	}
+}
+
+func TestMemoryBus_DeadLettersAfterMaxDeliver(t *testing.T) {
+	bus := NewMemoryBus()
+	bus.ConfigureConsumer(ArtifactUploaded, ConsumerConfig{MaxDeliver: 2, NakDelay: time.Millisecond})
+
+	bus.Subscribe(ArtifactUploaded, func(ctx context.Context, event *Event) error {
+		return errors.New("poison message")
+	})
+
+	startMemoryBus(t, bus)
+	event := newUploadEvent(t)
+	bus.Publish(context.Background(), event)
+	waitForBus(t, bus)
+
+	deadLetters := bus.DeadLetters()
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(deadLetters))
+	}
+	if deadLetters[0].Event.ID != event.ID {
+		t.Fatalf("expected dead letter for event %s, got %s", event.ID, deadLetters[0].Event.ID)
+	}
+	if deadLetters[0].Attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", deadLetters[0].Attempts)
+	}
+	if deadLetters[0].Error != "poison message" {
+		t.Fatalf("unexpected error: %s", deadLetters[0].Error)
+	}
+}
+
+func TestMemoryBus_AllEventsIsAnIndependentConsumer(t *testing.T) {
+	bus := NewMemoryBus()
+	bus.ConfigureConsumer(AllEvents, ConsumerConfig{MaxDeliver: 2, NakDelay: time.Millisecond})
+
+	var mu sync.Mutex
+	typed := 0
+	var all []EventType
+	bus.Subscribe(ArtifactUploaded, func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		typed++
+		return nil
+	})
+	bus.Subscribe(AllEvents, func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		all = append(all, event.Type)
+		if event.Type == ArtifactUploaded && event.Delivery.Attempt == 1 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+
+	startMemoryBus(t, bus)
+	bus.Publish(context.Background(), newUploadEvent(t))
+	bus.Publish(context.Background(), NewEvent("test.unregistered", uuid.New(), "test", nil))
+	waitForBus(t, bus)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if typed != 1 {
+		t.Fatalf("expected the per-type handler to run once, ran %d times", typed)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 AllEvents deliveries (one retried), got %v", all)
+	}
+}
+
+func TestMemoryBus_ListenIsNotRetried(t *testing.T) {
+	bus := NewMemoryBus()
+	startMemoryBus(t, bus)
+
+	// Registered after Start, as the API does
+	var mu sync.Mutex
+	calls := 0
+	bus.Listen(context.Background(), func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return errors.New("listener failure")
+	})
+
+	bus.Publish(context.Background(), newUploadEvent(t))
+	waitForBus(t, bus)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("expected the listener to see the event once, saw it %d times", calls)
+	}
+}
+
+func TestMemoryBus_PublishAfterCloseFails(t *testing.T) {
+	bus := NewMemoryBus()
+	bus.Close()
+
+	err := bus.Publish(context.Background(), newUploadEvent(t))
+	if err == nil {
+		t.Fatal("expected error publishing to closed bus, got nil")
+	}
+}
+
+func TestMemoryBus_RejectsInvalidPayload(t *testing.T) {
+	bus := NewMemoryBus()
+	defer bus.Close()
+
+	err := bus.Publish(context.Background(), NewEvent(ArtifactUploaded, uuid.New(), "artifacts", nil))
+	if err == nil {
+		t.Fatal("expected error publishing artifact.uploaded without artifact_id, got nil")
+	}
+}
+
+// newUploadEvent builds a valid artifact.uploaded event
+func newUploadEvent(t *testing.T) *Event {
+	t.Helper()
+	event, err := NewEventFromPayload(ArtifactUploaded, uuid.New(), "artifacts", ArtifactUploadedPayload{
+		ArtifactID: uuid.New(),
+		Trigger:    UploadTriggerUpload,
+	})
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	return event
+}
+
+// waitForBus waits for all in-flight deliveries, failing the test if they hang
+func waitForBus(t *testing.T, bus *MemoryBus) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		// Give Start a moment to flush the backlog before waiting
+		time.Sleep(10 * time.Millisecond)
+		bus.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event delivery")
+	}
+}
@@ -5,18 +5,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
+const (
+	// streamName is the JetStream stream holding all events
+	streamName = "EVENTS"
+
+	// DeadLetterSubjectPrefix is prepended to an event's subject when it exhausts its deliveries,
+	// e.g. events.artifact.uploaded -> events.dlq.artifact.uploaded
+	DeadLetterSubjectPrefix = "events.dlq."
+
+	// Headers attached to dead-lettered messages. The body is the original event.
+	HeaderDeadLetterError   = "Cerberus-Dlq-Error"
+	HeaderDeadLetterSubject = "Cerberus-Dlq-Original-Subject"
+	HeaderDeadLetterAttempt = "Cerberus-Dlq-Attempts"
+	HeaderDeadLetterFailed  = "Cerberus-Dlq-Failed-At"
+)
+
+// serverMaxDeliver is the MaxDeliver set on the JetStream consumer. It allows one delivery beyond
+// MaxDeliver so a message whose final attempt crashed, or whose dead-letter publish failed,
+// comes back once more and is dead-lettered instead of silently dropped by the server.
+func (c ConsumerConfig) serverMaxDeliver() int {
+	return c.MaxDeliver + 1
+}
+
+// Compile-time check that NATSBus satisfies Bus
+var _ Bus = (*NATSBus)(nil)
+
 // NATSBus implements event bus using NATS JetStream
 type NATSBus struct {
-	conn     *nats.Conn
-	js       nats.JetStreamContext
-	handlers map[EventType][]EventHandler
-	mu       sync.RWMutex
+	conn      *nats.Conn
+	js        nats.JetStreamContext
+	handlers  map[EventType][]EventHandler
+	consumers map[EventType]ConsumerConfig
+	mu        sync.RWMutex
 }
 
 // NewNATSBus creates a new NATS-based event bus
@@ -33,9 +61,9 @@ func NewNATSBus(url string) (*NATSBus, error) {
 		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
 	}
 
-	// Create stream for events
+	// Create stream for events (dead letters live under events.dlq.> in the same stream)
 	_, err = js.AddStream(&nats.StreamConfig{
-		Name:     "EVENTS",
+		Name:     streamName,
 		Subjects: []string{"events.>"},
 		Storage:  nats.FileStorage,
 	})
@@ -45,14 +73,19 @@ func NewNATSBus(url string) (*NATSBus, error) {
 	}
 
 	return &NATSBus{
-		conn:     conn,
-		js:       js,
-		handlers: make(map[EventType][]EventHandler),
+		conn:      conn,
+		js:        js,
+		handlers:  make(map[EventType][]EventHandler),
+		consumers: make(map[EventType]ConsumerConfig),
 	}, nil
 }
 
 // Publish publishes an event to NATS
 func (b *NATSBus) Publish(ctx context.Context, event *Event) error {
+	if err := ValidateEvent(event); err != nil {
+		return err
+	}
+
 	// Marshal event to JSON
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -79,6 +112,52 @@ func (b *NATSBus) Subscribe(eventType EventType, handler EventHandler) error {
 	return nil
 }
 
+// Listen subscribes handler to events.> with a plain (non-JetStream) subscription, so every
+// listening process receives every event as it is published
+func (b *NATSBus) Listen(ctx context.Context, handler EventHandler) error {
+	sub, err := b.conn.Subscribe("events.>", func(msg *nats.Msg) {
+		if strings.HasPrefix(msg.Subject, DeadLetterSubjectPrefix) {
+			return
+		}
+
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal event on %s: %v", msg.Subject, err)
+			return
+		}
+		event.Delivery = DeliveryInfo{Attempt: 1, MaxAttempts: 1}
+
+		if err := handler(ctx, &event); err != nil {
+			log.Printf("Event listener error for %s: %v", event.Type, err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to listen to events: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		sub.Unsubscribe()
+	}()
+	return nil
+}
+
+// ConfigureConsumer overrides delivery settings for an event type. Must be called before Start.
+// Zero fields fall back to DefaultConsumerConfig.
+func (b *NATSBus) ConfigureConsumer(eventType EventType, config ConsumerConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consumers[eventType] = config.withDefaults()
+}
+
+// consumerConfig returns the delivery settings for an event type
+func (b *NATSBus) consumerConfig(eventType EventType) ConsumerConfig {
+	if config, ok := b.consumers[eventType]; ok {
+		return config
+	}
+	return DefaultConsumerConfig()
+}
+
 // Start begins processing events
 func (b *NATSBus) Start(ctx context.Context) error {
 	b.mu.RLock()
@@ -86,41 +165,37 @@ func (b *NATSBus) Start(ctx context.Context) error {
 
 	// Subscribe to all registered event types
 	for eventType := range b.handlers {
+		eventType := eventType
 		subject := fmt.Sprintf("events.%s", eventType)
+		config := b.consumerConfig(eventType)
+		consumerName := durableName(eventType)
 
-		// Create durable consumer (replace periods with underscores for valid NATS name)
-		consumerName := strings.ReplaceAll(string(eventType), ".", "_") + "_consumer"
+		policy := deliverPolicy(eventType)
 
-		_, err := b.js.Subscribe(subject, func(msg *nats.Msg) {
-			// Parse event
-			var event Event
-			if err := json.Unmarshal(msg.Data, &event); err != nil {
-				log.Printf("Failed to unmarshal event: %v", err)
-				msg.Nak() // Negative acknowledge
-				return
-			}
-
-			// Call all handlers for this event type
-			b.mu.RLock()
-			handlers := b.handlers[event.Type]
-			b.mu.RUnlock()
+		if err := b.reconcileConsumer(consumerName, config, policy); err != nil {
+			return err
+		}
 
-			for _, handler := range handlers {
-				if err := handler(ctx, &event); err != nil {
-					log.Printf("Event handler error for %s: %v", event.Type, err)
-					// Don't nak on handler errors, just log
-				}
-			}
+		opts := []nats.SubOpt{
+			nats.Durable(consumerName),
+			nats.ManualAck(),
+			nats.AckWait(config.AckWait),
+			nats.MaxDeliver(config.serverMaxDeliver()),
+		}
+		if policy == nats.DeliverNewPolicy {
+			opts = append(opts, nats.DeliverNew())
+		}
 
-			// Acknowledge message
-			msg.Ack()
-		}, nats.Durable(consumerName))
+		_, err := b.js.Subscribe(subject, func(msg *nats.Msg) {
+			// Handle each message concurrently; the message is acked only once its handlers finish
+			go b.dispatch(ctx, msg, eventType, config)
+		}, opts...)
 
 		if err != nil {
 			return fmt.Errorf("failed to subscribe to %s: %w", subject, err)
 		}
 
-		log.Printf("Subscribed to event type: %s", eventType)
+		log.Printf("Subscribed to event type: %s (max deliver: %d, ack wait: %s)", eventType, config.MaxDeliver, config.AckWait)
 	}
 
 	// Wait for context cancellation
@@ -128,6 +203,167 @@ func (b *NATSBus) Start(ctx context.Context) error {
 	return nil
 }
 
+// durableName returns the durable consumer name for an event type
+// (periods become underscores for a valid NATS name)
+func durableName(eventType EventType) string {
+	if eventType == AllEvents {
+		return "all_events_consumer"
+	}
+	return strings.ReplaceAll(string(eventType), ".", "_") + "_consumer"
+}
+
+// deliverPolicy returns where a new durable consumer starts in the stream. The AllEvents
+// consumer starts at new events, so creating it does not replay the stream's history to
+// subscribers such as the webhook dispatcher.
+func deliverPolicy(eventType EventType) nats.DeliverPolicy {
+	if eventType == AllEvents {
+		return nats.DeliverNewPolicy
+	}
+	return nats.DeliverAllPolicy
+}
+
+// reconcileConsumer updates an existing durable consumer whose delivery settings differ from
+// config. The client refuses to bind to a consumer with mismatched settings otherwise. A
+// consumer's start policy cannot be changed, so a consumer with a different one is deleted and
+// recreated by the subscription.
+func (b *NATSBus) reconcileConsumer(consumerName string, config ConsumerConfig, policy nats.DeliverPolicy) error {
+	info, err := b.js.ConsumerInfo(streamName, consumerName)
+	if err == nats.ErrConsumerNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get consumer info for %s: %w", consumerName, err)
+	}
+
+	if info.Config.DeliverPolicy != policy {
+		if err := b.js.DeleteConsumer(streamName, consumerName); err != nil {
+			return fmt.Errorf("failed to delete consumer %s: %w", consumerName, err)
+		}
+		log.Printf("Recreating consumer %s with its new deliver policy", consumerName)
+		return nil
+	}
+
+	if info.Config.AckWait == config.AckWait && info.Config.MaxDeliver == config.serverMaxDeliver() {
+		return nil
+	}
+
+	updated := info.Config
+	updated.AckWait = config.AckWait
+	updated.MaxDeliver = config.serverMaxDeliver()
+	if _, err := b.js.UpdateConsumer(streamName, &updated); err != nil {
+		return fmt.Errorf("failed to update consumer %s: %w", consumerName, err)
+	}
+
+	log.Printf("Updated consumer %s delivery settings", consumerName)
+	return nil
+}
+
+// dispatch runs all handlers for a message and settles it afterwards:
+// ack on success, NAK with backoff on failure, dead-letter once deliveries are exhausted.
+// While handlers run, the message is kept in progress so JetStream does not redeliver it.
+func (b *NATSBus) dispatch(ctx context.Context, msg *nats.Msg, eventType EventType, config ConsumerConfig) {
+	delivery := DeliveryInfo{Attempt: 1, MaxAttempts: config.MaxDeliver}
+	if meta, err := msg.Metadata(); err == nil {
+		delivery.Attempt = int(meta.NumDelivered)
+		delivery.StreamSequence = meta.Sequence.Stream
+		delivery.PublishedAt = meta.Timestamp
+	}
+
+	// The AllEvents consumer also matches dead letters, which were already delivered
+	if strings.HasPrefix(msg.Subject, DeadLetterSubjectPrefix) {
+		msg.Ack()
+		return
+	}
+
+	// Parse event; a malformed message will never succeed, so dead-letter it right away
+	var event Event
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		log.Printf("Failed to unmarshal event: %v", err)
+		b.deadLetter(msg, delivery, fmt.Errorf("failed to unmarshal event: %w", err))
+		return
+	}
+	event.Delivery = delivery
+
+	// Deliveries are exhausted (the final attempt never settled); don't run handlers again
+	if delivery.Attempt > config.MaxDeliver {
+		b.deadLetter(msg, delivery, fmt.Errorf("exceeded %d deliveries without being acknowledged", config.MaxDeliver))
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(config.AckWait / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				msg.InProgress()
+			}
+		}
+	}()
+
+	// Call all handlers of this consumer
+	b.mu.RLock()
+	handlers := b.handlers[eventType]
+	b.mu.RUnlock()
+
+	var handlerErrs []string
+	for _, handler := range handlers {
+		if err := handler(ctx, &event); err != nil {
+			log.Printf("Event handler error for %s (attempt %d/%d): %v", event.Type, delivery.Attempt, config.MaxDeliver, err)
+			handlerErrs = append(handlerErrs, err.Error())
+		}
+	}
+
+	// Shutting down mid-handler: hand the message straight back for another consumer
+	if ctx.Err() != nil {
+		msg.Nak()
+		return
+	}
+
+	if len(handlerErrs) == 0 {
+		msg.Ack()
+		return
+	}
+
+	handlerErr := fmt.Errorf("%s", strings.Join(handlerErrs, "; "))
+	if delivery.IsFinalAttempt() {
+		b.deadLetter(msg, delivery, handlerErr)
+		return
+	}
+
+	delay := config.nakDelay(delivery.Attempt)
+	if err := msg.NakWithDelay(delay); err != nil {
+		log.Printf("Failed to NAK event %s: %v", event.ID, err)
+	}
+}
+
+// deadLetter republishes a message to events.dlq.<type> with the error attached,
+// then terminates the original so it is not redelivered
+func (b *NATSBus) deadLetter(msg *nats.Msg, delivery DeliveryInfo, cause error) {
+	subject := DeadLetterSubjectPrefix + strings.TrimPrefix(msg.Subject, "events.")
+
+	dlqMsg := nats.NewMsg(subject)
+	dlqMsg.Data = msg.Data
+	dlqMsg.Header.Set(HeaderDeadLetterError, cause.Error())
+	dlqMsg.Header.Set(HeaderDeadLetterSubject, msg.Subject)
+	dlqMsg.Header.Set(HeaderDeadLetterAttempt, strconv.Itoa(delivery.Attempt))
+	dlqMsg.Header.Set(HeaderDeadLetterFailed, time.Now().UTC().Format(time.RFC3339))
+
+	if _, err := b.js.PublishMsg(dlqMsg); err != nil {
+		// Leave the message to be redelivered (one spare delivery) rather than lose it
+		log.Printf("Failed to dead-letter message from %s: %v", msg.Subject, err)
+		msg.Nak()
+		return
+	}
+
+	log.Printf("Dead-lettered message from %s to %s after %d attempts: %v", msg.Subject, subject, delivery.Attempt, cause)
+	msg.Term()
+}
+
 // Close closes the NATS connection
 func (b *NATSBus) Close() error {
 	if b.conn != nil {
@@ -135,4 +371,3 @@ func (b *NATSBus) Close() error {
 	}
 	return nil
 }
-
@@ -0,0 +1,284 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// WriteOutbox records an event in event_outbox. Pass the transaction that makes the domain
+// change so the event is published if and only if the change commits.
+// Invalid payloads are rejected here, failing the transaction rather than the later publish.
+func WriteOutbox(ctx context.Context, tx db.Execer, event *Event) error {
+	if err := ValidateEvent(event); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var programID uuid.NullUUID
+	if event.ProgramID != uuid.Nil {
+		programID = uuid.NullUUID{UUID: event.ProgramID, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_outbox (event_id, event_type, program_id, event, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, event.ID, string(event.Type), programID, data, event.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to write event to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// OutboxRelayConfig controls how the relay drains event_outbox
+type OutboxRelayConfig struct {
+	PollInterval  time.Duration // How often to look for pending events when idle
+	BatchSize     int           // Events claimed per transaction
+	RetryDelay    time.Duration // Delay after the first failed publish; doubles up to MaxRetryDelay
+	MaxRetryDelay time.Duration
+	Retention     time.Duration // Sent events older than this are purged
+}
+
+// DefaultOutboxRelayConfig returns the relay settings used by the API and worker
+func DefaultOutboxRelayConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{
+		PollInterval:  time.Second,
+		BatchSize:     100,
+		RetryDelay:    5 * time.Second,
+		MaxRetryDelay: 5 * time.Minute,
+		Retention:     7 * 24 * time.Hour,
+	}
+}
+
+// OutboxRelay publishes pending event_outbox rows to the bus with at-least-once delivery.
+// Rows are claimed with FOR UPDATE SKIP LOCKED, so several relays (API replicas and workers) can run at once.
+// A row is marked sent only after Publish succeeds; if the process dies in between, the event
+// is published again, so consumers must tolerate duplicates (the event ID is preserved).
+type OutboxRelay struct {
+	db     *db.DB
+	bus    Bus
+	config OutboxRelayConfig
+}
+
+// NewOutboxRelay creates a relay from event_outbox to bus
+func NewOutboxRelay(database *db.DB, bus Bus, config OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		db:     database,
+		bus:    bus,
+		config: config,
+	}
+}
+
+// Run relays pending events until ctx is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	purgeTicker := time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	for {
+		// Drain everything that is due before sleeping again
+		for {
+			relayed, err := r.RelayBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Outbox relay error: %v", err)
+				}
+				break
+			}
+			if relayed < r.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-purgeTicker.C:
+			if err := r.purgeSent(ctx); err != nil {
+				log.Printf("Failed to purge sent outbox events: %v", err)
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes up to BatchSize due events in outbox order and returns how many it claimed
+func (r *OutboxRelay) RelayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT outbox_id, event, attempts
+		FROM event_outbox
+		WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY outbox_id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, r.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	type pendingEvent struct {
+		outboxID int64
+		data     []byte
+		attempts int
+	}
+	var pending []pendingEvent
+	for rows.Next() {
+		var p pendingEvent
+		if err := rows.Scan(&p.outboxID, &p.data, &p.attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	for _, p := range pending {
+		publishErr := r.publish(ctx, p.data)
+		if publishErr == nil {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE event_outbox SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL
+				WHERE outbox_id = $1
+			`, p.outboxID)
+		} else {
+			log.Printf("Failed to relay outbox event %d (attempt %d): %v", p.outboxID, p.attempts+1, publishErr)
+			delay := r.retryDelay(p.attempts + 1)
+			_, err = tx.ExecContext(ctx, `
+				UPDATE event_outbox
+				SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+				WHERE outbox_id = $1
+			`, p.outboxID, publishErr.Error(), delay.Milliseconds())
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update outbox event %d: %w", p.outboxID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox batch: %w", err)
+	}
+
+	return len(pending), nil
+}
+
+// publish decodes a stored event and hands it to the bus
+func (r *OutboxRelay) publish(ctx context.Context, data []byte) error {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return r.bus.Publish(ctx, &event)
+}
+
+// retryDelay returns the backoff after a failed publish
+func (r *OutboxRelay) retryDelay(attempt int) time.Duration {
+	delay := r.config.RetryDelay
+	for i := 1; i < attempt && delay < r.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxRetryDelay {
+		delay = r.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// purgeSent deletes sent events older than the retention period
+func (r *OutboxRelay) purgeSent(ctx context.Context) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM event_outbox
+		WHERE sent_at IS NOT NULL AND sent_at < NOW() - $1 * INTERVAL '1 second'
+	`, int64(r.config.Retention.Seconds()))
+	if err != nil {
+		return err
+	}
+
+	if purged, _ := result.RowsAffected(); purged > 0 {
+		log.Printf("Purged %d sent outbox events", purged)
+	}
+	return nil
+}
+
+// OutboxReader reads events back from event_outbox. Sent events are kept for the relay's
+// retention period, so it serves as a shared, durable event history.
+type OutboxReader struct {
+	db *db.DB
+}
+
+// NewOutboxReader creates a reader over event_outbox
+func NewOutboxReader(database *db.DB) *OutboxReader {
+	return &OutboxReader{db: database}
+}
+
+// EventsAfter returns up to limit sent events of programID with one of types that were written
+// after the event afterID, oldest first. found is false if afterID is not in the outbox, because
+// it was never written or has been purged.
+func (r *OutboxReader) EventsAfter(ctx context.Context, programID, afterID uuid.UUID, types []EventType, limit int) ([]*Event, bool, error) {
+	var afterOutboxID int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT outbox_id FROM event_outbox WHERE event_id = $1
+	`, afterID).Scan(&afterOutboxID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up event %s: %w", afterID, err)
+	}
+
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event
+		FROM event_outbox
+		WHERE program_id = $1 AND outbox_id > $2 AND event_type = ANY($3) AND sent_at IS NOT NULL
+		ORDER BY outbox_id
+		LIMIT $4
+	`, programID, afterOutboxID, pq.Array(typeNames), limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Event
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, false, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		result = append(result, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	return result, true, nil
+}
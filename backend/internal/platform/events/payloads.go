@@ -0,0 +1,345 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Typed payloads for domain events. Publishers build events with NewEventFromPayload and
+// subscribers read them back with DecodePayload; the schemas and their versions are
+// registered in newDefaultPayloadRegistry at the bottom of this file.
+
+// Triggers of an artifact.uploaded event
+const (
+	UploadTriggerUpload     = "upload"     // A new artifact was stored
+	UploadTriggerReanalysis = "reanalysis" // A user asked for the artifact to be analyzed again
+	UploadTriggerRequeue    = "requeue"    // A dead-lettered artifact was sent back through the pipeline
+)
+
+// ArtifactUploadedPayload is published when an artifact is queued for analysis.
+// Version 2 replaced the reanalysis/requeued flags of version 1 with Trigger.
+type ArtifactUploadedPayload struct {
+	ArtifactID   uuid.UUID `json:"artifact_id"`
+	Trigger      string    `json:"trigger"`
+	DeadLetterID uuid.UUID `json:"dead_letter_id"` // uuid.Nil unless Trigger is requeue
+}
+
+// Validate implements Payload
+func (p ArtifactUploadedPayload) Validate() error {
+	if err := requireID("artifact_id", p.ArtifactID); err != nil {
+		return err
+	}
+	if err := requireOneOf("trigger", p.Trigger, UploadTriggerUpload, UploadTriggerReanalysis, UploadTriggerRequeue); err != nil {
+		return err
+	}
+	if p.Trigger == UploadTriggerRequeue {
+		return requireID("dead_letter_id", p.DeadLetterID)
+	}
+	return nil
+}
+
+// upgradeArtifactUploadedV1 folds the version 1 reanalysis/requeued flags into trigger
+func upgradeArtifactUploadedV1(fields map[string]interface{}) error {
+	trigger := UploadTriggerUpload
+	if requeued, _ := fields["requeued"].(bool); requeued {
+		trigger = UploadTriggerRequeue
+	} else if reanalysis, _ := fields["reanalysis"].(bool); reanalysis {
+		trigger = UploadTriggerReanalysis
+	}
+
+	fields["trigger"] = trigger
+	delete(fields, "requeued")
+	delete(fields, "reanalysis")
+	return nil
+}
+
+// ArtifactAnalyzedPayload is published when an artifact completes the processing pipeline
+type ArtifactAnalyzedPayload struct {
+	ArtifactID uuid.UUID `json:"artifact_id"`
+}
+
+// Validate implements Payload
+func (p ArtifactAnalyzedPayload) Validate() error {
+	return requireID("artifact_id", p.ArtifactID)
+}
+
+// ArtifactDeadLetteredPayload is published when an artifact exhausts a pipeline stage's retries
+type ArtifactDeadLetteredPayload struct {
+	ArtifactID   uuid.UUID `json:"artifact_id"`
+	DeadLetterID uuid.UUID `json:"dead_letter_id"`
+	Stage        string    `json:"stage"`
+	Error        string    `json:"error"`
+	Attempts     int       `json:"attempts"`
+}
+
+// Validate implements Payload
+func (p ArtifactDeadLetteredPayload) Validate() error {
+	if err := requireID("artifact_id", p.ArtifactID); err != nil {
+		return err
+	}
+	if err := requireID("dead_letter_id", p.DeadLetterID); err != nil {
+		return err
+	}
+	return requireString("stage", p.Stage)
+}
+
+// ArtifactOCRCompletedPayload is published when text has been extracted from a scanned artifact
+// and the artifact is queued for analysis
+type ArtifactOCRCompletedPayload struct {
+	ArtifactID     uuid.UUID `json:"artifact_id"`
+	CharacterCount int       `json:"character_count"`
+	ChunkCount     int       `json:"chunk_count"`
+}
+
+// Validate implements Payload
+func (p ArtifactOCRCompletedPayload) Validate() error {
+	return requireID("artifact_id", p.ArtifactID)
+}
+
+// RiskIdentifiedPayload is published when a risk enters the register
+type RiskIdentifiedPayload struct {
+	RiskID       uuid.UUID `json:"risk_id"`
+	SuggestionID uuid.UUID `json:"suggestion_id"` // uuid.Nil unless the risk came from an approved AI suggestion
+	Title        string    `json:"title"`
+	Category     string    `json:"category"`
+	Probability  string    `json:"probability"`
+	Impact       string    `json:"impact"`
+	Severity     string    `json:"severity"`
+}
+
+// Validate implements Payload
+func (p RiskIdentifiedPayload) Validate() error {
+	if err := requireID("risk_id", p.RiskID); err != nil {
+		return err
+	}
+	if err := requireString("title", p.Title); err != nil {
+		return err
+	}
+	return requireSeverity("severity", p.Severity)
+}
+
+// RiskEscalatedPayload is published when a risk's severity is raised
+type RiskEscalatedPayload struct {
+	RiskID           uuid.UUID `json:"risk_id"`
+	Title            string    `json:"title"`
+	PreviousSeverity string    `json:"previous_severity"`
+	Severity         string    `json:"severity"`
+	Probability      string    `json:"probability"`
+	Impact           string    `json:"impact"`
+}
+
+// Validate implements Payload
+func (p RiskEscalatedPayload) Validate() error {
+	if err := requireID("risk_id", p.RiskID); err != nil {
+		return err
+	}
+	if err := requireSeverity("previous_severity", p.PreviousSeverity); err != nil {
+		return err
+	}
+	return requireSeverity("severity", p.Severity)
+}
+
+// IssueCreatedPayload is published when a risk is realized and becomes an issue
+type IssueCreatedPayload struct {
+	RiskID         uuid.UUID `json:"risk_id"`
+	Title          string    `json:"title"`
+	Category       string    `json:"category"`
+	Severity       string    `json:"severity"`
+	PreviousStatus string    `json:"previous_status"`
+}
+
+// Validate implements Payload
+func (p IssueCreatedPayload) Validate() error {
+	if err := requireID("risk_id", p.RiskID); err != nil {
+		return err
+	}
+	return requireSeverity("severity", p.Severity)
+}
+
+// RiskSuggestedPayload is published when AI analysis proposes a risk for review
+type RiskSuggestedPayload struct {
+	SuggestionID      uuid.UUID   `json:"suggestion_id"`
+	Title             string      `json:"title"`
+	Category          string      `json:"category"`
+	Severity          string      `json:"severity"`
+	SourceType        string      `json:"source_type"`
+	SourceArtifactIDs []uuid.UUID `json:"source_artifact_ids,omitempty"`
+	SourceVarianceID  uuid.UUID   `json:"source_variance_id"` // uuid.Nil unless the suggestion came from a variance
+	ConfidenceScore   float64     `json:"confidence_score"`
+}
+
+// Validate implements Payload
+func (p RiskSuggestedPayload) Validate() error {
+	if err := requireID("suggestion_id", p.SuggestionID); err != nil {
+		return err
+	}
+	if err := requireString("title", p.Title); err != nil {
+		return err
+	}
+	return requireSeverity("severity", p.Severity)
+}
+
+// InvoiceProcessedPayload is published when an invoice has been extracted and validated
+type InvoiceProcessedPayload struct {
+	InvoiceID          uuid.UUID   `json:"invoice_id"`
+	ArtifactID         uuid.UUID   `json:"artifact_id"`
+	InvoiceNumber      string      `json:"invoice_number,omitempty"`
+	VendorName         string      `json:"vendor_name"`
+	TotalAmount        float64     `json:"total_amount"`
+	Currency           string      `json:"currency"`
+	LineItemCount      int         `json:"line_item_count"`
+	VarianceCount      int         `json:"variance_count"`
+	ReplacedInvoiceIDs []uuid.UUID `json:"replaced_invoice_ids,omitempty"`
+}
+
+// Validate implements Payload
+func (p InvoiceProcessedPayload) Validate() error {
+	return requireID("invoice_id", p.InvoiceID)
+}
+
+// InvoiceApprovedPayload is published when an invoice is approved for payment
+type InvoiceApprovedPayload struct {
+	InvoiceID   uuid.UUID `json:"invoice_id"`
+	VendorName  string    `json:"vendor_name"`
+	TotalAmount float64   `json:"total_amount"`
+	Currency    string    `json:"currency"`
+	ApprovedBy  uuid.UUID `json:"approved_by"`
+}
+
+// Validate implements Payload
+func (p InvoiceApprovedPayload) Validate() error {
+	return requireID("invoice_id", p.InvoiceID)
+}
+
+// VarianceDetectedPayload is published for each financial variance saved during invoice processing
+type VarianceDetectedPayload struct {
+	VarianceID         uuid.UUID   `json:"variance_id"`
+	InvoiceID          uuid.UUID   `json:"invoice_id"` // uuid.Nil for variances not tied to an invoice
+	VarianceType       string      `json:"variance_type"`
+	Severity           string      `json:"severity"`
+	Title              string      `json:"title"`
+	Description        string      `json:"description"`
+	VarianceAmount     float64     `json:"variance_amount"`
+	VariancePercentage float64     `json:"variance_percentage,omitempty"`
+	ConfidenceScore    float64     `json:"confidence_score"`
+	SourceArtifactIDs  []uuid.UUID `json:"source_artifact_ids,omitempty"`
+}
+
+// Validate implements Payload
+func (p VarianceDetectedPayload) Validate() error {
+	if err := requireID("variance_id", p.VarianceID); err != nil {
+		return err
+	}
+	if err := requireString("variance_type", p.VarianceType); err != nil {
+		return err
+	}
+	return requireSeverity("severity", p.Severity)
+}
+
+// BudgetThresholdExceededPayload is published when a budget category's utilization crosses an alert threshold
+type BudgetThresholdExceededPayload struct {
+	CategoryID         uuid.UUID `json:"category_id"`
+	CategoryName       string    `json:"category_name"`
+	FiscalYear         int       `json:"fiscal_year"`
+	BudgetedAmount     float64   `json:"budgeted_amount"`
+	ActualSpend        float64   `json:"actual_spend"`
+	CommittedSpend     float64   `json:"committed_spend"`
+	UtilizationPercent float64   `json:"utilization_percent"` // (actual + committed) / budgeted
+	ThresholdPercent   float64   `json:"threshold_percent"`   // Highest threshold crossed by this change
+}
+
+// Validate implements Payload
+func (p BudgetThresholdExceededPayload) Validate() error {
+	if err := requireID("category_id", p.CategoryID); err != nil {
+		return err
+	}
+	if p.ThresholdPercent <= 0 {
+		return fmt.Errorf("threshold_percent must be positive")
+	}
+	return nil
+}
+
+// InsightEventPayload is the payload of events raised from artifact insights
+// (DecisionExtracted and ChangeProposed)
+type InsightEventPayload struct {
+	InsightID       uuid.UUID `json:"insight_id"`
+	ArtifactID      uuid.UUID `json:"artifact_id"`
+	InsightType     string    `json:"insight_type"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Severity        string    `json:"severity,omitempty"`
+	SuggestedAction string    `json:"suggested_action,omitempty"`
+	ConfidenceScore float64   `json:"confidence_score"`
+}
+
+// Validate implements Payload
+func (p InsightEventPayload) Validate() error {
+	if err := requireID("insight_id", p.InsightID); err != nil {
+		return err
+	}
+	if err := requireID("artifact_id", p.ArtifactID); err != nil {
+		return err
+	}
+	return requireString("insight_type", p.InsightType)
+}
+
+// requireID rejects a missing identifier
+func requireID(field string, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("%s is required", field)
+	}
+	return nil
+}
+
+// requireString rejects an empty string field
+func requireString(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	return nil
+}
+
+// requireOneOf rejects a value outside the allowed set
+func requireOneOf(field, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %v, got %q", field, allowed, value)
+}
+
+// requireSeverity rejects anything but the severities used by risks and variances
+func requireSeverity(field, value string) error {
+	return requireOneOf(field, value, "low", "medium", "high", "critical")
+}
+
+// newDefaultPayloadRegistry registers the payload schema of every published event type
+func newDefaultPayloadRegistry() *PayloadRegistry {
+	r := NewPayloadRegistry()
+
+	r.Register(ArtifactUploaded, PayloadSchema{
+		Version:  2,
+		New:      func() Payload { return &ArtifactUploadedPayload{} },
+		Upgrades: map[int]PayloadUpgrade{1: upgradeArtifactUploadedV1},
+	})
+	r.Register(ArtifactAnalyzed, PayloadSchema{Version: 1, New: func() Payload { return &ArtifactAnalyzedPayload{} }})
+	r.Register(ArtifactDeadLettered, PayloadSchema{Version: 1, New: func() Payload { return &ArtifactDeadLetteredPayload{} }})
+	r.Register(ArtifactOCRCompleted, PayloadSchema{Version: 1, New: func() Payload { return &ArtifactOCRCompletedPayload{} }})
+
+	r.Register(RiskIdentified, PayloadSchema{Version: 1, New: func() Payload { return &RiskIdentifiedPayload{} }})
+	r.Register(RiskEscalated, PayloadSchema{Version: 1, New: func() Payload { return &RiskEscalatedPayload{} }})
+	r.Register(IssueCreated, PayloadSchema{Version: 1, New: func() Payload { return &IssueCreatedPayload{} }})
+	r.Register(RiskSuggested, PayloadSchema{Version: 1, New: func() Payload { return &RiskSuggestedPayload{} }})
+
+	r.Register(InvoiceProcessed, PayloadSchema{Version: 1, New: func() Payload { return &InvoiceProcessedPayload{} }})
+	r.Register(InvoiceApproved, PayloadSchema{Version: 1, New: func() Payload { return &InvoiceApprovedPayload{} }})
+	r.Register(VarianceDetected, PayloadSchema{Version: 1, New: func() Payload { return &VarianceDetectedPayload{} }})
+	r.Register(BudgetThresholdExceeded, PayloadSchema{Version: 1, New: func() Payload { return &BudgetThresholdExceededPayload{} }})
+
+	r.Register(DecisionExtracted, PayloadSchema{Version: 1, New: func() Payload { return &InsightEventPayload{} }})
+	r.Register(ChangeProposed, PayloadSchema{Version: 1, New: func() Payload { return &InsightEventPayload{} }})
+
+	return r
+}
@@ -0,0 +1,195 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Payload is the typed body of an event. Validate reports a payload that must not be published.
+type Payload interface {
+	Validate() error
+}
+
+// PayloadUpgrade rewrites a decoded payload from one schema version to the next, in place
+type PayloadUpgrade func(fields map[string]interface{}) error
+
+// PayloadSchema describes the current payload of an event type and how to read older versions
+type PayloadSchema struct {
+	Version  int                    // Current version, stamped on every event the registry encodes
+	New      func() Payload         // Returns a pointer to an empty payload of the current version
+	Upgrades map[int]PayloadUpgrade // Upgrades[n] turns a version n payload into version n+1
+}
+
+// PayloadRegistry maps event types to their payload schemas.
+//
+// Versioning rules: adding a field does not need a new version, because decoding ignores
+// unknown fields and leaves missing ones zero. Renaming, removing or changing the meaning of a
+// field bumps Version and registers an upgrade from the previous version, so consumers keep
+// reading events written before the change (from the outbox or the stream). A consumer that
+// receives a newer version than it knows decodes it as its own version, which is safe as long
+// as producers keep the old fields until every consumer is upgraded.
+type PayloadRegistry struct {
+	mu      sync.RWMutex
+	schemas map[EventType]PayloadSchema
+}
+
+// NewPayloadRegistry creates an empty registry
+func NewPayloadRegistry() *PayloadRegistry {
+	return &PayloadRegistry{
+		schemas: make(map[EventType]PayloadSchema),
+	}
+}
+
+// Payloads holds the schemas of every event type the system publishes
+var Payloads = newDefaultPayloadRegistry()
+
+// Register sets the payload schema for an event type, replacing any previous one
+func (r *PayloadRegistry) Register(eventType EventType, schema PayloadSchema) {
+	if schema.Version < 1 {
+		schema.Version = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[eventType] = schema
+}
+
+// Schema returns the payload schema registered for an event type
+func (r *PayloadRegistry) Schema(eventType EventType) (PayloadSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[eventType]
+	return schema, ok
+}
+
+// Encode validates a typed payload and wraps it in a new event stamped with the current schema version
+func (r *PayloadRegistry) Encode(eventType EventType, programID uuid.UUID, source string, payload Payload) (*Event, error) {
+	schema, ok := r.Schema(eventType)
+	if !ok {
+		return nil, fmt.Errorf("no payload schema registered for %s", eventType)
+	}
+
+	if got, want := indirectType(payload), indirectType(schema.New()); got != want {
+		return nil, fmt.Errorf("invalid %s payload: expected %s, got %s", eventType, want, got)
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", eventType, err)
+	}
+
+	fields, err := toFields(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	event := NewEvent(eventType, programID, source, fields)
+	event.SchemaVersion = schema.Version
+	return event, nil
+}
+
+// Decode upgrades the event's payload to the current schema version, decodes it into v and
+// validates it. Payloads of unregistered event types are decoded as-is.
+func (r *PayloadRegistry) Decode(event *Event, v Payload) error {
+	fields, err := r.upgrade(event)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", event.Type, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Validate checks that an event's payload decodes into its registered schema.
+// Events of unregistered types are not checked.
+func (r *PayloadRegistry) Validate(event *Event) error {
+	schema, ok := r.Schema(event.Type)
+	if !ok {
+		return nil
+	}
+	return r.Decode(event, schema.New())
+}
+
+// upgrade returns a copy of the event's payload at the current schema version
+func (r *PayloadRegistry) upgrade(event *Event) (map[string]interface{}, error) {
+	fields, err := toFields(event.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s payload: %w", event.Type, err)
+	}
+
+	schema, ok := r.Schema(event.Type)
+	if !ok {
+		return fields, nil
+	}
+
+	// Events published before payloads were versioned carry no version; they are version 1
+	version := event.SchemaVersion
+	if version < 1 {
+		version = 1
+	}
+
+	for ; version < schema.Version; version++ {
+		upgrade, ok := schema.Upgrades[version]
+		if !ok {
+			continue // Compatible change: fields were only added
+		}
+		if err := upgrade(fields); err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s payload from version %d: %w", event.Type, version, err)
+		}
+	}
+
+	return fields, nil
+}
+
+// toFields converts a value to a fresh JSON object map
+func toFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	if string(data) == "null" {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// indirectType returns the struct type behind a payload, whether passed by value or pointer
+func indirectType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// NewEventFromPayload creates a new event whose payload is a typed, validated struct
+func NewEventFromPayload(eventType EventType, programID uuid.UUID, source string, payload Payload) (*Event, error) {
+	return Payloads.Encode(eventType, programID, source, payload)
+}
+
+// DecodePayload decodes the event's payload into a typed struct, upgrading older schema versions
+func (e *Event) DecodePayload(v Payload) error {
+	return Payloads.Decode(e, v)
+}
+
+// ValidateEvent checks an event's payload against its registered schema before it is published
+func ValidateEvent(event *Event) error {
+	return Payloads.Validate(event)
+}
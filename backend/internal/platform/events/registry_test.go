@@ -0,0 +1,170 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewEventFromPayload_StampsSchemaVersion(t *testing.T) {
+	artifactID := uuid.New()
+	event, err := NewEventFromPayload(ArtifactUploaded, uuid.New(), "artifacts", ArtifactUploadedPayload{
+		ArtifactID: artifactID,
+		Trigger:    UploadTriggerUpload,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if event.SchemaVersion != 2 {
+		t.Fatalf("expected schema version 2, got %d", event.SchemaVersion)
+	}
+	if event.Payload["artifact_id"] != artifactID.String() || event.Payload["trigger"] != UploadTriggerUpload {
+		t.Fatalf("unexpected payload: %v", event.Payload)
+	}
+}
+
+func TestNewEventFromPayload_RejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType EventType
+		payload   Payload
+		wantErr   string
+	}{
+		{
+			name:      "Missing required ID",
+			eventType: ArtifactAnalyzed,
+			payload:   ArtifactAnalyzedPayload{},
+			wantErr:   "artifact_id is required",
+		},
+		{
+			name:      "Requeue without dead letter",
+			eventType: ArtifactUploaded,
+			payload:   ArtifactUploadedPayload{ArtifactID: uuid.New(), Trigger: UploadTriggerRequeue},
+			wantErr:   "dead_letter_id is required",
+		},
+		{
+			name:      "Unknown severity",
+			eventType: RiskIdentified,
+			payload:   RiskIdentifiedPayload{RiskID: uuid.New(), Title: "Vendor delay", Severity: "severe"},
+			wantErr:   "severity must be one of",
+		},
+		{
+			name:      "Payload of another event type",
+			eventType: RiskEscalated,
+			payload:   RiskIdentifiedPayload{RiskID: uuid.New(), Title: "Vendor delay", Severity: "high"},
+			wantErr:   "expected events.RiskEscalatedPayload",
+		},
+		{
+			name:      "Unregistered event type",
+			eventType: DecisionApproved,
+			payload:   InsightEventPayload{InsightID: uuid.New(), ArtifactID: uuid.New(), InsightType: "decision"},
+			wantErr:   "no payload schema registered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEventFromPayload(tt.eventType, uuid.New(), "test", tt.payload)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodePayload_UpgradesUnversionedEvents(t *testing.T) {
+	artifactID := uuid.New()
+	deadLetterID := uuid.New()
+
+	// Requeue events published before payloads were versioned, read back from the wire
+	data, err := json.Marshal(NewEvent(ArtifactUploaded, uuid.New(), "artifacts", map[string]interface{}{
+		"artifact_id":    artifactID.String(),
+		"dead_letter_id": deadLetterID.String(),
+		"requeued":       true,
+	}))
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+
+	var payload ArtifactUploadedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if payload.ArtifactID != artifactID || payload.DeadLetterID != deadLetterID || payload.Trigger != UploadTriggerRequeue {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if _, ok := event.Payload["trigger"]; ok {
+		t.Fatal("expected decoding to leave the event payload untouched")
+	}
+}
+
+func TestDecodePayload_ToleratesNewerVersions(t *testing.T) {
+	event := NewEvent(ArtifactAnalyzed, uuid.New(), "artifacts", map[string]interface{}{
+		"artifact_id":  uuid.New().String(),
+		"duration_sec": 12.5, // Added by a newer producer
+	})
+	event.SchemaVersion = 3
+
+	var payload ArtifactAnalyzedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if payload.ArtifactID == uuid.Nil {
+		t.Fatal("expected artifact_id to decode")
+	}
+}
+
+func TestPayloadRegistry_AppliesUpgradesInOrder(t *testing.T) {
+	registry := NewPayloadRegistry()
+	registry.Register("test.counter", PayloadSchema{
+		Version: 3,
+		New:     func() Payload { return &testPayload{} },
+		Upgrades: map[int]PayloadUpgrade{
+			1: func(fields map[string]interface{}) error {
+				fields["count"] = fields["legacy_count"]
+				delete(fields, "legacy_count")
+				return nil
+			},
+			2: func(fields map[string]interface{}) error {
+				fields["count"] = fields["count"].(float64) * 10
+				return nil
+			},
+		},
+	})
+
+	event := NewEvent("test.counter", uuid.New(), "test", map[string]interface{}{"legacy_count": 4})
+
+	var payload testPayload
+	if err := registry.Decode(event, &payload); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if payload.Count != 40 {
+		t.Fatalf("expected count 40 after both upgrades, got %d", payload.Count)
+	}
+
+	event.SchemaVersion = 2
+	event.Payload = map[string]interface{}{"count": 4}
+	if err := registry.Decode(event, &payload); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if payload.Count != 40 {
+		t.Fatalf("expected count 40 after the version 2 upgrade, got %d", payload.Count)
+	}
+
+	if err := registry.Validate(NewEvent("test.unregistered", uuid.New(), "test", nil)); err != nil {
+		t.Fatalf("expected unregistered event types to pass validation, got: %v", err)
+	}
+}
+
+// testPayload is a payload for a registry-local event type
+type testPayload struct {
+	Count int `json:"count"`
+}
+
+func (p testPayload) Validate() error { return nil }
@@ -16,9 +16,12 @@ const (
 	ArtifactAnalyzed         EventType = "artifact.analyzed"
 	ArtifactMetadataExtracted EventType = "artifact.metadata_extracted"
 	ArtifactEmbeddingsCreated EventType = "artifact.embeddings_created"
+	ArtifactDeadLettered      EventType = "artifact.dead_lettered"
+	ArtifactOCRCompleted      EventType = "artifact.ocr_completed"
 
 	// Financial events
 	InvoiceProcessed        EventType = "financial.invoice_processed"
+	InvoiceApproved         EventType = "financial.invoice_approved"
 	VarianceDetected        EventType = "financial.variance_detected"
 	BudgetThresholdExceeded EventType = "financial.budget_exceeded"
 
@@ -26,6 +29,7 @@ const (
 	RiskIdentified EventType = "risk.identified"
 	RiskEscalated  EventType = "risk.escalated"
 	IssueCreated   EventType = "risk.issue_created"
+	RiskSuggested  EventType = "risk.suggested"
 
 	// Decision events
 	DecisionExtracted EventType = "decision.extracted"
@@ -44,8 +48,32 @@ type Event struct {
 	Timestamp     time.Time              `json:"timestamp"`
 	Source        string                 `json:"source"` // Module name
 	Payload       map[string]interface{} `json:"payload"`
+	SchemaVersion int                    `json:"schema_version,omitempty"` // Payload schema version; 0 for events published before versioning
 	CorrelationID uuid.UUID              `json:"correlation_id"`
 	Metadata      EventMetadata          `json:"metadata"`
+
+	// Delivery is populated by the bus when the event is handed to a subscriber.
+	// It is not part of the published message.
+	Delivery DeliveryInfo `json:"-"`
+}
+
+// DeliveryInfo describes the current delivery attempt of an event.
+// Handlers can use it to make idempotency decisions on redelivery.
+type DeliveryInfo struct {
+	Attempt        int       `json:"attempt"`         // 1 on first delivery
+	MaxAttempts    int       `json:"max_attempts"`    // Deliveries allowed before the event is dead-lettered
+	StreamSequence uint64    `json:"stream_sequence"` // Position of the message in the stream
+	PublishedAt    time.Time `json:"published_at"`    // When the stream stored the message
+}
+
+// IsRedelivery reports whether the event was delivered before
+func (d DeliveryInfo) IsRedelivery() bool {
+	return d.Attempt > 1
+}
+
+// IsFinalAttempt reports whether a failure on this delivery dead-letters the event
+func (d DeliveryInfo) IsFinalAttempt() bool {
+	return d.MaxAttempts > 0 && d.Attempt >= d.MaxAttempts
 }
 
 // EventMetadata contains additional event metadata
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Run statuses
+const (
+	StatusQueued    = "queued"    // Triggered manually, waiting for a worker
+	StatusRunning   = "running"   // Claimed by a worker
+	StatusSucceeded = "succeeded" // Returned without error
+	StatusFailed    = "failed"    // Returned an error, timed out or its worker died
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Func is the work a job does. It should return promptly once ctx is cancelled.
+type Func func(ctx context.Context) error
+
+// Job is a unit of recurring background work
+type Job struct {
+	Name        string
+	Description string
+	Schedule    string        // See ParseSchedule
+	Timeout     time.Duration // Zero means no timeout
+	Run         Func
+}
+
+// Definition is a job as registered in the database, where the API can see it
+type Definition struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Schedule     string    `json:"schedule"`
+	RegisteredAt time.Time `json:"registered_at"` // Last time a worker registered the job
+}
+
+// RunRecord is one execution of a job
+type RunRecord struct {
+	RunID        uuid.UUID      `json:"run_id"`
+	JobName      string         `json:"job_name"`
+	Trigger      string         `json:"trigger"`
+	Status       string         `json:"status"`
+	ScheduledFor sql.NullTime   `json:"scheduled_for,omitempty"` // The schedule slot; null for manual runs
+	RequestedBy  uuid.NullUUID  `json:"requested_by,omitempty"`
+	WorkerID     sql.NullString `json:"worker_id,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	StartedAt    sql.NullTime   `json:"started_at,omitempty"`
+	FinishedAt   sql.NullTime   `json:"finished_at,omitempty"`
+	DurationMS   sql.NullInt64  `json:"duration_ms,omitempty"`
+	Error        sql.NullString `json:"error,omitempty"`
+}
+
+// Status is a job with its most recent run, as listed by the admin API
+type Status struct {
+	Definition
+	LastRun     *RunRecord   `json:"last_run,omitempty"`
+	LastSuccess sql.NullTime `json:"last_success_at,omitempty"`
+}
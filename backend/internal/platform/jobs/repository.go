@@ -0,0 +1,367 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// RepositoryInterface defines the job storage the scheduler and admin API need
+type RepositoryInterface interface {
+	// Registration
+	RegisterJobs(ctx context.Context, definitions []Definition) error
+
+	// Execution
+	TryLock(ctx context.Context, jobName string) (release func(), acquired bool, err error)
+	FailAbandonedRuns(ctx context.Context, jobName string) (int64, error)
+	StartScheduledRun(ctx context.Context, jobName string, slot time.Time, workerID string) (*RunRecord, error)
+	ClaimQueuedRun(ctx context.Context, jobName, workerID string) (*RunRecord, error)
+	ListQueuedJobNames(ctx context.Context, jobNames []string) ([]string, error)
+	FinishRun(ctx context.Context, runID uuid.UUID, runErr error) error
+	PruneRuns(ctx context.Context, olderThan time.Duration) (int64, error)
+
+	// Admin
+	ListJobs(ctx context.Context) ([]Status, error)
+	GetJob(ctx context.Context, jobName string) (*Status, error)
+	ListRuns(ctx context.Context, jobName string, limit, offset int) ([]RunRecord, error)
+	QueueRun(ctx context.Context, jobName string, requestedBy uuid.UUID) (*RunRecord, error)
+}
+
+// Repository handles database operations for scheduled jobs
+type Repository struct {
+	db *db.DB
+}
+
+// NewRepository creates a new jobs repository
+func NewRepository(database *db.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// RegisterJobs records the jobs a worker runs so the admin API can list them
+func (r *Repository) RegisterJobs(ctx context.Context, definitions []Definition) error {
+	query := `
+		INSERT INTO scheduled_jobs (job_name, description, schedule, registered_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (job_name) DO UPDATE
+		SET description = EXCLUDED.description,
+		    schedule = EXCLUDED.schedule,
+		    registered_at = NOW()
+	`
+
+	for _, definition := range definitions {
+		if _, err := r.db.ExecContext(ctx, query, definition.Name, definition.Description, definition.Schedule); err != nil {
+			return fmt.Errorf("failed to register job %s: %w", definition.Name, err)
+		}
+	}
+	return nil
+}
+
+// TryLock takes the job's advisory lock on a dedicated connection. The lock belongs to that
+// session, so it is released by release or, if the worker dies, when the connection drops.
+func (r *Repository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection for job lock: %w", err)
+	}
+
+	key := lockKey(jobName)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take job lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The job's context may be cancelled by now; the unlock must still happen
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+// FailAbandonedRuns marks runs left running by a dead worker as failed. Only call it while
+// holding the job's lock, when no run of the job can be in progress.
+func (r *Repository) FailAbandonedRuns(ctx context.Context, jobName string) (int64, error) {
+	query := `
+		UPDATE job_runs
+		SET status = 'failed',
+		    finished_at = NOW(),
+		    error = 'abandoned: worker stopped before the run finished'
+		WHERE job_name = $1 AND status = 'running'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, jobName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail abandoned runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StartScheduledRun records the start of the run for a schedule slot. It returns nil if the slot
+// already has a run, i.e. another replica got there first.
+func (r *Repository) StartScheduledRun(ctx context.Context, jobName string, slot time.Time, workerID string) (*RunRecord, error) {
+	query := `
+		INSERT INTO job_runs (job_name, trigger, status, scheduled_for, worker_id, started_at)
+		VALUES ($1, 'schedule', 'running', $2, $3, NOW())
+		ON CONFLICT (job_name, scheduled_for) WHERE trigger = 'schedule' DO NOTHING
+		RETURNING ` + runColumns
+
+	run, err := scanRun(r.db.QueryRowContext(ctx, query, jobName, slot, workerID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start job run: %w", err)
+	}
+	return run, nil
+}
+
+// ClaimQueuedRun starts the oldest manually triggered run of a job. It returns nil if none is queued.
+func (r *Repository) ClaimQueuedRun(ctx context.Context, jobName, workerID string) (*RunRecord, error) {
+	query := `
+		UPDATE job_runs
+		SET status = 'running', worker_id = $2, started_at = NOW()
+		WHERE run_id = (
+			SELECT run_id FROM job_runs
+			WHERE job_name = $1 AND status = 'queued'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + runColumns
+
+	run, err := scanRun(r.db.QueryRowContext(ctx, query, jobName, workerID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queued job run: %w", err)
+	}
+	return run, nil
+}
+
+// ListQueuedJobNames returns which of jobNames have manually triggered runs waiting
+func (r *Repository) ListQueuedJobNames(ctx context.Context, jobNames []string) ([]string, error) {
+	query := `
+		SELECT DISTINCT job_name FROM job_runs
+		WHERE status = 'queued' AND job_name = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(jobNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan queued job: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// FinishRun records a run's outcome; a nil runErr means it succeeded
+func (r *Repository) FinishRun(ctx context.Context, runID uuid.UUID, runErr error) error {
+	status := StatusSucceeded
+	var errMsg sql.NullString
+	if runErr != nil {
+		status = StatusFailed
+		errMsg = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	query := `
+		UPDATE job_runs
+		SET status = $2,
+		    error = $3,
+		    finished_at = NOW(),
+		    duration_ms = (EXTRACT(EPOCH FROM (NOW() - started_at)) * 1000)::BIGINT
+		WHERE run_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, runID, status, errMsg); err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+	return nil
+}
+
+// PruneRuns deletes finished runs older than olderThan
+func (r *Repository) PruneRuns(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM job_runs
+		WHERE status IN ('succeeded', 'failed')
+		  AND created_at < NOW() - $1 * INTERVAL '1 second'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, int64(olderThan.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune job runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListJobs returns every registered job with its latest run
+func (r *Repository) ListJobs(ctx context.Context) ([]Status, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT job_name, description, schedule, registered_at
+		FROM scheduled_jobs
+		ORDER BY job_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	statuses := make([]Status, 0)
+	for rows.Next() {
+		var status Status
+		if err := rows.Scan(&status.Name, &status.Description, &status.Schedule, &status.RegisteredAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	for i := range statuses {
+		if err := r.loadLastRun(ctx, &statuses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+// GetJob returns a registered job with its latest run
+func (r *Repository) GetJob(ctx context.Context, jobName string) (*Status, error) {
+	var status Status
+	err := r.db.QueryRowContext(ctx, `
+		SELECT job_name, description, schedule, registered_at
+		FROM scheduled_jobs
+		WHERE job_name = $1
+	`, jobName).Scan(&status.Name, &status.Description, &status.Schedule, &status.RegisteredAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if err := r.loadLastRun(ctx, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// loadLastRun fills in the latest run and last success of a job
+func (r *Repository) loadLastRun(ctx context.Context, status *Status) error {
+	run, err := scanRun(r.db.QueryRowContext(ctx, `
+		SELECT `+runColumns+`
+		FROM job_runs
+		WHERE job_name = $1 AND status <> 'queued'
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, status.Name))
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get last job run: %w", err)
+	}
+	if err == nil {
+		status.LastRun = run
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT MAX(finished_at) FROM job_runs
+		WHERE job_name = $1 AND status = 'succeeded'
+	`, status.Name).Scan(&status.LastSuccess)
+	if err != nil {
+		return fmt.Errorf("failed to get last job success: %w", err)
+	}
+	return nil
+}
+
+// ListRuns returns a job's run history, newest first
+func (r *Repository) ListRuns(ctx context.Context, jobName string, limit, offset int) ([]RunRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+runColumns+`
+		FROM job_runs
+		WHERE job_name = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, jobName, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]RunRecord, 0)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// QueueRun asks a worker to run a job as soon as it can
+func (r *Repository) QueueRun(ctx context.Context, jobName string, requestedBy uuid.UUID) (*RunRecord, error) {
+	query := `
+		INSERT INTO job_runs (job_name, trigger, status, requested_by)
+		VALUES ($1, 'manual', 'queued', $2)
+		RETURNING ` + runColumns
+
+	run, err := scanRun(r.db.QueryRowContext(ctx, query, jobName, uuid.NullUUID{UUID: requestedBy, Valid: requestedBy != uuid.Nil}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue job run: %w", err)
+	}
+	return run, nil
+}
+
+// runColumns lists job_runs columns in scanRun order
+const runColumns = `run_id, job_name, trigger, status, scheduled_for, requested_by, worker_id,
+	created_at, started_at, finished_at, duration_ms, error`
+
+// scanRun reads a job_runs row selected with runColumns
+func scanRun(row interface{ Scan(...interface{}) error }) (*RunRecord, error) {
+	var run RunRecord
+	err := row.Scan(
+		&run.RunID,
+		&run.JobName,
+		&run.Trigger,
+		&run.Status,
+		&run.ScheduledFor,
+		&run.RequestedBy,
+		&run.WorkerID,
+		&run.CreatedAt,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.DurationMS,
+		&run.Error,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// lockKey maps a job name to its advisory lock key
+func lockKey(jobName string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("cerberus.jobs:" + jobName))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	from := time.Date(2025, time.March, 14, 10, 17, 42, 0, time.UTC) // A Friday
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/30 * * * *", time.Date(2025, time.March, 14, 10, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2025, time.March, 15, 2, 0, 0, 0, time.UTC)},
+		{"15 9 1 * *", time.Date(2025, time.April, 1, 9, 15, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 6", time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)}, // Day-of-month OR day-of-week
+		{"5/20 * * * *", time.Date(2025, time.March, 14, 10, 25, 0, 0, time.UTC)},
+		{"@every 10s", time.Date(2025, time.March, 14, 10, 17, 50, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		schedule, err := ParseSchedule(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tc.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: expected next run %s, got %s", tc.spec, tc.want, got)
+		}
+	}
+}
+
+func TestParseSchedule_RejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "@every 100ms", "@yearly"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestParseSchedule_NeverMatching(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected no run time for February 31st, got %s", next)
+	}
+}
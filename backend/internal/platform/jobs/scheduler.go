@@ -0,0 +1,256 @@
+// Package jobs runs recurring background work on cron-style schedules.
+// Every worker replica runs the same Scheduler; a Postgres advisory lock per job and a unique
+// (job, schedule slot) row in job_runs make sure each slot runs on exactly one replica.
+// Runs are recorded in job_runs, and runs queued through the admin API are picked up by
+// whichever replica is free to take the job's lock.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config controls the scheduler
+type Config struct {
+	PollInterval time.Duration // How often to look for manually triggered runs
+	WorkerID     string        // Recorded on each run; defaults to hostname:pid
+}
+
+// DefaultConfig returns the scheduler settings used by the worker
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// Scheduler runs registered jobs on their schedules
+type Scheduler struct {
+	repo   RepositoryInterface
+	config Config
+	jobs   []*scheduledJob
+	byName map[string]*scheduledJob
+	now    func() time.Time
+}
+
+// scheduledJob is a registered job with its parsed schedule
+type scheduledJob struct {
+	Job
+	schedule Schedule
+}
+
+// NewScheduler creates a scheduler
+func NewScheduler(repo RepositoryInterface, config Config) *Scheduler {
+	if config.WorkerID == "" {
+		hostname, _ := os.Hostname()
+		config.WorkerID = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+
+	return &Scheduler{
+		repo:   repo,
+		config: config,
+		byName: make(map[string]*scheduledJob),
+		now:    time.Now,
+	}
+}
+
+// Register adds a job. Must be called before Run.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job name and run function are required")
+	}
+	if _, exists := s.byName[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("failed to register job %s: %w", job.Name, err)
+	}
+
+	scheduled := &scheduledJob{Job: job, schedule: schedule}
+	s.jobs = append(s.jobs, scheduled)
+	s.byName[job.Name] = scheduled
+	return nil
+}
+
+// Run runs the registered jobs until ctx is cancelled, then waits for running jobs to return
+func (s *Scheduler) Run(ctx context.Context) {
+	definitions := make([]Definition, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		definitions = append(definitions, Definition{Name: job.Name, Description: job.Description, Schedule: job.Schedule})
+	}
+	if err := s.repo.RegisterJobs(ctx, definitions); err != nil {
+		// Jobs still run; the admin API just won't list new ones until the next start
+		log.Printf("Warning: Failed to register scheduled jobs: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		job := job
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runSchedule(ctx, job)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.pollQueued(ctx)
+	}()
+
+	log.Printf("Job scheduler started with %d jobs", len(s.jobs))
+	wg.Wait()
+}
+
+// runSchedule runs a job at each of its schedule slots. A slot that passes while the job is
+// still running is skipped rather than queued.
+func (s *Scheduler) runSchedule(ctx context.Context, job *scheduledJob) {
+	for {
+		slot := job.schedule.Next(s.now())
+		if slot.IsZero() {
+			log.Printf("Job %s has no upcoming run time; not scheduling it", job.Name)
+			return
+		}
+
+		timer := time.NewTimer(slot.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.RunSlot(ctx, job.Name, slot); err != nil && ctx.Err() == nil {
+			log.Printf("Scheduled job %s: %v", job.Name, err)
+		}
+	}
+}
+
+// RunSlot runs a job for a schedule slot unless another replica holds its lock or already ran
+// the slot
+func (s *Scheduler) RunSlot(ctx context.Context, jobName string, slot time.Time) error {
+	job, ok := s.byName[jobName]
+	if !ok {
+		return fmt.Errorf("job %s is not registered", jobName)
+	}
+
+	release, acquired, err := s.lock(ctx, job)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	run, err := s.repo.StartScheduledRun(ctx, job.Name, slot, s.config.WorkerID)
+	if err != nil || run == nil {
+		return err
+	}
+
+	return s.execute(ctx, job, run)
+}
+
+// pollQueued runs manually triggered jobs
+func (s *Scheduler) pollQueued(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	names := make([]string, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		names = append(names, job.Name)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		queued, err := s.repo.ListQueuedJobNames(ctx, names)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to check for triggered jobs: %v", err)
+			}
+			continue
+		}
+
+		for _, name := range queued {
+			if err := s.RunQueued(ctx, name); err != nil && ctx.Err() == nil {
+				log.Printf("Triggered job %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// RunQueued runs the oldest manually triggered run of a job. If the job is running elsewhere
+// the run stays queued for the next poll.
+func (s *Scheduler) RunQueued(ctx context.Context, jobName string) error {
+	job, ok := s.byName[jobName]
+	if !ok {
+		return fmt.Errorf("job %s is not registered", jobName)
+	}
+
+	release, acquired, err := s.lock(ctx, job)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	run, err := s.repo.ClaimQueuedRun(ctx, job.Name, s.config.WorkerID)
+	if err != nil || run == nil {
+		return err
+	}
+
+	return s.execute(ctx, job, run)
+}
+
+// lock takes the job's lock and clears out runs a dead worker left behind
+func (s *Scheduler) lock(ctx context.Context, job *scheduledJob) (func(), bool, error) {
+	release, acquired, err := s.repo.TryLock(ctx, job.Name)
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	if abandoned, err := s.repo.FailAbandonedRuns(ctx, job.Name); err != nil {
+		release()
+		return nil, false, err
+	} else if abandoned > 0 {
+		log.Printf("Marked %d abandoned runs of job %s as failed", abandoned, job.Name)
+	}
+
+	return release, true, nil
+}
+
+// execute runs a job and records the outcome. A panic fails the run instead of the worker.
+func (s *Scheduler) execute(ctx context.Context, job *scheduledJob, run *RunRecord) (err error) {
+	runCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+
+		// Record the outcome even when shutting down, so the run isn't left running
+		recordCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if recordErr := s.repo.FinishRun(recordCtx, run.RunID, err); recordErr != nil {
+			log.Printf("Failed to record run %s of job %s: %v", run.RunID, job.Name, recordErr)
+		}
+
+		if err != nil {
+			err = fmt.Errorf("run %s failed: %w", run.RunID, err)
+		}
+	}()
+
+	return job.Run(runCtx)
+}
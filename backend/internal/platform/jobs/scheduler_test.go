@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps job runs in memory and models the advisory lock as a set of held names
+type fakeRepository struct {
+	mu     sync.Mutex
+	locked map[string]bool
+	runs   []*RunRecord
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{locked: make(map[string]bool)}
+}
+
+func (f *fakeRepository) RegisterJobs(ctx context.Context, definitions []Definition) error {
+	return nil
+}
+
+func (f *fakeRepository) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked[jobName] {
+		return nil, false, nil
+	}
+	f.locked[jobName] = true
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.locked, jobName)
+	}, true, nil
+}
+
+func (f *fakeRepository) FailAbandonedRuns(ctx context.Context, jobName string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, run := range f.runs {
+		if run.JobName == jobName && run.Status == StatusRunning {
+			run.Status = StatusFailed
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeRepository) StartScheduledRun(ctx context.Context, jobName string, slot time.Time, workerID string) (*RunRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, run := range f.runs {
+		if run.JobName == jobName && run.Trigger == TriggerSchedule && run.ScheduledFor.Time.Equal(slot) {
+			return nil, nil
+		}
+	}
+	run := &RunRecord{RunID: uuid.New(), JobName: jobName, Trigger: TriggerSchedule, Status: StatusRunning}
+	run.ScheduledFor.Time, run.ScheduledFor.Valid = slot, true
+	f.runs = append(f.runs, run)
+	return run, nil
+}
+
+func (f *fakeRepository) ClaimQueuedRun(ctx context.Context, jobName, workerID string) (*RunRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, run := range f.runs {
+		if run.JobName == jobName && run.Status == StatusQueued {
+			run.Status = StatusRunning
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeRepository) ListQueuedJobNames(ctx context.Context, jobNames []string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) FinishRun(ctx context.Context, runID uuid.UUID, runErr error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, run := range f.runs {
+		if run.RunID == runID {
+			run.Status = StatusSucceeded
+			if runErr != nil {
+				run.Status = StatusFailed
+				run.Error.String, run.Error.Valid = runErr.Error(), true
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) PruneRuns(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRepository) ListJobs(ctx context.Context) ([]Status, error) { return nil, nil }
+
+func (f *fakeRepository) GetJob(ctx context.Context, jobName string) (*Status, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) ListRuns(ctx context.Context, jobName string, limit, offset int) ([]RunRecord, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) QueueRun(ctx context.Context, jobName string, requestedBy uuid.UUID) (*RunRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	run := &RunRecord{RunID: uuid.New(), JobName: jobName, Trigger: TriggerManual, Status: StatusQueued}
+	f.runs = append(f.runs, run)
+	return run, nil
+}
+
+func TestScheduler_RunsEachSlotOnce(t *testing.T) {
+	repo := newFakeRepository()
+	calls := 0
+	job := Job{Name: "sweep", Schedule: "@every 10s", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}}
+
+	// Two replicas sharing the same database
+	first, second := NewScheduler(repo, DefaultConfig()), NewScheduler(repo, DefaultConfig())
+	first.Register(job)
+	second.Register(job)
+
+	slot := time.Date(2025, time.January, 1, 0, 0, 10, 0, time.UTC)
+	if err := first.RunSlot(context.Background(), "sweep", slot); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RunSlot(context.Background(), "sweep", slot); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected the slot to run once across replicas, ran %d times", calls)
+	}
+}
+
+func TestScheduler_SkipsWhileAnotherReplicaHoldsTheLock(t *testing.T) {
+	repo := newFakeRepository()
+	scheduler := NewScheduler(repo, DefaultConfig())
+	scheduler.Register(Job{Name: "rebuild", Schedule: "@daily", Run: func(ctx context.Context) error {
+		t.Fatal("job ran while locked elsewhere")
+		return nil
+	}})
+
+	release, _, _ := repo.TryLock(context.Background(), "rebuild")
+	defer release()
+
+	if err := scheduler.RunSlot(context.Background(), "rebuild", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.runs) != 0 {
+		t.Fatalf("expected no run to be recorded, got %d", len(repo.runs))
+	}
+}
+
+func TestScheduler_RecordsFailuresAndPanics(t *testing.T) {
+	repo := newFakeRepository()
+	scheduler := NewScheduler(repo, DefaultConfig())
+	scheduler.Register(Job{Name: "failing", Schedule: "@hourly", Run: func(ctx context.Context) error {
+		return errors.New("database unavailable")
+	}})
+	scheduler.Register(Job{Name: "panicking", Schedule: "@hourly", Run: func(ctx context.Context) error {
+		panic("nil map")
+	}})
+
+	slot := time.Date(2025, time.January, 1, 1, 0, 0, 0, time.UTC)
+	if err := scheduler.RunSlot(context.Background(), "failing", slot); err == nil {
+		t.Fatal("expected the failing job to report an error")
+	}
+	if err := scheduler.RunSlot(context.Background(), "panicking", slot); err == nil {
+		t.Fatal("expected the panicking job to report an error")
+	}
+
+	for _, run := range repo.runs {
+		if run.Status != StatusFailed || !run.Error.Valid {
+			t.Errorf("expected run of %s to be recorded as failed, got %s", run.JobName, run.Status)
+		}
+	}
+}
+
+func TestScheduler_RunsQueuedManualRuns(t *testing.T) {
+	repo := newFakeRepository()
+	scheduler := NewScheduler(repo, DefaultConfig())
+	ran := false
+	scheduler.Register(Job{Name: "cleanup", Schedule: "@daily", Run: func(ctx context.Context) error {
+		ran = true
+		return nil
+	}})
+
+	run, _ := repo.QueueRun(context.Background(), "cleanup", uuid.New())
+	if err := scheduler.RunQueued(context.Background(), "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran || run.Status != StatusSucceeded {
+		t.Fatalf("expected the queued run to succeed, ran=%v status=%s", ran, run.Status)
+	}
+}
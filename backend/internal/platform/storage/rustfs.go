@@ -5,7 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -94,45 +97,34 @@ func (c *RustFSClient) ensureBucket(ctx context.Context) error {
 	return nil
 }
 
+// streamPartSize is the part size of multipart uploads. Streams of unknown length are buffered
+// one part at a time, so this bounds the memory an upload holds.
+const streamPartSize = 16 << 20
+
 // Upload stores a file in RustFS using S3 API
 func (c *RustFSClient) Upload(ctx context.Context, filename string, data []byte) (*FileInfo, error) {
+	return c.UploadStream(ctx, filename, bytes.NewReader(data), int64(len(data)))
+}
+
+// UploadStream stores a file in RustFS using S3 API. Files larger than one part, or of unknown
+// length, are sent as a multipart upload, which is aborted if reading r fails.
+func (c *RustFSClient) UploadStream(ctx context.Context, filename string, r io.Reader, size int64) (*FileInfo, error) {
 	// Generate unique file ID
 	fileID := uuid.New().String()
 
-	// Determine content type from file extension
-	contentType := "application/octet-stream"
-	ext := strings.ToLower(filepath.Ext(filename))
-	switch ext {
-	case ".pdf":
-		contentType = "application/pdf"
-	case ".txt":
-		contentType = "text/plain"
-	case ".json":
-		contentType = "application/json"
-	case ".csv":
-		contentType = "text/csv"
-	case ".xlsx":
-		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	case ".xls":
-		contentType = "application/vnd.ms-excel"
-	}
-
-	// Create reader from data
-	reader := bytes.NewReader(data)
-	fileSize := int64(len(data))
-
 	// Upload to S3-compatible storage
 	uploadInfo, err := c.client.PutObject(
 		ctx,
 		c.bucketName,
 		fileID,
-		reader,
-		fileSize,
+		r,
+		size,
 		minio.PutObjectOptions{
-			ContentType: contentType,
+			ContentType: contentTypeFor(filename),
 			UserMetadata: map[string]string{
 				"original-filename": filename,
 			},
+			PartSize: streamPartSize,
 		},
 	)
 	if err != nil {
@@ -144,12 +136,43 @@ func (c *RustFSClient) Upload(ctx context.Context, filename string, data []byte)
 		ID:          fileID,
 		Filename:    filename,
 		Path:        fmt.Sprintf("artifacts/%s", fileID),
-		Size:        fileSize,
+		Size:        uploadInfo.Size,
 		ContentHash: uploadInfo.ETag,
 		UploadedAt:  time.Now(),
 	}, nil
 }
 
+// contentTypeFor determines the content type to store a file with from its extension
+func contentTypeFor(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return "application/pdf"
+	case ".txt":
+		return "text/plain"
+	case ".json":
+		return "application/json"
+	case ".csv":
+		return "text/csv"
+	case ".xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ".xls":
+		return "application/vnd.ms-excel"
+	case ".docx":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case ".pptx":
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	case ".odt":
+		return "application/vnd.oasis.opendocument.text"
+	case ".odp":
+		return "application/vnd.oasis.opendocument.presentation"
+	case ".eml":
+		return "message/rfc822"
+	case ".mbox":
+		return "application/mbox"
+	}
+	return "application/octet-stream"
+}
+
 // Download retrieves a file from RustFS using S3 API
 func (c *RustFSClient) Download(ctx context.Context, fileID string) ([]byte, error) {
 	// Get object from S3
@@ -168,6 +191,16 @@ func (c *RustFSClient) Download(ctx context.Context, fileID string) ([]byte, err
 	return data, nil
 }
 
+// DownloadStream opens a file in RustFS for reading using S3 API
+func (c *RustFSClient) DownloadStream(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	object, err := c.client.GetObject(ctx, c.bucketName, fileID, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return object, nil
+}
+
 // Delete removes a file from RustFS using S3 API
 func (c *RustFSClient) Delete(ctx context.Context, fileID string) error {
 	err := c.client.RemoveObject(ctx, c.bucketName, fileID, minio.RemoveObjectOptions{})
@@ -201,3 +234,101 @@ func (c *RustFSClient) GetInfo(ctx context.Context, fileID string) (*FileInfo, e
 		UploadedAt:  stat.LastModified,
 	}, nil
 }
+
+// CreateMultipartUpload starts a multipart upload in RustFS using S3 API
+func (c *RustFSClient) CreateMultipartUpload(ctx context.Context, filename string) (string, string, error) {
+	fileID := uuid.New().String()
+
+	uploadID, err := c.core().NewMultipartUpload(ctx, c.bucketName, fileID, minio.PutObjectOptions{
+		ContentType: contentTypeFor(filename),
+		UserMetadata: map[string]string{
+			"original-filename": filename,
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	return fileID, uploadID, nil
+}
+
+// UploadPart stores one part of a multipart upload in RustFS using S3 API
+func (c *RustFSClient) UploadPart(ctx context.Context, fileID, uploadID string, partNumber int, r io.Reader, size int64) (*Part, error) {
+	part, err := c.core().PutObjectPart(ctx, c.bucketName, fileID, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	return &Part{Number: part.PartNumber, Size: part.Size, ETag: part.ETag}, nil
+}
+
+// PresignPart returns a presigned URL for uploading one part of a multipart upload directly to
+// RustFS. The URL points at the configured endpoint, so clients must be able to reach it.
+func (c *RustFSClient) PresignPart(ctx context.Context, fileID, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+
+	u, err := c.client.Presign(ctx, http.MethodPut, c.bucketName, fileID, expiry, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign part %d: %w", partNumber, err)
+	}
+
+	return u.String(), nil
+}
+
+// ListParts lists the stored parts of a multipart upload in RustFS using S3 API
+func (c *RustFSClient) ListParts(ctx context.Context, fileID, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		result, err := c.core().ListObjectParts(ctx, c.bucketName, fileID, uploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts: %w", err)
+		}
+		for _, part := range result.ObjectParts {
+			parts = append(parts, Part{Number: part.PartNumber, Size: part.Size, ETag: part.ETag})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// CompleteMultipartUpload joins the parts of a multipart upload into a file in RustFS using S3 API
+func (c *RustFSClient) CompleteMultipartUpload(ctx context.Context, fileID, uploadID string, parts []Part) (*FileInfo, error) {
+	completeParts := make([]minio.CompletePart, len(parts))
+	var size int64
+	for i, part := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+		size += part.Size
+	}
+
+	uploadInfo, err := c.core().CompleteMultipartUpload(ctx, c.bucketName, fileID, uploadID, completeParts, minio.PutObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return &FileInfo{
+		ID:          fileID,
+		Path:        fmt.Sprintf("artifacts/%s", fileID),
+		Size:        size,
+		ContentHash: uploadInfo.ETag,
+		UploadedAt:  time.Now(),
+	}, nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts in RustFS using S3 API
+func (c *RustFSClient) AbortMultipartUpload(ctx context.Context, fileID, uploadID string) error {
+	if err := c.core().AbortMultipartUpload(ctx, c.bucketName, fileID, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
+// core exposes the S3 multipart operations that the high-level client performs internally
+func (c *RustFSClient) core() *minio.Core {
+	return &minio.Core{Client: c.client}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -10,9 +11,16 @@ type Storage interface {
 	// Upload stores a file and returns its metadata
 	Upload(ctx context.Context, filename string, data []byte) (*FileInfo, error)
 
+	// UploadStream stores a file read from r without holding it in memory. size is the length
+	// of the file, or -1 if unknown, in which case r is read to EOF.
+	UploadStream(ctx context.Context, filename string, r io.Reader, size int64) (*FileInfo, error)
+
 	// Download retrieves a file by its ID
 	Download(ctx context.Context, fileID string) ([]byte, error)
 
+	// DownloadStream opens a file by its ID for reading without holding it in memory
+	DownloadStream(ctx context.Context, fileID string) (io.ReadCloser, error)
+
 	// Delete removes a file by its ID
 	Delete(ctx context.Context, fileID string) error
 
@@ -20,6 +28,40 @@ type Storage interface {
 	GetInfo(ctx context.Context, fileID string) (*FileInfo, error)
 }
 
+// MinPartSize is the smallest part of a multipart upload, other than its last part
+const MinPartSize int64 = 5 << 20
+
+// MultipartStorage stores a file sent in parts that are uploaded separately and in any order,
+// so an interrupted upload is resumed by sending only the parts that are missing
+type MultipartStorage interface {
+	// CreateMultipartUpload starts an upload and returns the ID of the file it will create and
+	// the ID of the upload
+	CreateMultipartUpload(ctx context.Context, filename string) (fileID, uploadID string, err error)
+
+	// UploadPart stores one part, numbered from 1, of size bytes read from r. Uploading a part
+	// again replaces it.
+	UploadPart(ctx context.Context, fileID, uploadID string, partNumber int, r io.Reader, size int64) (*Part, error)
+
+	// PresignPart returns a URL that a client can PUT a part to directly, valid for expiry
+	PresignPart(ctx context.Context, fileID, uploadID string, partNumber int, expiry time.Duration) (string, error)
+
+	// ListParts returns the parts stored so far, in part number order
+	ListParts(ctx context.Context, fileID, uploadID string) ([]Part, error)
+
+	// CompleteMultipartUpload joins the parts into the file
+	CompleteMultipartUpload(ctx context.Context, fileID, uploadID string, parts []Part) (*FileInfo, error)
+
+	// AbortMultipartUpload discards an upload and its parts
+	AbortMultipartUpload(ctx context.Context, fileID, uploadID string) error
+}
+
+// Part is a stored part of a multipart upload
+type Part struct {
+	Number int    `json:"part_number"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
 // FileInfo contains metadata about a stored file
 type FileInfo struct {
 	ID          string    `json:"id"`
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/cerberus/backend/internal/modules/risk"
+	"github.com/google/uuid"
+)
+
+// runAggregateRiskAnalysis analyzes the last 24 hours of unprocessed insights for each active
+// program (cross-artifact pattern detection). It fails if any program could not be analyzed.
+func (w *Worker) runAggregateRiskAnalysis(ctx context.Context) error {
+	log.Println("Running aggregate risk analysis...")
+
+	// Query for programs with recent insights
+	query := `
+		SELECT DISTINCT a.program_id
+		FROM artifact_insights ai
+		INNER JOIN artifacts a ON ai.artifact_id = a.artifact_id
+		WHERE ai.extracted_at >= NOW() - INTERVAL '24 hours'
+		  AND ai.is_dismissed = FALSE
+		  AND a.deleted_at IS NULL
+	`
+
+	rows, err := w.database.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query programs for aggregate analysis: %w", err)
+	}
+
+	var programIDs []uuid.UUID
+	for rows.Next() {
+		var programID uuid.UUID
+		if err := rows.Scan(&programID); err != nil {
+			log.Printf("Failed to scan program ID: %v", err)
+			continue
+		}
+		programIDs = append(programIDs, programID)
+	}
+	rows.Close()
+
+	// Analyze each program's recent insights
+	failed := 0
+	for _, programID := range programIDs {
+		log.Printf("Analyzing aggregate risks for program: %s", programID)
+
+		insights, err := w.fetchUnprocessedInsights(ctx, programID)
+		if err != nil {
+			log.Printf("Failed to query insights for program %s: %v", programID, err)
+			failed++
+			continue
+		}
+
+		if len(insights) > 0 {
+			log.Printf("Found %d unprocessed insights for program %s", len(insights), programID)
+			if err := w.riskDetector.AnalyzeForRisks(ctx, insights); err != nil {
+				log.Printf("Failed aggregate risk analysis for program %s: %v", programID, err)
+				failed++
+			} else {
+				log.Printf("Completed aggregate risk analysis for program %s", programID)
+			}
+
+			// Enrich existing risks with the insights
+			log.Printf("Checking for risk enrichment opportunities for program %s...", programID)
+			if err := w.riskDetector.EnrichExistingRisks(ctx, insights); err != nil {
+				log.Printf("Failed aggregate risk enrichment for program %s: %v", programID, err)
+			} else {
+				log.Printf("Completed aggregate risk enrichment for program %s", programID)
+			}
+		}
+	}
+
+	log.Println("Aggregate risk analysis completed")
+	if failed > 0 {
+		return fmt.Errorf("aggregate risk analysis failed for %d of %d programs", failed, len(programIDs))
+	}
+	return nil
+}
+
+// fetchUnprocessedInsights returns a program's insights from the last 24 hours
+// that have not already been converted to risk suggestions
+func (w *Worker) fetchUnprocessedInsights(ctx context.Context, programID uuid.UUID) ([]risk.ArtifactInsight, error) {
+	query := `
+		SELECT ai.insight_id, ai.artifact_id, ai.insight_type, ai.title,
+		       ai.description, ai.severity, ai.confidence_score
+		FROM artifact_insights ai
+		INNER JOIN artifacts a ON ai.artifact_id = a.artifact_id
+		WHERE a.program_id = $1
+		  AND ai.extracted_at >= NOW() - INTERVAL '24 hours'
+		  AND ai.is_dismissed = FALSE
+		  AND a.deleted_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM risk_suggestions rs
+		      WHERE rs.source_insight_id = ai.insight_id
+		  )
+		ORDER BY ai.severity DESC, ai.confidence_score DESC
+		LIMIT 100
+	`
+
+	rows, err := w.database.QueryContext(ctx, query, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var insights []risk.ArtifactInsight
+	for rows.Next() {
+		var insight risk.ArtifactInsight
+		var severity sql.NullString
+		var confidence sql.NullFloat64
+
+		err := rows.Scan(
+			&insight.InsightID,
+			&insight.ArtifactID,
+			&insight.InsightType,
+			&insight.Title,
+			&insight.Description,
+			&severity,
+			&confidence,
+		)
+		if err != nil {
+			log.Printf("Failed to scan insight: %v", err)
+			continue
+		}
+
+		insight.ProgramID = programID
+		insight.Severity = severity.String
+		insight.ConfidenceScore = confidence.Float64
+		insights = append(insights, insight)
+	}
+
+	return insights, nil
+}
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cerberus/backend/internal/modules/artifacts"
+	"github.com/cerberus/backend/internal/modules/webhooks"
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/cerberus/backend/internal/platform/jobs"
+)
+
+// Config holds the worker's external dependencies and tuning
+type Config struct {
+	AnthropicAPIKey string
+	OpenAIAPIKey    string // Embeddings are skipped when empty
+	RedisURL        string
+	StorageEndpoint string
+
+	// LLM selects the model API used for analysis and OCR
+	LLM ai.ProviderConfig
+
+	// ModelPolicyPath names a JSON file of model prices, fallbacks and routing rules; the
+	// built-in Claude prices are used when empty
+	ModelPolicyPath string
+
+	// ResponseCache sets how long AI responses are cached, overall and per job type
+	ResponseCache ai.ResponseCacheConfig
+
+	// RateLimit paces AI requests; its budgets are shared with every worker using the same
+	// provider through Redis
+	RateLimit ai.RateLimitConfig
+
+	// Concurrency limits how many artifacts are processed at once
+	Concurrency int
+
+	// MaxExtractBytes is the largest streamed upload whose content is extracted; extraction
+	// holds the whole file in memory
+	MaxExtractBytes int64
+
+	// Pipeline controls per-stage retries and artifact leases for artifact processing
+	Pipeline artifacts.PipelineConfig
+
+	// UploadConsumer controls delivery of artifact.uploaded events. The pipeline retries
+	// stages itself, so redelivery only covers infrastructure failures (database, claim errors).
+	UploadConsumer events.ConsumerConfig
+
+	// Webhooks controls delivery of program events to webhook subscriptions
+	Webhooks webhooks.DispatcherConfig
+
+	// Scheduler controls the scheduled job runner
+	Scheduler jobs.Config
+}
+
+// DefaultConfig returns the worker configuration used when nothing is overridden
+func DefaultConfig() Config {
+	return Config{
+		RedisURL:        "redis:6379",
+		StorageEndpoint: "http://rustfs:9000",
+		ResponseCache:   ai.DefaultResponseCacheConfig(),
+		Concurrency:     5,
+		MaxExtractBytes: artifacts.DefaultMaxExtractBytes,
+		Pipeline:        artifacts.DefaultPipelineConfig(),
+		UploadConsumer:  events.ConsumerConfig{MaxDeliver: 5, AckWait: time.Minute},
+		Webhooks:        webhooks.DefaultDispatcherConfig(),
+		Scheduler:       jobs.DefaultConfig(),
+	}
+}
+
+// ConfigFromEnv reads the worker configuration from environment variables
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.AnthropicAPIKey = getEnv("ANTHROPIC_API_KEY", "")
+	cfg.OpenAIAPIKey = getEnv("OPENAI_API_KEY", "")
+	cfg.RedisURL = getEnv("REDIS_URL", cfg.RedisURL)
+	cfg.StorageEndpoint = getEnv("STORAGE_ENDPOINT", cfg.StorageEndpoint)
+
+	cfg.LLM.Name = getEnv("LLM_PROVIDER", ai.ProviderAnthropic)
+	cfg.LLM.BaseURL = getEnv("LLM_BASE_URL", "")
+	cfg.LLM.Model = getEnv("LLM_MODEL", "")
+	cfg.LLM.FixturesDir = getEnv("LLM_FAKE_FIXTURES", "")
+	cfg.LLM.RecordDir = getEnv("LLM_RECORD_DIR", "")
+	cfg.LLM.APIKey = getEnv("LLM_API_KEY", "")
+	if cfg.LLM.APIKey == "" && cfg.LLM.Name == ai.ProviderAnthropic {
+		cfg.LLM.APIKey = cfg.AnthropicAPIKey
+	}
+
+	cfg.ModelPolicyPath = getEnv("AI_MODEL_POLICY", "")
+
+	cfg.ResponseCache.TTL = getEnvDuration("AI_CACHE_TTL", cfg.ResponseCache.TTL)
+	cfg.ResponseCache.JobTTLs = getEnvDurations("AI_CACHE_JOB_TTLS", cfg.ResponseCache.JobTTLs)
+
+	cfg.RateLimit.Name = cfg.LLM.Name
+	cfg.RateLimit.RequestsPerMinute = getEnvInt("AI_REQUESTS_PER_MINUTE", cfg.RateLimit.RequestsPerMinute)
+	cfg.RateLimit.TokensPerMinute = getEnvInt("AI_TOKENS_PER_MINUTE", cfg.RateLimit.TokensPerMinute)
+	cfg.RateLimit.MaxConcurrent = getEnvInt("AI_MAX_CONCURRENT", cfg.RateLimit.MaxConcurrent)
+	cfg.RateLimit.Replica = getEnv("WORKER_ID", cfg.RateLimit.Replica)
+
+	cfg.Concurrency = getEnvInt("ARTIFACT_CONCURRENCY", cfg.Concurrency)
+	cfg.MaxExtractBytes = int64(getEnvInt("EXTRACT_MAX_BYTES", int(cfg.MaxExtractBytes)))
+	cfg.Pipeline.MaxAttempts = getEnvInt("PIPELINE_MAX_ATTEMPTS", cfg.Pipeline.MaxAttempts)
+	cfg.UploadConsumer.MaxDeliver = getEnvInt("EVENT_MAX_DELIVER", cfg.UploadConsumer.MaxDeliver)
+	cfg.Webhooks.MaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", cfg.Webhooks.MaxAttempts)
+	cfg.Scheduler.WorkerID = getEnv("WORKER_ID", cfg.Scheduler.WorkerID)
+	cfg.Pipeline.WorkerID = getEnv("WORKER_ID", cfg.Pipeline.WorkerID)
+
+	return cfg
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt reads a positive integer, falling back on missing or invalid values
+func getEnvInt(key string, fallback int) int {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return fallback
+	}
+
+	var value int
+	if _, err := fmt.Sscanf(valueStr, "%d", &value); err != nil || value < 1 {
+		log.Printf("Invalid %s value, using default: %d", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration reads a duration such as 30m; zero is allowed
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return fallback
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value < 0 {
+		log.Printf("Invalid %s value, using default: %s", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDurations reads comma-separated name=duration pairs, e.g. ocr=24h,invoice_extraction=0
+func getEnvDurations(key string, fallback map[string]time.Duration) map[string]time.Duration {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return fallback
+	}
+
+	values := make(map[string]time.Duration)
+	for _, pair := range strings.Split(valueStr, ",") {
+		name, durationStr, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		value, err := time.ParseDuration(durationStr)
+		if !ok || name == "" || err != nil || value < 0 {
+			log.Printf("Invalid %s value, using default", key)
+			return fallback
+		}
+		values[name] = value
+	}
+	return values
+}
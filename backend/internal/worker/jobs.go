@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cerberus/backend/internal/platform/jobs"
+)
+
+// jobRunRetention is how long finished job runs are kept in job_runs
+const jobRunRetention = 30 * 24 * time.Hour
+
+// registerJobs adds the worker's recurring work to the scheduler. Pipeline polling runs too
+// often to record each run and is a plain loop in Run instead.
+func (w *Worker) registerJobs() error {
+	jobRepo := jobs.NewRepository(w.database)
+
+	for _, job := range []jobs.Job{
+		{
+			Name:        "aggregate-risk-analysis",
+			Description: "Looks for risks across each program's insights from the last 24 hours",
+			Schedule:    "*/30 * * * *",
+			Timeout:     20 * time.Minute,
+			Run:         w.runAggregateRiskAnalysis,
+		},
+		{
+			Name:        "context-cache-cleanup",
+			Description: "Removes expired entries from the analysis context cache",
+			Schedule:    "@hourly",
+			Timeout:     10 * time.Minute,
+			Run:         w.contextJobs.RunCacheCleanup,
+		},
+		{
+			Name:        "upload-session-cleanup",
+			Description: "Discards resumable uploads that expired before completion, with their stored parts",
+			Schedule:    "15 * * * *",
+			Timeout:     10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				discarded, err := w.artifactsService.AbortExpiredUploadSessions(ctx)
+				if discarded > 0 {
+					log.Printf("Discarded %d expired upload sessions", discarded)
+				}
+				return err
+			},
+		},
+		{
+			Name:        "entity-graph-rebuild",
+			Description: "Recomputes entity co-occurrences and relationship strengths",
+			Schedule:    "0 2 * * *",
+			Timeout:     time.Hour,
+			Run:         w.contextJobs.RunEntityGraphRebuild,
+		},
+		{
+			Name:        "sequence-detection",
+			Description: "Detects recurring artifact sequences such as weekly reports",
+			Schedule:    "0 3 * * *",
+			Timeout:     time.Hour,
+			Run:         w.contextJobs.RunSequenceDetection,
+		},
+		{
+			Name:        "job-history-cleanup",
+			Description: "Deletes finished job runs older than 30 days",
+			Schedule:    "30 4 * * *",
+			Timeout:     10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				pruned, err := jobRepo.PruneRuns(ctx, jobRunRetention)
+				if pruned > 0 {
+					log.Printf("Pruned %d finished job runs", pruned)
+				}
+				return err
+			},
+		},
+	} {
+		if err := w.scheduler.Register(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
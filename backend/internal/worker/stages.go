@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cerberus/backend/internal/modules/artifacts"
+	"github.com/cerberus/backend/internal/modules/financial"
+	"github.com/cerberus/backend/internal/modules/risk"
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/google/uuid"
+)
+
+// stageDeps holds the services the pipeline stages call into
+type stageDeps struct {
+	database          *db.DB
+	artifactsService  *artifacts.Service
+	aiAnalyzer        *artifacts.AIAnalyzer
+	ocrService        *artifacts.OCRService
+	embeddingsService *artifacts.EmbeddingsService
+	embeddingsEnabled bool
+	invoiceAnalyzer   *financial.InvoiceAnalyzer
+	riskDetector      *risk.RiskDetector
+	contextBuilder    *ai.ContextBuilder
+}
+
+// pipelineStages returns the artifact processing stages in execution order:
+// extract -> analyze -> risk -> embeddings -> invoice
+func (d *stageDeps) pipelineStages() []artifacts.PipelineStage {
+	return []artifacts.PipelineStage{
+		{Name: artifacts.StageExtract, Run: d.extract},
+		{Name: artifacts.StageAnalyze, Run: deferOverBudget(d.analyze)},
+		{Name: artifacts.StageRisk, Run: d.detectRisks},
+		{Name: artifacts.StageEmbeddings, Run: d.generateEmbeddings},
+		{Name: artifacts.StageInvoice, Run: deferOverBudget(d.processInvoice)},
+	}
+}
+
+// deferOverBudget defers the artifact until the program's AI budget resets when a stage is
+// refused for exceeding it, instead of retrying and dead-lettering the artifact
+func deferOverBudget(run artifacts.StageFunc) artifacts.StageFunc {
+	return func(ctx context.Context, artifact *artifacts.Artifact) error {
+		err := run(ctx, artifact)
+
+		var budgetErr *ai.BudgetExceededError
+		if errors.As(err, &budgetErr) {
+			return &artifacts.DeferredError{Until: budgetErr.ResetsAt, Reason: budgetErr.Error()}
+		}
+		return err
+	}
+}
+
+// extract extracts the content of streamed uploads and runs OCR for scanned documents; other
+// artifacts had their text extracted at upload
+func (d *stageDeps) extract(ctx context.Context, artifact *artifacts.Artifact) error {
+	if artifact.ExtractionPending {
+		log.Printf("Extracting content of artifact: %s", artifact.ArtifactID)
+		if err := d.artifactsService.ExtractDeferred(ctx, artifact); err != nil {
+			return fmt.Errorf("extraction failed: %w", err)
+		}
+	}
+
+	if artifact.ProcessingStatus != "ocr_required" {
+		return nil
+	}
+
+	log.Printf("Processing OCR-required artifact: %s", artifact.ArtifactID)
+	if err := d.ocrService.ProcessOCRRequired(ctx, artifact.ArtifactID); err != nil {
+		return fmt.Errorf("OCR failed: %w", err)
+	}
+
+	log.Printf("OCR completed for artifact: %s", artifact.ArtifactID)
+	return nil
+}
+
+// analyze runs AI analysis and stores the extracted metadata
+func (d *stageDeps) analyze(ctx context.Context, artifact *artifacts.Artifact) error {
+	programContext := d.contextBuilder.BuildContextOrDefault(ctx, artifact.ProgramID)
+
+	if err := d.aiAnalyzer.ProcessArtifact(ctx, artifact, programContext); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully analyzed artifact: %s (%s)", artifact.Filename, artifact.ArtifactID)
+	return nil
+}
+
+// detectRisks turns the artifact's insights into risk suggestions and enriches existing risks
+func (d *stageDeps) detectRisks(ctx context.Context, artifact *artifacts.Artifact) error {
+	insights, err := fetchArtifactInsights(ctx, d.database, artifact.ArtifactID, artifact.ProgramID)
+	if err != nil {
+		return err
+	}
+	if len(insights) == 0 {
+		return artifacts.ErrStageSkipped
+	}
+
+	log.Printf("Analyzing %d insights for risk detection...", len(insights))
+	if err := d.riskDetector.AnalyzeForRisks(ctx, insights); err != nil {
+		return fmt.Errorf("risk detection failed: %w", err)
+	}
+
+	if err := d.riskDetector.EnrichExistingRisks(ctx, insights); err != nil {
+		return fmt.Errorf("risk enrichment failed: %w", err)
+	}
+
+	log.Printf("Risk detection completed for artifact: %s", artifact.ArtifactID)
+	return nil
+}
+
+// generateEmbeddings creates vector embeddings for semantic search, if configured
+func (d *stageDeps) generateEmbeddings(ctx context.Context, artifact *artifacts.Artifact) error {
+	if !d.embeddingsEnabled || !artifact.RawContent.Valid || artifact.RawContent.String == "" {
+		return artifacts.ErrStageSkipped
+	}
+
+	if err := d.embeddingsService.GenerateEmbeddings(ctx, artifact.ArtifactID); err != nil {
+		return err
+	}
+
+	log.Printf("Generated embeddings for artifact: %s", artifact.ArtifactID)
+	return nil
+}
+
+// processInvoice extracts invoice data for artifacts the analysis categorized as invoices
+func (d *stageDeps) processInvoice(ctx context.Context, artifact *artifacts.Artifact) error {
+	if !artifact.ArtifactCategory.Valid || artifact.ArtifactCategory.String != "invoice" || !artifact.RawContent.Valid {
+		return artifacts.ErrStageSkipped
+	}
+
+	log.Printf("Processing invoice artifact: %s (filename: %s)", artifact.ArtifactID, artifact.Filename)
+
+	programContext := d.contextBuilder.BuildContextOrDefault(ctx, artifact.ProgramID)
+	err := d.invoiceAnalyzer.ProcessInvoice(
+		ctx,
+		artifact.ArtifactID,
+		artifact.RawContent.String,
+		artifact.ProgramID,
+		programContext,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to process invoice: %w", err)
+	}
+
+	log.Printf("Invoice processed for artifact: %s", artifact.ArtifactID)
+	return nil
+}
+
+// fetchArtifactInsights retrieves insights for risk analysis
+func fetchArtifactInsights(ctx context.Context, database *db.DB, artifactID, programID uuid.UUID) ([]risk.ArtifactInsight, error) {
+	query := `
+		SELECT insight_id, artifact_id, insight_type, title, description, severity, confidence_score
+		FROM artifact_insights
+		WHERE artifact_id = $1 AND is_dismissed = FALSE
+		ORDER BY extracted_at DESC
+	`
+
+	rows, err := database.QueryContext(ctx, query, artifactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insights: %w", err)
+	}
+	defer rows.Close()
+
+	var insights []risk.ArtifactInsight
+	for rows.Next() {
+		var insight risk.ArtifactInsight
+		var severity sql.NullString
+		var confidence sql.NullFloat64
+
+		err := rows.Scan(
+			&insight.InsightID,
+			&insight.ArtifactID,
+			&insight.InsightType,
+			&insight.Title,
+			&insight.Description,
+			&severity,
+			&confidence,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insight: %w", err)
+		}
+
+		insight.ProgramID = programID
+		insight.Severity = severity.String
+		insight.ConfidenceScore = confidence.Float64
+
+		insights = append(insights, insight)
+	}
+
+	return insights, nil
+}
@@ -0,0 +1,396 @@
+// Package worker runs background processing: it consumes artifact.uploaded events, turns
+// financial.variance_detected events into risk suggestions, delivers events to webhook
+// subscriptions, polls the database for outstanding pipeline work, and runs scheduled jobs
+// (aggregate risk analysis, context graph maintenance).
+// The same worker runs as its own binary (cmd/worker, over NATS) or inside the API process
+// (over the in-memory bus) for local development and integration tests.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cerberus/backend/internal/modules/artifacts"
+	"github.com/cerberus/backend/internal/modules/financial"
+	"github.com/cerberus/backend/internal/modules/programs"
+	"github.com/cerberus/backend/internal/modules/prompts"
+	"github.com/cerberus/backend/internal/modules/risk"
+	"github.com/cerberus/backend/internal/modules/webhooks"
+	"github.com/cerberus/backend/internal/platform/ai"
+	"github.com/cerberus/backend/internal/platform/db"
+	"github.com/cerberus/backend/internal/platform/events"
+	"github.com/cerberus/backend/internal/platform/jobs"
+	"github.com/cerberus/backend/internal/platform/storage"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Worker processes artifacts through the analysis pipeline
+type Worker struct {
+	config           Config
+	database         *db.DB
+	eventBus         events.Bus
+	redisClient      *redis.Client
+	rateLimiter      *ai.RateLimiter
+	responseCache    *ai.ResponseCache
+	artifactsRepo    *artifacts.Repository
+	artifactsService *artifacts.Service
+	riskDetector     *risk.RiskDetector
+	pipeline         *artifacts.Pipeline
+	webhooks         *webhooks.Dispatcher
+	scheduler        *jobs.Scheduler
+	contextJobs      *artifacts.BackgroundJobs
+
+	sem      chan struct{}
+	queued   sync.Map // artifact IDs already waiting for or holding a slot in this worker
+	inFlight sync.WaitGroup
+}
+
+// New builds the worker's services and subscribes its handlers to eventBus.
+// The caller owns eventBus and must Start it after New returns.
+func New(database *db.DB, eventBus events.Bus, cfg Config) (*Worker, error) {
+	// Create Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: cfg.RedisURL,
+	})
+
+	// Test Redis connection
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	log.Println("Redis connection established")
+
+	// Create AI client; spend is attributed per program and checked against program budgets
+	provider, err := ai.NewProvider(cfg.LLM)
+	if err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("failed to create AI provider: %w", err)
+	}
+	log.Printf("AI provider: %s", cfg.LLM.Name)
+
+	modelPolicy, err := ai.LoadModelPolicy(cfg.ModelPolicyPath)
+	if err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("failed to load AI model policy: %w", err)
+	}
+
+	configService := programs.NewConfigService(database)
+	modelPolicy.SetProgramConfigs(configService)
+	responseCache := ai.NewResponseCache(redisClient, cfg.ResponseCache)
+	responseCache.SetProgramConfigs(configService)
+	metricsTracker := ai.NewDBMetricsTracker(database)
+	rateLimiter := ai.NewRateLimiter(cfg.RateLimit, redisClient)
+	claudeClient := ai.NewClient(&ai.ClientConfig{
+		Provider:       provider,
+		ResponseCache:  responseCache,
+		MetricsTracker: metricsTracker,
+		BudgetChecker:  ai.NewBudgetGuard(configService, metricsTracker),
+		RateLimiter:    rateLimiter,
+		ModelPolicy:    modelPolicy,
+	})
+
+	// Create storage client for extraction and OCR
+	storageClient := storage.NewRustFSClient(cfg.StorageEndpoint)
+
+	// Create artifacts repository, service and analyzer
+	artifactsRepo := artifacts.NewRepository(database)
+	artifactsService := artifacts.NewService(artifactsRepo, storageClient)
+	artifactsService.SetMaxExtractBytes(cfg.MaxExtractBytes)
+
+	// Initialize AI Analyzer with enriched context support
+	log.Println("Initializing AI Analyzer with enriched context graph support...")
+	aiAnalyzer, err := artifacts.InitializeAIAnalyzerWithContext(claudeClient, artifactsRepo, redisClient)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize context graph, using basic analyzer: %v", err)
+		aiAnalyzer = artifacts.NewAIAnalyzer(claudeClient, artifactsRepo)
+	} else {
+		log.Println("✅ Enriched context graph system ENABLED")
+	}
+
+	// Serve analysis prompts from the prompt registry, seeded with the built-in versions
+	promptsService := prompts.NewService(prompts.NewRepository(database))
+	if err := promptsService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to store built-in prompts: %v", err)
+	}
+	aiAnalyzer.SetPromptStore(promptsService)
+
+	embeddingsService := artifacts.NewEmbeddingsService(cfg.OpenAIAPIKey, artifactsRepo)
+	ocrService := artifacts.NewOCRService(artifactsRepo, storageClient, claudeClient)
+
+	// Create financial module services
+	financialRepo := financial.NewRepository(database)
+	invoiceAnalyzer := financial.NewInvoiceAnalyzer(claudeClient, financialRepo)
+
+	// Create risk detection services
+	riskRepo := risk.NewRepository(database)
+	riskDetector := risk.NewRiskDetector(riskRepo)
+
+	// Create program context builder
+	stakeholderRepo := programs.NewStakeholderRepository(database)
+	contextBuilder := ai.NewContextBuilder(configService, stakeholderRepo)
+
+	// Build the artifact processing pipeline (extract -> analyze -> risk -> embeddings -> invoice)
+	deps := &stageDeps{
+		database:          database,
+		artifactsService:  artifactsService,
+		aiAnalyzer:        aiAnalyzer,
+		ocrService:        ocrService,
+		embeddingsService: embeddingsService,
+		embeddingsEnabled: cfg.OpenAIAPIKey != "",
+		invoiceAnalyzer:   invoiceAnalyzer,
+		riskDetector:      riskDetector,
+		contextBuilder:    contextBuilder,
+	}
+	pipeline := artifacts.NewPipeline(artifactsRepo, cfg.Pipeline, deps.pipelineStages()...)
+	log.Printf("Artifact pipeline configured with max %d attempts per stage", cfg.Pipeline.MaxAttempts)
+
+	// Create webhook dispatcher
+	webhookDispatcher := webhooks.NewDispatcher(webhooks.NewRepository(database), cfg.Webhooks)
+
+	// Context graph maintenance, run by the scheduler
+	contextJobs := artifacts.NewBackgroundJobs(
+		artifacts.NewContextCache(redisClient, artifactsRepo, 24*time.Hour),
+		artifacts.NewEntityGraphQuery(artifactsRepo),
+		artifacts.NewTemporalOrganizer(artifactsRepo),
+	)
+
+	w := &Worker{
+		config:           cfg,
+		database:         database,
+		eventBus:         eventBus,
+		redisClient:      redisClient,
+		rateLimiter:      rateLimiter,
+		responseCache:    responseCache,
+		artifactsRepo:    artifactsRepo,
+		artifactsService: artifactsService,
+		riskDetector:     riskDetector,
+		pipeline:         pipeline,
+		webhooks:         webhookDispatcher,
+		scheduler:        jobs.NewScheduler(jobs.NewRepository(database), cfg.Scheduler),
+		contextJobs:      contextJobs,
+		sem:              make(chan struct{}, cfg.Concurrency),
+	}
+	log.Printf("Worker configured with max concurrency: %d", cfg.Concurrency)
+
+	if err := w.registerJobs(); err != nil {
+		redisClient.Close()
+		return nil, err
+	}
+
+	// Subscribe to artifact.uploaded and financial.variance_detected events, and feed every
+	// event to the webhook dispatcher
+	eventBus.ConfigureConsumer(events.ArtifactUploaded, cfg.UploadConsumer)
+	subscriptions := map[events.EventType]events.EventHandler{
+		events.ArtifactUploaded: w.handleArtifactUploaded,
+		events.VarianceDetected: w.handleVarianceDetected,
+		events.AllEvents:        webhookDispatcher.HandleEvent,
+	}
+	for eventType, handler := range subscriptions {
+		if err := eventBus.Subscribe(eventType, handler); err != nil {
+			redisClient.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+		}
+	}
+
+	return w, nil
+}
+
+// Run polls for outstanding pipeline work, runs scheduled jobs, sends webhook deliveries and
+// reports the AI request queue until ctx is cancelled, then waits for in-flight pipelines to
+// release their artifacts
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		w.pollPipeline(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		w.scheduler.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		w.webhooks.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		w.rateLimiter.ReportStats(ctx)
+	}()
+
+	log.Println("Worker started, processing artifact pipeline...")
+
+	wg.Wait()
+	w.inFlight.Wait() // Interrupted pipelines release their artifacts for the next worker
+}
+
+// Close releases the worker's connections. The event bus and database belong to the caller.
+func (w *Worker) Close() error {
+	return w.redisClient.Close()
+}
+
+// handleArtifactUploaded runs the pipeline for an uploaded artifact
+func (w *Worker) handleArtifactUploaded(ctx context.Context, event *events.Event) error {
+	if event.Delivery.IsRedelivery() {
+		log.Printf("Received artifact upload event: %s (redelivery %d/%d)", event.ID, event.Delivery.Attempt, event.Delivery.MaxAttempts)
+	} else {
+		log.Printf("Received artifact upload event: %s", event.ID)
+	}
+
+	var payload events.ArtifactUploadedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+	if payload.ArtifactID == uuid.Nil {
+		return fmt.Errorf("invalid artifact_id in payload")
+	}
+
+	// Reanalysis and requeues yield AI capacity to new uploads and interactive requests
+	if payload.Trigger != events.UploadTriggerUpload {
+		ctx = ai.WithPriority(ctx, ai.PriorityBatch)
+	}
+
+	// A reanalysis asks for fresh results rather than the cached ones
+	if payload.Trigger == events.UploadTriggerReanalysis {
+		if err := w.responseCache.InvalidateArtifact(ctx, payload.ArtifactID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return w.processArtifact(ctx, payload.ArtifactID, event.CorrelationID)
+}
+
+// handleVarianceDetected turns a financial variance into a risk suggestion.
+// Redeliveries are harmless: the detector skips suggestions it already made.
+func (w *Worker) handleVarianceDetected(ctx context.Context, event *events.Event) error {
+	var payload events.VarianceDetectedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	log.Printf("Received variance detected event: %s (variance %s, severity %s)", event.ID, payload.VarianceID, payload.Severity)
+
+	variance := risk.FinancialVariance{
+		VarianceID:        payload.VarianceID,
+		ProgramID:         event.ProgramID,
+		VarianceType:      payload.VarianceType,
+		Title:             payload.Title,
+		Description:       payload.Description,
+		Severity:          payload.Severity,
+		VarianceAmount:    payload.VarianceAmount,
+		ConfidenceScore:   payload.ConfidenceScore,
+		SourceArtifactIDs: payload.SourceArtifactIDs,
+	}
+	if err := w.riskDetector.ProcessFinancialVariance(ctx, variance); err != nil {
+		return fmt.Errorf("failed to process financial variance: %w", err)
+	}
+
+	return nil
+}
+
+// processArtifact is the single code path for events and database polling.
+// It blocks until the pipeline finishes so the event is acknowledged only after completion.
+func (w *Worker) processArtifact(ctx context.Context, artifactID, correlationID uuid.UUID) error {
+	if _, loaded := w.queued.LoadOrStore(artifactID, struct{}{}); loaded {
+		return nil
+	}
+	defer w.queued.Delete(artifactID)
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-w.sem }()
+
+	return pipelineResult(w.pipeline.Process(ctx, artifactID, correlationID))
+}
+
+// pipelineResult drops pipeline errors that redelivering the event would not help with
+func pipelineResult(err error) error {
+	if errors.Is(err, artifacts.ErrDeadLettered) || errors.Is(err, artifacts.ErrDeferred) || errors.Is(err, artifacts.ErrLeaseLost) {
+		// Parked (dead-lettered, or deferred until it can resume), or another worker took it over
+		return nil
+	}
+	return err
+}
+
+// pipelinePollInterval is how often the database is polled for outstanding pipeline work
+const pipelinePollInterval = 10 * time.Second
+
+// pollPipeline picks up outstanding pipeline work (missed events, interrupted runs, expired
+// leases) until ctx is cancelled
+func (w *Worker) pollPipeline(ctx context.Context) {
+	ticker := time.NewTicker(pipelinePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.claimPipelineWork(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Pipeline poll failed: %v", err)
+			}
+		}
+	}
+}
+
+// claimPipelineWork claims only as many artifacts as there are free slots, so no lease is held
+// by an artifact waiting for a slot. The artifacts are processed in the background; it returns
+// once they are dispatched.
+func (w *Worker) claimPipelineWork(ctx context.Context) error {
+	slots := w.acquireFreeSlots()
+	if slots == 0 {
+		return nil
+	}
+
+	claimed, err := w.pipeline.ClaimBatch(ctx, slots)
+	if err != nil {
+		w.releaseSlots(slots)
+		return fmt.Errorf("failed to claim pipeline work: %w", err)
+	}
+	w.releaseSlots(slots - len(claimed))
+
+	if len(claimed) > 0 {
+		log.Printf("Claimed %d artifacts awaiting processing in database polling", len(claimed))
+	}
+
+	for _, artifactID := range claimed {
+		artifactID := artifactID // Capture loop variable
+		w.inFlight.Add(1)
+		go func() {
+			defer w.inFlight.Done()
+			defer w.releaseSlots(1)
+			err := pipelineResult(w.pipeline.ProcessClaimed(ctx, artifactID, uuid.Nil))
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Failed to process artifact %s: %v", artifactID, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// acquireFreeSlots takes every processing slot that is free right now and returns how many
+func (w *Worker) acquireFreeSlots() int {
+	for n := 0; ; n++ {
+		select {
+		case w.sem <- struct{}{}:
+		default:
+			return n
+		}
+	}
+}
+
+// releaseSlots gives back n processing slots
+func (w *Worker) releaseSlots(n int) {
+	for i := 0; i < n; i++ {
+		<-w.sem
+	}
+}
@@ -0,0 +1,69 @@
+-- Migration: 013_artifact_pipeline.sql
+-- Purpose: Durable, stage-based artifact processing pipeline
+-- Tracks per-stage progress (extract -> analyze -> risk -> embeddings -> invoice)
+-- so a worker crash resumes from the last completed stage, and records artifacts
+-- that exhausted their retries in a dead-letter table operators can requeue.
+
+-- ============================================================================
+-- Pipeline state on the artifact
+-- ============================================================================
+
+ALTER TABLE artifacts
+    ADD COLUMN pipeline_status VARCHAR(20),          -- NULL (not started), queued, running, completed, dead_lettered
+    ADD COLUMN pipeline_stage VARCHAR(50),           -- Stage currently (or last) being executed
+    ADD COLUMN pipeline_updated_at TIMESTAMPTZ;      -- Heartbeat while running, used to detect stalled runs
+
+ALTER TABLE artifacts
+    ADD CONSTRAINT artifacts_pipeline_status_check
+    CHECK (pipeline_status IS NULL OR pipeline_status IN ('queued', 'running', 'completed', 'dead_lettered'));
+
+CREATE INDEX idx_artifacts_pipeline_active ON artifacts(pipeline_status, pipeline_updated_at)
+    WHERE pipeline_status IN ('queued', 'running') AND deleted_at IS NULL;
+
+-- ============================================================================
+-- Table: artifact_pipeline_stages
+-- Purpose: Per-stage execution state for each artifact
+-- ============================================================================
+
+CREATE TABLE artifact_pipeline_stages (
+    artifact_id UUID NOT NULL REFERENCES artifacts(artifact_id) ON DELETE CASCADE,
+    stage VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',   -- pending, running, completed, skipped, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (artifact_id, stage),
+    CONSTRAINT pipeline_stage_status_check CHECK (status IN ('pending', 'running', 'completed', 'skipped', 'failed'))
+);
+
+CREATE INDEX idx_pipeline_stages_status ON artifact_pipeline_stages(status)
+    WHERE status IN ('running', 'failed');
+
+COMMENT ON TABLE artifact_pipeline_stages IS 'Per-stage processing state for the artifact worker pipeline';
+COMMENT ON COLUMN artifact_pipeline_stages.attempts IS 'Total attempts across all runs, including retries';
+
+-- ============================================================================
+-- Table: artifact_dead_letters
+-- Purpose: Artifacts whose pipeline stage exhausted its retries
+-- ============================================================================
+
+CREATE TABLE artifact_dead_letters (
+    dead_letter_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    artifact_id UUID NOT NULL REFERENCES artifacts(artifact_id) ON DELETE CASCADE,
+    program_id UUID NOT NULL REFERENCES programs(program_id) ON DELETE CASCADE,
+    stage VARCHAR(50) NOT NULL,
+    error_message TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    requeued_at TIMESTAMPTZ,
+    requeued_by UUID REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_dead_letters_program ON artifact_dead_letters(program_id, created_at DESC);
+CREATE INDEX idx_dead_letters_open ON artifact_dead_letters(program_id)
+    WHERE requeued_at IS NULL;
+
+COMMENT ON TABLE artifact_dead_letters IS 'Artifacts that failed a pipeline stage after all retries; requeue via API';
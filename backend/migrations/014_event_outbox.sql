@@ -0,0 +1,34 @@
+-- Migration: 014_event_outbox.sql
+-- Purpose: Transactional outbox for domain events
+-- Services write events to event_outbox in the same transaction as the domain change.
+-- A relay publishes pending rows to the event bus and marks them sent, so an event is
+-- emitted if and only if its change committed (at-least-once; consumers dedupe on event_id).
+
+-- ============================================================================
+-- Table: event_outbox
+-- Purpose: Events awaiting (or already) published to the event bus
+-- ============================================================================
+
+CREATE TABLE event_outbox (
+    outbox_id BIGSERIAL PRIMARY KEY,                   -- Publish order
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+    program_id UUID,
+    event JSONB NOT NULL,                              -- The full serialized event
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Relay state
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ                                -- NULL until published
+);
+
+CREATE INDEX idx_event_outbox_pending ON event_outbox(next_attempt_at, outbox_id)
+    WHERE sent_at IS NULL;
+CREATE INDEX idx_event_outbox_sent ON event_outbox(sent_at)
+    WHERE sent_at IS NOT NULL;
+
+COMMENT ON TABLE event_outbox IS 'Domain events written in the same transaction as the change that produced them';
+COMMENT ON COLUMN event_outbox.next_attempt_at IS 'Earliest time the relay retries a failed publish';
+COMMENT ON COLUMN event_outbox.sent_at IS 'When the relay published the event; sent rows are purged after a retention period';
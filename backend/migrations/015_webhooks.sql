@@ -0,0 +1,67 @@
+-- Migration: 015_webhooks.sql
+-- Purpose: Outbound webhooks for program events
+-- Programs subscribe external URLs to event types. Every matching event becomes a
+-- delivery row that a dispatcher POSTs with an HMAC signature, retrying with backoff.
+-- Deliveries double as the delivery log and can be replayed.
+
+-- ============================================================================
+-- Table: webhook_subscriptions
+-- Purpose: Per-program webhook endpoints and the events they receive
+-- ============================================================================
+
+CREATE TABLE webhook_subscriptions (
+    subscription_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES programs(program_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,                              -- HMAC-SHA256 signing key
+    event_types TEXT[] NOT NULL DEFAULT '{}',          -- Empty means every event type
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_subscriptions_program ON webhook_subscriptions(program_id)
+    WHERE deleted_at IS NULL;
+
+-- ============================================================================
+-- Table: webhook_deliveries
+-- Purpose: One row per event sent (or to be sent) to a subscription
+-- ============================================================================
+
+CREATE TABLE webhook_deliveries (
+    delivery_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    program_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,                            -- Request body, signed as sent
+    replay_of UUID REFERENCES webhook_deliveries(delivery_id) ON DELETE SET NULL,
+
+    -- Delivery state
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error TEXT,
+    last_response_body TEXT,                           -- Truncated
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+
+    CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('pending', 'sending', 'succeeded', 'failed'))
+);
+
+-- An event is delivered once per subscription even if the bus redelivers it; replays are extra rows
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id)
+    WHERE replay_of IS NULL;
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+    WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+
+COMMENT ON TABLE webhook_subscriptions IS 'External endpoints notified of program events';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS 'Event types delivered to the endpoint; empty for all';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook delivery log and retry queue';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending (due at next_attempt_at), sending (claimed by a dispatcher until next_attempt_at), succeeded, failed (retries exhausted)';
+COMMENT ON COLUMN webhook_deliveries.replay_of IS 'Original delivery when this row was created by a replay';
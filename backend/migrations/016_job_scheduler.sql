@@ -0,0 +1,50 @@
+-- Migration: 016_job_scheduler.sql
+-- Purpose: Scheduled background jobs and their run history
+-- Workers register the jobs they run in scheduled_jobs and record every execution in job_runs.
+-- A unique (job, slot) row per scheduled run, together with a per-job advisory lock, keeps
+-- replicas from running the same slot twice. Admins trigger runs by inserting queued rows.
+
+-- ============================================================================
+-- Table: scheduled_jobs
+-- Purpose: Jobs known to the scheduler, as last registered by a worker
+-- ============================================================================
+
+CREATE TABLE scheduled_jobs (
+    job_name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    schedule VARCHAR(100) NOT NULL,                    -- Cron expression or @every descriptor
+    registered_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE scheduled_jobs IS 'Recurring background jobs run by the worker scheduler';
+COMMENT ON COLUMN scheduled_jobs.registered_at IS 'Last time a worker started with this job; stale rows are jobs no longer deployed';
+
+-- ============================================================================
+-- Table: job_runs
+-- Purpose: History of scheduled and manually triggered job executions
+-- ============================================================================
+
+CREATE TABLE job_runs (
+    run_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name VARCHAR(100) NOT NULL REFERENCES scheduled_jobs(job_name) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    scheduled_for TIMESTAMPTZ,                         -- Schedule slot; NULL for manual runs
+    requested_by UUID REFERENCES users(user_id),       -- Admin who triggered a manual run
+    worker_id VARCHAR(255),                            -- Replica that ran the job
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    error TEXT
+);
+
+-- One run per schedule slot, whichever replica gets there first
+CREATE UNIQUE INDEX idx_job_runs_slot ON job_runs(job_name, scheduled_for)
+    WHERE trigger = 'schedule';
+CREATE INDEX idx_job_runs_job ON job_runs(job_name, created_at DESC);
+CREATE INDEX idx_job_runs_queued ON job_runs(job_name, created_at)
+    WHERE status = 'queued';
+
+COMMENT ON TABLE job_runs IS 'Execution history of scheduled jobs; finished runs are pruned by the job-history-cleanup job';
+COMMENT ON COLUMN job_runs.status IS 'queued (manual, waiting for a worker), running, succeeded or failed';
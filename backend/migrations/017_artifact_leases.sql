@@ -0,0 +1,31 @@
+-- Migration: 017_artifact_leases.sql
+-- Purpose: Lease-based ownership of artifact pipeline runs
+-- Workers claim artifacts with SELECT ... FOR UPDATE SKIP LOCKED and record who holds the
+-- claim and until when. A running pipeline renews its lease while it works; once a lease
+-- expires (the worker died or hung) any worker may reclaim the artifact.
+
+-- ============================================================================
+-- Lease columns on the artifact
+-- ============================================================================
+
+ALTER TABLE artifacts
+    ADD COLUMN claimed_by VARCHAR(255),              -- Worker holding the pipeline lease
+    ADD COLUMN lease_expires_at TIMESTAMPTZ;         -- When the lease lapses unless renewed
+
+-- Runs in flight during the upgrade keep the five minutes they had under the heartbeat rule
+UPDATE artifacts
+SET lease_expires_at = pipeline_updated_at + INTERVAL '5 minutes'
+WHERE pipeline_status = 'running';
+
+-- Claimable work: new uploads (pending and ocr_required) and requeued runs
+CREATE INDEX idx_artifacts_pipeline_claimable ON artifacts(uploaded_at)
+    WHERE deleted_at IS NULL
+      AND ((pipeline_status IS NULL AND processing_status IN ('pending', 'ocr_required'))
+           OR pipeline_status = 'queued');
+
+-- Running pipelines, for reclaiming expired leases
+CREATE INDEX idx_artifacts_pipeline_lease ON artifacts(lease_expires_at)
+    WHERE pipeline_status = 'running' AND deleted_at IS NULL;
+
+COMMENT ON COLUMN artifacts.claimed_by IS 'Worker ID holding the pipeline lease while pipeline_status is running';
+COMMENT ON COLUMN artifacts.lease_expires_at IS 'Pipeline lease expiry; an expired running pipeline may be reclaimed by another worker';
@@ -0,0 +1,38 @@
+-- Migration: 018_ai_cost_attribution.sql
+-- Purpose: Per-program AI cost attribution and budget deferral
+-- AI usage is recorded against the program and artifact it was spent on. Programs can cap
+-- their daily and monthly AI spend in configuration (configuration->'ai_budget'); an artifact
+-- whose analysis hits a cap is deferred and resumes when the budget resets.
+
+-- ============================================================================
+-- AI usage attribution
+-- ============================================================================
+
+-- Usage that cannot be attributed to a program is still recorded
+ALTER TABLE ai_usage
+    ALTER COLUMN program_id DROP NOT NULL,
+    ADD COLUMN artifact_id UUID REFERENCES artifacts(artifact_id) ON DELETE SET NULL;
+
+-- Budget checks sum a program's spend for the current day and month
+CREATE INDEX idx_ai_usage_program_date ON ai_usage(program_id, created_at DESC);
+CREATE INDEX idx_ai_usage_artifact ON ai_usage(artifact_id) WHERE artifact_id IS NOT NULL;
+
+COMMENT ON COLUMN ai_usage.artifact_id IS 'Artifact the request was made for, if any';
+
+-- ============================================================================
+-- Deferred pipeline runs
+-- ============================================================================
+
+ALTER TABLE artifacts
+    ADD COLUMN deferred_until TIMESTAMPTZ,           -- When a deferred pipeline may resume
+    ADD COLUMN deferred_reason TEXT;                 -- Why it was deferred (e.g. AI budget exceeded)
+
+ALTER TABLE artifacts DROP CONSTRAINT artifacts_pipeline_status_check;
+ALTER TABLE artifacts
+    ADD CONSTRAINT artifacts_pipeline_status_check
+    CHECK (pipeline_status IS NULL OR pipeline_status IN ('queued', 'running', 'deferred', 'completed', 'dead_lettered'));
+
+CREATE INDEX idx_artifacts_pipeline_deferred ON artifacts(deferred_until)
+    WHERE pipeline_status = 'deferred' AND deleted_at IS NULL;
+
+COMMENT ON COLUMN artifacts.deferred_until IS 'Deferred pipelines are claimable again from this time; stages already completed are not re-run';
@@ -0,0 +1,97 @@
+-- Migration: 019_prompt_templates.sql
+-- Purpose: Versioned AI prompt templates with per-program overrides and A/B evaluation
+-- Prompts are stored with their full version history. A prompt has at most one active version
+-- per scope (global, or a program override) and optionally a candidate version that receives
+-- a share of artifacts. Every summary, insight and AI usage row records the prompt version
+-- that produced it, and prompt_runs records each analysis outcome, so versions can be compared.
+
+-- ============================================================================
+-- Table: prompt_templates
+-- Purpose: Prompt versions, global (program_id NULL) or overriding a prompt for one program
+-- ============================================================================
+
+CREATE TABLE prompt_templates (
+    prompt_template_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_id VARCHAR(100) NOT NULL,                   -- Stable prompt name, e.g. artifact_analysis_v1
+    version VARCHAR(50) NOT NULL,
+    program_id UUID REFERENCES programs(program_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'active', 'candidate', 'retired')),
+    traffic_percent INTEGER NOT NULL DEFAULT 0
+        CHECK (traffic_percent BETWEEN 0 AND 100),     -- Share of artifacts routed to a candidate
+    module VARCHAR(100) NOT NULL,
+    purpose TEXT NOT NULL DEFAULT '',
+    system_prompt TEXT NOT NULL,
+    user_prompt_template TEXT NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    temperature DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_tokens INTEGER NOT NULL CHECK (max_tokens > 0),
+    notes TEXT,
+    created_by UUID REFERENCES users(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Versions are unique within a scope; NULL program_id is the global scope
+CREATE UNIQUE INDEX idx_prompt_templates_version
+    ON prompt_templates(prompt_id, COALESCE(program_id, '00000000-0000-0000-0000-000000000000'::uuid), version);
+
+-- At most one active and one candidate version per prompt and scope
+CREATE UNIQUE INDEX idx_prompt_templates_active
+    ON prompt_templates(prompt_id, COALESCE(program_id, '00000000-0000-0000-0000-000000000000'::uuid))
+    WHERE status = 'active';
+CREATE UNIQUE INDEX idx_prompt_templates_candidate
+    ON prompt_templates(prompt_id, COALESCE(program_id, '00000000-0000-0000-0000-000000000000'::uuid))
+    WHERE status = 'candidate';
+
+CREATE INDEX idx_prompt_templates_program ON prompt_templates(program_id) WHERE program_id IS NOT NULL;
+
+COMMENT ON TABLE prompt_templates IS 'Versioned AI prompts; built-in prompts are seeded as global versions by the worker';
+COMMENT ON COLUMN prompt_templates.status IS 'draft, active (serves requests), candidate (serves traffic_percent of artifacts) or retired';
+COMMENT ON COLUMN prompt_templates.program_id IS 'Program whose requests use this version instead of the global one; NULL for global versions';
+
+-- ============================================================================
+-- Table: prompt_runs
+-- Purpose: Outcome of each analysis run, by prompt version
+-- ============================================================================
+
+CREATE TABLE prompt_runs (
+    run_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_id VARCHAR(100) NOT NULL,
+    prompt_version VARCHAR(50) NOT NULL,
+    program_id UUID NOT NULL REFERENCES programs(program_id) ON DELETE CASCADE,
+    artifact_id UUID REFERENCES artifacts(artifact_id) ON DELETE SET NULL,
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('succeeded', 'parse_failed')),
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_prompt_runs_prompt ON prompt_runs(prompt_id, prompt_version, created_at DESC);
+CREATE INDEX idx_prompt_runs_program ON prompt_runs(program_id, created_at DESC);
+
+COMMENT ON TABLE prompt_runs IS 'One row per analysis that got a model response; parse_failed when the response did not match the expected schema';
+
+-- ============================================================================
+-- Prompt version stamps
+-- ============================================================================
+
+ALTER TABLE artifact_summaries
+    ADD COLUMN prompt_id VARCHAR(100),
+    ADD COLUMN prompt_version VARCHAR(50);
+
+ALTER TABLE artifact_insights
+    ADD COLUMN prompt_id VARCHAR(100),
+    ADD COLUMN prompt_version VARCHAR(50);
+
+ALTER TABLE ai_usage
+    ADD COLUMN prompt_id VARCHAR(100),
+    ADD COLUMN prompt_version VARCHAR(50);
+
+CREATE INDEX idx_artifact_insights_prompt ON artifact_insights(prompt_id, prompt_version)
+    WHERE prompt_id IS NOT NULL;
+CREATE INDEX idx_ai_usage_prompt ON ai_usage(prompt_id, prompt_version, created_at DESC)
+    WHERE prompt_id IS NOT NULL;
+
+COMMENT ON COLUMN artifact_summaries.prompt_version IS 'Version of prompt_id that produced the summary';
+COMMENT ON COLUMN artifact_insights.prompt_version IS 'Version of prompt_id that produced the insight';
+COMMENT ON COLUMN ai_usage.prompt_version IS 'Version of prompt_id the request was built from, if any';
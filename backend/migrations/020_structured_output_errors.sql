@@ -0,0 +1,35 @@
+-- Migration: 020_structured_output_errors.sql
+-- Purpose: Record the kind of pipeline failures and structured output repairs
+-- AI extraction now validates model output against a JSON schema and asks the model to repair
+-- invalid output once. Output that is still invalid fails the stage with error code
+-- output_validation, recorded on the stage and its dead letter so these failures can be queried
+-- apart from API errors. Prompt runs record analyses that only succeeded after a repair.
+
+-- ============================================================================
+-- Pipeline error codes
+-- ============================================================================
+
+ALTER TABLE artifact_pipeline_stages
+    ADD COLUMN error_code VARCHAR(50);                -- Kind of last_error, e.g. output_validation
+
+ALTER TABLE artifact_dead_letters
+    ADD COLUMN error_code VARCHAR(50);
+
+CREATE INDEX idx_dead_letters_error_code ON artifact_dead_letters(program_id, error_code)
+    WHERE error_code IS NOT NULL;
+
+COMMENT ON COLUMN artifact_pipeline_stages.error_code IS 'Kind of failure for querying, e.g. output_validation; NULL for unclassified errors';
+COMMENT ON COLUMN artifact_dead_letters.error_code IS 'Kind of failure for querying, e.g. output_validation; NULL for unclassified errors';
+
+-- ============================================================================
+-- Repaired prompt runs
+-- ============================================================================
+
+ALTER TABLE prompt_runs
+    DROP CONSTRAINT prompt_runs_outcome_check;
+
+ALTER TABLE prompt_runs
+    ADD CONSTRAINT prompt_runs_outcome_check
+    CHECK (outcome IN ('succeeded', 'repaired', 'parse_failed'));
+
+COMMENT ON COLUMN prompt_runs.outcome IS 'succeeded, repaired (valid only after the model was asked to fix its output) or parse_failed';
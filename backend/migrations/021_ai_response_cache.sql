@@ -0,0 +1,10 @@
+-- Migration: 021_ai_response_cache.sql
+-- Purpose: Record AI requests answered from the response cache
+-- The AI client caches responses in Redis per program and job type. Cache hits are recorded in
+-- ai_usage at no cost so usage reports can show how many API calls the cache saved. Programs
+-- can keep their responses out of the cache with configuration->'disable_ai_cache'.
+
+ALTER TABLE ai_usage
+    ADD COLUMN cache_hit BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN ai_usage.cache_hit IS 'Answered from the response cache without an API call; tokens and cost are zero';
@@ -0,0 +1,18 @@
+-- Migration: 022_artifact_extraction_structure.sql
+-- Purpose: Persist the structure of extracted documents and locate chunks and facts in them
+-- Extractors now report page, sheet, slide and section boundaries as offsets into raw_content,
+-- table rows, and document properties (author, dates, title, email headers). The structure is
+-- stored with the artifact so chunks and facts can cite "page 7" or "sheet Budget, row 14".
+
+ALTER TABLE artifacts
+    ADD COLUMN extraction JSONB;                      -- page_count, word_count, metadata, sections, tables
+
+ALTER TABLE artifact_chunks
+    ADD COLUMN source_location TEXT;
+
+ALTER TABLE artifact_facts
+    ADD COLUMN source_location TEXT;
+
+COMMENT ON COLUMN artifacts.extraction IS 'Document structure and properties reported by the extractor; section and table offsets index raw_content';
+COMMENT ON COLUMN artifact_chunks.source_location IS 'Where the chunk is in the source document, e.g. "page 3 to page 4"; NULL for unstructured content';
+COMMENT ON COLUMN artifact_facts.source_location IS 'Where the fact''s context snippet is in the source document, e.g. "sheet Budget, row 14"';
@@ -0,0 +1,49 @@
+-- Migration: 023_email_threads.sql
+-- Purpose: Ingest emails as one artifact per message, threaded, with attachments as children
+-- Each uploaded .eml, and each message of an uploaded .mbox, becomes an artifact with its
+-- headers recorded in artifact_emails. Messages are grouped into threads by Message-ID,
+-- In-Reply-To and References, across uploads: a message joins the thread of any message it
+-- references or that references it, merging threads that it connects. Attachments become
+-- artifacts of their own that point to the message through parent_artifact_id.
+
+-- ============================================================================
+-- Attachments
+-- ============================================================================
+
+ALTER TABLE artifacts
+    ADD COLUMN parent_artifact_id UUID REFERENCES artifacts(artifact_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_artifacts_parent ON artifacts(parent_artifact_id)
+    WHERE parent_artifact_id IS NOT NULL;
+
+COMMENT ON COLUMN artifacts.parent_artifact_id IS 'Email artifact this artifact was attached to; NULL for uploaded files';
+
+-- ============================================================================
+-- Email messages
+-- ============================================================================
+
+CREATE TABLE artifact_emails (
+    artifact_id UUID PRIMARY KEY REFERENCES artifacts(artifact_id) ON DELETE CASCADE,
+    program_id UUID NOT NULL REFERENCES programs(program_id) ON DELETE CASCADE,
+    thread_id UUID NOT NULL,
+
+    message_id TEXT,                                  -- Including angle brackets, e.g. <abc@example.com>
+    in_reply_to TEXT,
+    reference_ids TEXT[] NOT NULL DEFAULT '{}',       -- References header, oldest first
+
+    from_address TEXT,                                -- From header as sent, e.g. Maria Chen <maria@acme.example>
+    to_addresses TEXT[] NOT NULL DEFAULT '{}',        -- Lowercased addresses
+    cc_addresses TEXT[] NOT NULL DEFAULT '{}',
+    subject TEXT,
+    sent_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_artifact_emails_message ON artifact_emails(program_id, message_id);
+CREATE INDEX idx_artifact_emails_in_reply_to ON artifact_emails(program_id, in_reply_to);
+CREATE INDEX idx_artifact_emails_references ON artifact_emails USING gin(reference_ids);
+CREATE INDEX idx_artifact_emails_thread ON artifact_emails(thread_id, sent_at);
+
+COMMENT ON TABLE artifact_emails IS 'Headers of email artifacts and the conversation thread each belongs to';
+COMMENT ON COLUMN artifact_emails.thread_id IS 'Conversation thread; shared by messages linked through Message-ID, In-Reply-To and References';
@@ -0,0 +1,11 @@
+-- Migration: 024_deferred_extraction.sql
+-- Purpose: Stream uploads to storage and extract their content in the worker
+-- Uploads through the API are streamed to object storage and hashed on the way, so the file is
+-- never held in memory by the request. The artifact is created with extraction_pending set and
+-- the worker's extract stage downloads the file, extracts and chunks its content, and clears
+-- the flag. Each program may set its own upload size limit (configuration->'max_upload_bytes').
+
+ALTER TABLE artifacts
+    ADD COLUMN extraction_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN artifacts.extraction_pending IS 'Content has not been extracted yet; the worker extract stage extracts it';
@@ -0,0 +1,38 @@
+-- Migration: 025_upload_sessions.sql
+-- Purpose: Resumable uploads, sent in parts to an S3 multipart upload
+-- A client starts an upload session with the file's name, type and size, and sends the file in
+-- parts of part_size_bytes, either through the API or to presigned storage URLs. Parts can be
+-- sent in any order and again after a dropped connection; the stored parts are listed by the
+-- storage's multipart upload, so they are not recorded here. Completing the session joins the
+-- parts into the file and creates the artifact (or artifacts, for ZIP archives and mailboxes)
+-- as a direct upload does. Sessions that are not completed expire and their parts are discarded.
+
+CREATE TABLE artifact_upload_sessions (
+    session_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES programs(program_id) ON DELETE CASCADE,
+    uploaded_by UUID NOT NULL,
+
+    filename VARCHAR(500) NOT NULL,
+    mime_type VARCHAR(255) NOT NULL,
+    file_size_bytes BIGINT NOT NULL CHECK (file_size_bytes > 0),
+    part_size_bytes BIGINT NOT NULL CHECK (part_size_bytes > 0),
+    force_upload BOOLEAN NOT NULL DEFAULT FALSE,
+
+    file_id VARCHAR(255) NOT NULL,                    -- Storage ID of the file being assembled
+    storage_upload_id TEXT NOT NULL,                  -- Storage multipart upload ID
+
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'assembled', 'completed', 'aborted', 'expired')),
+    artifact_ids UUID[] NOT NULL DEFAULT '{}',        -- Artifacts created on completion
+
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_artifact_upload_sessions_program ON artifact_upload_sessions(program_id, created_at DESC);
+CREATE INDEX idx_artifact_upload_sessions_expiry ON artifact_upload_sessions(expires_at)
+    WHERE status IN ('active', 'assembled');
+
+COMMENT ON TABLE artifact_upload_sessions IS 'Resumable uploads, sent in parts and turned into artifacts on completion';
+COMMENT ON COLUMN artifact_upload_sessions.status IS 'active: receiving parts; assembled: parts joined, artifact not yet created; completed; aborted; expired';
@@ -0,0 +1,7 @@
+-- Migration: 026_event_outbox_history.sql
+-- Purpose: Resume program event streams from the outbox
+-- A client reconnecting to the program event stream with Last-Event-ID is sent the program's
+-- sent events written after that one, read from event_outbox in publish order.
+
+CREATE INDEX idx_event_outbox_program_history ON event_outbox(program_id, outbox_id)
+    WHERE sent_at IS NOT NULL;
@@ -26,7 +26,7 @@ export function ArtifactUploader({ programId, onUploadSuccess }: ArtifactUploade
         },
         onError: (error: any) => {
           // Check for duplicate conflict (409)
-          if (error.response?.status === 409) {
+          if (error.response?.status === 409 && error.response.data?.existing_artifact_id) {
             const data = error.response.data
             setDuplicateInfo({
               existingId: data.existing_artifact_id,
@@ -60,8 +60,14 @@ export function ArtifactUploader({ programId, onUploadSuccess }: ArtifactUploade
       'application/json': ['.json'],
       'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet': ['.xlsx'],
       'application/vnd.ms-excel': ['.xls'],
+      'application/vnd.openxmlformats-officedocument.wordprocessingml.document': ['.docx'],
+      'application/vnd.openxmlformats-officedocument.presentationml.presentation': ['.pptx'],
+      'application/vnd.oasis.opendocument.text': ['.odt'],
+      'application/vnd.oasis.opendocument.presentation': ['.odp'],
+      'message/rfc822': ['.eml'],
+      'application/mbox': ['.mbox'],
     },
-    maxSize: 50 * 1024 * 1024, // 50MB
+    // The size limit is set per program and enforced by the server (413)
   })
 
   return (
@@ -93,7 +99,7 @@ export function ArtifactUploader({ programId, onUploadSuccess }: ArtifactUploade
         </p>
 
         <p className="mt-1 text-xs text-gray-500">
-          PDF, Excel, or text files up to 50MB
+          PDF, Office, email, or text files up to your program's upload limit
         </p>
       </div>
 
@@ -141,7 +147,8 @@ export function ArtifactUploader({ programId, onUploadSuccess }: ArtifactUploade
       {uploadMutation.isError && !duplicateInfo && (
         <div className="mt-4 bg-red-50 border border-red-200 rounded-lg p-4">
           <p className="text-sm text-red-700">
-            Error: {uploadMutation.error instanceof Error ? uploadMutation.error.message : 'Upload failed'}
+            Error: {(uploadMutation.error as any)?.response?.data?.message ??
+              (uploadMutation.error instanceof Error ? uploadMutation.error.message : 'Upload failed')}
           </p>
         </div>
       )}
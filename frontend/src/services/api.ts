@@ -216,10 +216,32 @@ export interface CreateProgramRequest {
   status: string
 }
 
+// Files larger than this are sent in parts through an upload session, so a dropped connection
+// only costs the parts that did not arrive
+const RESUMABLE_UPLOAD_THRESHOLD = 8 * 1024 * 1024
+const RESUMABLE_UPLOAD_RETRIES = 5
+
+interface UploadSession {
+  session_id: string
+  part_size_bytes: number
+  part_count: number
+  missing_parts: number[]
+}
+
+// Client errors (other than timeouts and throttling) will not succeed on retry
+const isPermanentError = (error: any) => {
+  const status = error.response?.status
+  return status !== undefined && status < 500 && status !== 408 && status !== 429
+}
+
 // Artifacts API
 export const artifactsApi = {
   // Upload artifact
   upload: async (programId: string, file: File, force = false) => {
+    if (file.size > RESUMABLE_UPLOAD_THRESHOLD) {
+      return artifactsApi.uploadResumable(programId, file, force)
+    }
+
     const formData = new FormData()
     formData.append('file', file)
 
@@ -233,6 +255,45 @@ export const artifactsApi = {
     return response.data.data as Artifact
   },
 
+  // Upload artifact in parts, retrying with the parts still missing after a failure
+  uploadResumable: async (programId: string, file: File, force = false) => {
+    const base = `/programs/${programId}/artifacts/uploads`
+    const created = await api.post(base, {
+      filename: file.name,
+      mime_type: file.type,
+      file_size_bytes: file.size,
+      force_upload: force,
+    })
+    let session = created.data.data as UploadSession
+
+    for (let attempt = 0; session.missing_parts.length > 0; attempt++) {
+      if (attempt > RESUMABLE_UPLOAD_RETRIES) {
+        throw new Error('Upload interrupted. Please check your connection and try again.')
+      }
+      if (attempt > 0) {
+        await new Promise((resolve) => setTimeout(resolve, 1000 * 2 ** attempt))
+      }
+
+      try {
+        for (const partNumber of session.missing_parts) {
+          const start = (partNumber - 1) * session.part_size_bytes
+          await api.put(`${base}/${session.session_id}/parts/${partNumber}`, file.slice(start, start + session.part_size_bytes), {
+            headers: { 'Content-Type': 'application/octet-stream' },
+          })
+        }
+        const progress = await api.get(`${base}/${session.session_id}`)
+        session = progress.data.data as UploadSession
+      } catch (error) {
+        if (isPermanentError(error)) {
+          throw error
+        }
+      }
+    }
+
+    const response = await api.post(`${base}/${session.session_id}/complete`)
+    return response.data.data as Artifact
+  },
+
   // List artifacts
   list: async (programId: string, params?: { limit?: number; offset?: number; status?: string }) => {
     const response = await api.get(`/programs/${programId}/artifacts`, { params })